@@ -28,6 +28,16 @@ const (
 const (
 	OR_BAD_REQUEST_001 = "OR-400-001" // invalid request body
 	OR_BAD_REQUEST_002 = "OR-400-002" // create/update/delete failed
+	OR_BAD_REQUEST_003 = "OR-400-003" // price or total mismatch
+	OR_BAD_REQUEST_004 = "OR-400-004" // order cannot be voided
+	OR_BAD_REQUEST_005 = "OR-400-005" // credit sale over the customer credit limit
+	OR_BAD_REQUEST_006 = "OR-400-006" // controlled drug compliance data missing or invalid
+	OR_BAD_REQUEST_007 = "OR-400-007" // blocked by allergy or drug interaction
+	OR_FORBIDDEN_001   = "OR-403-001" // manual price override not allowed
+	OR_FORBIDDEN_002   = "OR-403-002" // void after business day close needs SUPER approval
+	OR_FORBIDDEN_003   = "OR-403-003" // clinical override needs a registered pharmacist
+	OR_CONFLICT_001    = "OR-409-001" // insufficient stock
+	OR_CONFLICT_002    = "OR-409-002" // lot is not sellable (recalled, expired, quarantined or damaged)
 	OR_INTERNAL_001    = "OR-500-001" // internal server error
 )
 
@@ -94,6 +104,109 @@ const (
 	CN_INTERNAL_001    = "CN-500-001" // internal server error
 )
 
+// ─── Cart (CT) ──────────────────────────────────────────────────────────────
+const (
+	CT_BAD_REQUEST_001 = "CT-400-001" // invalid request body
+	CT_BAD_REQUEST_002 = "CT-400-002" // create/update/park/resume/transfer failed
+	CT_FORBIDDEN_001   = "CT-403-001" // cart belongs to another cashier
+	CT_INTERNAL_001    = "CT-500-001" // internal server error
+)
+
+// ─── Shift (SH) ─────────────────────────────────────────────────────────────
+const (
+	SH_BAD_REQUEST_001 = "SH-400-001" // invalid request body
+	SH_BAD_REQUEST_002 = "SH-400-002" // open/cash movement/close failed
+	SH_CONFLICT_001    = "SH-409-001" // cashier already has an open shift
+	SH_INTERNAL_001    = "SH-500-001" // internal server error
+)
+
+// ─── Accounts Receivable (AR) ───────────────────────────────────────────────
+const (
+	AR_BAD_REQUEST_001 = "AR-400-001" // invalid request body
+	AR_BAD_REQUEST_002 = "AR-400-002" // receipt/allocation failed
+	AR_INTERNAL_001    = "AR-500-001" // internal server error
+)
+
+// ─── Drug Interaction (IX) ──────────────────────────────────────────────────
+const (
+	IX_BAD_REQUEST_001 = "IX-400-001" // invalid request body
+	IX_BAD_REQUEST_002 = "IX-400-002" // create/update/delete/import failed
+	IX_CONFLICT_001    = "IX-409-001" // ingredient pair already exists
+	IX_INTERNAL_001    = "IX-500-001" // internal server error
+)
+
+// ─── Ingredient (IG) ────────────────────────────────────────────────────────
+const (
+	IG_BAD_REQUEST_001 = "IG-400-001" // invalid request body
+	IG_BAD_REQUEST_002 = "IG-400-002" // create/update/delete failed
+	IG_BAD_REQUEST_003 = "IG-400-003" // ingredient not found
+	IG_CONFLICT_001    = "IG-409-001" // ingredient name already exists or still linked to products
+	IG_INTERNAL_001    = "IG-500-001" // internal server error
+)
+
+// ─── Tax Invoice (TI) ───────────────────────────────────────────────────────
+const (
+	TI_BAD_REQUEST_001 = "TI-400-001" // invalid request body
+	TI_BAD_REQUEST_002 = "TI-400-002" // issue/convert/reissue failed
+	TI_BAD_REQUEST_003 = "TI-400-003" // tax invoice or order not found
+	TI_FORBIDDEN_001   = "TI-403-001" // branch is not VAT registered
+	TI_CONFLICT_001    = "TI-409-001" // order already has a tax invoice in force
+	TI_INTERNAL_001    = "TI-500-001" // internal server error
+)
+
+// ─── e-Tax (ET) ─────────────────────────────────────────────────────────────
+const (
+	ET_BAD_REQUEST_001 = "ET-400-001" // invalid request body
+	ET_BAD_REQUEST_002 = "ET-400-002" // document failed e-Tax validation
+	ET_BAD_REQUEST_003 = "ET-400-003" // document not found
+	ET_FORBIDDEN_001   = "ET-403-001" // branch is not VAT registered or no signer is configured
+	ET_CONFLICT_001    = "ET-409-001" // document was already sent as e-Tax
+	ET_INTERNAL_001    = "ET-500-001" // internal server error
+)
+
+// ─── Stock Adjustment (SA) ──────────────────────────────────────────────────
+const (
+	SA_BAD_REQUEST_001 = "SA-400-001" // invalid request body
+	SA_BAD_REQUEST_002 = "SA-400-002" // create/approve/reject failed
+	SA_BAD_REQUEST_003 = "SA-400-003" // adjustment or lot not found
+	SA_CONFLICT_001    = "SA-409-001" // adjustment is no longer pending
+	SA_INTERNAL_001    = "SA-500-001" // internal server error
+)
+
+// ─── Stocktake (SK) ─────────────────────────────────────────────────────────
+const (
+	SK_BAD_REQUEST_001 = "SK-400-001" // invalid request body
+	SK_BAD_REQUEST_002 = "SK-400-002" // create/count/post failed
+	SK_BAD_REQUEST_003 = "SK-400-003" // stocktake, lot or barcode not found
+	SK_CONFLICT_001    = "SK-409-001" // stocktake is no longer counting
+	SK_INTERNAL_001    = "SK-500-001" // internal server error
+)
+
+// ─── Recall (RL) ────────────────────────────────────────────────────────────
+const (
+	RL_BAD_REQUEST_001 = "RL-400-001" // invalid request body
+	RL_BAD_REQUEST_002 = "RL-400-002" // create/return failed
+	RL_BAD_REQUEST_003 = "RL-400-003" // recall or product not found
+	RL_BAD_REQUEST_004 = "RL-400-004" // no recalled stock left to return
+	RL_INTERNAL_001    = "RL-500-001" // internal server error
+)
+
+// ─── Disposal (DP) ──────────────────────────────────────────────────────────
+const (
+	DP_BAD_REQUEST_001 = "DP-400-001" // invalid request body
+	DP_BAD_REQUEST_002 = "DP-400-002" // create failed
+	DP_BAD_REQUEST_003 = "DP-400-003" // disposal or lot not found
+	DP_BAD_REQUEST_004 = "DP-400-004" // lot is still sellable or holds less than the quantity
+	DP_INTERNAL_001    = "DP-500-001" // internal server error
+)
+
+// ─── Valuation (VL) ─────────────────────────────────────────────────────────
+const (
+	VL_BAD_REQUEST_001 = "VL-400-001" // invalid request query
+	VL_CONFLICT_001    = "VL-409-001" // valuation ledger already opened for the branch
+	VL_INTERNAL_001    = "VL-500-001" // internal server error
+)
+
 // ─── Billing (BL) ───────────────────────────────────────────────────────────
 const (
 	BL_BAD_REQUEST_001 = "BL-400-001" // invalid request body
@@ -159,8 +272,11 @@ const (
 
 // ─── System (SY) ────────────────────────────────────────────────────────────
 const (
-	SY_NOT_FOUND_001 = "SY-404-001" // route not found
-	SY_FORBIDDEN_001 = "SY-403-001" // invalid request / restricted endpoint
-	SY_FORBIDDEN_002 = "SY-403-002" // no permission
-	SY_INTERNAL_001  = "SY-500-001" // panic recovery / internal server error
+	SY_BAD_REQUEST_001   = "SY-400-001" // unreadable request body
+	SY_NOT_FOUND_001     = "SY-404-001" // route not found
+	SY_FORBIDDEN_001     = "SY-403-001" // invalid request / restricted endpoint
+	SY_FORBIDDEN_002     = "SY-403-002" // no permission
+	SY_CONFLICT_001      = "SY-409-001" // idempotent request in progress
+	SY_UNPROCESSABLE_001 = "SY-422-001" // idempotency key reused with another request
+	SY_INTERNAL_001      = "SY-500-001" // panic recovery / internal server error
 )
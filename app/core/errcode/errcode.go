@@ -3,10 +3,16 @@ package errcode
 import "github.com/gin-gonic/gin"
 
 type AppError struct {
-	ErrCode string `json:"errcode"`
-	Error   string `json:"error"`
+	ErrCode string      `json:"errcode"`
+	Error   string      `json:"error"`
+	Data    interface{} `json:"data,omitempty"`
 }
 
 func Abort(ctx *gin.Context, httpStatus int, code string, msg string) {
 	ctx.AbortWithStatusJSON(httpStatus, AppError{ErrCode: code, Error: msg})
 }
+
+// AbortWithData aborts like Abort and attaches structured details of the error
+func AbortWithData(ctx *gin.Context, httpStatus int, code string, msg string, data interface{}) {
+	ctx.AbortWithStatusJSON(httpStatus, AppError{ErrCode: code, Error: msg, Data: data})
+}
@@ -0,0 +1,144 @@
+package escpos
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+)
+
+const (
+	AlignLeft   = 0
+	AlignCenter = 1
+	AlignRight  = 2
+)
+
+// Printer builds the byte stream of an Epson compatible thermal printer. Columns is the number
+// of Font A characters on a line and Dots the printable width in dots.
+type Printer struct {
+	buf     bytes.Buffer
+	Columns int
+	Dots    int
+}
+
+func New(columns int, dots int, codePage int) *Printer {
+	p := &Printer{Columns: columns, Dots: dots}
+	p.buf.Write([]byte{0x1B, 0x40})                 // ESC @ initialize
+	p.buf.Write([]byte{0x1B, 0x74, byte(codePage)}) // ESC t select code page
+	return p
+}
+
+func (p *Printer) Align(align byte) {
+	p.buf.Write([]byte{0x1B, 0x61, align})
+}
+
+func (p *Printer) Bold(on bool) {
+	p.buf.Write([]byte{0x1B, 0x45, boolByte(on)})
+}
+
+// DoubleSize prints twice the width and height, a line then holds half the columns
+func (p *Printer) DoubleSize(on bool) {
+	size := byte(0x00)
+	if on {
+		size = 0x11
+	}
+	p.buf.Write([]byte{0x1D, 0x21, size})
+}
+
+func (p *Printer) Text(text string) {
+	p.buf.Write(EncodeThai(text))
+}
+
+func (p *Printer) Line(text string) {
+	p.Text(text + "\n")
+}
+
+// Row prints the left text and the right text aligned to the end of the line, the left
+// text wraps when both do not fit and a right text wider than the paper is printed as is
+func (p *Printer) Row(left string, right string) {
+	space := p.Columns - Width(left) - Width(right)
+	if space < 1 {
+		p.Line(left)
+		space = max(p.Columns-Width(right), 0)
+		left = ""
+	}
+	p.Line(left + strings.Repeat(" ", space) + right)
+}
+
+func (p *Printer) Separator() {
+	p.Line(strings.Repeat("-", p.Columns))
+}
+
+func (p *Printer) Feed(lines int) {
+	p.buf.Write([]byte{0x1B, 0x64, byte(lines)})
+}
+
+// Cut feeds the paper past the cutter and makes a partial cut
+func (p *Printer) Cut() {
+	p.buf.Write([]byte{0x1D, 0x56, 0x42, 0x00})
+}
+
+// Image prints the image as a monochrome raster with GS v 0, scaled down to the printable width
+func (p *Printer) Image(img image.Image) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return
+	}
+	scale := 1.0
+	if width > p.Dots {
+		scale = float64(p.Dots) / float64(width)
+	}
+	outWidth := int(float64(width) * scale)
+	outHeight := int(float64(height) * scale)
+	rowBytes := (outWidth + 7) / 8
+
+	p.buf.Write([]byte{0x1D, 0x76, 0x30, 0x00,
+		byte(rowBytes), byte(rowBytes >> 8), byte(outHeight), byte(outHeight >> 8)})
+	for y := 0; y < outHeight; y++ {
+		row := make([]byte, rowBytes)
+		for x := 0; x < outWidth; x++ {
+			source := img.At(bounds.Min.X+int(float64(x)/scale), bounds.Min.Y+int(float64(y)/scale))
+			if isDark(source) {
+				row[x/8] |= 0x80 >> (x % 8)
+			}
+		}
+		p.buf.Write(row)
+	}
+}
+
+// QR prints a QR code from its module bitmap, each module drawn as a square of dots
+func (p *Printer) QR(bitmap [][]bool, moduleDots int) {
+	size := len(bitmap) * moduleDots
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			value := uint8(0xFF)
+			if bitmap[y/moduleDots][x/moduleDots] {
+				value = 0x00
+			}
+			img.SetGray(x, y, color.Gray{Y: value})
+		}
+	}
+	p.Image(img)
+}
+
+func (p *Printer) Bytes() []byte {
+	return p.buf.Bytes()
+}
+
+func isDark(c color.Color) bool {
+	r, g, b, a := c.RGBA()
+	if a < 0x8000 {
+		return false
+	}
+	luminance := (299*r + 587*g + 114*b) / 1000
+	return luminance < 0x8000
+}
+
+func boolByte(on bool) byte {
+	if on {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,31 @@
+package escpos
+
+// EncodeThai converts UTF-8 text to TIS-620, which the Thai code pages of thermal printers
+// share. Characters outside ASCII and Thai print as "?".
+func EncodeThai(text string) []byte {
+	results := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r < 0x80:
+			results = append(results, byte(r))
+		case r >= 0x0E01 && r <= 0x0E5B:
+			results = append(results, byte(r-0x0E00+0xA0))
+		default:
+			results = append(results, '?')
+		}
+	}
+	return results
+}
+
+// Width counts the printed columns of the text, Thai vowels and tone marks written above or
+// below the consonant take no column of their own
+func Width(text string) int {
+	width := 0
+	for _, r := range text {
+		if r == 0x0E31 || (r >= 0x0E34 && r <= 0x0E3A) || (r >= 0x0E47 && r <= 0x0E4E) {
+			continue
+		}
+		width++
+	}
+	return width
+}
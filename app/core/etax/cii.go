@@ -0,0 +1,187 @@
+package etax
+
+import (
+	"pos/app/core/utils"
+	"strconv"
+	"time"
+)
+
+const (
+	xmlHeader   = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+	countryCode = "TH"
+	unitCode    = "EA"
+)
+
+// schemaName returns the ETDA schema a document type is written in
+func schemaName(typeCode string) string {
+	switch typeCode {
+	case TypeTaxInvoice, TypeInvoiceTaxInvoice, TypeReceiptTaxInvoice:
+		return "TaxInvoice"
+	case TypeAbbreviatedTaxInvoice, TypeReceiptAbbreviatedInvoice:
+		return "AbbreviatedTaxInvoice"
+	case TypeCreditNote, TypeDebitNote:
+		return "DebitCreditNote"
+	case TypeReceipt:
+		return "Receipt"
+	}
+	return ""
+}
+
+// Build writes the document as an ETDA CrossIndustryInvoice, the root element is ready to be signed
+func Build(doc *Document) *Element {
+	schema := schemaName(doc.TypeCode)
+	rsm := Namespace{Prefix: "rsm", URI: "urn:etda:uncefact:data:standard:" + schema + "_CrossIndustryInvoice:2"}
+	ram := Namespace{Prefix: "ram", URI: "urn:etda:uncefact:data:standard:" + schema + "_ReusableAggregateBusinessInformationEntity:2"}
+
+	context := rsm.Element("ExchangedDocumentContext",
+		ram.Element("GuidelineSpecifiedDocumentContextParameter",
+			ram.Text("ID", "ER3-2560", Attr{"schemeAgencyID", "ETDA"}, Attr{"schemeVersionID", "v2.0"}),
+		),
+	)
+
+	exchanged := rsm.Element("ExchangedDocument",
+		ram.Text("ID", doc.Code),
+		ram.Text("Name", doc.Name),
+		ram.Text("TypeCode", doc.TypeCode),
+		ram.Text("IssueDateTime", formatDateTime(doc.IssuedDate)),
+	)
+	if doc.Purpose != "" {
+		exchanged.Add(ram.Text("Purpose", doc.Purpose), ram.Text("PurposeCode", doc.PurposeCode))
+	}
+	exchanged.Add(ram.Text("CreationDateTime", formatDateTime(doc.CreatedDate)))
+
+	agreement := ram.Element("ApplicableHeaderTradeAgreement",
+		tradeParty(ram, "SellerTradeParty", &doc.Seller),
+		tradeParty(ram, "BuyerTradeParty", doc.Buyer),
+	)
+	for _, ref := range doc.References {
+		agreement.Add(ram.Element("AdditionalReferencedDocument",
+			ram.Text("IssuerAssignedID", ref.Code),
+			ram.Text("IssueDateTime", formatDateTime(ref.IssuedDate)),
+			ram.Text("ReferenceTypeCode", ref.TypeCode),
+		))
+	}
+
+	settlement := ram.Element("ApplicableHeaderTradeSettlement",
+		ram.Text("InvoiceCurrencyCode", doc.CurrencyCode, Attr{"listID", "ISO 4217 3A"}),
+	)
+	for _, tax := range doc.Taxes {
+		settlement.Add(ram.Element("ApplicableTradeTax",
+			ram.Text("TypeCode", taxTypeCode(tax.Exempt)),
+			ram.Text("CalculatedRate", formatRate(tax.Rate)),
+			ram.Text("BasisAmount", formatAmount(tax.Basis)),
+			ram.Text("CalculatedAmount", formatAmount(tax.Amount)),
+		))
+	}
+	if doc.Allowance != 0 {
+		settlement.Add(ram.Element("SpecifiedTradeAllowanceCharge",
+			ram.Text("ChargeIndicator", "false"),
+			ram.Text("ActualAmount", formatAmount(doc.Allowance)),
+		))
+	}
+	summation := ram.Element("SpecifiedTradeSettlementHeaderMonetarySummation")
+	if doc.IsNote() {
+		summation.Add(ram.Text("OriginalInformationAmount", formatAmount(doc.Original)))
+	}
+	summation.Add(ram.Text("LineTotalAmount", formatAmount(doc.LineTotal)))
+	if doc.IsNote() {
+		summation.Add(ram.Text("DifferenceInformationAmount", formatAmount(doc.Difference)))
+	}
+	summation.Add(
+		ram.Text("AllowanceTotalAmount", formatAmount(doc.Allowance)),
+		ram.Text("TaxBasisTotalAmount", formatAmount(doc.TaxBasis)),
+		ram.Text("TaxTotalAmount", formatAmount(doc.TaxTotal)),
+		ram.Text("GrandTotalAmount", formatAmount(doc.GrandTotal)),
+	)
+	settlement.Add(summation)
+
+	transaction := rsm.Element("SupplyChainTradeTransaction",
+		agreement,
+		ram.Element("ApplicableHeaderTradeDelivery"),
+		settlement,
+	)
+	for i, line := range doc.Lines {
+		transaction.Add(ram.Element("IncludedSupplyChainTradeLineItem",
+			ram.Element("AssociatedDocumentLineDocument", ram.Text("LineID", strconv.Itoa(i+1))),
+			ram.Element("SpecifiedTradeProduct", ram.Text("Name", line.Name)),
+			ram.Element("SpecifiedLineTradeAgreement",
+				ram.Element("GrossPriceProductTradePrice", ram.Text("ChargeAmount", formatAmount(line.UnitPrice))),
+			),
+			ram.Element("SpecifiedLineTradeDelivery",
+				ram.Text("BilledQuantity", strconv.FormatFloat(line.Quantity, 'f', -1, 64), Attr{"unitCode", unitCode}),
+			),
+			ram.Element("SpecifiedLineTradeSettlement",
+				ram.Element("ApplicableTradeTax",
+					ram.Text("TypeCode", taxTypeCode(line.VatExempt)),
+					ram.Text("CalculatedRate", formatRate(lineRate(line))),
+				),
+				ram.Element("SpecifiedTradeSettlementLineMonetarySummation",
+					ram.Text("NetLineTotalAmount", formatAmount(line.Amount)),
+				),
+			),
+		))
+	}
+
+	return rsm.Element(schema+"_CrossIndustryInvoice", context, exchanged, transaction)
+}
+
+// Marshal writes the element as an XML document
+func Marshal(root *Element) []byte {
+	return append([]byte(xmlHeader), root.Canonical()...)
+}
+
+func tradeParty(ram Namespace, local string, party *Party) *Element {
+	if party == nil {
+		return nil
+	}
+	el := ram.Element(local, ram.Text("Name", party.Name))
+	if party.TaxId != "" {
+		// TXID is the tax id followed by the Revenue Department branch number
+		branchNo := party.BranchNo
+		if branchNo == "" {
+			branchNo = "00000"
+		}
+		el.Add(ram.Element("SpecifiedTaxRegistration", ram.Text("ID", party.TaxId+branchNo, Attr{"schemeID", "TXID"})))
+	} else {
+		el.Add(ram.Element("SpecifiedTaxRegistration", ram.Text("ID", "N/A", Attr{"schemeID", "OTHR"})))
+	}
+	if party.Address != "" {
+		address := ram.Element("PostalTradeAddress")
+		if party.Postcode != "" {
+			address.Add(ram.Text("PostcodeCode", party.Postcode))
+		}
+		address.Add(
+			ram.Text("LineOne", party.Address),
+			ram.Text("CountryID", countryCode, Attr{"schemeID", "3166-1 alpha-2"}),
+		)
+		el.Add(address)
+	}
+	return el
+}
+
+func taxTypeCode(exempt bool) string {
+	if exempt {
+		return "FRE"
+	}
+	return "VAT"
+}
+
+func lineRate(line Line) float64 {
+	if line.VatExempt {
+		return 0
+	}
+	return line.VatRate
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+func formatRate(rate float64) string {
+	return strconv.FormatFloat(rate, 'f', -1, 64)
+}
+
+// formatDateTime writes the time in Bangkok time without a zone, as the ETDA samples do
+func formatDateTime(t time.Time) string {
+	return t.In(utils.GetLocation()).Format("2006-01-02T15:04:05")
+}
@@ -0,0 +1,287 @@
+package etax
+
+import (
+	"fmt"
+	"math"
+	"pos/app/core/utils"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ETDA document type codes (ขมธอ. 3-2560)
+const (
+	TypeDebitNote                 = "80"
+	TypeCreditNote                = "81"
+	TypeTaxInvoice                = "388"
+	TypeReceipt                   = "T01"
+	TypeInvoiceTaxInvoice         = "T02"
+	TypeReceiptTaxInvoice         = "T03"
+	TypeAbbreviatedTaxInvoice     = "T05"
+	TypeReceiptAbbreviatedInvoice = "T06"
+)
+
+// TypeName returns the Thai name of a document type, as printed on the document
+func TypeName(typeCode string) string {
+	switch typeCode {
+	case TypeDebitNote:
+		return "ใบเพิ่มหนี้"
+	case TypeCreditNote:
+		return "ใบลดหนี้"
+	case TypeTaxInvoice:
+		return "ใบกำกับภาษี"
+	case TypeReceipt:
+		return "ใบเสร็จรับเงิน"
+	case TypeInvoiceTaxInvoice:
+		return "ใบแจ้งหนี้/ใบกำกับภาษี"
+	case TypeReceiptTaxInvoice:
+		return "ใบเสร็จรับเงิน/ใบกำกับภาษี"
+	case TypeAbbreviatedTaxInvoice:
+		return "ใบกำกับภาษีอย่างย่อ"
+	case TypeReceiptAbbreviatedInvoice:
+		return "ใบเสร็จรับเงิน/ใบกำกับภาษีอย่างย่อ"
+	}
+	return ""
+}
+
+// ETDA purpose codes of credit and debit notes
+const (
+	PurposeGoodsReturned = "CDNG05"
+	PurposeCreditOther   = "CDNG99"
+	PurposeDebitOther    = "DBNG99"
+)
+
+// Document is an e-Tax invoice, receipt, credit or debit note before it is written as XML.
+// Line and header amounts exclude VAT, as the CrossIndustryInvoice summation expects.
+type Document struct {
+	TypeCode     string
+	Name         string
+	Code         string
+	IssuedDate   time.Time
+	CreatedDate  time.Time
+	Purpose      string
+	PurposeCode  string
+	Seller       Party
+	Buyer        *Party
+	References   []Reference
+	Lines        []Line
+	Taxes        []Tax
+	Allowance    float64
+	LineTotal    float64
+	TaxBasis     float64
+	TaxTotal     float64
+	GrandTotal   float64
+	Original     float64
+	Difference   float64
+	CurrencyCode string
+}
+
+type Party struct {
+	Name     string
+	TaxId    string
+	BranchNo string
+	Address  string
+	Postcode string
+}
+
+// Reference is the document a credit or debit note corrects
+type Reference struct {
+	Code       string
+	IssuedDate time.Time
+	TypeCode   string
+}
+
+type Line struct {
+	Name      string
+	Quantity  float64
+	UnitPrice float64
+	Amount    float64
+	VatRate   float64
+	VatExempt bool
+}
+
+// Tax is the VAT charged at one rate, Basis is the value it is charged on
+type Tax struct {
+	Rate   float64
+	Exempt bool
+	Basis  float64
+	Amount float64
+}
+
+// IsNote reports whether the document corrects an earlier one
+func (doc *Document) IsNote() bool {
+	return doc.TypeCode == TypeCreditNote || doc.TypeCode == TypeDebitNote
+}
+
+// IsAbbreviated reports whether the document may leave out the buyer
+func (doc *Document) IsAbbreviated() bool {
+	return doc.TypeCode == TypeAbbreviatedTaxInvoice || doc.TypeCode == TypeReceiptAbbreviatedInvoice
+}
+
+var postcodePattern = regexp.MustCompile(`\b(\d{5})\s*$`)
+
+// Postcode returns the postcode a Thai address ends with
+func Postcode(address string) string {
+	match := postcodePattern.FindStringSubmatch(strings.TrimSpace(address))
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// ValidationErrors lists every field of a document the Revenue Department would reject
+type ValidationErrors []string
+
+func (errs ValidationErrors) Error() string {
+	return strings.Join(errs, "; ")
+}
+
+// Validate checks the fields ETDA requires and that the summation adds up, it returns nil or ValidationErrors
+func (doc *Document) Validate() error {
+	errs := ValidationErrors{}
+	add := func(field string, format string, args ...interface{}) {
+		errs = append(errs, field+": "+fmt.Sprintf(format, args...))
+	}
+
+	if schemaName(doc.TypeCode) == "" {
+		add("typeCode", "unknown document type %q", doc.TypeCode)
+	}
+	if doc.Code == "" || len(doc.Code) > 35 {
+		add("code", "is required and at most 35 characters")
+	}
+	if doc.IssuedDate.IsZero() {
+		add("issuedDate", "is required")
+	}
+	if doc.CurrencyCode == "" {
+		add("currencyCode", "is required")
+	}
+
+	if doc.Seller.Name == "" {
+		add("seller.name", "is required")
+	}
+	if !utils.IsValidThaiId(doc.Seller.TaxId) {
+		add("seller.taxId", "must be a valid 13 digit tax id")
+	}
+	if !isBranchNo(doc.Seller.BranchNo) {
+		add("seller.branchNo", "must be 5 digits")
+	}
+	if doc.Seller.Address == "" {
+		add("seller.address", "is required")
+	}
+	if doc.Seller.Postcode == "" {
+		add("seller.postcode", "is required, end the address with the postcode")
+	}
+
+	switch {
+	case doc.Buyer == nil && !doc.IsAbbreviated():
+		add("buyer", "is required")
+	case doc.Buyer != nil:
+		if doc.Buyer.Name == "" {
+			add("buyer.name", "is required")
+		}
+		if doc.Buyer.TaxId != "" && !utils.IsValidThaiId(doc.Buyer.TaxId) {
+			add("buyer.taxId", "must be a valid 13 digit tax id")
+		}
+		if doc.Buyer.BranchNo != "" && !isBranchNo(doc.Buyer.BranchNo) {
+			add("buyer.branchNo", "must be 5 digits")
+		}
+		if !doc.IsAbbreviated() {
+			if doc.Buyer.Address == "" {
+				add("buyer.address", "is required")
+			}
+			if doc.Buyer.Postcode == "" {
+				add("buyer.postcode", "is required, end the address with the postcode")
+			}
+		}
+	}
+
+	if doc.IsNote() {
+		if len(doc.References) == 0 {
+			add("references", "a credit or debit note must refer to the document it corrects")
+		}
+		if doc.Purpose == "" || doc.PurposeCode == "" {
+			add("purpose", "a credit or debit note must give its reason")
+		}
+	}
+	for i, ref := range doc.References {
+		if ref.Code == "" || ref.IssuedDate.IsZero() || ref.TypeCode == "" {
+			add(fmt.Sprintf("references[%d]", i), "code, issued date and type are required")
+		}
+	}
+
+	if len(doc.Lines) == 0 {
+		add("lines", "at least one line is required")
+	}
+	lineTotal := 0.0
+	for i, line := range doc.Lines {
+		if line.Name == "" {
+			add(fmt.Sprintf("lines[%d].name", i), "is required")
+		}
+		if line.Quantity <= 0 {
+			add(fmt.Sprintf("lines[%d].quantity", i), "must be more than 0")
+		}
+		if line.Amount < 0 {
+			add(fmt.Sprintf("lines[%d].amount", i), "must not be negative")
+		}
+		lineTotal += line.Amount
+	}
+
+	if len(doc.Taxes) == 0 {
+		add("taxes", "at least one tax breakdown is required")
+	}
+	basis, tax := 0.0, 0.0
+	for _, t := range doc.Taxes {
+		basis += t.Basis
+		tax += t.Amount
+	}
+	// The lines of a note are the difference it makes, its line total is the corrected value
+	linesAmount := doc.LineTotal
+	switch doc.TypeCode {
+	case TypeCreditNote:
+		linesAmount = doc.Difference
+		if !sameAmount(doc.Original-doc.Difference, doc.LineTotal) {
+			add("lineTotal", "%.2f is not the original amount less the difference %.2f", doc.LineTotal, doc.Original-doc.Difference)
+		}
+	case TypeDebitNote:
+		linesAmount = doc.Difference
+		if !sameAmount(doc.Original+doc.Difference, doc.LineTotal) {
+			add("lineTotal", "%.2f is not the original amount plus the difference %.2f", doc.LineTotal, doc.Original+doc.Difference)
+		}
+	}
+	if !sameAmount(lineTotal, linesAmount) {
+		add("lines", "add up to %.2f, not %.2f", lineTotal, linesAmount)
+	}
+	if !sameAmount(linesAmount-doc.Allowance, doc.TaxBasis) {
+		add("taxBasis", "%.2f is not the lines less the allowance %.2f", doc.TaxBasis, linesAmount-doc.Allowance)
+	}
+	if !sameAmount(basis, doc.TaxBasis) {
+		add("taxBasis", "%.2f is not the sum of the tax breakdown %.2f", doc.TaxBasis, basis)
+	}
+	if !sameAmount(tax, doc.TaxTotal) {
+		add("taxTotal", "%.2f is not the sum of the tax breakdown %.2f", doc.TaxTotal, tax)
+	}
+	if !sameAmount(doc.TaxBasis+doc.TaxTotal, doc.GrandTotal) {
+		add("grandTotal", "%.2f is not the tax basis plus tax %.2f", doc.GrandTotal, doc.TaxBasis+doc.TaxTotal)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func isBranchNo(branchNo string) bool {
+	if len(branchNo) != 5 {
+		return false
+	}
+	for _, c := range branchNo {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func sameAmount(a float64, b float64) bool {
+	return math.Abs(a-b) < 0.005
+}
@@ -0,0 +1,79 @@
+package etax
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"io"
+	"os"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+// The built-in signer reads the key and certificate from the PKCS#12 file at ETAX_PKCS12_FILE,
+// unlocked with ETAX_PKCS12_PASSWORD. The file may carry the CA chain after the signing
+// certificate. It must use the legacy 3DES encryption, re-export newer files with openssl -legacy.
+
+type pkcs12Signer struct {
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+// LoadPKCS12 reads an RSA signing key and its certificate from a .p12 or .pfx file
+func LoadPKCS12(path string, password string) (Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := pkcs12.ToPEM(data, password)
+	if err != nil {
+		return nil, err
+	}
+	var key *rsa.PrivateKey
+	certs := []*x509.Certificate{}
+	for _, block := range blocks {
+		switch block.Type {
+		case "PRIVATE KEY":
+			key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, errors.New("e-Tax signing needs an RSA key")
+			}
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			certs = append(certs, cert)
+		}
+	}
+	if key == nil {
+		return nil, errors.New("no private key in " + path)
+	}
+	for _, cert := range certs {
+		if pub, ok := cert.PublicKey.(*rsa.PublicKey); ok && pub.Equal(&key.PublicKey) {
+			return &pkcs12Signer{key: key, cert: cert}, nil
+		}
+	}
+	return nil, errors.New("no certificate for the private key in " + path)
+}
+
+func loadPKCS12FromEnv() (Signer, error) {
+	path := os.Getenv("ETAX_PKCS12_FILE")
+	if path == "" {
+		return nil, nil
+	}
+	return LoadPKCS12(path, os.Getenv("ETAX_PKCS12_PASSWORD"))
+}
+
+func (s *pkcs12Signer) Public() crypto.PublicKey {
+	return &s.key.PublicKey
+}
+
+func (s *pkcs12Signer) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.key.Sign(rand, digest, opts)
+}
+
+func (s *pkcs12Signer) Certificate() *x509.Certificate {
+	return s.cert
+}
@@ -0,0 +1,137 @@
+package etax
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	algExcC14N      = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	algEnveloped    = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	algRSASHA256    = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	algSHA256       = "http://www.w3.org/2001/04/xmlenc#sha256"
+	signedPropsType = "http://uri.etsi.org/01903#SignedProperties"
+)
+
+var (
+	ds    = Namespace{Prefix: "ds", URI: "http://www.w3.org/2000/09/xmldsig#"}
+	xades = Namespace{Prefix: "xades", URI: "http://uri.etsi.org/01903/v1.3.2#"}
+)
+
+// Signer holds the key an e-Tax document is signed with. The PKCS#12 signer is built in, an HSM
+// or a remote signing service plugs in by implementing crypto.Signer for its key.
+type Signer interface {
+	crypto.Signer
+	Certificate() *x509.Certificate
+}
+
+var (
+	signerMu     sync.Mutex
+	signerLoaded bool
+	signer       Signer
+	signerErr    error
+)
+
+// RegisterSigner replaces the PKCS#12 signer, call it at startup before any document is signed
+func RegisterSigner(s Signer) {
+	signerMu.Lock()
+	defer signerMu.Unlock()
+	signer, signerErr, signerLoaded = s, nil, true
+}
+
+// DefaultSigner returns the registered signer, or the PKCS#12 file configured in the environment.
+// It returns nil without an error when no signer is configured.
+func DefaultSigner() (Signer, error) {
+	signerMu.Lock()
+	defer signerMu.Unlock()
+	if !signerLoaded {
+		signer, signerErr = loadPKCS12FromEnv()
+		signerLoaded = true
+	}
+	return signer, signerErr
+}
+
+// Sign appends an enveloped XAdES-BES signature to the root element, RSA-SHA256 over the exclusive
+// canonical form, with the signing certificate and time as signed properties
+func Sign(root *Element, s Signer, signingTime time.Time) error {
+	if _, ok := s.Public().(*rsa.PublicKey); !ok {
+		return errors.New("e-Tax signing needs an RSA key")
+	}
+	cert := s.Certificate()
+	docDigest := sha256.Sum256(root.Canonical())
+	id := hex.EncodeToString(docDigest[:8])
+	signatureId := "signature-" + id
+	propertiesId := "xades-" + id
+
+	certDigest := sha256.Sum256(cert.Raw)
+	properties := xades.Element("SignedProperties",
+		xades.Element("SignedSignatureProperties",
+			xades.Text("SigningTime", signingTime.UTC().Format("2006-01-02T15:04:05Z")),
+			xades.Element("SigningCertificate",
+				xades.Element("Cert",
+					xades.Element("CertDigest",
+						ds.Text("DigestMethod", "", Attr{"Algorithm", algSHA256}),
+						ds.Text("DigestValue", base64.StdEncoding.EncodeToString(certDigest[:])),
+					),
+					xades.Element("IssuerSerial",
+						ds.Text("X509IssuerName", cert.Issuer.String()),
+						ds.Text("X509SerialNumber", cert.SerialNumber.String()),
+					),
+				),
+			),
+		),
+	)
+	properties.Attrs = []Attr{{"Id", propertiesId}}
+	propertiesDigest := sha256.Sum256(properties.Canonical())
+
+	signedInfo := ds.Element("SignedInfo",
+		ds.Text("CanonicalizationMethod", "", Attr{"Algorithm", algExcC14N}),
+		ds.Text("SignatureMethod", "", Attr{"Algorithm", algRSASHA256}),
+		reference("", "", docDigest[:], algEnveloped, algExcC14N),
+		reference("#"+propertiesId, signedPropsType, propertiesDigest[:], algExcC14N),
+	)
+	signedInfoDigest := sha256.Sum256(signedInfo.Canonical())
+	signatureValue, err := s.Sign(rand.Reader, signedInfoDigest[:], crypto.SHA256)
+	if err != nil {
+		return err
+	}
+
+	qualifying := xades.Element("QualifyingProperties", properties)
+	qualifying.Attrs = []Attr{{"Target", "#" + signatureId}}
+	signature := ds.Element("Signature",
+		signedInfo,
+		ds.Text("SignatureValue", base64.StdEncoding.EncodeToString(signatureValue)),
+		ds.Element("KeyInfo",
+			ds.Element("X509Data", ds.Text("X509Certificate", base64.StdEncoding.EncodeToString(cert.Raw))),
+		),
+		ds.Element("Object", qualifying),
+	)
+	signature.Attrs = []Attr{{"Id", signatureId}}
+	root.Add(signature)
+	return nil
+}
+
+func reference(uri string, refType string, digest []byte, transforms ...string) *Element {
+	el := ds.Element("Reference")
+	el.Attrs = []Attr{{"URI", uri}}
+	if refType != "" {
+		el.Attrs = append(el.Attrs, Attr{"Type", refType})
+	}
+	list := ds.Element("Transforms")
+	for _, transform := range transforms {
+		list.Add(ds.Text("Transform", "", Attr{"Algorithm", transform}))
+	}
+	return el.Add(
+		list,
+		ds.Text("DigestMethod", "", Attr{"Algorithm", algSHA256}),
+		ds.Text("DigestValue", base64.StdEncoding.EncodeToString(digest)),
+	)
+}
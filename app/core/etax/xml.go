@@ -0,0 +1,98 @@
+package etax
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+)
+
+// Documents are built as a small element tree rather than with encoding/xml, so the bytes written
+// are already in Exclusive XML Canonicalization form (xml-exc-c14n). The signature digests are
+// taken over the same bytes a verifier canonicalizes to, without a general purpose canonicalizer.
+
+type Namespace struct {
+	Prefix string
+	URI    string
+}
+
+type Attr struct {
+	Name  string
+	Value string
+}
+
+type Element struct {
+	Space    Namespace
+	Local    string
+	Attrs    []Attr
+	Text     string
+	Children []*Element
+}
+
+// Element returns an element of the namespace holding the children
+func (ns Namespace) Element(local string, children ...*Element) *Element {
+	return &Element{Space: ns, Local: local, Children: children}
+}
+
+// Text returns an element of the namespace holding text
+func (ns Namespace) Text(local string, text string, attrs ...Attr) *Element {
+	return &Element{Space: ns, Local: local, Text: text, Attrs: attrs}
+}
+
+// Add appends the children that are not nil
+func (el *Element) Add(children ...*Element) *Element {
+	for _, child := range children {
+		if child != nil {
+			el.Children = append(el.Children, child)
+		}
+	}
+	return el
+}
+
+// Canonical returns the element as the apex of an exclusive canonical document subset
+func (el *Element) Canonical() []byte {
+	buf := &bytes.Buffer{}
+	el.write(buf, map[string]string{})
+	return buf.Bytes()
+}
+
+// write renders a namespace declaration only where the prefix is used and no output ancestor
+// declared it already, and the attributes sorted by name, as exclusive canonicalization does
+func (el *Element) write(buf *bytes.Buffer, rendered map[string]string) {
+	name := el.Local
+	if el.Space.Prefix != "" {
+		name = el.Space.Prefix + ":" + el.Local
+	}
+	buf.WriteString("<" + name)
+	if el.Space.Prefix != "" && rendered[el.Space.Prefix] != el.Space.URI {
+		scope := make(map[string]string, len(rendered)+1)
+		for prefix, uri := range rendered {
+			scope[prefix] = uri
+		}
+		scope[el.Space.Prefix] = el.Space.URI
+		rendered = scope
+		buf.WriteString(" xmlns:" + el.Space.Prefix + "=\"" + escapeAttr(el.Space.URI) + "\"")
+	}
+	attrs := append([]Attr{}, el.Attrs...)
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Name < attrs[j].Name })
+	for _, attr := range attrs {
+		buf.WriteString(" " + attr.Name + "=\"" + escapeAttr(attr.Value) + "\"")
+	}
+	buf.WriteString(">")
+	buf.WriteString(escapeText(el.Text))
+	for _, child := range el.Children {
+		child.write(buf, rendered)
+	}
+	buf.WriteString("</" + name + ">")
+}
+
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+
+var attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", "\"", "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+
+func escapeText(text string) string {
+	return textEscaper.Replace(text)
+}
+
+func escapeAttr(value string) string {
+	return attrEscaper.Replace(value)
+}
@@ -0,0 +1,72 @@
+package pdf
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/sirupsen/logrus"
+)
+
+// Documents embed a UTF-8 TrueType font so Thai text renders. The font files are read once from
+// PDF_FONT_DIR (default "fonts") named <PDF_FONT_FAMILY>-Regular.ttf, -Bold.ttf, -Italic.ttf and
+// -BoldItalic.ttf, with Sarabun as the default family. Only the regular face is required, the
+// other styles fall back to it. Without the font the core Arial font is kept, which has no Thai.
+
+type fontFace struct {
+	style string
+	data  []byte
+}
+
+var (
+	fontOnce  sync.Once
+	fontFaces []fontFace
+)
+
+var fontStyles = []struct {
+	style string
+	name  string
+}{
+	{"", "Regular"},
+	{"B", "Bold"},
+	{"I", "Italic"},
+	{"BI", "BoldItalic"},
+}
+
+func loadFonts() {
+	dir := os.Getenv("PDF_FONT_DIR")
+	if dir == "" {
+		dir = "fonts"
+	}
+	family := os.Getenv("PDF_FONT_FAMILY")
+	if family == "" {
+		family = "Sarabun"
+	}
+
+	var regular []byte
+	for _, fontStyle := range fontStyles {
+		path := filepath.Join(dir, family+"-"+fontStyle.name+".ttf")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if fontStyle.style == "" {
+				logrus.Warn("pdf font not found, Thai text will not render: ", err)
+				return
+			}
+			data = regular
+		}
+		if fontStyle.style == "" {
+			regular = data
+		}
+		fontFaces = append(fontFaces, fontFace{style: fontStyle.style, data: data})
+	}
+	FontFamily = family
+}
+
+// registerFonts adds the embedded font to the document
+func registerFonts(doc *fpdf.Fpdf) {
+	fontOnce.Do(loadFonts)
+	for _, face := range fontFaces {
+		doc.AddUTF8FontFromBytes(FontFamily, face.style, face.data)
+	}
+}
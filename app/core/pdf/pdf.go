@@ -4,8 +4,10 @@ import (
 	"github.com/go-pdf/fpdf"
 )
 
+// FontFamily is the embedded Thai font once it is loaded, see font.go
+var FontFamily = "Arial"
+
 const (
-	FontFamily = "Arial"
 	FontSize   = 10
 	HeaderSize = 14
 	TitleSize  = 12
@@ -13,11 +15,35 @@ const (
 
 func NewPDF() *fpdf.Fpdf {
 	pdf := fpdf.New("P", "mm", "A4", "")
+	registerFonts(pdf)
 	pdf.SetAutoPageBreak(true, 15)
 	pdf.SetFont(FontFamily, "", FontSize)
 	return pdf
 }
 
+// NewLandscapePDF creates an A4 landscape document for wide registers, 277 mm between the margins
+func NewLandscapePDF() *fpdf.Fpdf {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	registerFonts(pdf)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.SetFont(FontFamily, "", FontSize)
+	return pdf
+}
+
+// NewRollPDF creates a single page document for roll paper of the given width and length in mm
+func NewRollPDF(width float64, length float64) *fpdf.Fpdf {
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		OrientationStr: "P",
+		UnitStr:        "mm",
+		Size:           fpdf.SizeType{Wd: width, Ht: length},
+	})
+	registerFonts(pdf)
+	pdf.SetMargins(3, 3, 3)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetFont(FontFamily, "", 8)
+	return pdf
+}
+
 func AddHeader(pdf *fpdf.Fpdf, companyName string, companyAddress string, companyPhone string, title string) {
 	pdf.SetFont(FontFamily, "B", HeaderSize)
 	pdf.CellFormat(0, 8, companyName, "", 1, "C", false, 0, "")
@@ -55,15 +81,38 @@ func AddTableHeader(pdf *fpdf.Fpdf, headers []string, widths []float64) {
 	pdf.SetFont(FontFamily, "", FontSize)
 }
 
+// AddTableRow wraps long cells onto more lines, the row grows to the tallest cell
 func AddTableRow(pdf *fpdf.Fpdf, cells []string, widths []float64, aligns []string) {
+	lineHeight := 6.0
+	lines := make([][]string, len(cells))
+	rowLines := 1
 	for i, cell := range cells {
+		lines[i] = SplitText(pdf, cell, widths[i]-2*pdf.GetCellMargin())
+		if len(lines[i]) > rowLines {
+			rowLines = len(lines[i])
+		}
+	}
+	height := float64(rowLines) * lineHeight
+
+	_, pageHeight := pdf.GetPageSize()
+	if auto, bottom := pdf.GetAutoPageBreak(); auto && pdf.GetY()+height > pageHeight-bottom {
+		pdf.AddPage()
+	}
+
+	x, y := pdf.GetX(), pdf.GetY()
+	for i := range cells {
 		align := "L"
 		if i < len(aligns) {
 			align = aligns[i]
 		}
-		pdf.CellFormat(widths[i], 6, cell, "1", 0, align, false, 0, "")
+		pdf.Rect(x, y, widths[i], height, "D")
+		for j, line := range lines[i] {
+			pdf.SetXY(x, y+float64(j)*lineHeight)
+			pdf.CellFormat(widths[i], lineHeight, line, "", 0, align, false, 0, "")
+		}
+		x += widths[i]
 	}
-	pdf.Ln(-1)
+	pdf.SetY(y + height)
 }
 
 func AddSummaryLine(pdf *fpdf.Fpdf, label string, value string, totalWidth float64) {
@@ -0,0 +1,42 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// SetPDFA3 embeds the files in the document and declares PDF/A-3B conformance in its XMP metadata,
+// which is how an e-Tax XML travels inside its PDF. fpdf writes no ICC output intent, so strict
+// validators such as veraPDF still flag the colour space, the Revenue Department only reads the XML.
+func SetPDFA3(doc *fpdf.Fpdf, title string, created time.Time, attachments ...fpdf.Attachment) {
+	doc.SetTitle(title, true)
+	doc.SetProducer("POS System", false)
+	doc.SetCreationDate(created)
+	doc.SetModificationDate(created)
+	doc.SetAttachments(attachments)
+
+	escapedTitle := &bytes.Buffer{}
+	xml.EscapeText(escapedTitle, []byte(title))
+	date := created.Format(time.RFC3339)
+	doc.SetXmpMetadata([]byte(`<?xpacket begin="` + "\ufeff" + `" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/">
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description rdf:about="" xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/">
+<pdfaid:part>3</pdfaid:part>
+<pdfaid:conformance>B</pdfaid:conformance>
+</rdf:Description>
+<rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:title><rdf:Alt><rdf:li xml:lang="x-default">` + escapedTitle.String() + `</rdf:li></rdf:Alt></dc:title>
+</rdf:Description>
+<rdf:Description rdf:about="" xmlns:xmp="http://ns.adobe.com/xap/1.0/" xmlns:pdf="http://ns.adobe.com/pdf/1.3/">
+<xmp:CreateDate>` + date + `</xmp:CreateDate>
+<xmp:ModifyDate>` + date + `</xmp:ModifyDate>
+<pdf:Producer>POS System</pdf:Producer>
+</rdf:Description>
+</rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>`))
+}
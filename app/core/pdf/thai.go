@@ -0,0 +1,102 @@
+package pdf
+
+import (
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Thai is written without spaces between words and stacks vowels and tone marks on the
+// consonant, so text is wrapped between character clusters rather than between runes.
+
+func isThaiMark(r rune) bool {
+	return r == 0x0E31 || (r >= 0x0E34 && r <= 0x0E3A) || (r >= 0x0E47 && r <= 0x0E4E)
+}
+
+func isThaiFollowingVowel(r rune) bool {
+	return r == 0x0E30 || r == 0x0E32 || r == 0x0E33 || r == 0x0E45
+}
+
+func isThaiLeadingVowel(r rune) bool {
+	return r >= 0x0E40 && r <= 0x0E44
+}
+
+// clusters splits the text into units a line may not break inside: a consonant with its marks
+// and following vowels, joined to a leading vowel written before it
+func clusters(text string) []string {
+	results := []string{}
+	joinNext := false
+	for _, r := range text {
+		if len(results) > 0 && (joinNext || isThaiMark(r) || isThaiFollowingVowel(r)) {
+			results[len(results)-1] += string(r)
+		} else {
+			results = append(results, string(r))
+		}
+		joinNext = isThaiLeadingVowel(r)
+	}
+	return results
+}
+
+// SplitText wraps the text to the width, breaking at spaces where possible and between Thai
+// character clusters otherwise. Line breaks in the text are kept.
+func SplitText(pdf *fpdf.Fpdf, text string, width float64) []string {
+	lines := []string{}
+	for _, paragraph := range strings.Split(text, "\n") {
+		lines = append(lines, splitParagraph(pdf, paragraph, width)...)
+	}
+	return lines
+}
+
+func splitParagraph(pdf *fpdf.Fpdf, text string, width float64) []string {
+	if pdf.GetStringWidth(text) <= width {
+		return []string{text}
+	}
+	lines := []string{}
+	line := []string{}
+	lastSpace := -1
+	for _, cluster := range clusters(text) {
+		if len(line) > 0 && pdf.GetStringWidth(strings.Join(line, "")+cluster) > width {
+			if cluster == " " {
+				lines = append(lines, strings.Join(line, ""))
+				line, lastSpace = nil, -1
+				continue
+			}
+			if lastSpace > 0 {
+				lines = append(lines, strings.Join(line[:lastSpace], ""))
+				line = append([]string{}, line[lastSpace+1:]...)
+			} else {
+				lines = append(lines, strings.Join(line, ""))
+				line = nil
+			}
+			lastSpace = -1
+			for i, c := range line {
+				if c == " " {
+					lastSpace = i
+				}
+			}
+		}
+		if cluster == " " {
+			lastSpace = len(line)
+		}
+		line = append(line, cluster)
+	}
+	if len(line) > 0 {
+		lines = append(lines, strings.Join(line, ""))
+	}
+	return lines
+}
+
+// Truncate shortens the text to the width with a trailing "..", never cutting a Thai cluster
+func Truncate(pdf *fpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	result := ""
+	for _, cluster := range clusters(text) {
+		if pdf.GetStringWidth(result+cluster+"..") > width {
+			break
+		}
+		result += cluster
+	}
+	return result + ".."
+}
@@ -0,0 +1,8 @@
+package utils
+
+import "math"
+
+// Round2 rounds an amount to the satang
+func Round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,18 @@
+package utils
+
+// IsValidThaiId checks a 13 digit Thai citizen or tax id against its mod 11 check digit
+func IsValidThaiId(id string) bool {
+	if len(id) != 13 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 13; i++ {
+		if id[i] < '0' || id[i] > '9' {
+			return false
+		}
+		if i < 12 {
+			sum += int(id[i]-'0') * (13 - i)
+		}
+	}
+	return (11-sum%11)%10 == int(id[12]-'0')
+}
@@ -0,0 +1,38 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Cart struct {
+	Id            primitive.ObjectID `bson:"_id" json:"id"`
+	BranchId      primitive.ObjectID `bson:"branchId" json:"branchId"`
+	UserId        string             `bson:"userId" json:"userId"`
+	Name          string             `bson:"name" json:"name"`
+	CustomerCode  string             `bson:"customerCode" json:"customerCode"`
+	CustomerName  string             `bson:"customerName" json:"customerName"`
+	Discount      float64            `bson:"discount" json:"discount"`
+	PromotionCode string             `bson:"promotionCode,omitempty" json:"promotionCode,omitempty"`
+	Note          string             `bson:"note" json:"note"`
+	Items         []CartItem         `bson:"items" json:"items"`
+	Status        string             `bson:"status" json:"status"`
+	ReservedUntil *time.Time         `bson:"reservedUntil,omitempty" json:"reservedUntil,omitempty"`
+	OrderId       string             `bson:"orderId,omitempty" json:"orderId,omitempty"`
+	OrderCode     string             `bson:"orderCode,omitempty" json:"orderCode,omitempty"`
+	CreatedBy     string             `bson:"createdBy" json:"createdBy"`
+	CreatedDate   time.Time          `bson:"createdDate" json:"createdDate"`
+	UpdatedBy     string             `bson:"updatedBy" json:"-"`
+	UpdatedDate   time.Time          `bson:"updatedDate" json:"updatedDate"`
+}
+
+type CartItem struct {
+	ProductId     primitive.ObjectID `bson:"productId" json:"productId"`
+	UnitId        primitive.ObjectID `bson:"unitId" json:"unitId"`
+	Quantity      int                `bson:"quantity" json:"quantity"`
+	Price         float64            `bson:"price" json:"price"`
+	Discount      float64            `bson:"discount" json:"discount"`
+	PriceOverride bool               `bson:"priceOverride" json:"priceOverride"`
+	BaseQuantity  int                `bson:"baseQuantity" json:"baseQuantity"`
+}
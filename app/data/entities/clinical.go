@@ -0,0 +1,87 @@
+package entities
+
+import (
+	"strings"
+	"time"
+)
+
+// ClinicalAlert is an allergy or drug interaction found on the items of a sale.
+// Blocking alerts stop the sale unless a pharmacist overrides them.
+type ClinicalAlert struct {
+	Type             string `bson:"type" json:"type"`
+	ProductId        string `bson:"productId" json:"productId"`
+	ProductName      string `bson:"productName" json:"productName"`
+	OtherProductId   string `bson:"otherProductId,omitempty" json:"otherProductId,omitempty"`
+	OtherProductName string `bson:"otherProductName,omitempty" json:"otherProductName,omitempty"`
+	DrugName         string `bson:"drugName" json:"drugName"`
+	Reaction         string `bson:"reaction,omitempty" json:"reaction,omitempty"`
+	Management       string `bson:"management,omitempty" json:"management,omitempty"`
+	Severity         string `bson:"severity" json:"severity"`
+	Blocking         bool   `bson:"blocking" json:"blocking"`
+}
+
+// ClinicalOverride records the pharmacist who let a sale through blocking alerts and why
+type ClinicalOverride struct {
+	Alerts         []ClinicalAlert `bson:"alerts" json:"alerts"`
+	Justification  string          `bson:"justification" json:"justification"`
+	PharmacistId   string          `bson:"pharmacistId" json:"pharmacistId"`
+	PharmacistName string          `bson:"pharmacistName" json:"pharmacistName"`
+	LicenseNo      string          `bson:"licenseNo" json:"licenseNo"`
+	OverriddenDate time.Time       `bson:"overriddenDate" json:"overriddenDate"`
+}
+
+// FindAllergy returns the allergy of the patient matching the trade or generic name of the
+// product, one of its ingredients or a drug class of those ingredients. classes comes from
+// IngredientClasses and may be nil when the ingredient master is not at hand.
+func (patient *Patient) FindAllergy(product *Product, classes map[string][]string) *DrugAllergy {
+	if product == nil || product.DrugInfo == nil {
+		return nil
+	}
+	ingredients := product.Ingredients()
+	for i, allergy := range patient.Allergies {
+		allergen := NormalizeIngredient(allergy.DrugName)
+		if allergen == "" {
+			continue
+		}
+		if allergen == NormalizeIngredient(product.Name) || allergen == NormalizeIngredient(product.DrugInfo.GenericName) {
+			return &patient.Allergies[i]
+		}
+		for _, ingredient := range ingredients {
+			if ingredient == allergen || containsString(classes[ingredient], allergen) {
+				return &patient.Allergies[i]
+			}
+		}
+	}
+	return nil
+}
+
+// FindInteraction returns the entry of the product interaction list naming the other product
+// by trade name, generic name, one of its ingredients or serial number
+func (product *Product) FindInteraction(other *Product) string {
+	if product == nil || other == nil || product.DrugInfo == nil {
+		return ""
+	}
+	generic := ""
+	if other.DrugInfo != nil {
+		generic = other.DrugInfo.GenericName
+	}
+	ingredients := other.Ingredients()
+	for _, interaction := range product.DrugInfo.DrugInteractions {
+		if strings.EqualFold(interaction, other.Name) || (generic != "" && strings.EqualFold(interaction, generic)) ||
+			(other.SerialNumber != "" && strings.EqualFold(interaction, other.SerialNumber)) ||
+			containsString(ingredients, NormalizeIngredient(interaction)) {
+			return interaction
+		}
+	}
+	return ""
+}
+
+// ClinicalIntervention is one overridden sale on the clinical interventions report
+type ClinicalIntervention struct {
+	OrderId     string           `json:"orderId"`
+	OrderCode   string           `json:"orderCode"`
+	PatientId   string           `json:"patientId,omitempty"`
+	Status      string           `json:"status"`
+	CreatedDate time.Time        `json:"createdDate"`
+	Override    ClinicalOverride `json:"override"`
+}
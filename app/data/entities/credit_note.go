@@ -0,0 +1,52 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CreditNote struct {
+	Id           primitive.ObjectID `bson:"_id" json:"id"`
+	BranchId     primitive.ObjectID `bson:"branchId" json:"branchId"`
+	OrderId      primitive.ObjectID `bson:"orderId" json:"orderId"`
+	OrderCode    string             `bson:"orderCode" json:"orderCode"`
+	Code         string             `bson:"code" json:"code"`
+	CustomerCode string             `bson:"customerCode" json:"customerCode"`
+	CustomerName string             `bson:"customerName" json:"customerName"`
+	Reason       string             `bson:"reason" json:"reason"`
+	Items        []CreditNoteItem   `bson:"items" json:"items"`
+	Refunds      []CreditNoteRefund `bson:"refunds" json:"refunds"`
+	Total        float64            `bson:"total" json:"total"`
+	TotalCost    float64            `bson:"totalCost" json:"totalCost"`
+	Status       string             `bson:"status" json:"status"`
+	ShiftId      string             `bson:"shiftId,omitempty" json:"shiftId,omitempty"`
+	CreatedBy    string             `bson:"createdBy" json:"-"`
+	CreatedDate  time.Time          `bson:"createdDate" json:"createdDate"`
+	UpdatedBy    string             `bson:"updatedBy" json:"-"`
+	UpdatedDate  time.Time          `bson:"updatedDate" json:"-"`
+}
+
+type CreditNoteItem struct {
+	OrderItemId primitive.ObjectID    `bson:"orderItemId" json:"orderItemId"`
+	ProductId   primitive.ObjectID    `bson:"productId" json:"productId"`
+	UnitId      primitive.ObjectID    `bson:"unitId" json:"unitId"`
+	Quantity    int                   `bson:"quantity" json:"quantity"`
+	Price       float64               `bson:"price" json:"price"`
+	CostPrice   float64               `bson:"costPrice" json:"costPrice"`
+	Damaged     bool                  `bson:"damaged" json:"damaged"`
+	Stocks      []CreditNoteItemStock `bson:"stocks" json:"stocks"`
+}
+
+type CreditNoteItemStock struct {
+	OrderStockId string `bson:"orderStockId" json:"orderStockId"`
+	StockId      string `bson:"stockId" json:"stockId"`
+	LotNumber    string `bson:"lotNumber" json:"lotNumber"`
+	Quantity     int    `bson:"quantity" json:"quantity"`
+	Quarantined  bool   `bson:"quarantined" json:"quarantined"`
+}
+
+type CreditNoteRefund struct {
+	Type   string  `bson:"type" json:"type"`
+	Amount float64 `bson:"amount" json:"amount"`
+}
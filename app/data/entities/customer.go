@@ -14,6 +14,8 @@ type Customer struct {
 	Phone        string             `bson:"phone" json:"phone"`
 	Email        string             `bson:"email" json:"email"`
 	Status       string             `bson:"status" json:"status"`
+	CreditLimit  float64            `bson:"creditLimit" json:"creditLimit"`
+	CreditDays   int                `bson:"creditDays" json:"creditDays"`
 	CreatedBy    string             `bson:"createdBy" json:"-"`
 	CreatedDate  time.Time          `bson:"createdDate" json:"createdDate"`
 	UpdatedBy    string             `bson:"updatedBy" json:"-"`
@@ -7,24 +7,33 @@ import (
 )
 
 type DispensingItem struct {
-	ProductId   primitive.ObjectID `bson:"productId" json:"productId"`
-	ProductName string             `bson:"productName" json:"productName"`
-	GenericName string             `bson:"genericName" json:"genericName"`
-	Quantity    int                `bson:"quantity" json:"quantity"`
-	Unit        string             `bson:"unit" json:"unit"`
-	Dosage      string             `bson:"dosage" json:"dosage"`
-	LotNumber   string             `bson:"lotNumber" json:"lotNumber"`
+	OrderItemId      primitive.ObjectID `bson:"orderItemId,omitempty" json:"orderItemId,omitempty"`
+	ProductId        primitive.ObjectID `bson:"productId" json:"productId"`
+	ProductName      string             `bson:"productName" json:"productName"`
+	GenericName      string             `bson:"genericName" json:"genericName"`
+	Quantity         int                `bson:"quantity" json:"quantity"`
+	Unit             string             `bson:"unit" json:"unit"`
+	Dosage           string             `bson:"dosage" json:"dosage"`
+	LotNumber        string             `bson:"lotNumber" json:"lotNumber"`
+	ReturnedQuantity int                `bson:"returnedQuantity,omitempty" json:"returnedQuantity,omitempty"`
+}
+
+// NetQuantity is the quantity dispensed less what came back on credit notes
+func (item DispensingItem) NetQuantity() int {
+	return item.Quantity - item.ReturnedQuantity
 }
 
 type DispensingLog struct {
-	Id              primitive.ObjectID `bson:"_id" json:"id"`
-	BranchId        primitive.ObjectID `bson:"branchId" json:"branchId"`
-	OrderId         primitive.ObjectID `bson:"orderId" json:"orderId"`
-	PatientId       primitive.ObjectID `bson:"patientId" json:"patientId"`
-	Items           []DispensingItem   `bson:"items" json:"items"`
-	PharmacistName  string             `bson:"pharmacistName" json:"pharmacistName"`
-	LicenseNo       string             `bson:"licenseNo" json:"licenseNo"`
-	Note            string             `bson:"note" json:"note"`
-	CreatedBy       string             `bson:"createdBy" json:"-"`
-	CreatedDate     time.Time          `bson:"createdDate" json:"createdDate"`
+	Id             primitive.ObjectID `bson:"_id" json:"id"`
+	BranchId       primitive.ObjectID `bson:"branchId" json:"branchId"`
+	OrderId        primitive.ObjectID `bson:"orderId" json:"orderId"`
+	OrderCode      string             `bson:"orderCode,omitempty" json:"orderCode,omitempty"`
+	PatientId      primitive.ObjectID `bson:"patientId" json:"patientId"`
+	Items          []DispensingItem   `bson:"items" json:"items"`
+	PharmacistName string             `bson:"pharmacistName" json:"pharmacistName"`
+	LicenseNo      string             `bson:"licenseNo" json:"licenseNo"`
+	Note           string             `bson:"note" json:"note"`
+	Status         string             `bson:"status" json:"status"`
+	CreatedBy      string             `bson:"createdBy" json:"-"`
+	CreatedDate    time.Time          `bson:"createdDate" json:"createdDate"`
 }
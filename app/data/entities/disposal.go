@@ -0,0 +1,45 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Disposal records the destruction of non-sellable stock with its witnesses and method, as GPP
+// inspections require
+type Disposal struct {
+	Id           primitive.ObjectID `bson:"_id" json:"id"`
+	BranchId     primitive.ObjectID `bson:"branchId" json:"branchId"`
+	Code         string             `bson:"code" json:"code"`
+	Method       string             `bson:"method" json:"method"`
+	Location     string             `bson:"location" json:"location"`
+	DisposedDate time.Time          `bson:"disposedDate" json:"disposedDate"`
+	Witnesses    []DisposalWitness  `bson:"witnesses" json:"witnesses"`
+	Items        []DisposalItem     `bson:"items" json:"items"`
+	TotalCost    float64            `bson:"totalCost" json:"totalCost"`
+	Note         string             `bson:"note" json:"note"`
+	CreatedBy    string             `bson:"createdBy" json:"createdBy"`
+	CreatedDate  time.Time          `bson:"createdDate" json:"createdDate"`
+}
+
+type DisposalWitness struct {
+	Name      string `bson:"name" json:"name"`
+	Position  string `bson:"position" json:"position"`
+	LicenseNo string `bson:"licenseNo" json:"licenseNo"`
+}
+
+type DisposalItem struct {
+	StockId      string             `bson:"stockId" json:"stockId"`
+	ProductId    primitive.ObjectID `bson:"productId" json:"productId"`
+	UnitId       primitive.ObjectID `bson:"unitId" json:"unitId"`
+	ProductName  string             `bson:"productName" json:"productName"`
+	SerialNumber string             `bson:"serialNumber" json:"serialNumber"`
+	Unit         string             `bson:"unit" json:"unit"`
+	LotNumber    string             `bson:"lotNumber" json:"lotNumber"`
+	ExpireDate   time.Time          `bson:"expireDate" json:"expireDate"`
+	Status       string             `bson:"status" json:"status"`
+	Quantity     int                `bson:"quantity" json:"quantity"`
+	CostPrice    float64            `bson:"costPrice" json:"costPrice"`
+	CostAmount   float64            `bson:"costAmount" json:"costAmount"`
+}
@@ -0,0 +1,166 @@
+package entities
+
+import (
+	"pos/app/domain/constant"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DrugInteraction is a known interaction between two active ingredients, stored with the
+// normalized ingredient names in order so a pair has a single entry
+type DrugInteraction struct {
+	Id          primitive.ObjectID `bson:"_id" json:"id"`
+	IngredientA string             `bson:"ingredientA" json:"ingredientA"`
+	IngredientB string             `bson:"ingredientB" json:"ingredientB"`
+	Severity    string             `bson:"severity" json:"severity"`
+	Mechanism   string             `bson:"mechanism" json:"mechanism"`
+	Management  string             `bson:"management" json:"management"`
+	Source      string             `bson:"source" json:"source"`
+	CreatedBy   string             `bson:"createdBy" json:"-"`
+	CreatedDate time.Time          `bson:"createdDate" json:"createdDate"`
+	UpdatedBy   string             `bson:"updatedBy" json:"-"`
+	UpdatedDate time.Time          `bson:"updatedDate" json:"-"`
+}
+
+// DrugInteractionMatch is an interaction found between two products of a sale
+type DrugInteractionMatch struct {
+	ProductAId   string `json:"productAId"`
+	ProductAName string `json:"productAName"`
+	ProductBId   string `json:"productBId"`
+	ProductBName string `json:"productBName"`
+	IngredientA  string `json:"ingredientA,omitempty"`
+	IngredientB  string `json:"ingredientB,omitempty"`
+	Interaction  string `json:"interaction"`
+	Severity     string `json:"severity"`
+	Mechanism    string `json:"mechanism,omitempty"`
+	Management   string `json:"management,omitempty"`
+	Source       string `json:"source,omitempty"`
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// NormalizeIngredient folds case, punctuation and spacing so spelling variants of an
+// ingredient name compare equal
+func NormalizeIngredient(name string) string {
+	return strings.TrimSpace(nonAlphanumeric.ReplaceAllString(strings.ToLower(name), " "))
+}
+
+// IngredientPair returns the two normalized names in the order they are stored in
+func IngredientPair(a string, b string) (string, string) {
+	a, b = NormalizeIngredient(a), NormalizeIngredient(b)
+	if b < a {
+		return b, a
+	}
+	return a, b
+}
+
+// Ingredients returns the normalized active ingredients of the product, taken from the linked
+// ingredients or, for products not linked yet, from the generic name where combinations are
+// written with "+", "/" or ","
+func (product *Product) Ingredients() []string {
+	if product == nil || product.DrugInfo == nil {
+		return nil
+	}
+	results := []string{}
+	if len(product.DrugInfo.Ingredients) > 0 {
+		for _, ingredient := range product.DrugInfo.Ingredients {
+			if name := NormalizeIngredient(ingredient.Name); name != "" && !containsString(results, name) {
+				results = append(results, name)
+			}
+		}
+		return results
+	}
+	for _, part := range strings.FieldsFunc(product.DrugInfo.GenericName, func(r rune) bool {
+		return r == '+' || r == '/' || r == ','
+	}) {
+		if name := NormalizeIngredient(part); name != "" && !containsString(results, name) {
+			results = append(results, name)
+		}
+	}
+	return results
+}
+
+// MatchDrugInteractions pairs up the products against the known interactions of their
+// ingredients, falling back to the free text list on the product when no entry exists, unrated.
+// The worst interaction of each product pair is kept and the results are ranked by severity.
+func MatchDrugInteractions(products []*Product, known []DrugInteraction) []DrugInteractionMatch {
+	pairs := make(map[string]*DrugInteraction)
+	for i := range known {
+		pairs[known[i].IngredientA+"|"+known[i].IngredientB] = &known[i]
+	}
+
+	results := []DrugInteractionMatch{}
+	for i := 0; i < len(products); i++ {
+		for j := i + 1; j < len(products); j++ {
+			a, b := products[i], products[j]
+			var match *DrugInteractionMatch
+			for _, ingredientA := range a.Ingredients() {
+				for _, ingredientB := range b.Ingredients() {
+					first, second := IngredientPair(ingredientA, ingredientB)
+					interaction, ok := pairs[first+"|"+second]
+					if !ok || (match != nil && SeverityRank(interaction.Severity) >= SeverityRank(match.Severity)) {
+						continue
+					}
+					match = &DrugInteractionMatch{
+						ProductAId:   a.Id.Hex(),
+						ProductAName: a.Name,
+						ProductBId:   b.Id.Hex(),
+						ProductBName: b.Name,
+						IngredientA:  ingredientA,
+						IngredientB:  ingredientB,
+						Interaction:  ingredientA + " + " + ingredientB,
+						Severity:     interaction.Severity,
+						Mechanism:    interaction.Mechanism,
+						Management:   interaction.Management,
+						Source:       interaction.Source,
+					}
+				}
+			}
+			if match == nil {
+				if a.FindInteraction(b) == "" {
+					a, b = b, a
+				}
+				if text := a.FindInteraction(b); text != "" {
+					match = &DrugInteractionMatch{
+						ProductAId:   a.Id.Hex(),
+						ProductAName: a.Name,
+						ProductBId:   b.Id.Hex(),
+						ProductBName: b.Name,
+						Interaction:  text,
+						Severity:     constant.InteractionSeverityUnrated,
+					}
+				}
+			}
+			if match != nil {
+				results = append(results, *match)
+			}
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return SeverityRank(results[i].Severity) < SeverityRank(results[j].Severity)
+	})
+	return results
+}
+
+// SeverityRank orders interaction severities, lower is more severe
+func SeverityRank(severity string) int {
+	for i, s := range constant.InteractionSeverities() {
+		if s == severity {
+			return i
+		}
+	}
+	return len(constant.InteractionSeverities())
+}
+
+func containsString(items []string, value string) bool {
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -11,6 +11,8 @@ type Employee struct {
 	BranchId    primitive.ObjectID `bson:"branchId" json:"branchId"`
 	UserId      string             `bson:"userId" json:"userId"`
 	Role        string             `bson:"role" json:"role"`
+	Name        string             `bson:"name" json:"name"`
+	LicenseNo   string             `bson:"licenseNo" json:"licenseNo"`
 	CreatedBy   string             `bson:"createdBy" json:"-"`
 	CreatedDate time.Time          `bson:"createdDate" json:"createdDate"`
 	UpdatedBy   string             `bson:"updatedBy" json:"-"`
@@ -22,6 +24,8 @@ type EmployeeDetail struct {
 	BranchId    primitive.ObjectID `bson:"branchId" json:"branchId"`
 	UserId      string             `bson:"userId" json:"userId"`
 	Role        string             `bson:"role" json:"role"`
+	Name        string             `bson:"name" json:"name"`
+	LicenseNo   string             `bson:"licenseNo" json:"licenseNo"`
 	CreatedBy   string             `bson:"createdBy" json:"-"`
 	CreatedDate time.Time          `bson:"createdDate" json:"createdDate"`
 	UpdatedBy   string             `bson:"updatedBy" json:"-"`
@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EtaxDocument is an e-Tax document as it was signed, the XML and the PDF/A-3 carrying it are
+// archived with the branch that issued it and never change afterwards
+type EtaxDocument struct {
+	Id            primitive.ObjectID `bson:"_id" json:"id"`
+	BranchId      primitive.ObjectID `bson:"branchId" json:"branchId"`
+	SourceType    string             `bson:"sourceType" json:"sourceType"`
+	SourceId      primitive.ObjectID `bson:"sourceId" json:"sourceId"`
+	TypeCode      string             `bson:"typeCode" json:"typeCode"`
+	Code          string             `bson:"code" json:"code"`
+	IssuedDate    time.Time          `bson:"issuedDate" json:"issuedDate"`
+	Total         float64            `bson:"total" json:"total"`
+	XmlDigest     string             `bson:"xmlDigest" json:"xmlDigest"`
+	SignerSubject string             `bson:"signerSubject" json:"signerSubject"`
+	SignerSerial  string             `bson:"signerSerial" json:"signerSerial"`
+	Xml           []byte             `bson:"xml,omitempty" json:"-"`
+	Pdf           []byte             `bson:"pdf,omitempty" json:"-"`
+	CreatedBy     string             `bson:"createdBy" json:"-"`
+	CreatedDate   time.Time          `bson:"createdDate" json:"createdDate"`
+}
@@ -0,0 +1,14 @@
+package entities
+
+const (
+	IdempotencyProcessing = "PROCESSING"
+	IdempotencyCompleted  = "COMPLETED"
+)
+
+type IdempotencyRecord struct {
+	Status      string `json:"status"`
+	Fingerprint string `json:"fingerprint"`
+	StatusCode  int    `json:"statusCode"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
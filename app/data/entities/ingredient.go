@@ -0,0 +1,68 @@
+package entities
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Ingredient is an active ingredient of the master list. Classes name the drug classes it
+// belongs to, such as "penicillins", so a class allergy covers every member.
+type Ingredient struct {
+	Id             primitive.ObjectID `bson:"_id" json:"id"`
+	Name           string             `bson:"name" json:"name"`
+	NormalizedName string             `bson:"normalizedName" json:"normalizedName"`
+	Classes        []string           `bson:"classes" json:"classes"`
+	Unit           string             `bson:"unit" json:"unit"`
+	Description    string             `bson:"description" json:"description"`
+	CreatedBy      string             `bson:"createdBy" json:"-"`
+	CreatedDate    time.Time          `bson:"createdDate" json:"createdDate"`
+	UpdatedBy      string             `bson:"updatedBy" json:"-"`
+	UpdatedDate    time.Time          `bson:"updatedDate" json:"-"`
+}
+
+// ProductIngredient links a product to an ingredient with its strength per dosage unit
+type ProductIngredient struct {
+	IngredientId primitive.ObjectID `bson:"ingredientId" json:"ingredientId"`
+	Name         string             `bson:"name" json:"name"`
+	Strength     float64            `bson:"strength" json:"strength"`
+	Unit         string             `bson:"unit" json:"unit"`
+}
+
+// IngredientClasses maps the normalized name of each ingredient to its drug classes
+func IngredientClasses(ingredients []Ingredient) map[string][]string {
+	results := make(map[string][]string, len(ingredients))
+	for _, ingredient := range ingredients {
+		results[ingredient.NormalizedName] = ingredient.Classes
+	}
+	return results
+}
+
+// IngredientLabel writes the ingredients with their strengths, e.g. "Amoxicillin 500 mg +
+// Clavulanic acid 125 mg", falling back to the generic name for unlinked products
+func (drugInfo *DrugInfo) IngredientLabel() string {
+	if drugInfo == nil {
+		return ""
+	}
+	if len(drugInfo.Ingredients) == 0 {
+		return drugInfo.GenericName
+	}
+	parts := make([]string, len(drugInfo.Ingredients))
+	for i, ingredient := range drugInfo.Ingredients {
+		parts[i] = ingredient.Name
+		if ingredient.Strength > 0 {
+			parts[i] += " " + strconv.FormatFloat(ingredient.Strength, 'f', -1, 64)
+			if ingredient.Unit != "" {
+				parts[i] += " " + ingredient.Unit
+			}
+		}
+	}
+	return strings.Join(parts, " + ")
+}
+
+// HasIngredient reports whether the product contains the ingredient
+func (product *Product) HasIngredient(name string) bool {
+	return containsString(product.Ingredients(), NormalizeIngredient(name))
+}
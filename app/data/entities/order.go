@@ -8,107 +8,146 @@ import (
 )
 
 type Order struct {
-	Id             primitive.ObjectID `bson:"_id" json:"id"`
-	BranchId       primitive.ObjectID `bson:"branchId" json:"branchId"`
-	Code           string             `bson:"code" json:"code"`
-	CustomerCode   string             `bson:"customerCode" json:"customerCode"`
-	CustomerName   string             `bson:"customerName" json:"customerName"`
-	PatientId      string             `bson:"patientId,omitempty" json:"patientId,omitempty"`
-	PharmacistName string             `bson:"pharmacistName,omitempty" json:"pharmacistName,omitempty"`
-	PrescriberName string             `bson:"prescriberName,omitempty" json:"prescriberName,omitempty"`
-	BuyerName      string             `bson:"buyerName,omitempty" json:"buyerName,omitempty"`
-	BuyerIdCard    string             `bson:"buyerIdCard,omitempty" json:"buyerIdCard,omitempty"`
-	Status         string             `bson:"status" json:"status"`
-	CreatedBy      string             `bson:"createdBy" json:"-"`
-	CreatedDate    time.Time          `bson:"createdDate" json:"createdDate"`
-	UpdatedBy      string             `bson:"updatedBy" json:"-"`
-	UpdatedDate    time.Time          `bson:"updatedDate" json:"-"`
-	Total          float64            `bson:"total" json:"total"`
-	TotalCost      float64            `bson:"totalCost" json:"totalCost"`
-	Discount       float64            `bson:"discount" json:"discount"`
-	Type           string             `bson:"type" json:"type"`
+	Id                primitive.ObjectID `bson:"_id" json:"id"`
+	BranchId          primitive.ObjectID `bson:"branchId" json:"branchId"`
+	Code              string             `bson:"code" json:"code"`
+	CustomerCode      string             `bson:"customerCode" json:"customerCode"`
+	CustomerName      string             `bson:"customerName" json:"customerName"`
+	PatientId         string             `bson:"patientId,omitempty" json:"patientId,omitempty"`
+	PharmacistId      string             `bson:"pharmacistId,omitempty" json:"pharmacistId,omitempty"`
+	PharmacistName    string             `bson:"pharmacistName,omitempty" json:"pharmacistName,omitempty"`
+	LicenseNo         string             `bson:"licenseNo,omitempty" json:"licenseNo,omitempty"`
+	PrescriberName    string             `bson:"prescriberName,omitempty" json:"prescriberName,omitempty"`
+	BuyerName         string             `bson:"buyerName,omitempty" json:"buyerName,omitempty"`
+	BuyerIdCard       string             `bson:"buyerIdCard,omitempty" json:"buyerIdCard,omitempty"`
+	DispensingLogId   string             `bson:"dispensingLogId,omitempty" json:"dispensingLogId,omitempty"`
+	ClinicalOverride  *ClinicalOverride  `bson:"clinicalOverride,omitempty" json:"clinicalOverride,omitempty"`
+	Status            string             `bson:"status" json:"status"`
+	CreatedBy         string             `bson:"createdBy" json:"-"`
+	CreatedDate       time.Time          `bson:"createdDate" json:"createdDate"`
+	UpdatedBy         string             `bson:"updatedBy" json:"-"`
+	UpdatedDate       time.Time          `bson:"updatedDate" json:"-"`
+	Total             float64            `bson:"total" json:"total"`
+	TotalCost         float64            `bson:"totalCost" json:"totalCost"`
+	Discount          float64            `bson:"discount" json:"discount"`
+	PromotionCode     string             `bson:"promotionCode,omitempty" json:"promotionCode,omitempty"`
+	PromotionDiscount float64            `bson:"promotionDiscount" json:"promotionDiscount"`
+	Type              string             `bson:"type" json:"type"`
+	VoidedBy          string             `bson:"voidedBy,omitempty" json:"voidedBy,omitempty"`
+	VoidedDate        *time.Time         `bson:"voidedDate,omitempty" json:"voidedDate,omitempty"`
+	VoidReasonCode    string             `bson:"voidReasonCode,omitempty" json:"voidReasonCode,omitempty"`
+	VoidReason        string             `bson:"voidReason,omitempty" json:"voidReason,omitempty"`
+	VoidApprovedBy    string             `bson:"voidApprovedBy,omitempty" json:"voidApprovedBy,omitempty"`
 }
 
 type OrderDetail struct {
-	Id             primitive.ObjectID       `bson:"_id" json:"id"`
-	BranchId       primitive.ObjectID       `bson:"branchId" json:"branchId"`
-	Code           string                   `bson:"code" json:"code"`
-	CustomerCode   string                   `bson:"customerCode" json:"customerCode"`
-	CustomerName   string                   `bson:"customerName" json:"customerName"`
-	PatientId      string                   `bson:"patientId,omitempty" json:"patientId,omitempty"`
-	PharmacistName string                   `bson:"pharmacistName,omitempty" json:"pharmacistName,omitempty"`
-	PrescriberName string                   `bson:"prescriberName,omitempty" json:"prescriberName,omitempty"`
-	BuyerName      string                   `bson:"buyerName,omitempty" json:"buyerName,omitempty"`
-	BuyerIdCard    string                   `bson:"buyerIdCard,omitempty" json:"buyerIdCard,omitempty"`
-	Status         string                   `bson:"status" json:"status"`
-	CreatedBy      string                   `bson:"createdBy" json:"-"`
-	CreatedDate    time.Time                `bson:"createdDate" json:"createdDate"`
-	UpdatedBy      string                   `bson:"updatedBy" json:"-"`
-	UpdatedDate    time.Time                `bson:"updatedDate" json:"-"`
-	Total          float64                  `bson:"total" json:"total"`
-	TotalCost      float64                  `bson:"totalCost" json:"totalCost"`
-	Discount       float64                  `bson:"discount" json:"discount"`
-	Type           string                   `bson:"type" json:"type"`
-	Items          []OrderItemProductDetail `json:"items"`
-	Payment        Payment                  `json:"payment"`
+	Id                primitive.ObjectID       `bson:"_id" json:"id"`
+	BranchId          primitive.ObjectID       `bson:"branchId" json:"branchId"`
+	Code              string                   `bson:"code" json:"code"`
+	CustomerCode      string                   `bson:"customerCode" json:"customerCode"`
+	CustomerName      string                   `bson:"customerName" json:"customerName"`
+	PatientId         string                   `bson:"patientId,omitempty" json:"patientId,omitempty"`
+	PharmacistId      string                   `bson:"pharmacistId,omitempty" json:"pharmacistId,omitempty"`
+	PharmacistName    string                   `bson:"pharmacistName,omitempty" json:"pharmacistName,omitempty"`
+	LicenseNo         string                   `bson:"licenseNo,omitempty" json:"licenseNo,omitempty"`
+	PrescriberName    string                   `bson:"prescriberName,omitempty" json:"prescriberName,omitempty"`
+	BuyerName         string                   `bson:"buyerName,omitempty" json:"buyerName,omitempty"`
+	BuyerIdCard       string                   `bson:"buyerIdCard,omitempty" json:"buyerIdCard,omitempty"`
+	DispensingLogId   string                   `bson:"dispensingLogId,omitempty" json:"dispensingLogId,omitempty"`
+	ClinicalOverride  *ClinicalOverride        `bson:"clinicalOverride,omitempty" json:"clinicalOverride,omitempty"`
+	Status            string                   `bson:"status" json:"status"`
+	CreatedBy         string                   `bson:"createdBy" json:"-"`
+	CreatedDate       time.Time                `bson:"createdDate" json:"createdDate"`
+	UpdatedBy         string                   `bson:"updatedBy" json:"-"`
+	UpdatedDate       time.Time                `bson:"updatedDate" json:"-"`
+	Total             float64                  `bson:"total" json:"total"`
+	TotalCost         float64                  `bson:"totalCost" json:"totalCost"`
+	Discount          float64                  `bson:"discount" json:"discount"`
+	PromotionCode     string                   `bson:"promotionCode,omitempty" json:"promotionCode,omitempty"`
+	PromotionDiscount float64                  `bson:"promotionDiscount" json:"promotionDiscount"`
+	Type              string                   `bson:"type" json:"type"`
+	VoidedBy          string                   `bson:"voidedBy,omitempty" json:"voidedBy,omitempty"`
+	VoidedDate        *time.Time               `bson:"voidedDate,omitempty" json:"voidedDate,omitempty"`
+	VoidReasonCode    string                   `bson:"voidReasonCode,omitempty" json:"voidReasonCode,omitempty"`
+	VoidReason        string                   `bson:"voidReason,omitempty" json:"voidReason,omitempty"`
+	VoidApprovedBy    string                   `bson:"voidApprovedBy,omitempty" json:"voidApprovedBy,omitempty"`
+	Items             []OrderItemProductDetail `json:"items"`
+	Payment           Payment                  `json:"payment"`
 }
 
 type OrderItemStock struct {
-	Quantity int    `json:"quantity" binding:"required"`
-	StockId  string `json:"stockId"`
+	Quantity   int       `json:"quantity" binding:"required"`
+	StockId    string    `json:"stockId"`
+	LotNumber  string    `json:"lotNumber"`
+	ExpireDate time.Time `json:"expireDate"`
 }
 
 type OrderItem struct {
-	Id          primitive.ObjectID `bson:"_id" json:"id"`
-	BranchId    primitive.ObjectID `bson:"branchId" json:"branchId"`
-	OrderId     primitive.ObjectID `bson:"orderId" json:"orderId"`
-	ProductId   primitive.ObjectID `bson:"productId" json:"productId"`
-	UnitId      primitive.ObjectID `bson:"unitId" json:"unitId"`
-	Stocks      []OrderItemStock   `bson:"stocks" json:"stocks"`
-	Quantity    int                `bson:"quantity" json:"quantity"`
-	Price       float64            `bson:"price" json:"price"`
-	CostPrice   float64            `bson:"costPrice" json:"costPrice"`
-	Discount    float64            `bson:"discount" json:"discount"`
-	CreatedBy   string             `bson:"createdBy" json:"-"`
-	CreatedDate time.Time          `bson:"createdDate" json:"createdDate"`
-	UpdatedBy   string             `bson:"updatedBy" json:"-"`
-	UpdatedDate time.Time          `bson:"updatedDate" json:"-"`
+	Id            primitive.ObjectID `bson:"_id" json:"id"`
+	BranchId      primitive.ObjectID `bson:"branchId" json:"branchId"`
+	OrderId       primitive.ObjectID `bson:"orderId" json:"orderId"`
+	ProductId     primitive.ObjectID `bson:"productId" json:"productId"`
+	UnitId        primitive.ObjectID `bson:"unitId" json:"unitId"`
+	Stocks        []OrderItemStock   `bson:"stocks" json:"stocks"`
+	Quantity      int                `bson:"quantity" json:"quantity"`
+	Price         float64            `bson:"price" json:"price"`
+	CostPrice     float64            `bson:"costPrice" json:"costPrice"`
+	Discount      float64            `bson:"discount" json:"discount"`
+	ListPrice     float64            `bson:"listPrice" json:"listPrice"`
+	PriceOverride bool               `bson:"priceOverride" json:"priceOverride"`
+	CreatedBy     string             `bson:"createdBy" json:"-"`
+	CreatedDate   time.Time          `bson:"createdDate" json:"createdDate"`
+	UpdatedBy     string             `bson:"updatedBy" json:"-"`
+	UpdatedDate   time.Time          `bson:"updatedDate" json:"-"`
 }
 
 type OrderItemProductDetail struct {
-	Id          primitive.ObjectID `bson:"_id" json:"id"`
-	BranchId    primitive.ObjectID `bson:"branchId" json:"branchId"`
-	OrderId     primitive.ObjectID `bson:"orderId" json:"orderId"`
-	ProductId   primitive.ObjectID `bson:"productId" json:"productId"`
-	UnitId      primitive.ObjectID `bson:"unitId" json:"unitId"`
-	Stocks      []OrderItemStock   `bson:"stocks" json:"stocks"`
-	Quantity    int                `bson:"quantity" json:"quantity"`
-	Price       float64            `bson:"price" json:"price"`
-	CostPrice   float64            `bson:"costPrice" json:"costPrice"`
-	Discount    float64            `bson:"discount" json:"discount"`
-	CreatedBy   string             `bson:"createdBy" json:"-"`
-	CreatedDate time.Time          `bson:"createdDate" json:"createdDate"`
-	UpdatedBy   string             `bson:"updatedBy" json:"-"`
-	UpdatedDate time.Time          `bson:"updatedDate" json:"-"`
-	Product     Product            `bson:"product" json:"product"`
+	Id            primitive.ObjectID `bson:"_id" json:"id"`
+	BranchId      primitive.ObjectID `bson:"branchId" json:"branchId"`
+	OrderId       primitive.ObjectID `bson:"orderId" json:"orderId"`
+	ProductId     primitive.ObjectID `bson:"productId" json:"productId"`
+	UnitId        primitive.ObjectID `bson:"unitId" json:"unitId"`
+	Stocks        []OrderItemStock   `bson:"stocks" json:"stocks"`
+	Quantity      int                `bson:"quantity" json:"quantity"`
+	Price         float64            `bson:"price" json:"price"`
+	CostPrice     float64            `bson:"costPrice" json:"costPrice"`
+	Discount      float64            `bson:"discount" json:"discount"`
+	ListPrice     float64            `bson:"listPrice" json:"listPrice"`
+	PriceOverride bool               `bson:"priceOverride" json:"priceOverride"`
+	CreatedBy     string             `bson:"createdBy" json:"-"`
+	CreatedDate   time.Time          `bson:"createdDate" json:"createdDate"`
+	UpdatedBy     string             `bson:"updatedBy" json:"-"`
+	UpdatedDate   time.Time          `bson:"updatedDate" json:"-"`
+	Product       Product            `bson:"product" json:"product"`
 }
 
 type OrderItemOrderDetail struct {
-	Id          primitive.ObjectID `bson:"_id" json:"id"`
-	BranchId    primitive.ObjectID `bson:"branchId" json:"branchId"`
-	OrderId     primitive.ObjectID `bson:"orderId" json:"orderId"`
-	ProductId   primitive.ObjectID `bson:"productId" json:"productId"`
-	UnitId      primitive.ObjectID `bson:"unitId" json:"unitId"`
-	Stocks      []OrderItemStock   `bson:"stocks" json:"stocks"`
-	Quantity    int                `bson:"quantity" json:"quantity"`
-	Price       float64            `bson:"price" json:"price"`
-	CostPrice   float64            `bson:"costPrice" json:"costPrice"`
-	Discount    float64            `bson:"discount" json:"discount"`
-	CreatedBy   string             `bson:"createdBy" json:"-"`
-	CreatedDate time.Time          `bson:"createdDate" json:"createdDate"`
-	UpdatedBy   string             `bson:"updatedBy" json:"-"`
-	UpdatedDate time.Time          `bson:"updatedDate" json:"-"`
-	Order       Order              `bson:"order" json:"order"`
+	Id            primitive.ObjectID `bson:"_id" json:"id"`
+	BranchId      primitive.ObjectID `bson:"branchId" json:"branchId"`
+	OrderId       primitive.ObjectID `bson:"orderId" json:"orderId"`
+	ProductId     primitive.ObjectID `bson:"productId" json:"productId"`
+	UnitId        primitive.ObjectID `bson:"unitId" json:"unitId"`
+	Stocks        []OrderItemStock   `bson:"stocks" json:"stocks"`
+	Quantity      int                `bson:"quantity" json:"quantity"`
+	Price         float64            `bson:"price" json:"price"`
+	CostPrice     float64            `bson:"costPrice" json:"costPrice"`
+	Discount      float64            `bson:"discount" json:"discount"`
+	ListPrice     float64            `bson:"listPrice" json:"listPrice"`
+	PriceOverride bool               `bson:"priceOverride" json:"priceOverride"`
+	CreatedBy     string             `bson:"createdBy" json:"-"`
+	CreatedDate   time.Time          `bson:"createdDate" json:"createdDate"`
+	UpdatedBy     string             `bson:"updatedBy" json:"-"`
+	UpdatedDate   time.Time          `bson:"updatedDate" json:"-"`
+	Order         Order              `bson:"order" json:"order"`
+}
+
+type StockShortage struct {
+	ProductId string `json:"productId"`
+	UnitId    string `json:"unitId"`
+	StockId   string `json:"stockId,omitempty"`
+	LotNumber string `json:"lotNumber,omitempty"`
+	Quantity  int    `json:"quantity"`
+	Available int    `json:"available"`
 }
 
 type OrderSummary struct {
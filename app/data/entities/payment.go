@@ -15,6 +15,7 @@ type Payment struct {
 	Total       float64            `bson:"total" json:"total"`
 	Change      float64            `bson:"change" json:"change"`
 	Type        string             `bson:"type" json:"type"`
+	ShiftId     string             `bson:"shiftId,omitempty" json:"shiftId,omitempty"`
 	CreatedBy   string             `bson:"createdBy" json:"-"`
 	CreatedDate time.Time          `bson:"createdDate" json:"createdDate"`
 	UpdatedBy   string             `bson:"updatedBy" json:"-"`
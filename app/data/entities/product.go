@@ -1,25 +1,27 @@
 package entities
 
 import (
+	"pos/app/domain/constant"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type DrugInfo struct {
-	GenericName       string   `bson:"genericName" json:"genericName"`
-	DrugType          string   `bson:"drugType" json:"drugType"`
-	DosageForm        string   `bson:"dosageForm" json:"dosageForm"`
-	Strength          string   `bson:"strength" json:"strength"`
-	Indication        string   `bson:"indication" json:"indication"`
-	Dosage            string   `bson:"dosage" json:"dosage"`
-	SideEffects       string   `bson:"sideEffects" json:"sideEffects"`
-	Contraindications string   `bson:"contraindications" json:"contraindications"`
-	StorageCondition  string   `bson:"storageCondition" json:"storageCondition"`
-	Manufacturer      string   `bson:"manufacturer" json:"manufacturer"`
-	RegistrationNo    string   `bson:"registrationNo" json:"registrationNo"`
-	IsControlled      bool     `bson:"isControlled" json:"isControlled"`
-	DrugInteractions  []string `bson:"drugInteractions,omitempty" json:"drugInteractions,omitempty"`
+	GenericName       string              `bson:"genericName" json:"genericName"`
+	Ingredients       []ProductIngredient `bson:"ingredients,omitempty" json:"ingredients,omitempty"`
+	DrugType          string              `bson:"drugType" json:"drugType"`
+	DosageForm        string              `bson:"dosageForm" json:"dosageForm"`
+	Strength          string              `bson:"strength" json:"strength"`
+	Indication        string              `bson:"indication" json:"indication"`
+	Dosage            string              `bson:"dosage" json:"dosage"`
+	SideEffects       string              `bson:"sideEffects" json:"sideEffects"`
+	Contraindications string              `bson:"contraindications" json:"contraindications"`
+	StorageCondition  string              `bson:"storageCondition" json:"storageCondition"`
+	Manufacturer      string              `bson:"manufacturer" json:"manufacturer"`
+	RegistrationNo    string              `bson:"registrationNo" json:"registrationNo"`
+	IsControlled      bool                `bson:"isControlled" json:"isControlled"`
+	DrugInteractions  []string            `bson:"drugInteractions,omitempty" json:"drugInteractions,omitempty"`
 }
 
 type Product struct {
@@ -36,6 +38,8 @@ type Product struct {
 	Category          string             `bson:"category"  json:"category"`
 	Status            string             `bson:"status"  json:"status"`
 	MinStock          int                `bson:"minStock" json:"minStock"`
+	VatExempt         bool               `bson:"vatExempt" json:"vatExempt"`
+	VatRate           float64            `bson:"vatRate" json:"vatRate"`
 	DrugInfo          *DrugInfo          `bson:"drugInfo,omitempty" json:"drugInfo,omitempty"`
 	DrugRegistrations []string           `bson:"drugRegistrations,omitempty" json:"drugRegistrations,omitempty"`
 	DeletedDate       *time.Time         `bson:"deletedDate,omitempty" json:"deletedDate,omitempty"`
@@ -59,6 +63,8 @@ type ProductDetail struct {
 	Category          string             `bson:"category"  json:"category"`
 	Status            string             `bson:"status"  json:"status"`
 	MinStock          int                `bson:"minStock" json:"minStock"`
+	VatExempt         bool               `bson:"vatExempt" json:"vatExempt"`
+	VatRate           float64            `bson:"vatRate" json:"vatRate"`
 	DrugInfo          *DrugInfo          `bson:"drugInfo,omitempty" json:"drugInfo,omitempty"`
 	DrugRegistrations []string           `bson:"drugRegistrations,omitempty" json:"drugRegistrations,omitempty"`
 	DeletedDate       *time.Time         `bson:"deletedDate,omitempty" json:"deletedDate,omitempty"`
@@ -111,6 +117,19 @@ type ProductUnit struct {
 	Barcode    string             `bson:"barcode" json:"barcode"`
 }
 
+// BaseSize returns how many base units one unit holds, a unit without a size counts as one
+func (unit *ProductUnit) BaseSize() int {
+	if unit == nil || unit.Size <= 0 {
+		return 1
+	}
+	return unit.Size
+}
+
+// UnitSize returns the base size of the unit of the id among the units, unknown units count as one
+func UnitSize(units map[string]*ProductUnit, unitId string) int {
+	return units[unitId].BaseSize()
+}
+
 type ProductPrice struct {
 	Id           primitive.ObjectID `bson:"_id" json:"id"`
 	ProductId    primitive.ObjectID `bson:"productId" json:"productId"`
@@ -133,6 +152,31 @@ type ProductStock struct {
 	Quantity    int                `bson:"quantity" json:"quantity"`
 	ExpireDate  time.Time          `bson:"expireDate" json:"expireDate"`
 	ImportDate  time.Time          `bson:"importDate" json:"importDate"`
+	Status      string             `bson:"status,omitempty" json:"status,omitempty"`
+}
+
+// IsSellable reports whether the lot may be sold, a lot with a blocking status or past its expire date
+// may not even before the expiry job has marked it
+func (stock ProductStock) IsSellable() bool {
+	if !constant.IsSellableStockStatus(stock.Status) {
+		return false
+	}
+	return stock.ExpireDate.IsZero() || stock.ExpireDate.After(time.Now())
+}
+
+// ProductStockDetail is a lot with the names of its product and unit
+type ProductStockDetail struct {
+	Id           primitive.ObjectID `bson:"_id" json:"id"`
+	ProductId    primitive.ObjectID `bson:"productId" json:"productId"`
+	UnitId       primitive.ObjectID `bson:"unitId" json:"unitId"`
+	LotNumber    string             `bson:"lotNumber" json:"lotNumber"`
+	CostPrice    float64            `bson:"costPrice" json:"costPrice"`
+	Quantity     int                `bson:"quantity" json:"quantity"`
+	ExpireDate   time.Time          `bson:"expireDate" json:"expireDate"`
+	Status       string             `bson:"status,omitempty" json:"status,omitempty"`
+	ProductName  string             `bson:"productName" json:"productName"`
+	SerialNumber string             `bson:"serialNumber" json:"serialNumber"`
+	Unit         string             `bson:"unit" json:"unit"`
 }
 
 type LowStockProduct struct {
@@ -153,17 +197,20 @@ type StockReport struct {
 }
 
 type ProductHistory struct {
-	Id          primitive.ObjectID `bson:"_id" json:"id"`
-	BranchId    primitive.ObjectID `bson:"branchId" json:"branchId"`
-	ProductId   primitive.ObjectID `bson:"productId" json:"productId"`
-	Type        string             `bson:"type" json:"type"`
-	Description string             `bson:"description" json:"description"`
-	Unit        string             `bson:"unit" json:"unit"`
-	Import      int                `bson:"import" json:"import"`
-	Quantity    int                `bson:"quantity" json:"quantity"`
-	CostPrice   float64            `bson:"costPrice" json:"costPrice"`
-	Price       float64            `bson:"price" json:"price"`
-	Balance     int                `bson:"balance" json:"balance"`
-	CreatedBy   string             `bson:"createdBy" json:"-"`
-	CreatedDate time.Time          `bson:"createdDate" json:"createdDate"`
+	Id           primitive.ObjectID `bson:"_id" json:"id"`
+	BranchId     primitive.ObjectID `bson:"branchId" json:"branchId"`
+	ProductId    primitive.ObjectID `bson:"productId" json:"productId"`
+	Type         string             `bson:"type" json:"type"`
+	Description  string             `bson:"description" json:"description"`
+	Unit         string             `bson:"unit" json:"unit"`
+	Import       int                `bson:"import" json:"import"`
+	Quantity     int                `bson:"quantity" json:"quantity"`
+	CostPrice    float64            `bson:"costPrice" json:"costPrice"`
+	Price        float64            `bson:"price" json:"price"`
+	Balance      int                `bson:"balance" json:"balance"`
+	DocumentType string             `bson:"documentType,omitempty" json:"documentType,omitempty"`
+	DocumentId   string             `bson:"documentId,omitempty" json:"documentId,omitempty"`
+	DocumentCode string             `bson:"documentCode,omitempty" json:"documentCode,omitempty"`
+	CreatedBy    string             `bson:"createdBy" json:"-"`
+	CreatedDate  time.Time          `bson:"createdDate" json:"createdDate"`
 }
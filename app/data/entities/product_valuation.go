@@ -0,0 +1,66 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProductValuation is an entry of the valuation ledger, the cost layer of a lot moved by a document.
+// Quantity and Amount are negative when stock leaves, a revaluation moves Amount only.
+type ProductValuation struct {
+	Id           primitive.ObjectID `bson:"_id" json:"id"`
+	BranchId     primitive.ObjectID `bson:"branchId" json:"branchId"`
+	ProductId    primitive.ObjectID `bson:"productId" json:"productId"`
+	UnitId       primitive.ObjectID `bson:"unitId" json:"unitId"`
+	StockId      primitive.ObjectID `bson:"stockId" json:"stockId"`
+	LotNumber    string             `bson:"lotNumber" json:"lotNumber"`
+	Type         string             `bson:"type" json:"type"`
+	Quantity     int                `bson:"quantity" json:"quantity"`
+	UnitCost     float64            `bson:"unitCost" json:"unitCost"`
+	Amount       float64            `bson:"amount" json:"amount"`
+	DocumentType string             `bson:"documentType,omitempty" json:"documentType,omitempty"`
+	DocumentId   string             `bson:"documentId,omitempty" json:"documentId,omitempty"`
+	DocumentCode string             `bson:"documentCode,omitempty" json:"documentCode,omitempty"`
+	CreatedBy    string             `bson:"createdBy" json:"createdBy"`
+	CreatedDate  time.Time          `bson:"createdDate" json:"createdDate"`
+}
+
+// InventoryValuation is what the ledger holds of a product at a date
+type InventoryValuation struct {
+	ProductId    primitive.ObjectID `bson:"_id" json:"productId"`
+	Name         string             `bson:"name" json:"name"`
+	SerialNumber string             `bson:"serialNumber" json:"serialNumber"`
+	Unit         string             `bson:"unit" json:"unit"`
+	Quantity     int                `bson:"quantity" json:"quantity"`
+	Value        float64            `bson:"value" json:"value"`
+}
+
+// CostOfGoodsSold is the cost of the sales of a product in a period, less the voids and returns
+type CostOfGoodsSold struct {
+	ProductId        primitive.ObjectID `bson:"_id" json:"productId"`
+	Name             string             `bson:"name" json:"name"`
+	SerialNumber     string             `bson:"serialNumber" json:"serialNumber"`
+	Unit             string             `bson:"unit" json:"unit"`
+	SoldQuantity     int                `bson:"soldQuantity" json:"soldQuantity"`
+	SoldCost         float64            `bson:"soldCost" json:"soldCost"`
+	ReturnedQuantity int                `bson:"returnedQuantity" json:"returnedQuantity"`
+	ReturnedCost     float64            `bson:"returnedCost" json:"returnedCost"`
+	Quantity         int                `bson:"quantity" json:"quantity"`
+	Cost             float64            `bson:"cost" json:"cost"`
+}
+
+// ValuationReconciliation compares the ledger of a product with its lots, the stock report values the
+// sellable lots and the blocked lots make up the rest
+type ValuationReconciliation struct {
+	ProductId       primitive.ObjectID `json:"productId"`
+	Name            string             `json:"name"`
+	SerialNumber    string             `json:"serialNumber"`
+	LedgerQuantity  int                `json:"ledgerQuantity"`
+	LedgerValue     float64            `json:"ledgerValue"`
+	StockQuantity   int                `json:"stockQuantity"`
+	StockValue      float64            `json:"stockValue"`
+	BlockedQuantity int                `json:"blockedQuantity"`
+	BlockedValue    float64            `json:"blockedValue"`
+	Difference      float64            `json:"difference"`
+}
@@ -25,3 +25,23 @@ type Promotion struct {
 	UpdatedBy   string             `bson:"updatedBy" json:"-"`
 	UpdatedDate time.Time          `bson:"updatedDate" json:"-"`
 }
+
+// CalculateDiscount returns the discount of the promotion on the given total.
+func (p Promotion) CalculateDiscount(total float64) float64 {
+	var discount float64
+	switch p.Type {
+	case "PERCENTAGE":
+		discount = total * p.Value / 100
+		if p.MaxDiscount > 0 && discount > p.MaxDiscount {
+			discount = p.MaxDiscount
+		}
+	case "FIXED":
+		discount = p.Value
+		if discount > total {
+			discount = total
+		}
+	default:
+		discount = 0
+	}
+	return discount
+}
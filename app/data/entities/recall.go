@@ -0,0 +1,105 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Recall blocks lots of a product in every branch, usually on a notice of the Thai FDA. Reference is the
+// number of the notice.
+type Recall struct {
+	Id           primitive.ObjectID `bson:"_id" json:"id"`
+	Code         string             `bson:"code" json:"code"`
+	ProductId    primitive.ObjectID `bson:"productId" json:"productId"`
+	ProductName  string             `bson:"productName" json:"productName"`
+	SerialNumber string             `bson:"serialNumber" json:"serialNumber"`
+	LotNumbers   []string           `bson:"lotNumbers" json:"lotNumbers"`
+	Reason       string             `bson:"reason" json:"reason"`
+	Reference    string             `bson:"reference" json:"reference"`
+	Note         string             `bson:"note" json:"note"`
+	BlockedLots  int64              `bson:"blockedLots" json:"blockedLots"`
+	CreatedBy    string             `bson:"createdBy" json:"createdBy"`
+	CreatedDate  time.Time          `bson:"createdDate" json:"createdDate"`
+	UpdatedBy    string             `bson:"updatedBy" json:"-"`
+	UpdatedDate  time.Time          `bson:"updatedDate" json:"-"`
+}
+
+// RecallTrace is everything that received or still holds the recalled lots
+type RecallTrace struct {
+	Recall      Recall             `json:"recall"`
+	Orders      []RecallOrder      `json:"orders"`
+	Dispensings []RecallDispensing `json:"dispensings"`
+	Contacts    []RecallContact    `json:"contacts"`
+	Stocks      []ProductStock     `json:"stocks"`
+	Returns     []RecallReturn     `json:"returns"`
+	Remaining   int                `json:"remaining"`
+}
+
+// RecallOrder is a sale of a recalled lot
+type RecallOrder struct {
+	OrderId      primitive.ObjectID `json:"orderId"`
+	OrderCode    string             `json:"orderCode"`
+	BranchId     primitive.ObjectID `json:"branchId"`
+	CustomerCode string             `json:"customerCode"`
+	CustomerName string             `json:"customerName"`
+	PatientId    string             `json:"patientId,omitempty"`
+	BuyerName    string             `json:"buyerName,omitempty"`
+	LotNumber    string             `json:"lotNumber"`
+	Quantity     int                `json:"quantity"`
+	CreatedDate  time.Time          `json:"createdDate"`
+}
+
+// RecallDispensing is a dispensing register line of a recalled lot
+type RecallDispensing struct {
+	DispensingLogId primitive.ObjectID `json:"dispensingLogId"`
+	OrderCode       string             `json:"orderCode"`
+	BranchId        primitive.ObjectID `json:"branchId"`
+	PatientId       primitive.ObjectID `json:"patientId"`
+	PharmacistName  string             `json:"pharmacistName"`
+	LotNumber       string             `json:"lotNumber"`
+	Quantity        int                `json:"quantity"`
+	CreatedDate     time.Time          `json:"createdDate"`
+}
+
+// RecallContact is a person to call about the recall, from the patient register or the customers
+type RecallContact struct {
+	Type       string    `json:"type"`
+	Id         string    `json:"id"`
+	Name       string    `json:"name"`
+	Phone      string    `json:"phone"`
+	Email      string    `json:"email"`
+	Address    string    `json:"address"`
+	LotNumbers []string  `json:"lotNumbers"`
+	OrderCodes []string  `json:"orderCodes"`
+	Quantity   int       `json:"quantity"`
+	LastDate   time.Time `json:"lastDate"`
+}
+
+// RecallReturn sends what a branch still holds of the recalled lots back to the supplier that
+// delivered them
+type RecallReturn struct {
+	Id           primitive.ObjectID `bson:"_id" json:"id"`
+	BranchId     primitive.ObjectID `bson:"branchId" json:"branchId"`
+	Code         string             `bson:"code" json:"code"`
+	RecallId     primitive.ObjectID `bson:"recallId" json:"recallId"`
+	RecallCode   string             `bson:"recallCode" json:"recallCode"`
+	SupplierId   primitive.ObjectID `bson:"supplierId,omitempty" json:"supplierId,omitempty"`
+	SupplierName string             `bson:"supplierName" json:"supplierName"`
+	Items        []RecallReturnItem `bson:"items" json:"items"`
+	TotalCost    float64            `bson:"totalCost" json:"totalCost"`
+	CreatedBy    string             `bson:"createdBy" json:"createdBy"`
+	CreatedDate  time.Time          `bson:"createdDate" json:"createdDate"`
+}
+
+type RecallReturnItem struct {
+	ProductId   primitive.ObjectID `bson:"productId" json:"productId"`
+	UnitId      primitive.ObjectID `bson:"unitId" json:"unitId"`
+	StockId     string             `bson:"stockId" json:"stockId"`
+	LotNumber   string             `bson:"lotNumber" json:"lotNumber"`
+	ReceiveCode string             `bson:"receiveCode" json:"receiveCode"`
+	ExpireDate  time.Time          `bson:"expireDate" json:"expireDate"`
+	Quantity    int                `bson:"quantity" json:"quantity"`
+	CostPrice   float64            `bson:"costPrice" json:"costPrice"`
+	CostAmount  float64            `bson:"costAmount" json:"costAmount"`
+}
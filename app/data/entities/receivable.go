@@ -0,0 +1,72 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReceivableInvoice is what a customer owes for the CREDIT part of an order
+type ReceivableInvoice struct {
+	Id           primitive.ObjectID `bson:"_id" json:"id"`
+	BranchId     primitive.ObjectID `bson:"branchId" json:"branchId"`
+	CustomerId   primitive.ObjectID `bson:"customerId" json:"customerId"`
+	CustomerCode string             `bson:"customerCode" json:"customerCode"`
+	CustomerName string             `bson:"customerName" json:"customerName"`
+	OrderId      primitive.ObjectID `bson:"orderId" json:"orderId"`
+	OrderCode    string             `bson:"orderCode" json:"orderCode"`
+	Amount       float64            `bson:"amount" json:"amount"`
+	Paid         float64            `bson:"paid" json:"paid"`
+	Balance      float64            `bson:"balance" json:"balance"`
+	DueDate      time.Time          `bson:"dueDate" json:"dueDate"`
+	Status       string             `bson:"status" json:"status"`
+	CreatedBy    string             `bson:"createdBy" json:"-"`
+	CreatedDate  time.Time          `bson:"createdDate" json:"createdDate"`
+	UpdatedBy    string             `bson:"updatedBy" json:"-"`
+	UpdatedDate  time.Time          `bson:"updatedDate" json:"-"`
+}
+
+// ReceivableReceipt is a payment received from a customer, allocated against its invoices
+type ReceivableReceipt struct {
+	Id           primitive.ObjectID     `bson:"_id" json:"id"`
+	BranchId     primitive.ObjectID     `bson:"branchId" json:"branchId"`
+	Code         string                 `bson:"code" json:"code"`
+	CustomerId   primitive.ObjectID     `bson:"customerId" json:"customerId"`
+	CustomerCode string                 `bson:"customerCode" json:"customerCode"`
+	CustomerName string                 `bson:"customerName" json:"customerName"`
+	Amount       float64                `bson:"amount" json:"amount"`
+	PaymentType  string                 `bson:"paymentType" json:"paymentType"`
+	Reference    string                 `bson:"reference" json:"reference"`
+	Note         string                 `bson:"note" json:"note"`
+	Allocations  []ReceivableAllocation `bson:"allocations" json:"allocations"`
+	ShiftId      string                 `bson:"shiftId,omitempty" json:"shiftId,omitempty"`
+	Status       string                 `bson:"status" json:"status"`
+	CreatedBy    string                 `bson:"createdBy" json:"-"`
+	CreatedDate  time.Time              `bson:"createdDate" json:"createdDate"`
+	UpdatedBy    string                 `bson:"updatedBy" json:"-"`
+	UpdatedDate  time.Time              `bson:"updatedDate" json:"-"`
+}
+
+type ReceivableAllocation struct {
+	InvoiceId primitive.ObjectID `bson:"invoiceId" json:"invoiceId"`
+	OrderCode string             `bson:"orderCode" json:"orderCode"`
+	Amount    float64            `bson:"amount" json:"amount"`
+}
+
+type ReceivableCustomer struct {
+	Customer        Customer            `json:"customer"`
+	Outstanding     float64             `json:"outstanding"`
+	AvailableCredit float64             `json:"availableCredit"`
+	Invoices        []ReceivableInvoice `json:"invoices"`
+}
+
+type ReceivableAging struct {
+	CustomerId   string  `json:"customerId"`
+	CustomerCode string  `json:"customerCode"`
+	CustomerName string  `json:"customerName"`
+	Current      float64 `json:"current"`
+	Days31To60   float64 `json:"days31To60"`
+	Days61To90   float64 `json:"days61To90"`
+	Over90       float64 `json:"over90"`
+	Total        float64 `json:"total"`
+}
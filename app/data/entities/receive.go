@@ -13,6 +13,7 @@ type Receive struct {
 	Code        string             `bson:"code" json:"code"`
 	Reference   string             `bson:"reference" json:"reference"`
 	TotalCost   float64            `bson:"totalCost" json:"totalCost"`
+	TaxInvoice  *ReceiveTaxInvoice `bson:"taxInvoice,omitempty" json:"taxInvoice,omitempty"`
 	Items       []ReceiveItem      `bson:"items" json:"items"`
 	Status      string             `bson:"status" json:"status"`
 	CreatedBy   string             `bson:"createdBy" json:"-"`
@@ -21,6 +22,14 @@ type Receive struct {
 	UpdatedDate time.Time          `bson:"updatedDate" json:"-"`
 }
 
+// ReceiveTaxInvoice is the tax invoice of the supplier for the goods, it claims the input VAT
+type ReceiveTaxInvoice struct {
+	Number        string    `bson:"number" json:"number"`
+	Date          time.Time `bson:"date" json:"date"`
+	TaxableAmount float64   `bson:"taxableAmount" json:"taxableAmount"`
+	VatAmount     float64   `bson:"vatAmount" json:"vatAmount"`
+}
+
 type ReceiveItem struct {
 	ReceiveId    primitive.ObjectID `bson:"receiveId,omitempty" json:"receiveId,omitempty"`
 	ProductId    primitive.ObjectID `bson:"productId" json:"productId"`
@@ -1,23 +1,108 @@
 package entities
 
 import (
+	"pos/app/domain/constant"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Setting struct {
-	Id             primitive.ObjectID `bson:"_id" json:"id"`
-	BranchId       primitive.ObjectID `bson:"branchId" json:"branchId"`
-	ReceiptFooter  string             `bson:"receiptFooter" json:"receiptFooter"`
-	CompanyName    string             `bson:"companyName" json:"companyName"`
-	CompanyAddress string             `bson:"companyAddress" json:"companyAddress"`
-	CompanyPhone   string             `bson:"companyPhone" json:"companyPhone"`
-	CompanyTaxId   string             `bson:"companyTaxId" json:"companyTaxId"`
-	LogoUrl        string             `bson:"logoUrl" json:"logoUrl"`
-	ShowCredit     bool               `bson:"showCredit" json:"showCredit"`
-	PromptPayId    string             `bson:"promptPayId" json:"promptPayId"`
-	Features       map[string]bool    `bson:"features,omitempty" json:"features,omitempty"`
-	UpdatedBy      string             `bson:"updatedBy" json:"-"`
-	UpdatedDate    time.Time          `bson:"updatedDate" json:"-"`
+	Id                 primitive.ObjectID `bson:"_id" json:"id"`
+	BranchId           primitive.ObjectID `bson:"branchId" json:"branchId"`
+	ReceiptFooter      string             `bson:"receiptFooter" json:"receiptFooter"`
+	CompanyName        string             `bson:"companyName" json:"companyName"`
+	CompanyAddress     string             `bson:"companyAddress" json:"companyAddress"`
+	CompanyPhone       string             `bson:"companyPhone" json:"companyPhone"`
+	CompanyTaxId       string             `bson:"companyTaxId" json:"companyTaxId"`
+	LogoUrl            string             `bson:"logoUrl" json:"logoUrl"`
+	ShowCredit         bool               `bson:"showCredit" json:"showCredit"`
+	ReceiptPaper       string             `bson:"receiptPaper" json:"receiptPaper"`
+	ReceiptCodePage    int                `bson:"receiptCodePage" json:"receiptCodePage"`
+	ReceiptPromptPayQr bool               `bson:"receiptPromptPayQr" json:"receiptPromptPayQr"`
+	VatRegistered      bool               `bson:"vatRegistered" json:"vatRegistered"`
+	VatRate            float64            `bson:"vatRate" json:"vatRate"`
+	TaxBranchNo        string             `bson:"taxBranchNo" json:"taxBranchNo"`
+	PromptPayId        string             `bson:"promptPayId" json:"promptPayId"`
+	AllowNegativeStock bool               `bson:"allowNegativeStock" json:"allowNegativeStock"`
+	AdjustmentApproval bool               `bson:"adjustmentApproval" json:"adjustmentApproval"`
+	CostingMethod      string             `bson:"costingMethod" json:"costingMethod"`
+	DayCloseTime       string             `bson:"dayCloseTime" json:"dayCloseTime"`
+	CartReserveMinutes int                `bson:"cartReserveMinutes" json:"cartReserveMinutes"`
+	VoidReasons        []ReasonCode       `bson:"voidReasons" json:"voidReasons"`
+	Features           map[string]bool    `bson:"features,omitempty" json:"features,omitempty"`
+	UpdatedBy          string             `bson:"updatedBy" json:"-"`
+	UpdatedDate        time.Time          `bson:"updatedDate" json:"-"`
+}
+
+type ReasonCode struct {
+	Code string `bson:"code" json:"code"`
+	Name string `bson:"name" json:"name"`
+}
+
+var defaultVoidReasons = []ReasonCode{
+	{Code: "WRONG_ITEM", Name: "คีย์สินค้าผิด"},
+	{Code: "WRONG_PRICE", Name: "คีย์ราคาผิด"},
+	{Code: "WRONG_PAYMENT", Name: "รับชำระผิดประเภท"},
+	{Code: "DUPLICATE", Name: "บิลซ้ำ"},
+	{Code: "CUSTOMER_CANCEL", Name: "ลูกค้ายกเลิก"},
+	{Code: "OTHER", Name: "อื่นๆ"},
+}
+
+// GetVoidReasons returns the void reason codes of the branch, or the defaults when none are configured
+func (setting *Setting) GetVoidReasons() []ReasonCode {
+	if setting == nil || len(setting.VoidReasons) == 0 {
+		return defaultVoidReasons
+	}
+	return setting.VoidReasons
+}
+
+// FindVoidReason returns the void reason with the given code, nil when the code is not configured
+func (setting *Setting) FindVoidReason(code string) *ReasonCode {
+	for _, reason := range setting.GetVoidReasons() {
+		if reason.Code == code {
+			return &reason
+		}
+	}
+	return nil
+}
+
+// GetReceiptPaper returns the paper profile receipts are printed on, A4 when not configured
+func (setting *Setting) GetReceiptPaper() string {
+	if setting == nil || setting.ReceiptPaper == "" {
+		return constant.ReceiptPaperA4
+	}
+	return setting.ReceiptPaper
+}
+
+// GetReceiptCodePage returns the ESC/POS code page of the counter printer
+func (setting *Setting) GetReceiptCodePage() int {
+	if setting == nil || setting.ReceiptCodePage == 0 {
+		return constant.DefaultReceiptCodePage
+	}
+	return setting.ReceiptCodePage
+}
+
+// GetVatRate returns the VAT rate in percent charged by the branch, the standard rate when not configured
+func (setting *Setting) GetVatRate() float64 {
+	if setting == nil || setting.VatRate <= 0 {
+		return constant.DefaultVatRate
+	}
+	return setting.VatRate
+}
+
+// GetTaxBranchNo returns the five digit branch number printed on tax invoices, the head office when not configured
+func (setting *Setting) GetTaxBranchNo() string {
+	if setting == nil || setting.TaxBranchNo == "" {
+		return constant.HeadOfficeBranchNo
+	}
+	return setting.TaxBranchNo
+}
+
+// GetCostingMethod returns how the branch values its stock, FIFO when not configured
+func (setting *Setting) GetCostingMethod() string {
+	if setting == nil || setting.CostingMethod == "" {
+		return constant.CostingMethodFIFO
+	}
+	return setting.CostingMethod
 }
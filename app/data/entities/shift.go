@@ -0,0 +1,63 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Shift struct {
+	Id            primitive.ObjectID    `bson:"_id" json:"id"`
+	BranchId      primitive.ObjectID    `bson:"branchId" json:"branchId"`
+	Code          string                `bson:"code" json:"code"`
+	UserId        string                `bson:"userId" json:"userId"`
+	Status        string                `bson:"status" json:"status"`
+	OpeningFloat  float64               `bson:"openingFloat" json:"openingFloat"`
+	CashMovements []CashMovement        `bson:"cashMovements" json:"cashMovements"`
+	Summary       []ShiftPaymentSummary `bson:"summary,omitempty" json:"summary,omitempty"`
+	TotalVariance float64               `bson:"totalVariance" json:"totalVariance"`
+	Note          string                `bson:"note" json:"note"`
+	CloseNote     string                `bson:"closeNote,omitempty" json:"closeNote,omitempty"`
+	OpenedDate    time.Time             `bson:"openedDate" json:"openedDate"`
+	ClosedBy      string                `bson:"closedBy,omitempty" json:"closedBy,omitempty"`
+	ClosedDate    *time.Time            `bson:"closedDate,omitempty" json:"closedDate,omitempty"`
+	CreatedBy     string                `bson:"createdBy" json:"-"`
+	CreatedDate   time.Time             `bson:"createdDate" json:"createdDate"`
+	UpdatedBy     string                `bson:"updatedBy" json:"-"`
+	UpdatedDate   time.Time             `bson:"updatedDate" json:"-"`
+}
+
+type CashMovement struct {
+	Id          primitive.ObjectID `bson:"_id" json:"id"`
+	Type        string             `bson:"type" json:"type"`
+	Amount      float64            `bson:"amount" json:"amount"`
+	Reason      string             `bson:"reason" json:"reason"`
+	CreatedBy   string             `bson:"createdBy" json:"createdBy"`
+	CreatedDate time.Time          `bson:"createdDate" json:"createdDate"`
+}
+
+// ShiftPaymentSummary is the reconciliation of one payment type, Expected is what the drawer
+// should hold (sales and receipts less refunds, plus float and cash in/out for cash) and Counted what was counted
+type ShiftPaymentSummary struct {
+	Type     string  `bson:"type" json:"type"`
+	Sales    float64 `bson:"sales" json:"sales"`
+	Receipts float64 `bson:"receipts" json:"receipts"`
+	Refunds  float64 `bson:"refunds" json:"refunds"`
+	Expected float64 `bson:"expected" json:"expected"`
+	Counted  float64 `bson:"counted" json:"counted"`
+	Variance float64 `bson:"variance" json:"variance"`
+}
+
+type ShiftReport struct {
+	Shift         Shift                 `json:"shift"`
+	Final         bool                  `json:"final"`
+	TotalOrders   int                   `json:"totalOrders"`
+	TotalSales    float64               `json:"totalSales"`
+	VoidedOrders  int                   `json:"voidedOrders"`
+	VoidedTotal   float64               `json:"voidedTotal"`
+	TotalRefunds  float64               `json:"totalRefunds"`
+	TotalReceipts float64               `json:"totalReceipts"`
+	CashIn        float64               `json:"cashIn"`
+	CashOut       float64               `json:"cashOut"`
+	Payments      []ShiftPaymentSummary `json:"payments"`
+}
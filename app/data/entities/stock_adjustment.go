@@ -0,0 +1,46 @@
+package entities
+
+import (
+	"pos/app/domain/constant"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StockAdjustment writes stock off, or back on, for one reason. TotalCost is the cost impact of the
+// document, negative when stock is written off.
+type StockAdjustment struct {
+	Id           primitive.ObjectID    `bson:"_id" json:"id"`
+	BranchId     primitive.ObjectID    `bson:"branchId" json:"branchId"`
+	Code         string                `bson:"code" json:"code"`
+	Reason       string                `bson:"reason" json:"reason"`
+	Note         string                `bson:"note" json:"note"`
+	Items        []StockAdjustmentItem `bson:"items" json:"items"`
+	TotalCost    float64               `bson:"totalCost" json:"totalCost"`
+	Status       string                `bson:"status" json:"status"`
+	ApprovedBy   string                `bson:"approvedBy,omitempty" json:"approvedBy,omitempty"`
+	ApprovedDate *time.Time            `bson:"approvedDate,omitempty" json:"approvedDate,omitempty"`
+	RejectReason string                `bson:"rejectReason,omitempty" json:"rejectReason,omitempty"`
+	StocktakeId  string                `bson:"stocktakeId,omitempty" json:"stocktakeId,omitempty"`
+	CreatedBy    string                `bson:"createdBy" json:"createdBy"`
+	CreatedDate  time.Time             `bson:"createdDate" json:"createdDate"`
+	UpdatedBy    string                `bson:"updatedBy" json:"-"`
+	UpdatedDate  time.Time             `bson:"updatedDate" json:"-"`
+}
+
+// StockAdjustmentItem is one lot of the adjustment, Quantity is in the unit of the lot and
+// CostAmount carries the sign of the stock movement
+type StockAdjustmentItem struct {
+	ProductId  primitive.ObjectID `bson:"productId" json:"productId"`
+	UnitId     primitive.ObjectID `bson:"unitId" json:"unitId"`
+	StockId    string             `bson:"stockId" json:"stockId"`
+	LotNumber  string             `bson:"lotNumber" json:"lotNumber"`
+	Quantity   int                `bson:"quantity" json:"quantity"`
+	CostPrice  float64            `bson:"costPrice" json:"costPrice"`
+	CostAmount float64            `bson:"costAmount" json:"costAmount"`
+}
+
+// IsPending reports whether the adjustment still waits for an ADMIN to approve it
+func (adjustment *StockAdjustment) IsPending() bool {
+	return adjustment.Status == constant.StockAdjustmentStatusPending
+}
@@ -0,0 +1,164 @@
+package entities
+
+import (
+	"pos/app/domain/constant"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Stocktake is a physical count of a branch. Lines freeze the system quantity of every lot in scope when
+// the session starts, variance is what was counted less that quantity. The counts of each counter are
+// kept in stocktake_counts and written into the lines when the session is posted.
+type Stocktake struct {
+	Id              primitive.ObjectID `bson:"_id" json:"id"`
+	BranchId        primitive.ObjectID `bson:"branchId" json:"branchId"`
+	Code            string             `bson:"code" json:"code"`
+	Scope           string             `bson:"scope" json:"scope"`
+	Category        string             `bson:"category,omitempty" json:"category,omitempty"`
+	Blind           bool               `bson:"blind" json:"blind"`
+	Note            string             `bson:"note" json:"note"`
+	Status          string             `bson:"status" json:"status"`
+	Lines           []StocktakeLine    `bson:"lines" json:"lines,omitempty"`
+	SystemCost      float64            `bson:"systemCost" json:"systemCost"`
+	CountedCost     float64            `bson:"countedCost" json:"countedCost"`
+	VarianceCost    float64            `bson:"varianceCost" json:"varianceCost"`
+	UncountedLines  int                `bson:"uncountedLines" json:"uncountedLines"`
+	AdjustmentCodes []string           `bson:"adjustmentCodes,omitempty" json:"adjustmentCodes,omitempty"`
+	PostedBy        string             `bson:"postedBy,omitempty" json:"postedBy,omitempty"`
+	PostedDate      *time.Time         `bson:"postedDate,omitempty" json:"postedDate,omitempty"`
+	CreatedBy       string             `bson:"createdBy" json:"createdBy"`
+	CreatedDate     time.Time          `bson:"createdDate" json:"createdDate"`
+	UpdatedBy       string             `bson:"updatedBy" json:"-"`
+	UpdatedDate     time.Time          `bson:"updatedDate" json:"-"`
+}
+
+type StocktakeLine struct {
+	StockId         string               `bson:"stockId" json:"stockId"`
+	ProductId       primitive.ObjectID   `bson:"productId" json:"productId"`
+	UnitId          primitive.ObjectID   `bson:"unitId" json:"unitId"`
+	ProductName     string               `bson:"productName" json:"productName"`
+	SerialNumber    string               `bson:"serialNumber" json:"serialNumber"`
+	Unit            string               `bson:"unit" json:"unit"`
+	LotNumber       string               `bson:"lotNumber" json:"lotNumber"`
+	ExpireDate      time.Time            `bson:"expireDate" json:"expireDate"`
+	CostPrice       float64              `bson:"costPrice" json:"costPrice"`
+	SystemQuantity  int                  `bson:"systemQuantity" json:"systemQuantity"`
+	Counted         bool                 `bson:"counted" json:"counted"`
+	CountedQuantity int                  `bson:"countedQuantity" json:"countedQuantity"`
+	Variance        int                  `bson:"variance" json:"variance"`
+	VarianceCost    float64              `bson:"varianceCost" json:"varianceCost"`
+	Counts          []StocktakeLineCount `bson:"counts,omitempty" json:"counts,omitempty"`
+}
+
+type StocktakeLineCount struct {
+	CountedBy   string    `bson:"countedBy" json:"countedBy"`
+	Quantity    int       `bson:"quantity" json:"quantity"`
+	CountedDate time.Time `bson:"countedDate" json:"countedDate"`
+}
+
+// StocktakeCount is what one counter counted of one lot
+type StocktakeCount struct {
+	Id          primitive.ObjectID `bson:"_id" json:"id"`
+	StocktakeId primitive.ObjectID `bson:"stocktakeId" json:"stocktakeId"`
+	StockId     string             `bson:"stockId" json:"stockId"`
+	CountedBy   string             `bson:"countedBy" json:"countedBy"`
+	Quantity    int                `bson:"quantity" json:"quantity"`
+	CountedDate time.Time          `bson:"countedDate" json:"countedDate"`
+}
+
+// StocktakeSheetLine is a lot as a counter sees it, the system quantity is left out of a blind count
+type StocktakeSheetLine struct {
+	StockId        string    `json:"stockId"`
+	ProductName    string    `json:"productName"`
+	SerialNumber   string    `json:"serialNumber"`
+	Unit           string    `json:"unit"`
+	LotNumber      string    `json:"lotNumber"`
+	ExpireDate     time.Time `json:"expireDate"`
+	SystemQuantity *int      `json:"systemQuantity,omitempty"`
+	Quantity       *int      `json:"quantity,omitempty"`
+}
+
+func (stocktake *Stocktake) IsCounting() bool {
+	return stocktake.Status == constant.StocktakeStatusCounting
+}
+
+// FindLine returns the line of a lot, nil when the lot is not in the stocktake
+func (stocktake *Stocktake) FindLine(stockId string) *StocktakeLine {
+	for i := range stocktake.Lines {
+		if stocktake.Lines[i].StockId == stockId {
+			return &stocktake.Lines[i]
+		}
+	}
+	return nil
+}
+
+// ApplyCounts adds up what every counter counted of each lot and values the variance at the lot cost.
+// Lots nobody counted are left out of the variance, unless zeroUncounted takes them as counted at zero.
+func (stocktake *Stocktake) ApplyCounts(counts []StocktakeCount, zeroUncounted bool) {
+	byStock := make(map[string][]StocktakeLineCount)
+	for _, count := range counts {
+		byStock[count.StockId] = append(byStock[count.StockId], StocktakeLineCount{
+			CountedBy:   count.CountedBy,
+			Quantity:    count.Quantity,
+			CountedDate: count.CountedDate,
+		})
+	}
+
+	stocktake.SystemCost = 0
+	stocktake.CountedCost = 0
+	stocktake.VarianceCost = 0
+	stocktake.UncountedLines = 0
+	for i := range stocktake.Lines {
+		line := &stocktake.Lines[i]
+		line.Counts = byStock[line.StockId]
+		line.Counted = len(line.Counts) > 0 || zeroUncounted
+		line.CountedQuantity = 0
+		for _, count := range line.Counts {
+			line.CountedQuantity += count.Quantity
+		}
+		line.Variance = 0
+		line.VarianceCost = 0
+		stocktake.SystemCost += float64(line.SystemQuantity) * line.CostPrice
+		if !line.Counted {
+			stocktake.UncountedLines++
+			continue
+		}
+		line.Variance = line.CountedQuantity - line.SystemQuantity
+		line.VarianceCost = roundAmount(float64(line.Variance) * line.CostPrice)
+		stocktake.CountedCost += float64(line.CountedQuantity) * line.CostPrice
+		stocktake.VarianceCost += line.VarianceCost
+	}
+	stocktake.SystemCost = roundAmount(stocktake.SystemCost)
+	stocktake.CountedCost = roundAmount(stocktake.CountedCost)
+	stocktake.VarianceCost = roundAmount(stocktake.VarianceCost)
+}
+
+// Sheet lists the lots for one counter with what that counter has counted so far
+func (stocktake *Stocktake) Sheet(counts []StocktakeCount, countedBy string) []StocktakeSheetLine {
+	mine := make(map[string]int)
+	for _, count := range counts {
+		if count.CountedBy == countedBy {
+			mine[count.StockId] = count.Quantity
+		}
+	}
+	lines := make([]StocktakeSheetLine, len(stocktake.Lines))
+	for i, line := range stocktake.Lines {
+		lines[i] = StocktakeSheetLine{
+			StockId:      line.StockId,
+			ProductName:  line.ProductName,
+			SerialNumber: line.SerialNumber,
+			Unit:         line.Unit,
+			LotNumber:    line.LotNumber,
+			ExpireDate:   line.ExpireDate,
+		}
+		if !stocktake.Blind {
+			systemQuantity := line.SystemQuantity
+			lines[i].SystemQuantity = &systemQuantity
+		}
+		if quantity, ok := mine[line.StockId]; ok {
+			lines[i].Quantity = &quantity
+		}
+	}
+	return lines
+}
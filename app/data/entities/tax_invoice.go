@@ -0,0 +1,193 @@
+package entities
+
+import (
+	"math"
+	"pos/app/domain/constant"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaxInvoice is a Thai tax invoice issued for an order, abbreviated (ใบกำกับภาษีอย่างย่อ)
+// or full (ใบกำกับภาษีเต็มรูป). Each type runs its own number series per branch.
+type TaxInvoice struct {
+	Id             primitive.ObjectID  `bson:"_id" json:"id"`
+	BranchId       primitive.ObjectID  `bson:"branchId" json:"branchId"`
+	Type           string              `bson:"type" json:"type"`
+	Code           string              `bson:"code" json:"code"`
+	OrderId        primitive.ObjectID  `bson:"orderId" json:"orderId"`
+	OrderCode      string              `bson:"orderCode" json:"orderCode"`
+	Status         string              `bson:"status" json:"status"`
+	Seller         TaxInvoiceParty     `bson:"seller" json:"seller"`
+	Buyer          *TaxInvoiceParty    `bson:"buyer,omitempty" json:"buyer,omitempty"`
+	Items          []TaxInvoiceItem    `bson:"items" json:"items"`
+	Subtotal       float64             `bson:"subtotal" json:"subtotal"`
+	Discount       float64             `bson:"discount" json:"discount"`
+	ExemptAmount   float64             `bson:"exemptAmount" json:"exemptAmount"`
+	TaxableAmount  float64             `bson:"taxableAmount" json:"taxableAmount"`
+	VatAmount      float64             `bson:"vatAmount" json:"vatAmount"`
+	Total          float64             `bson:"total" json:"total"`
+	VatRates       []TaxInvoiceVat     `bson:"vatRates" json:"vatRates"`
+	ReplacesId     *primitive.ObjectID `bson:"replacesId,omitempty" json:"replacesId,omitempty"`
+	ReplacesCode   string              `bson:"replacesCode,omitempty" json:"replacesCode,omitempty"`
+	ReplacedById   *primitive.ObjectID `bson:"replacedById,omitempty" json:"replacedById,omitempty"`
+	ReplacedByCode string              `bson:"replacedByCode,omitempty" json:"replacedByCode,omitempty"`
+	PrintCount     int                 `bson:"printCount" json:"printCount"`
+	Reissues       []TaxInvoiceReissue `bson:"reissues" json:"reissues"`
+	CancelReason   string              `bson:"cancelReason,omitempty" json:"cancelReason,omitempty"`
+	CancelledDate  *time.Time          `bson:"cancelledDate,omitempty" json:"cancelledDate,omitempty"`
+	IssuedDate     time.Time           `bson:"issuedDate" json:"issuedDate"`
+	CreatedBy      string              `bson:"createdBy" json:"-"`
+	CreatedDate    time.Time           `bson:"createdDate" json:"createdDate"`
+	UpdatedBy      string              `bson:"updatedBy" json:"-"`
+	UpdatedDate    time.Time           `bson:"updatedDate" json:"-"`
+}
+
+type TaxInvoiceParty struct {
+	Name     string `bson:"name" json:"name"`
+	Address  string `bson:"address" json:"address"`
+	TaxId    string `bson:"taxId" json:"taxId"`
+	BranchNo string `bson:"branchNo" json:"branchNo"`
+}
+
+type TaxInvoiceItem struct {
+	OrderItemId primitive.ObjectID `bson:"orderItemId,omitempty" json:"orderItemId,omitempty"`
+	ProductId   primitive.ObjectID `bson:"productId" json:"productId"`
+	Name        string             `bson:"name" json:"name"`
+	Quantity    int                `bson:"quantity" json:"quantity"`
+	UnitPrice   float64            `bson:"unitPrice" json:"unitPrice"`
+	Discount    float64            `bson:"discount" json:"discount"`
+	Amount      float64            `bson:"amount" json:"amount"`
+	VatRate     float64            `bson:"vatRate" json:"vatRate"`
+	VatExempt   bool               `bson:"vatExempt" json:"vatExempt"`
+}
+
+// TaxInvoiceVat is the VAT charged at one rate, Amount is the value before VAT
+type TaxInvoiceVat struct {
+	Rate   float64 `bson:"rate" json:"rate"`
+	Amount float64 `bson:"amount" json:"amount"`
+	Vat    float64 `bson:"vat" json:"vat"`
+}
+
+type TaxInvoiceReissue struct {
+	Reason       string    `bson:"reason" json:"reason"`
+	ReissuedBy   string    `bson:"reissuedBy" json:"reissuedBy"`
+	ReissuedDate time.Time `bson:"reissuedDate" json:"reissuedDate"`
+}
+
+// ComputeVat works out the VAT included in the item amounts. The bill discount is spread
+// over the lines by amount, then each rate is extracted from its VAT inclusive total.
+func (invoice *TaxInvoice) ComputeVat() {
+	subtotal := 0.0
+	for _, item := range invoice.Items {
+		subtotal += item.Amount
+	}
+	discount := math.Min(invoice.Discount, subtotal)
+
+	exempt := 0.0
+	grossByRate := map[float64]float64{}
+	remaining := discount
+	for i, item := range invoice.Items {
+		share := remaining
+		if i < len(invoice.Items)-1 && subtotal > 0 {
+			share = roundAmount(discount * item.Amount / subtotal)
+			remaining -= share
+		}
+		if item.VatExempt {
+			exempt += item.Amount - share
+		} else {
+			grossByRate[item.VatRate] += item.Amount - share
+		}
+	}
+
+	rates := make([]float64, 0, len(grossByRate))
+	for rate := range grossByRate {
+		rates = append(rates, rate)
+	}
+	sort.Float64s(rates)
+
+	invoice.VatRates = []TaxInvoiceVat{}
+	taxable, vat := 0.0, 0.0
+	for _, rate := range rates {
+		gross := roundAmount(grossByRate[rate])
+		rateVat := roundAmount(gross * rate / (100 + rate))
+		invoice.VatRates = append(invoice.VatRates, TaxInvoiceVat{Rate: rate, Amount: roundAmount(gross - rateVat), Vat: rateVat})
+		taxable += gross - rateVat
+		vat += rateVat
+	}
+	invoice.Subtotal = roundAmount(subtotal)
+	invoice.Discount = roundAmount(discount)
+	invoice.ExemptAmount = roundAmount(exempt)
+	invoice.TaxableAmount = roundAmount(taxable)
+	invoice.VatAmount = roundAmount(vat)
+	invoice.Total = roundAmount(subtotal - discount)
+}
+
+// CreditNoteInvoice returns the returned lines of a credit note as they were invoiced, with the VAT
+// worked out the same way, so the refund splits by the VAT treatment of the invoice. Lines are matched
+// by order item, invoices issued before lines carried it are matched by product.
+func (invoice *TaxInvoice) CreditNoteInvoice(note *CreditNote) TaxInvoice {
+	lines := make(map[primitive.ObjectID]TaxInvoiceItem, len(invoice.Items))
+	products := make(map[primitive.ObjectID]TaxInvoiceItem, len(invoice.Items))
+	for _, item := range invoice.Items {
+		if !item.OrderItemId.IsZero() {
+			lines[item.OrderItemId] = item
+		}
+		products[item.ProductId] = item
+	}
+	credit := TaxInvoice{
+		BranchId: invoice.BranchId,
+		Type:     invoice.Type,
+		Code:     note.Code,
+		Seller:   invoice.Seller,
+		Buyer:    invoice.Buyer,
+		Items:    make([]TaxInvoiceItem, 0, len(note.Items)),
+	}
+	for _, item := range note.Items {
+		line, ok := lines[item.OrderItemId]
+		if !ok {
+			line = products[item.ProductId]
+		}
+		unitPrice := 0.0
+		if item.Quantity > 0 {
+			unitPrice = roundAmount(item.Price / float64(item.Quantity))
+		}
+		credit.Items = append(credit.Items, TaxInvoiceItem{
+			OrderItemId: item.OrderItemId,
+			ProductId:   item.ProductId,
+			Name:        line.Name,
+			Quantity:    item.Quantity,
+			UnitPrice:   unitPrice,
+			Amount:      item.Price,
+			VatRate:     line.VatRate,
+			VatExempt:   line.VatExempt,
+		})
+	}
+	credit.ComputeVat()
+	return credit
+}
+
+// CreditedTaxInvoice returns the invoice a credit note of the order refers to, the one in force
+// or else the latest that was not cancelled
+func CreditedTaxInvoice(invoices []TaxInvoice) *TaxInvoice {
+	var result *TaxInvoice
+	for i := range invoices {
+		switch invoices[i].Status {
+		case constant.TaxInvoiceStatusIssued:
+			return &invoices[i]
+		case constant.TaxInvoiceStatusReplaced:
+			result = &invoices[i]
+		}
+	}
+	return result
+}
+
+// IsFull reports whether the invoice names the buyer, only a full tax invoice lets the buyer claim input VAT
+func (invoice *TaxInvoice) IsFull() bool {
+	return invoice.Type == constant.TaxInvoiceTypeFull
+}
+
+func roundAmount(value float64) float64 {
+	return math.Round(value*100) / 100
+}
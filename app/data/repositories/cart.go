@@ -0,0 +1,330 @@
+package repositories
+
+import (
+	"context"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+	"pos/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type cartEntity struct {
+	repo            *mongo.Collection
+	reservationRepo *mongo.Collection
+}
+
+type ICart interface {
+	CreateCart(form request.Cart) (*entities.Cart, error)
+	GetCartById(id string) (*entities.Cart, error)
+	GetCartsByUserId(branchId string, userId string) ([]entities.Cart, error)
+	UpdateCartById(id string, form request.Cart) (*entities.Cart, error)
+	ResumeCartById(id string, updatedBy string) (*entities.Cart, error)
+	TransferCartById(id string, userId string, updatedBy string) (*entities.Cart, error)
+	CancelCartById(id string, updatedBy string) (*entities.Cart, error)
+
+	// Transactional, ctx must come from ITransaction.WithTransaction
+	ParkCartByIdTx(ctx context.Context, id string, reservedUntil *time.Time, updatedBy string) (*entities.Cart, error)
+	ConvertCartByIdTx(ctx context.Context, id string, order *entities.Order, updatedBy string) (*entities.Cart, error)
+	GetReservedQuantitiesTx(ctx context.Context, branchId string, excludeCartId string) (map[string]int, error)
+	LockReservationsTx(ctx context.Context, branchId string, productIds []string) error
+}
+
+func NewCartEntity(resource *db.Resource) ICart {
+	repo := resource.PosDb.Collection("carts")
+	reservationRepo := resource.PosDb.Collection("cart_reservations")
+	entity := &cartEntity{repo: repo, reservationRepo: reservationRepo}
+	ensureCartIndexes(repo)
+	ensureCartReservationIndexes(reservationRepo)
+	return entity
+}
+
+func ensureCartIndexes(repo *mongo.Collection) {
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	_, err := repo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "branchId", Value: 1}, {Key: "userId", Value: 1}, {Key: "status", Value: 1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create carts branchId index: ", err)
+	}
+	_, err = repo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "branchId", Value: 1}, {Key: "reservedUntil", Value: 1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create carts reservedUntil index: ", err)
+	}
+}
+
+func ensureCartReservationIndexes(repo *mongo.Collection) {
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	_, err := repo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "branchId", Value: 1}, {Key: "productId", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		logrus.Error("failed to create cart_reservations branchId productId index: ", err)
+	}
+}
+
+func toCartItems(items []request.CartItem) []entities.CartItem {
+	results := make([]entities.CartItem, len(items))
+	for i, item := range items {
+		productId, _ := primitive.ObjectIDFromHex(item.ProductId)
+		unitId, _ := primitive.ObjectIDFromHex(item.UnitId)
+		results[i] = entities.CartItem{
+			ProductId:     productId,
+			UnitId:        unitId,
+			Quantity:      item.Quantity,
+			Price:         item.Price,
+			Discount:      item.Discount,
+			PriceOverride: item.PriceOverride,
+			BaseQuantity:  item.BaseQuantity,
+		}
+	}
+	return results
+}
+
+func (entity *cartEntity) CreateCart(form request.Cart) (*entities.Cart, error) {
+	logrus.Info("CreateCart")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+	data := entities.Cart{
+		Id:            primitive.NewObjectID(),
+		BranchId:      branchId,
+		UserId:        form.UserId,
+		Name:          form.Name,
+		CustomerCode:  form.CustomerCode,
+		CustomerName:  form.CustomerName,
+		Discount:      form.Discount,
+		PromotionCode: form.PromotionCode,
+		Note:          form.Note,
+		Items:         toCartItems(form.Items),
+		Status:        constant.CartStatusOpen,
+		CreatedBy:     form.UpdatedBy,
+		CreatedDate:   time.Now(),
+		UpdatedBy:     form.UpdatedBy,
+		UpdatedDate:   time.Now(),
+	}
+	_, err := entity.repo.InsertOne(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *cartEntity) GetCartById(id string) (*entities.Cart, error) {
+	logrus.Info("GetCartById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	data := entities.Cart{}
+	err = entity.repo.FindOne(ctx, bson.M{"_id": objId}).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *cartEntity) GetCartsByUserId(branchId string, userId string) ([]entities.Cart, error) {
+	logrus.Info("GetCartsByUserId")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	branchObjId, _ := primitive.ObjectIDFromHex(branchId)
+	filter := bson.M{
+		"branchId": branchObjId,
+		"userId":   userId,
+		"status":   bson.M{"$in": bson.A{constant.CartStatusOpen, constant.CartStatusParked}},
+	}
+	opts := options.Find().SetSort(bson.M{"createdDate": 1})
+	cursor, err := entity.repo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.Cart{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// updateCart applies the update to a cart that is still in one of the given statuses
+func (entity *cartEntity) updateCart(ctx context.Context, id string, statuses bson.A, update bson.M) (*entities.Cart, error) {
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	isReturnNewDoc := options.After
+	opts := &options.FindOneAndUpdateOptions{
+		ReturnDocument: &isReturnNewDoc,
+	}
+	data := entities.Cart{}
+	err = entity.repo.FindOneAndUpdate(ctx, bson.M{"_id": objId, "status": bson.M{"$in": statuses}}, update, opts).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *cartEntity) UpdateCartById(id string, form request.Cart) (*entities.Cart, error) {
+	logrus.Info("UpdateCartById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	return entity.updateCart(ctx, id, bson.A{constant.CartStatusOpen}, bson.M{
+		"$set": bson.M{
+			"name":          form.Name,
+			"customerCode":  form.CustomerCode,
+			"customerName":  form.CustomerName,
+			"discount":      form.Discount,
+			"promotionCode": form.PromotionCode,
+			"note":          form.Note,
+			"items":         toCartItems(form.Items),
+			"updatedBy":     form.UpdatedBy,
+			"updatedDate":   time.Now(),
+		},
+	})
+}
+
+func (entity *cartEntity) ParkCartByIdTx(ctx context.Context, id string, reservedUntil *time.Time, updatedBy string) (*entities.Cart, error) {
+	logrus.Info("ParkCartById")
+	update := bson.M{
+		"$set": bson.M{
+			"status":      constant.CartStatusParked,
+			"updatedBy":   updatedBy,
+			"updatedDate": time.Now(),
+		},
+	}
+	if reservedUntil != nil {
+		update["$set"].(bson.M)["reservedUntil"] = reservedUntil
+	} else {
+		update["$unset"] = bson.M{"reservedUntil": ""}
+	}
+	return entity.updateCart(ctx, id, bson.A{constant.CartStatusOpen, constant.CartStatusParked}, update)
+}
+
+func (entity *cartEntity) ResumeCartById(id string, updatedBy string) (*entities.Cart, error) {
+	logrus.Info("ResumeCartById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	return entity.updateCart(ctx, id, bson.A{constant.CartStatusParked}, bson.M{
+		"$set": bson.M{
+			"status":      constant.CartStatusOpen,
+			"updatedBy":   updatedBy,
+			"updatedDate": time.Now(),
+		},
+		"$unset": bson.M{"reservedUntil": ""},
+	})
+}
+
+func (entity *cartEntity) TransferCartById(id string, userId string, updatedBy string) (*entities.Cart, error) {
+	logrus.Info("TransferCartById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	return entity.updateCart(ctx, id, bson.A{constant.CartStatusOpen, constant.CartStatusParked}, bson.M{
+		"$set": bson.M{
+			"userId":      userId,
+			"updatedBy":   updatedBy,
+			"updatedDate": time.Now(),
+		},
+	})
+}
+
+func (entity *cartEntity) CancelCartById(id string, updatedBy string) (*entities.Cart, error) {
+	logrus.Info("CancelCartById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	return entity.updateCart(ctx, id, bson.A{constant.CartStatusOpen, constant.CartStatusParked}, bson.M{
+		"$set": bson.M{
+			"status":      constant.CartStatusCancelled,
+			"updatedBy":   updatedBy,
+			"updatedDate": time.Now(),
+		},
+		"$unset": bson.M{"reservedUntil": ""},
+	})
+}
+
+func (entity *cartEntity) ConvertCartByIdTx(ctx context.Context, id string, order *entities.Order, updatedBy string) (*entities.Cart, error) {
+	logrus.Info("ConvertCartById")
+	return entity.updateCart(ctx, id, bson.A{constant.CartStatusOpen, constant.CartStatusParked}, bson.M{
+		"$set": bson.M{
+			"status":      constant.CartStatusConverted,
+			"orderId":     order.Id.Hex(),
+			"orderCode":   order.Code,
+			"updatedBy":   updatedBy,
+			"updatedDate": time.Now(),
+		},
+		"$unset": bson.M{"reservedUntil": ""},
+	})
+}
+
+// GetReservedQuantitiesTx sums the base unit quantity held by the unexpired reservations
+// of parked carts in the branch, keyed by product id
+func (entity *cartEntity) GetReservedQuantitiesTx(ctx context.Context, branchId string, excludeCartId string) (map[string]int, error) {
+	logrus.Info("GetReservedQuantities")
+	branchObjId, _ := primitive.ObjectIDFromHex(branchId)
+	matchFilter := bson.M{
+		"branchId":      branchObjId,
+		"status":        constant.CartStatusParked,
+		"reservedUntil": bson.M{"$gt": time.Now()},
+	}
+	if excludeCartId != "" {
+		cartObjId, _ := primitive.ObjectIDFromHex(excludeCartId)
+		matchFilter["_id"] = bson.M{"$ne": cartObjId}
+	}
+	pipeline := []bson.M{
+		{"$match": matchFilter},
+		{"$unwind": "$items"},
+		{"$group": bson.M{
+			"_id":      "$items.productId",
+			"quantity": bson.M{"$sum": "$items.baseQuantity"},
+		}},
+	}
+	cursor, err := entity.repo.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		ProductId primitive.ObjectID `bson:"_id"`
+		Quantity  int                `bson:"quantity"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	results := make(map[string]int)
+	for _, row := range rows {
+		results[row.ProductId.Hex()] = row.Quantity
+	}
+	return results, nil
+}
+
+// LockReservationsTx bumps the reservation counter of each product in the branch. Parks reserving
+// the same product write the same counter, concurrent ones conflict and the transaction is retried.
+func (entity *cartEntity) LockReservationsTx(ctx context.Context, branchId string, productIds []string) error {
+	logrus.Info("LockReservations")
+	branchObjId, _ := primitive.ObjectIDFromHex(branchId)
+	opts := options.Update().SetUpsert(true)
+	for _, productId := range productIds {
+		productObjId, _ := primitive.ObjectIDFromHex(productId)
+		filter := bson.M{"branchId": branchObjId, "productId": productObjId}
+		update := bson.M{
+			"$inc": bson.M{"version": 1},
+			"$set": bson.M{"updatedDate": time.Now()},
+		}
+		if _, err := entity.reservationRepo.UpdateOne(ctx, filter, update, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
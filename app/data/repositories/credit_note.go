@@ -0,0 +1,212 @@
+package repositories
+
+import (
+	"context"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+	"pos/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type creditNoteEntity struct {
+	repo *mongo.Collection
+}
+
+type ICreditNote interface {
+	GetCreditNoteRange(form request.GetCreditNoteRange) ([]entities.CreditNote, error)
+	GetCreditNoteById(id string) (*entities.CreditNote, error)
+	GetCreditNotesByOrderId(orderId string) ([]entities.CreditNote, error)
+	GetCreditNotesByShiftId(shiftId string) ([]entities.CreditNote, error)
+
+	// Transactional, ctx must come from ITransaction.WithTransaction
+	CreateCreditNoteTx(ctx context.Context, form request.CreditNote) (*entities.CreditNote, error)
+	GetCreditNotesByOrderIdTx(ctx context.Context, orderId string) ([]entities.CreditNote, error)
+}
+
+func NewCreditNoteEntity(resource *db.Resource) ICreditNote {
+	repo := resource.PosDb.Collection("credit_notes")
+	entity := &creditNoteEntity{repo: repo}
+	ensureCreditNoteIndexes(repo)
+	return entity
+}
+
+func ensureCreditNoteIndexes(repo *mongo.Collection) {
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	_, err := repo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "branchId", Value: 1}, {Key: "createdDate", Value: -1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create credit_notes branchId index: ", err)
+	}
+	_, err = repo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "orderId", Value: 1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create credit_notes orderId index: ", err)
+	}
+	_, err = repo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "shiftId", Value: 1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create credit_notes shiftId index: ", err)
+	}
+}
+
+func (entity *creditNoteEntity) CreateCreditNoteTx(ctx context.Context, form request.CreditNote) (*entities.CreditNote, error) {
+	logrus.Info("CreateCreditNote")
+	branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+	orderId, _ := primitive.ObjectIDFromHex(form.OrderId)
+
+	items := make([]entities.CreditNoteItem, len(form.Items))
+	for i, item := range form.Items {
+		orderItemId, _ := primitive.ObjectIDFromHex(item.OrderItemId)
+		productId, _ := primitive.ObjectIDFromHex(item.ProductId)
+		unitId, _ := primitive.ObjectIDFromHex(item.UnitId)
+		stocks := make([]entities.CreditNoteItemStock, len(item.Stocks))
+		for j, stock := range item.Stocks {
+			stocks[j] = entities.CreditNoteItemStock{
+				OrderStockId: stock.OrderStockId,
+				StockId:      stock.StockId,
+				LotNumber:    stock.LotNumber,
+				Quantity:     stock.Quantity,
+				Quarantined:  stock.Quarantined,
+			}
+		}
+		items[i] = entities.CreditNoteItem{
+			OrderItemId: orderItemId,
+			ProductId:   productId,
+			UnitId:      unitId,
+			Quantity:    item.Quantity,
+			Price:       item.Price,
+			CostPrice:   item.CostPrice,
+			Damaged:     item.Damaged,
+			Stocks:      stocks,
+		}
+	}
+
+	refunds := make([]entities.CreditNoteRefund, len(form.Refunds))
+	for i, refund := range form.Refunds {
+		refunds[i] = entities.CreditNoteRefund{
+			Type:   refund.Type,
+			Amount: refund.Amount,
+		}
+	}
+
+	data := entities.CreditNote{
+		Id:           primitive.NewObjectID(),
+		BranchId:     branchId,
+		OrderId:      orderId,
+		OrderCode:    form.OrderCode,
+		Code:         form.Code,
+		CustomerCode: form.CustomerCode,
+		CustomerName: form.CustomerName,
+		Reason:       form.Reason,
+		Items:        items,
+		Refunds:      refunds,
+		Total:        form.Total,
+		TotalCost:    form.TotalCost,
+		Status:       constant.ACTIVE,
+		ShiftId:      form.ShiftId,
+		CreatedBy:    form.CreatedBy,
+		CreatedDate:  time.Now(),
+		UpdatedBy:    form.CreatedBy,
+		UpdatedDate:  time.Now(),
+	}
+	_, err := entity.repo.InsertOne(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *creditNoteEntity) GetCreditNoteRange(form request.GetCreditNoteRange) ([]entities.CreditNote, error) {
+	logrus.Info("GetCreditNoteRange")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+
+	filter := bson.M{
+		"createdDate": bson.M{
+			"$gt": form.StartDate,
+			"$lt": form.EndDate,
+		},
+	}
+	if form.BranchId != "" {
+		branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+		filter["branchId"] = branchId
+	}
+	opts := options.Find().SetSort(bson.M{"createdDate": -1})
+	cursor, err := entity.repo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.CreditNote{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (entity *creditNoteEntity) GetCreditNoteById(id string) (*entities.CreditNote, error) {
+	logrus.Info("GetCreditNoteById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	data := entities.CreditNote{}
+	err = entity.repo.FindOne(ctx, bson.M{"_id": objId}).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *creditNoteEntity) GetCreditNotesByOrderId(orderId string) ([]entities.CreditNote, error) {
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	return entity.GetCreditNotesByOrderIdTx(ctx, orderId)
+}
+
+func (entity *creditNoteEntity) GetCreditNotesByOrderIdTx(ctx context.Context, orderId string) ([]entities.CreditNote, error) {
+	logrus.Info("GetCreditNotesByOrderId")
+	objId, err := primitive.ObjectIDFromHex(orderId)
+	if err != nil {
+		return nil, err
+	}
+	opts := options.Find().SetSort(bson.M{"createdDate": 1})
+	cursor, err := entity.repo.Find(ctx, bson.M{"orderId": objId, "status": constant.ACTIVE}, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.CreditNote{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (entity *creditNoteEntity) GetCreditNotesByShiftId(shiftId string) ([]entities.CreditNote, error) {
+	logrus.Info("GetCreditNotesByShiftId")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	opts := options.Find().SetSort(bson.M{"createdDate": 1})
+	cursor, err := entity.repo.Find(ctx, bson.M{"shiftId": shiftId, "status": constant.ACTIVE}, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.CreditNote{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -28,6 +28,7 @@ type ICustomer interface {
 	RemoveCustomerById(id string) (*entities.Customer, error)
 	UpdateCustomerById(id string, form request.UpdateCustomer) (*entities.Customer, error)
 	UpdateCustomerStatusById(id string, form request.UpdateCustomerStatus) (*entities.Customer, error)
+	UpdateCustomerCreditById(id string, form request.UpdateCustomerCredit) (*entities.Customer, error)
 }
 
 func NewCustomerEntity(resource *db.Resource) ICustomer {
@@ -176,6 +177,32 @@ func (entity *customerEntity) UpdateCustomerStatusById(id string, form request.U
 	return &data, nil
 }
 
+func (entity *customerEntity) UpdateCustomerCreditById(id string, form request.UpdateCustomerCredit) (*entities.Customer, error) {
+	logrus.Info("UpdateCustomerCreditById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	cid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	isReturnNewDoc := options.After
+	opts := &options.FindOneAndUpdateOptions{
+		ReturnDocument: &isReturnNewDoc,
+	}
+	var data entities.Customer
+	err = entity.customerRepo.FindOneAndUpdate(ctx, bson.M{"_id": cid}, bson.M{"$set": bson.M{
+		"creditLimit": form.CreditLimit,
+		"creditDays":  form.CreditDays,
+		"updatedBy":   form.UpdatedBy,
+		"updatedDate": time.Now(),
+	}}, opts).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
 func (entity *customerEntity) CreateIndex() (string, error) {
 	ctx, cancel := utils.InitContext()
 	defer cancel()
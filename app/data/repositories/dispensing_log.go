@@ -1,8 +1,10 @@
 package repositories
 
 import (
+	"context"
 	"pos/app/core/utils"
 	"pos/app/data/entities"
+	"pos/app/domain/constant"
 	"pos/app/domain/request"
 	"pos/db"
 	"time"
@@ -30,7 +32,13 @@ type IDispensingLog interface {
 	GetDispensingLogById(id string) (*entities.DispensingLog, error)
 	GetDispensingLogsByPatientId(patientId string) ([]entities.DispensingLog, error)
 	GetDispensingLogsByDateRange(branchId string, startDate time.Time, endDate time.Time) ([]entities.DispensingLog, error)
+	GetDispensingLogsByLotNumbers(productId string, lotNumbers []string) ([]entities.DispensingLog, error)
 	GetRefillReminders(branchId string, refillDays int) ([]RefillReminder, error)
+
+	// Transactional, ctx must come from ITransaction.WithTransaction
+	CreateDispensingLogTx(ctx context.Context, form request.DispensingLog) (*entities.DispensingLog, error)
+	ReturnDispensingItemTx(ctx context.Context, orderId string, orderItemId string, quantity int) error
+	VoidDispensingLogByOrderIdTx(ctx context.Context, orderId string) error
 }
 
 func NewDispensingLogEntity(resource *db.Resource) IDispensingLog {
@@ -55,13 +63,22 @@ func ensureDispensingLogIndexes(repo *mongo.Collection) {
 	if err != nil {
 		logrus.Error("failed to create dispensing_logs patientId index: ", err)
 	}
+	_, err = repo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "orderId", Value: 1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create dispensing_logs orderId index: ", err)
+	}
 }
 
 func (entity *dispensingLogEntity) CreateDispensingLog(form request.DispensingLog) (*entities.DispensingLog, error) {
-	logrus.Info("CreateDispensingLog")
 	ctx, cancel := utils.InitContext()
 	defer cancel()
+	return entity.CreateDispensingLogTx(ctx, form)
+}
 
+func (entity *dispensingLogEntity) CreateDispensingLogTx(ctx context.Context, form request.DispensingLog) (*entities.DispensingLog, error) {
+	logrus.Info("CreateDispensingLog")
 	branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
 	orderId, _ := primitive.ObjectIDFromHex(form.OrderId)
 	patientId, _ := primitive.ObjectIDFromHex(form.PatientId)
@@ -69,7 +86,9 @@ func (entity *dispensingLogEntity) CreateDispensingLog(form request.DispensingLo
 	items := make([]entities.DispensingItem, len(form.Items))
 	for i, item := range form.Items {
 		productId, _ := primitive.ObjectIDFromHex(item.ProductId)
+		orderItemId, _ := primitive.ObjectIDFromHex(item.OrderItemId)
 		items[i] = entities.DispensingItem{
+			OrderItemId: orderItemId,
 			ProductId:   productId,
 			ProductName: item.ProductName,
 			GenericName: item.GenericName,
@@ -84,11 +103,13 @@ func (entity *dispensingLogEntity) CreateDispensingLog(form request.DispensingLo
 		Id:             primitive.NewObjectID(),
 		BranchId:       branchId,
 		OrderId:        orderId,
+		OrderCode:      form.OrderCode,
 		PatientId:      patientId,
 		Items:          items,
 		PharmacistName: form.PharmacistName,
 		LicenseNo:      form.LicenseNo,
 		Note:           form.Note,
+		Status:         constant.ACTIVE,
 		CreatedBy:      form.CreatedBy,
 		CreatedDate:    time.Now(),
 	}
@@ -160,6 +181,35 @@ func (entity *dispensingLogEntity) GetDispensingLogsByPatientId(patientId string
 	return results, nil
 }
 
+// GetDispensingLogsByLotNumbers returns the dispensing logs of every branch that gave out the lots of
+// the product, voided logs are left out
+func (entity *dispensingLogEntity) GetDispensingLogsByLotNumbers(productId string, lotNumbers []string) ([]entities.DispensingLog, error) {
+	logrus.Info("GetDispensingLogsByLotNumbers")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(productId)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{
+		"items": bson.M{"$elemMatch": bson.M{
+			"productId": objId,
+			"lotNumber": bson.M{"$in": lotNumbers},
+		}},
+		"status": bson.M{"$ne": constant.DispensingStatusVoided},
+	}
+	opts := options.Find().SetSort(bson.M{"createdDate": -1})
+	cursor, err := entity.repo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.DispensingLog{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
 func (entity *dispensingLogEntity) GetRefillReminders(branchId string, refillDays int) ([]RefillReminder, error) {
 	logrus.Info("GetRefillReminders")
 	ctx, cancel := utils.InitContext()
@@ -171,6 +221,7 @@ func (entity *dispensingLogEntity) GetRefillReminders(branchId string, refillDay
 
 	matchFilter := bson.M{
 		"patientId": bson.M{"$ne": primitive.NilObjectID},
+		"status":    bson.M{"$ne": constant.DispensingStatusVoided},
 	}
 	if branchId != "" {
 		objId, _ := primitive.ObjectIDFromHex(branchId)
@@ -219,6 +270,7 @@ func (entity *dispensingLogEntity) GetDispensingLogsByDateRange(branchId string,
 
 	filter := bson.M{
 		"createdDate": bson.M{"$gte": startDate, "$lte": endDate},
+		"status":      bson.M{"$ne": constant.DispensingStatusVoided},
 	}
 	if branchId != "" {
 		objId, _ := primitive.ObjectIDFromHex(branchId)
@@ -238,3 +290,37 @@ func (entity *dispensingLogEntity) GetDispensingLogsByDateRange(branchId string,
 	}
 	return results, nil
 }
+
+// ReturnDispensingItemTx records the quantity of an order line that came back on a credit note,
+// orders without dispensing log are ignored
+func (entity *dispensingLogEntity) ReturnDispensingItemTx(ctx context.Context, orderId string, orderItemId string, quantity int) error {
+	logrus.Info("ReturnDispensingItem")
+	orderObjId, err := primitive.ObjectIDFromHex(orderId)
+	if err != nil {
+		return err
+	}
+	orderItemObjId, err := primitive.ObjectIDFromHex(orderItemId)
+	if err != nil {
+		return err
+	}
+	_, err = entity.repo.UpdateOne(ctx, bson.M{
+		"orderId":           orderObjId,
+		"items.orderItemId": orderItemObjId,
+	}, bson.M{
+		"$inc": bson.M{"items.$.returnedQuantity": quantity},
+	})
+	return err
+}
+
+// VoidDispensingLogByOrderIdTx takes the dispensing of a voided order off the registers
+func (entity *dispensingLogEntity) VoidDispensingLogByOrderIdTx(ctx context.Context, orderId string) error {
+	logrus.Info("VoidDispensingLogByOrderId")
+	objId, err := primitive.ObjectIDFromHex(orderId)
+	if err != nil {
+		return err
+	}
+	_, err = entity.repo.UpdateMany(ctx, bson.M{"orderId": objId}, bson.M{
+		"$set": bson.M{"status": constant.DispensingStatusVoided},
+	})
+	return err
+}
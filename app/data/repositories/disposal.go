@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"context"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/domain/request"
+	"pos/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type disposalEntity struct {
+	repo *mongo.Collection
+}
+
+type IDisposal interface {
+	GetDisposalRange(form request.GetDisposalRange) ([]entities.Disposal, error)
+	GetDisposalById(id string) (*entities.Disposal, error)
+
+	// Transactional, ctx must come from ITransaction.WithTransaction
+	CreateDisposalTx(ctx context.Context, form request.Disposal) (*entities.Disposal, error)
+}
+
+func NewDisposalEntity(resource *db.Resource) IDisposal {
+	repo := resource.PosDb.Collection("disposals")
+	entity := &disposalEntity{repo: repo}
+	ensureDisposalIndexes(repo)
+	return entity
+}
+
+func ensureDisposalIndexes(repo *mongo.Collection) {
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	_, err := repo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "branchId", Value: 1}, {Key: "createdDate", Value: -1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create disposals branchId index: ", err)
+	}
+}
+
+func (entity *disposalEntity) CreateDisposalTx(ctx context.Context, form request.Disposal) (*entities.Disposal, error) {
+	logrus.Info("CreateDisposal")
+	branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+	witnesses := []entities.DisposalWitness{}
+	for _, witness := range form.Witnesses {
+		witnesses = append(witnesses, entities.DisposalWitness{
+			Name:      witness.Name,
+			Position:  witness.Position,
+			LicenseNo: witness.LicenseNo,
+		})
+	}
+	data := entities.Disposal{
+		Id:           primitive.NewObjectID(),
+		BranchId:     branchId,
+		Code:         form.Code,
+		Method:       form.Method,
+		Location:     form.Location,
+		DisposedDate: form.DisposedDate,
+		Witnesses:    witnesses,
+		Items:        form.Lots,
+		TotalCost:    form.TotalCost,
+		Note:         form.Note,
+		CreatedBy:    form.CreatedBy,
+		CreatedDate:  time.Now(),
+	}
+	_, err := entity.repo.InsertOne(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *disposalEntity) GetDisposalRange(form request.GetDisposalRange) ([]entities.Disposal, error) {
+	logrus.Info("GetDisposalRange")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+
+	filter := bson.M{
+		"createdDate": bson.M{
+			"$gt": form.StartDate,
+			"$lt": form.EndDate,
+		},
+	}
+	if form.BranchId != "" {
+		branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+		filter["branchId"] = branchId
+	}
+	opts := options.Find().SetSort(bson.M{"createdDate": -1})
+	cursor, err := entity.repo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.Disposal{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (entity *disposalEntity) GetDisposalById(id string) (*entities.Disposal, error) {
+	logrus.Info("GetDisposalById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	data := entities.Disposal{}
+	err = entity.repo.FindOne(ctx, bson.M{"_id": objId}).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
@@ -0,0 +1,219 @@
+package repositories
+
+import (
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/domain/request"
+	"pos/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type drugInteractionEntity struct {
+	repo *mongo.Collection
+}
+
+type IDrugInteraction interface {
+	CreateDrugInteraction(form request.DrugInteraction) (*entities.DrugInteraction, error)
+	UpsertDrugInteraction(form request.DrugInteraction) (*entities.DrugInteraction, error)
+	GetDrugInteractions(form request.GetDrugInteractions) ([]entities.DrugInteraction, error)
+	GetDrugInteractionById(id string) (*entities.DrugInteraction, error)
+	GetDrugInteractionsByIngredients(ingredients []string) ([]entities.DrugInteraction, error)
+	UpdateDrugInteractionById(id string, form request.DrugInteraction) (*entities.DrugInteraction, error)
+	RemoveDrugInteractionById(id string) (*entities.DrugInteraction, error)
+}
+
+func NewDrugInteractionEntity(resource *db.Resource) IDrugInteraction {
+	repo := resource.PosDb.Collection("drug_interactions")
+	entity := &drugInteractionEntity{repo: repo}
+	ensureDrugInteractionIndexes(repo)
+	return entity
+}
+
+func ensureDrugInteractionIndexes(repo *mongo.Collection) {
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	_, err := repo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "ingredientA", Value: 1}, {Key: "ingredientB", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		logrus.Error("failed to create drug_interactions pair index: ", err)
+	}
+	_, err = repo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "ingredientB", Value: 1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create drug_interactions ingredientB index: ", err)
+	}
+}
+
+func (entity *drugInteractionEntity) CreateDrugInteraction(form request.DrugInteraction) (*entities.DrugInteraction, error) {
+	logrus.Info("CreateDrugInteraction")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	ingredientA, ingredientB := entities.IngredientPair(form.IngredientA, form.IngredientB)
+	data := entities.DrugInteraction{
+		Id:          primitive.NewObjectID(),
+		IngredientA: ingredientA,
+		IngredientB: ingredientB,
+		Severity:    form.Severity,
+		Mechanism:   form.Mechanism,
+		Management:  form.Management,
+		Source:      form.Source,
+		CreatedBy:   form.UpdatedBy,
+		CreatedDate: time.Now(),
+		UpdatedBy:   form.UpdatedBy,
+		UpdatedDate: time.Now(),
+	}
+	_, err := entity.repo.InsertOne(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// UpsertDrugInteraction creates the entry of the ingredient pair or replaces its details
+func (entity *drugInteractionEntity) UpsertDrugInteraction(form request.DrugInteraction) (*entities.DrugInteraction, error) {
+	logrus.Info("UpsertDrugInteraction")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	ingredientA, ingredientB := entities.IngredientPair(form.IngredientA, form.IngredientB)
+	isReturnNewDoc := options.After
+	upsert := true
+	opts := &options.FindOneAndUpdateOptions{
+		ReturnDocument: &isReturnNewDoc,
+		Upsert:         &upsert,
+	}
+	data := entities.DrugInteraction{}
+	err := entity.repo.FindOneAndUpdate(ctx, bson.M{"ingredientA": ingredientA, "ingredientB": ingredientB}, bson.M{
+		"$set": bson.M{
+			"severity":    form.Severity,
+			"mechanism":   form.Mechanism,
+			"management":  form.Management,
+			"source":      form.Source,
+			"updatedBy":   form.UpdatedBy,
+			"updatedDate": time.Now(),
+		},
+		"$setOnInsert": bson.M{
+			"_id":         primitive.NewObjectID(),
+			"createdBy":   form.UpdatedBy,
+			"createdDate": time.Now(),
+		},
+	}, opts).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *drugInteractionEntity) find(filter bson.M) ([]entities.DrugInteraction, error) {
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "ingredientA", Value: 1}, {Key: "ingredientB", Value: 1}})
+	cursor, err := entity.repo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.DrugInteraction{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (entity *drugInteractionEntity) GetDrugInteractions(form request.GetDrugInteractions) ([]entities.DrugInteraction, error) {
+	logrus.Info("GetDrugInteractions")
+	filter := bson.M{}
+	if ingredient := entities.NormalizeIngredient(form.Ingredient); ingredient != "" {
+		filter["$or"] = bson.A{
+			bson.M{"ingredientA": ingredient},
+			bson.M{"ingredientB": ingredient},
+		}
+	}
+	if form.Severity != "" {
+		filter["severity"] = form.Severity
+	}
+	return entity.find(filter)
+}
+
+// GetDrugInteractionsByIngredients returns the entries between any two of the ingredients
+func (entity *drugInteractionEntity) GetDrugInteractionsByIngredients(ingredients []string) ([]entities.DrugInteraction, error) {
+	logrus.Info("GetDrugInteractionsByIngredients")
+	if len(ingredients) < 2 {
+		return []entities.DrugInteraction{}, nil
+	}
+	return entity.find(bson.M{
+		"ingredientA": bson.M{"$in": ingredients},
+		"ingredientB": bson.M{"$in": ingredients},
+	})
+}
+
+func (entity *drugInteractionEntity) GetDrugInteractionById(id string) (*entities.DrugInteraction, error) {
+	logrus.Info("GetDrugInteractionById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	data := entities.DrugInteraction{}
+	err = entity.repo.FindOne(ctx, bson.M{"_id": objId}).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *drugInteractionEntity) UpdateDrugInteractionById(id string, form request.DrugInteraction) (*entities.DrugInteraction, error) {
+	logrus.Info("UpdateDrugInteractionById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	ingredientA, ingredientB := entities.IngredientPair(form.IngredientA, form.IngredientB)
+	isReturnNewDoc := options.After
+	opts := &options.FindOneAndUpdateOptions{
+		ReturnDocument: &isReturnNewDoc,
+	}
+	data := entities.DrugInteraction{}
+	err = entity.repo.FindOneAndUpdate(ctx, bson.M{"_id": objId}, bson.M{
+		"$set": bson.M{
+			"ingredientA": ingredientA,
+			"ingredientB": ingredientB,
+			"severity":    form.Severity,
+			"mechanism":   form.Mechanism,
+			"management":  form.Management,
+			"source":      form.Source,
+			"updatedBy":   form.UpdatedBy,
+			"updatedDate": time.Now(),
+		},
+	}, opts).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *drugInteractionEntity) RemoveDrugInteractionById(id string) (*entities.DrugInteraction, error) {
+	logrus.Info("RemoveDrugInteractionById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	data := entities.DrugInteraction{}
+	err = entity.repo.FindOneAndDelete(ctx, bson.M{"_id": objId}).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
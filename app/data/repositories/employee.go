@@ -70,6 +70,8 @@ func (entity *employeeEntity) CreateEmployee(form request.Employee) (*entities.E
 		BranchId:    branchId,
 		UserId:      form.UserId,
 		Role:        form.Role,
+		Name:        form.Name,
+		LicenseNo:   form.LicenseNo,
 		CreatedBy:   form.CreatedBy,
 		CreatedDate: time.Now(),
 		UpdatedBy:   form.CreatedBy,
@@ -179,6 +181,8 @@ func (entity *employeeEntity) UpdateEmployeeById(id string, form request.UpdateE
 	err = entity.employeeRepo.FindOneAndUpdate(ctx, bson.M{"_id": objectId}, bson.M{"$set": bson.M{
 		"branchId":    branchId,
 		"role":        form.Role,
+		"name":        form.Name,
+		"licenseNo":   form.LicenseNo,
 		"updatedBy":   form.UpdatedBy,
 		"updatedDate": time.Now(),
 	}}, opts).Decode(&data)
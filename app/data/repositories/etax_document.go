@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/domain/request"
+	"pos/db"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type etaxDocumentEntity struct {
+	repo *mongo.Collection
+}
+
+type IEtaxDocument interface {
+	CreateEtaxDocument(form request.EtaxDocument) (*entities.EtaxDocument, error)
+	GetEtaxDocumentRange(form request.GetEtaxDocumentRange) ([]entities.EtaxDocument, error)
+	GetEtaxDocumentById(id string) (*entities.EtaxDocument, error)
+	GetEtaxDocumentBySourceId(sourceId string) (*entities.EtaxDocument, error)
+}
+
+func NewEtaxDocumentEntity(resource *db.Resource) IEtaxDocument {
+	repo := resource.PosDb.Collection("etax_documents")
+	entity := &etaxDocumentEntity{repo: repo}
+	ensureEtaxDocumentIndexes(repo)
+	return entity
+}
+
+func ensureEtaxDocumentIndexes(repo *mongo.Collection) {
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	// A tax invoice or credit note is sent as an e-Tax document once
+	_, err := repo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "sourceId", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		logrus.Error("failed to create etax_documents sourceId index: ", err)
+	}
+	_, err = repo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "branchId", Value: 1}, {Key: "issuedDate", Value: -1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create etax_documents branchId index: ", err)
+	}
+}
+
+func (entity *etaxDocumentEntity) CreateEtaxDocument(form request.EtaxDocument) (*entities.EtaxDocument, error) {
+	logrus.Info("CreateEtaxDocument")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+	sourceId, _ := primitive.ObjectIDFromHex(form.SourceId)
+	data := entities.EtaxDocument{
+		Id:            primitive.NewObjectID(),
+		BranchId:      branchId,
+		SourceType:    form.SourceType,
+		SourceId:      sourceId,
+		TypeCode:      form.TypeCode,
+		Code:          form.Code,
+		IssuedDate:    form.IssuedDate,
+		Total:         form.Total,
+		XmlDigest:     form.XmlDigest,
+		SignerSubject: form.SignerSubject,
+		SignerSerial:  form.SignerSerial,
+		Xml:           form.Xml,
+		Pdf:           form.Pdf,
+		CreatedBy:     form.CreatedBy,
+		CreatedDate:   form.CreatedDate,
+	}
+	_, err := entity.repo.InsertOne(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetEtaxDocumentRange lists the archive without the XML and PDF
+func (entity *etaxDocumentEntity) GetEtaxDocumentRange(form request.GetEtaxDocumentRange) ([]entities.EtaxDocument, error) {
+	logrus.Info("GetEtaxDocumentRange")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	filter := bson.M{
+		"issuedDate": bson.M{
+			"$gt": form.StartDate,
+			"$lt": form.EndDate,
+		},
+	}
+	if form.BranchId != "" {
+		branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+		filter["branchId"] = branchId
+	}
+	if form.SourceType != "" {
+		filter["sourceType"] = form.SourceType
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "issuedDate", Value: 1}}).
+		SetProjection(bson.M{"xml": 0, "pdf": 0})
+	cursor, err := entity.repo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.EtaxDocument{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (entity *etaxDocumentEntity) GetEtaxDocumentById(id string) (*entities.EtaxDocument, error) {
+	logrus.Info("GetEtaxDocumentById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	data := entities.EtaxDocument{}
+	err = entity.repo.FindOne(ctx, bson.M{"_id": objId}).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *etaxDocumentEntity) GetEtaxDocumentBySourceId(sourceId string) (*entities.EtaxDocument, error) {
+	logrus.Info("GetEtaxDocumentBySourceId")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(sourceId)
+	if err != nil {
+		return nil, err
+	}
+	data := entities.EtaxDocument{}
+	opts := options.FindOne().SetProjection(bson.M{"xml": 0, "pdf": 0})
+	err = entity.repo.FindOne(ctx, bson.M{"sourceId": objId}, opts).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"pos/app/data/entities"
+	"pos/db"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+type idempotencyEntity struct {
+	rdb *redis.Client
+}
+
+type IIdempotency interface {
+	LockIdempotencyKey(key string, fingerprint string, ttl time.Duration) (bool, error)
+	GetIdempotencyRecord(key string) (*entities.IdempotencyRecord, error)
+	SaveIdempotencyRecord(key string, record entities.IdempotencyRecord, ttl time.Duration) error
+	RemoveIdempotencyKey(key string) error
+}
+
+func NewIdempotencyEntity(resource *db.Resource) IIdempotency {
+	entity := &idempotencyEntity{rdb: resource.RdDb}
+	return entity
+}
+
+// LockIdempotencyKey marks the key as in progress, it returns false when the key already exists
+func (entity *idempotencyEntity) LockIdempotencyKey(key string, fingerprint string, ttl time.Duration) (bool, error) {
+	logrus.Info("LockIdempotencyKey")
+	data, err := json.Marshal(entities.IdempotencyRecord{
+		Status:      entities.IdempotencyProcessing,
+		Fingerprint: fingerprint,
+	})
+	if err != nil {
+		return false, err
+	}
+	return entity.rdb.SetNX(context.Background(), key, data, ttl).Result()
+}
+
+func (entity *idempotencyEntity) GetIdempotencyRecord(key string) (*entities.IdempotencyRecord, error) {
+	logrus.Info("GetIdempotencyRecord")
+	result, err := entity.rdb.Get(context.Background(), key).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	data := entities.IdempotencyRecord{}
+	if err = json.Unmarshal(result, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *idempotencyEntity) SaveIdempotencyRecord(key string, record entities.IdempotencyRecord, ttl time.Duration) error {
+	logrus.Info("SaveIdempotencyRecord")
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return entity.rdb.Set(context.Background(), key, data, ttl).Err()
+}
+
+func (entity *idempotencyEntity) RemoveIdempotencyKey(key string) error {
+	logrus.Info("RemoveIdempotencyKey")
+	return entity.rdb.Del(context.Background(), key).Err()
+}
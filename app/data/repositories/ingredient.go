@@ -0,0 +1,187 @@
+package repositories
+
+import (
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/domain/request"
+	"pos/db"
+	"regexp"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ingredientEntity struct {
+	repo *mongo.Collection
+}
+
+type IIngredient interface {
+	CreateIngredient(form request.Ingredient) (*entities.Ingredient, error)
+	GetIngredients(form request.GetIngredients) ([]entities.Ingredient, error)
+	GetIngredientById(id string) (*entities.Ingredient, error)
+	GetIngredientsByNames(names []string) ([]entities.Ingredient, error)
+	UpdateIngredientById(id string, form request.Ingredient) (*entities.Ingredient, error)
+	RemoveIngredientById(id string) (*entities.Ingredient, error)
+}
+
+func NewIngredientEntity(resource *db.Resource) IIngredient {
+	repo := resource.PosDb.Collection("ingredients")
+	entity := &ingredientEntity{repo: repo}
+	ensureIngredientIndexes(repo)
+	return entity
+}
+
+func ensureIngredientIndexes(repo *mongo.Collection) {
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	_, err := repo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "normalizedName", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		logrus.Error("failed to create ingredients normalizedName index: ", err)
+	}
+	_, err = repo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "classes", Value: 1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create ingredients classes index: ", err)
+	}
+}
+
+// normalizeClasses stores drug classes the way allergies are compared against them
+func normalizeClasses(classes []string) []string {
+	results := []string{}
+	for _, class := range classes {
+		if name := entities.NormalizeIngredient(class); name != "" && !utils.InArrayString(name, results) {
+			results = append(results, name)
+		}
+	}
+	return results
+}
+
+func (entity *ingredientEntity) CreateIngredient(form request.Ingredient) (*entities.Ingredient, error) {
+	logrus.Info("CreateIngredient")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	data := entities.Ingredient{
+		Id:             primitive.NewObjectID(),
+		Name:           form.Name,
+		NormalizedName: entities.NormalizeIngredient(form.Name),
+		Classes:        normalizeClasses(form.Classes),
+		Unit:           form.Unit,
+		Description:    form.Description,
+		CreatedBy:      form.UpdatedBy,
+		CreatedDate:    time.Now(),
+		UpdatedBy:      form.UpdatedBy,
+		UpdatedDate:    time.Now(),
+	}
+	_, err := entity.repo.InsertOne(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *ingredientEntity) find(filter bson.M) ([]entities.Ingredient, error) {
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	opts := options.Find().SetSort(bson.M{"normalizedName": 1})
+	cursor, err := entity.repo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.Ingredient{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetIngredients lists the master, a class filter expands the class to its member ingredients
+func (entity *ingredientEntity) GetIngredients(form request.GetIngredients) ([]entities.Ingredient, error) {
+	logrus.Info("GetIngredients")
+	filter := bson.M{}
+	if keyword := entities.NormalizeIngredient(form.Keyword); keyword != "" {
+		filter["normalizedName"] = bson.M{"$regex": regexp.QuoteMeta(keyword)}
+	}
+	if class := entities.NormalizeIngredient(form.Class); class != "" {
+		filter["classes"] = class
+	}
+	return entity.find(filter)
+}
+
+func (entity *ingredientEntity) GetIngredientById(id string) (*entities.Ingredient, error) {
+	logrus.Info("GetIngredientById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	data := entities.Ingredient{}
+	err = entity.repo.FindOne(ctx, bson.M{"_id": objId}).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetIngredientsByNames returns the master entries of the normalized ingredient names
+func (entity *ingredientEntity) GetIngredientsByNames(names []string) ([]entities.Ingredient, error) {
+	logrus.Info("GetIngredientsByNames")
+	if len(names) == 0 {
+		return []entities.Ingredient{}, nil
+	}
+	return entity.find(bson.M{"normalizedName": bson.M{"$in": names}})
+}
+
+func (entity *ingredientEntity) UpdateIngredientById(id string, form request.Ingredient) (*entities.Ingredient, error) {
+	logrus.Info("UpdateIngredientById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	isReturnNewDoc := options.After
+	opts := &options.FindOneAndUpdateOptions{
+		ReturnDocument: &isReturnNewDoc,
+	}
+	data := entities.Ingredient{}
+	err = entity.repo.FindOneAndUpdate(ctx, bson.M{"_id": objId}, bson.M{
+		"$set": bson.M{
+			"name":           form.Name,
+			"normalizedName": entities.NormalizeIngredient(form.Name),
+			"classes":        normalizeClasses(form.Classes),
+			"unit":           form.Unit,
+			"description":    form.Description,
+			"updatedBy":      form.UpdatedBy,
+			"updatedDate":    time.Now(),
+		},
+	}, opts).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *ingredientEntity) RemoveIngredientById(id string) (*entities.Ingredient, error) {
+	logrus.Info("RemoveIngredientById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	data := entities.Ingredient{}
+	err = entity.repo.FindOneAndDelete(ctx, bson.M{"_id": objId}).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
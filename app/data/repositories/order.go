@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"pos/app/core/utils"
 	"pos/app/data/entities"
 	"pos/app/domain/constant"
@@ -25,6 +26,7 @@ type IOrder interface {
 	CreateOrder(form request.Order) (*entities.Order, error)
 	GetOrderRange(form request.GetOrderRange) ([]entities.Order, error)
 	GetOrdersByCustomerCode(customerCode string) ([]entities.Order, error)
+	GetClinicalOverrides(form request.GetOrderRange) ([]entities.Order, error)
 	UpdateTotal() ([]entities.Order, error)
 	GetOrderById(id string) (*entities.Order, error)
 	GetOrderDetailById(id string) (*entities.OrderDetail, error)
@@ -38,17 +40,24 @@ type IOrder interface {
 	GetOrderItemRange(form request.GetOrderRange) ([]entities.OrderItemProductDetail, error)
 	GetOrderItemById(id string) (*entities.OrderItem, error)
 	UpdateOrderItemById(id string, form request.OrderItem) (*entities.OrderItem, error)
-	RemoveOrderItemById(id string) (*entities.OrderItemProductDetail, error)
 	GetOrderItemDetailById(id string) (*entities.OrderItemProductDetail, error)
 	GetOrderItemDetailByOrderId(orderId string) ([]entities.OrderItemProductDetail, error)
 	GetOrderItemDetailByOrderProductId(orderId string, productId string) (*entities.OrderItemProductDetail, error)
-	RemoveOrderItemByOrderProductId(orderId string, productId string) (*entities.OrderItemProductDetail, error)
 	GetOrderItemByProductId(productId string) ([]entities.OrderItem, error)
 	GetOrderItemOrderDetailsByProductId(productId string, form request.GetOrderRange) ([]entities.OrderItemOrderDetail, error)
+	GetOrderItemOrderDetailsByLotNumbers(productId string, lotNumbers []string) ([]entities.OrderItemOrderDetail, error)
 
 	GetPaymentByOrderId(orderId string) (*entities.Payment, error)
+	GetPaymentsByOrderId(orderId string) ([]entities.Payment, error)
+	GetPaymentsByShiftId(shiftId string) ([]entities.Payment, error)
 	RemovePaymentByOrderId(orderId string) (*entities.Payment, error)
 
+	// Transactional, ctx must come from ITransaction.WithTransaction
+	CreateOrderTx(ctx context.Context, form request.Order) (*entities.Order, error)
+	RemoveOrderByIdTx(ctx context.Context, id string) (*entities.OrderDetail, error)
+	VoidOrderByIdTx(ctx context.Context, id string, form request.VoidOrder) (*entities.OrderDetail, error)
+	UpdateDispensingLogIdOrderByIdTx(ctx context.Context, id string, dispensingLogId string) error
+
 	GetOrderSummary(form request.GetOrderRange) (*entities.OrderSummary, error)
 	GetOrderDailyChart(form request.GetOrderRange) ([]entities.OrderDailyChart, error)
 	GetOrderMonthlyChart(branchId string) ([]entities.OrderDailyChart, error)
@@ -130,30 +139,50 @@ func ensureOrderIndexes(orderRepo *mongo.Collection, orderItemRepo *mongo.Collec
 	if err != nil {
 		logrus.Error("failed to create payments branchId+orderId index: ", err)
 	}
+
+	_, err = paymentRepo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "shiftId", Value: 1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create payments shiftId index: ", err)
+	}
 }
 
 func (entity *orderEntity) CreateOrder(form request.Order) (*entities.Order, error) {
-	logrus.Info("CreateOrder")
 	ctx, cancel := utils.InitContext()
 	defer cancel()
+	return entity.CreateOrderTx(ctx, form)
+}
 
+func (entity *orderEntity) CreateOrderTx(ctx context.Context, form request.Order) (*entities.Order, error) {
+	logrus.Info("CreateOrder")
 	branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
 	var orderId = primitive.NewObjectID()
 	data := entities.Order{
-		Id:           orderId,
-		BranchId:     branchId,
-		Code:         form.Code,
-		CustomerCode: form.CustomerCode,
-		CustomerName: form.CustomerName,
-		Status:       constant.ACTIVE,
-		Total:        form.Total,
-		TotalCost:    form.TotalCost,
-		Discount:     form.Discount,
-		Type:         form.Type,
-		CreatedBy:    form.CreatedBy,
-		CreatedDate:  time.Now(),
-		UpdatedBy:    form.CreatedBy,
-		UpdatedDate:  time.Now(),
+		Id:                orderId,
+		BranchId:          branchId,
+		Code:              form.Code,
+		CustomerCode:      form.CustomerCode,
+		CustomerName:      form.CustomerName,
+		PatientId:         form.PatientId,
+		PharmacistId:      form.PharmacistId,
+		PharmacistName:    form.PharmacistName,
+		LicenseNo:         form.LicenseNo,
+		PrescriberName:    form.PrescriberName,
+		BuyerName:         form.BuyerName,
+		BuyerIdCard:       form.BuyerIdCard,
+		ClinicalOverride:  toEntityClinicalOverride(form.ClinicalOverride),
+		Status:            constant.ACTIVE,
+		Total:             form.Total,
+		TotalCost:         form.TotalCost,
+		Discount:          form.Discount,
+		PromotionCode:     form.PromotionCode,
+		PromotionDiscount: form.PromotionDiscount,
+		Type:              form.Type,
+		CreatedBy:         form.CreatedBy,
+		CreatedDate:       time.Now(),
+		UpdatedBy:         form.CreatedBy,
+		UpdatedDate:       time.Now(),
 	}
 	_, err := entity.orderRepo.InsertOne(ctx, data)
 	if err != nil {
@@ -171,26 +200,35 @@ func (entity *orderEntity) CreateOrder(form request.Order) (*entities.Order, err
 		for j := 0; j < countStock; j++ {
 			formStock := formItem.Stocks[j]
 			stock := entities.OrderItemStock{
-				Quantity: formStock.Quantity,
-				StockId:  formStock.StockId,
+				Quantity:   formStock.Quantity,
+				StockId:    formStock.StockId,
+				LotNumber:  formStock.LotNumber,
+				ExpireDate: formStock.ExpireDate,
 			}
 			stocks[j] = stock
 		}
+		// The caller may assign the item id up front to link other documents to the line
+		itemId := primitive.NewObjectID()
+		if formItem.Id != "" {
+			itemId, _ = primitive.ObjectIDFromHex(formItem.Id)
+		}
 		item := entities.OrderItem{
-			Id:          primitive.NewObjectID(),
-			BranchId:    branchId,
-			OrderId:     orderId,
-			ProductId:   productId,
-			UnitId:      unitId,
-			Stocks:      stocks,
-			Quantity:    formItem.Quantity,
-			Price:       formItem.Price,
-			CostPrice:   formItem.CostPrice,
-			Discount:    formItem.Discount,
-			CreatedBy:   form.CreatedBy,
-			CreatedDate: time.Now(),
-			UpdatedBy:   form.CreatedBy,
-			UpdatedDate: time.Now(),
+			Id:            itemId,
+			BranchId:      branchId,
+			OrderId:       orderId,
+			ProductId:     productId,
+			UnitId:        unitId,
+			Stocks:        stocks,
+			Quantity:      formItem.Quantity,
+			Price:         formItem.Price,
+			CostPrice:     formItem.CostPrice,
+			Discount:      formItem.Discount,
+			ListPrice:     formItem.ListPrice,
+			PriceOverride: formItem.PriceOverride,
+			CreatedBy:     form.CreatedBy,
+			CreatedDate:   time.Now(),
+			UpdatedBy:     form.CreatedBy,
+			UpdatedDate:   time.Now(),
 		}
 		orderItem[i] = item
 	}
@@ -211,6 +249,7 @@ func (entity *orderEntity) CreateOrder(form request.Order) (*entities.Order, err
 				Total:       form.Total,
 				Change:      form.Change,
 				Type:        p.Type,
+				ShiftId:     form.ShiftId,
 				CreatedBy:   form.CreatedBy,
 				CreatedDate: time.Now(),
 				UpdatedBy:   form.CreatedBy,
@@ -228,6 +267,7 @@ func (entity *orderEntity) CreateOrder(form request.Order) (*entities.Order, err
 			Total:       form.Total,
 			Change:      form.Change,
 			Type:        form.Type,
+			ShiftId:     form.ShiftId,
 			CreatedBy:   form.CreatedBy,
 			CreatedDate: time.Now(),
 			UpdatedBy:   form.CreatedBy,
@@ -266,6 +306,49 @@ func (entity *orderEntity) GetOrderRange(form request.GetOrderRange) ([]entities
 	return items, nil
 }
 
+func toEntityClinicalOverride(form *request.ClinicalOverride) *entities.ClinicalOverride {
+	if form == nil {
+		return nil
+	}
+	return &entities.ClinicalOverride{
+		Alerts:         form.Alerts,
+		Justification:  form.Justification,
+		PharmacistId:   form.PharmacistId,
+		PharmacistName: form.PharmacistName,
+		LicenseNo:      form.LicenseNo,
+		OverriddenDate: time.Now(),
+	}
+}
+
+// GetClinicalOverrides returns the orders sold through blocking clinical alerts in the range
+func (entity *orderEntity) GetClinicalOverrides(form request.GetOrderRange) ([]entities.Order, error) {
+	logrus.Info("GetClinicalOverrides")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+
+	filter := bson.M{
+		"createdDate": bson.M{
+			"$gt": form.StartDate,
+			"$lt": form.EndDate,
+		},
+		"clinicalOverride": bson.M{"$exists": true},
+	}
+	if form.BranchId != "" {
+		branchObjId, _ := primitive.ObjectIDFromHex(form.BranchId)
+		filter["branchId"] = branchObjId
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdDate", Value: 1}})
+	cursor, err := entity.orderRepo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	items := []entities.Order{}
+	if err = cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 func (entity *orderEntity) GetOrdersByCustomerCode(customerCode string) ([]entities.Order, error) {
 	logrus.Info("GetOrdersByCustomerCode")
 	ctx, cancel := utils.InitContext()
@@ -415,10 +498,13 @@ func (entity *orderEntity) GetOrderDetailById(id string) (*entities.OrderDetail,
 }
 
 func (entity *orderEntity) RemoveOrderById(id string) (*entities.OrderDetail, error) {
-	logrus.Info("RemoveOrderById")
 	ctx, cancel := utils.InitContext()
 	defer cancel()
+	return entity.RemoveOrderByIdTx(ctx, id)
+}
 
+func (entity *orderEntity) RemoveOrderByIdTx(ctx context.Context, id string) (*entities.OrderDetail, error) {
+	logrus.Info("RemoveOrderById")
 	objId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, err
@@ -429,12 +515,82 @@ func (entity *orderEntity) RemoveOrderById(id string) (*entities.OrderDetail, er
 		return nil, err
 	}
 
-	payment, pErr := entity.RemovePaymentByOrderId(id)
-	if pErr == nil && payment != nil {
-		data.Payment = *payment
+	var payment entities.Payment
+	err = entity.paymentRepo.FindOne(ctx, bson.M{"orderId": objId}).Decode(&payment)
+	if err == nil {
+		data.Payment = payment
+	}
+	_, err = entity.paymentRepo.DeleteMany(ctx, bson.M{"orderId": objId})
+	if err != nil {
+		return nil, err
 	}
 
-	items, _ := entity.RemoveOrderItemByOrderId(id)
+	items, err := entity.getOrderItemDetailByOrderId(ctx, objId)
+	if err != nil {
+		return nil, err
+	}
+	_, err = entity.orderItemRepo.DeleteMany(ctx, bson.M{"orderId": objId})
+	if err != nil {
+		return nil, err
+	}
+	data.Items = items
+
+	return &data, nil
+}
+
+// VoidOrderByIdTx marks the order and its payments as voided, the order and its items are kept
+// for the audit trail. An order that is already voided is not found.
+func (entity *orderEntity) VoidOrderByIdTx(ctx context.Context, id string, form request.VoidOrder) (*entities.OrderDetail, error) {
+	logrus.Info("VoidOrderById")
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	isReturnNewDoc := options.After
+	opts := &options.FindOneAndUpdateOptions{
+		ReturnDocument: &isReturnNewDoc,
+	}
+	var data entities.OrderDetail
+	err = entity.orderRepo.FindOneAndUpdate(ctx, bson.M{
+		"_id":    objId,
+		"status": bson.M{"$ne": constant.OrderStatusVoided},
+	}, bson.M{
+		"$set": bson.M{
+			"status":         constant.OrderStatusVoided,
+			"voidedBy":       form.VoidedBy,
+			"voidedDate":     now,
+			"voidReasonCode": form.ReasonCode,
+			"voidReason":     form.Reason,
+			"voidApprovedBy": form.ApprovedBy,
+			"updatedBy":      form.VoidedBy,
+			"updatedDate":    now,
+		},
+	}, opts).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = entity.paymentRepo.UpdateMany(ctx, bson.M{"orderId": objId}, bson.M{
+		"$set": bson.M{
+			"status":      constant.OrderStatusVoided,
+			"updatedBy":   form.VoidedBy,
+			"updatedDate": now,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var payment entities.Payment
+	err = entity.paymentRepo.FindOne(ctx, bson.M{"orderId": objId}).Decode(&payment)
+	if err == nil {
+		data.Payment = payment
+	}
+
+	items, err := entity.getOrderItemDetailByOrderId(ctx, objId)
+	if err != nil {
+		return nil, err
+	}
 	data.Items = items
 
 	return &data, nil
@@ -509,6 +665,8 @@ func (entity *orderEntity) GetTotalCostOrderById(orderId string) float64 {
 	return totalCost
 }
 
+// GetOrderItemRange returns the order lines of the period with their product, lines of voided orders are
+// left out
 func (entity *orderEntity) GetOrderItemRange(form request.GetOrderRange) ([]entities.OrderItemProductDetail, error) {
 	logrus.Info("GetOrderItemRange")
 	ctx, cancel := utils.InitContext()
@@ -525,6 +683,17 @@ func (entity *orderEntity) GetOrderItemRange(form request.GetOrderRange) ([]enti
 	}
 	cursor, err := entity.orderItemRepo.Aggregate(ctx, []bson.M{
 		{"$match": matchFilter},
+		{
+			"$lookup": bson.M{
+				"from":         "orders",
+				"localField":   "orderId",
+				"foreignField": "_id",
+				"as":           "order",
+			},
+		},
+		{"$unwind": "$order"},
+		{"$match": bson.M{"order.status": bson.M{"$ne": constant.OrderStatusVoided}}},
+		{"$project": bson.M{"order": 0}},
 		{
 			"$lookup": bson.M{
 				"from":         "products",
@@ -588,25 +757,6 @@ func (entity *orderEntity) UpdateOrderItemById(id string, form request.OrderItem
 	return &data, nil
 }
 
-func (entity *orderEntity) RemoveOrderItemById(id string) (*entities.OrderItemProductDetail, error) {
-	logrus.Info("RemoveOrderItemById")
-	ctx, cancel := utils.InitContext()
-	defer cancel()
-	objId, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		return nil, err
-	}
-	item, err := entity.GetOrderItemDetailById(id)
-	if err != nil {
-		return nil, err
-	}
-	_, err = entity.orderItemRepo.DeleteOne(ctx, bson.M{"_id": objId})
-	if err != nil {
-		return nil, err
-	}
-	return item, nil
-}
-
 func (entity *orderEntity) GetOrderItemDetailById(id string) (*entities.OrderItemProductDetail, error) {
 	logrus.Info("GetOrderItemDetailById")
 	ctx, cancel := utils.InitContext()
@@ -652,10 +802,14 @@ func (entity *orderEntity) GetOrderItemDetailByOrderId(orderId string) ([]entiti
 	if err != nil {
 		return nil, err
 	}
+	return entity.getOrderItemDetailByOrderId(ctx, objId)
+}
+
+func (entity *orderEntity) getOrderItemDetailByOrderId(ctx context.Context, orderId primitive.ObjectID) ([]entities.OrderItemProductDetail, error) {
 	cursor, err := entity.orderItemRepo.Aggregate(ctx, []bson.M{
 		{
 			"$match": bson.M{
-				"orderId": objId,
+				"orderId": orderId,
 			},
 		},
 		{
@@ -743,6 +897,8 @@ func (entity *orderEntity) GetOrderItemByProductId(productId string) ([]entities
 	return items, nil
 }
 
+// GetOrderItemOrderDetailsByProductId returns the order lines of the product in the period with their
+// order, lines of voided orders are left out
 func (entity *orderEntity) GetOrderItemOrderDetailsByProductId(productId string, form request.GetOrderRange) ([]entities.OrderItemOrderDetail, error) {
 	logrus.Info("GetOrderItemOrderDetailsByProductId")
 	ctx, cancel := utils.InitContext()
@@ -770,6 +926,7 @@ func (entity *orderEntity) GetOrderItemOrderDetailsByProductId(productId string,
 			},
 		},
 		{"$unwind": "$order"},
+		{"$match": bson.M{"order.status": bson.M{"$ne": constant.OrderStatusVoided}}},
 	})
 
 	if err != nil {
@@ -782,6 +939,46 @@ func (entity *orderEntity) GetOrderItemOrderDetailsByProductId(productId string,
 	return items, nil
 }
 
+// GetOrderItemOrderDetailsByLotNumbers returns the order lines of every branch that were cut from the
+// lots of the product, lines of voided orders are left out
+func (entity *orderEntity) GetOrderItemOrderDetailsByLotNumbers(productId string, lotNumbers []string) ([]entities.OrderItemOrderDetail, error) {
+	logrus.Info("GetOrderItemOrderDetailsByLotNumbers")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	productObjId, err := primitive.ObjectIDFromHex(productId)
+	if err != nil {
+		return nil, err
+	}
+	// Order item stocks are stored without bson tags, their keys are the lowercased field names
+	cursor, err := entity.orderItemRepo.Aggregate(ctx, []bson.M{
+		{
+			"$match": bson.M{
+				"productId":        productObjId,
+				"stocks.lotnumber": bson.M{"$in": lotNumbers},
+			},
+		},
+		{
+			"$lookup": bson.M{
+				"from":         "orders",
+				"localField":   "orderId",
+				"foreignField": "_id",
+				"as":           "order",
+			},
+		},
+		{"$unwind": "$order"},
+		{"$match": bson.M{"order.status": bson.M{"$ne": constant.OrderStatusVoided}}},
+		{"$sort": bson.M{"createdDate": -1}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	items := []entities.OrderItemOrderDetail{}
+	if err = cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 func (entity *orderEntity) RemoveOrderItemByOrderId(orderId string) ([]entities.OrderItemProductDetail, error) {
 	logrus.Info("RemoveOrderItemByOrderId")
 	ctx, cancel := utils.InitContext()
@@ -801,43 +998,55 @@ func (entity *orderEntity) RemoveOrderItemByOrderId(orderId string) ([]entities.
 	return items, nil
 }
 
-func (entity *orderEntity) RemoveOrderItemByOrderProductId(orderId string, productId string) (*entities.OrderItemProductDetail, error) {
-	logrus.Info("RemoveOrderItemByOrderProductId")
+func (entity *orderEntity) GetPaymentByOrderId(orderId string) (*entities.Payment, error) {
+	logrus.Info("GetPaymentByOrderId")
 	ctx, cancel := utils.InitContext()
 	defer cancel()
 	objId, err := primitive.ObjectIDFromHex(orderId)
 	if err != nil {
 		return nil, err
 	}
-	productObjId, err := primitive.ObjectIDFromHex(productId)
+	var data entities.Payment
+	err = entity.paymentRepo.FindOne(ctx, bson.M{"orderId": objId}).Decode(&data)
 	if err != nil {
 		return nil, err
 	}
-	item, err := entity.GetOrderItemDetailByOrderProductId(orderId, productId)
+	return &data, nil
+}
+
+func (entity *orderEntity) GetPaymentsByOrderId(orderId string) ([]entities.Payment, error) {
+	logrus.Info("GetPaymentsByOrderId")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(orderId)
 	if err != nil {
 		return nil, err
 	}
-	_, err = entity.orderItemRepo.DeleteOne(ctx, bson.M{"orderId": objId, "productId": productObjId})
+	cursor, err := entity.paymentRepo.Find(ctx, bson.M{"orderId": objId})
 	if err != nil {
 		return nil, err
 	}
-	return item, nil
+	items := []entities.Payment{}
+	if err = cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (entity *orderEntity) GetPaymentByOrderId(orderId string) (*entities.Payment, error) {
-	logrus.Info("GetPaymentByOrderId")
+func (entity *orderEntity) GetPaymentsByShiftId(shiftId string) ([]entities.Payment, error) {
+	logrus.Info("GetPaymentsByShiftId")
 	ctx, cancel := utils.InitContext()
 	defer cancel()
-	objId, err := primitive.ObjectIDFromHex(orderId)
+	opts := options.Find().SetSort(bson.M{"createdDate": 1})
+	cursor, err := entity.paymentRepo.Find(ctx, bson.M{"shiftId": shiftId}, opts)
 	if err != nil {
 		return nil, err
 	}
-	var data entities.Payment
-	err = entity.paymentRepo.FindOne(ctx, bson.M{"orderId": objId}).Decode(&data)
-	if err != nil {
+	results := []entities.Payment{}
+	if err = cursor.All(ctx, &results); err != nil {
 		return nil, err
 	}
-	return &data, nil
+	return results, nil
 }
 
 func (entity *orderEntity) RemovePaymentByOrderId(orderId string) (*entities.Payment, error) {
@@ -866,6 +1075,7 @@ func (entity *orderEntity) GetOrderSummary(form request.GetOrderRange) (*entitie
 			"$gt": form.StartDate,
 			"$lt": form.EndDate,
 		},
+		"status": bson.M{"$ne": constant.OrderStatusVoided},
 	}
 	if form.BranchId != "" {
 		branchObjId, _ := primitive.ObjectIDFromHex(form.BranchId)
@@ -909,6 +1119,7 @@ func (entity *orderEntity) GetOrderDailyChart(form request.GetOrderRange) ([]ent
 			"$gt": form.StartDate,
 			"$lt": form.EndDate,
 		},
+		"status": bson.M{"$ne": constant.OrderStatusVoided},
 	}
 	if form.BranchId != "" {
 		branchObjId, _ := primitive.ObjectIDFromHex(form.BranchId)
@@ -953,6 +1164,7 @@ func (entity *orderEntity) GetOrderMonthlyChart(branchId string) ([]entities.Ord
 	startDate := time.Now().AddDate(-1, 0, 0)
 	matchFilter := bson.M{
 		"createdDate": bson.M{"$gte": startDate},
+		"status":      bson.M{"$ne": constant.OrderStatusVoided},
 	}
 	if branchId != "" {
 		branchObjId, _ := primitive.ObjectIDFromHex(branchId)
@@ -1005,6 +1217,14 @@ func (entity *orderEntity) GetABCAnalysis(branchId string) ([]entities.ABCProduc
 
 	pipeline := []bson.M{
 		{"$match": matchFilter},
+		// Items of voided orders are kept, leave them out of the sales
+		{"$lookup": bson.M{
+			"from":         "orders",
+			"localField":   "orderId",
+			"foreignField": "_id",
+			"as":           "order",
+		}},
+		{"$match": bson.M{"order.status": bson.M{"$ne": constant.OrderStatusVoided}}},
 		{"$group": bson.M{
 			"_id":          "$productId",
 			"totalRevenue": bson.M{"$sum": bson.M{"$multiply": bson.A{"$price", "$quantity"}}},
@@ -1057,3 +1277,15 @@ func (entity *orderEntity) GetABCAnalysis(branchId string) ([]entities.ABCProduc
 
 	return abcResults, nil
 }
+
+func (entity *orderEntity) UpdateDispensingLogIdOrderByIdTx(ctx context.Context, id string, dispensingLogId string) error {
+	logrus.Info("UpdateDispensingLogIdOrderById")
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	_, err = entity.orderRepo.UpdateOne(ctx, bson.M{"_id": objId}, bson.M{
+		"$set": bson.M{"dispensingLogId": dispensingLogId},
+	})
+	return err
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"errors"
 	"pos/app/core/utils"
 	"pos/app/data/entities"
@@ -17,13 +18,17 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrInsufficientStock is returned when a lot does not hold the quantity to remove
+var ErrInsufficientStock = errors.New("insufficient stock")
+
 type productEntity struct {
-	productsRepo       *mongo.Collection
-	productPricesRepo  *mongo.Collection
-	productLotsRepo    *mongo.Collection
-	productUnitsRepo   *mongo.Collection
-	productStockRepo   *mongo.Collection
-	productHistoryRepo *mongo.Collection
+	productsRepo         *mongo.Collection
+	productPricesRepo    *mongo.Collection
+	productLotsRepo      *mongo.Collection
+	productUnitsRepo     *mongo.Collection
+	productStockRepo     *mongo.Collection
+	productHistoryRepo   *mongo.Collection
+	productValuationRepo *mongo.Collection
 }
 
 type IProduct interface {
@@ -33,6 +38,8 @@ type IProduct interface {
 	GetProductBySerialNumber(serialNumber string) (*entities.Product, error)
 	GetProductById(id string) (*entities.Product, error)
 	GetProductsByIds(ids []string) ([]entities.Product, error)
+	CountProductsByIngredientId(ingredientId string) (int64, error)
+	RenameProductIngredient(ingredientId string, name string) error
 	CreateProduct(param request.Product) (*entities.Product, error)
 	RemoveProductById(id string) (*entities.Product, error)
 	UpdateProductById(id string, param request.UpdateProduct) (*entities.Product, error)
@@ -54,6 +61,7 @@ type IProduct interface {
 	GetProductUnitById(id string) (*entities.ProductUnit, error)
 	GetProductUnitByDefault(productId string, unit string) (*entities.ProductUnit, error)
 	GetProductUnitByUnit(productId string, unit string) (*entities.ProductUnit, error)
+	GetProductUnitByBarcode(barcode string) (*entities.ProductUnit, error)
 	UpdateProductUnitById(id string, param request.ProductUnit) (*entities.ProductUnit, error)
 	RemoveProductUnitById(id string) (*entities.ProductUnit, error)
 	GetProductUnitsByProductId(productId string) ([]entities.ProductUnit, error)
@@ -73,16 +81,49 @@ type IProduct interface {
 	UpdateProductStockSequence(param request.UpdateProductStockSequence) ([]entities.ProductStock, error)
 	RemoveProductStockById(id string) (*entities.ProductStock, error)
 	GetProductStocksByProductId(productId string, branchId string) ([]entities.ProductStock, error)
+	GetProductStocksForCount(branchId string, category string, stockIds []string) ([]entities.ProductStockDetail, error)
+	GetProductStocksByLotNumbers(productId string, lotNumbers []string, branchId string) ([]entities.ProductStock, error)
+	GetProductStocksByStatus(branchId string, statuses []string) ([]entities.ProductStockDetail, error)
+	UpdateProductStockStatusById(id string, status string) (*entities.ProductStock, error)
+	ExpireProductStocks(now time.Time) (int64, error)
 	GetProductStockMaxSequence(productId string, unitId string) int
 	GetProductStockBalance(productId string, unitId string) int
 	RemoveProductStockQuantityById(stockId string, quantity int) (*entities.ProductStock, error)
 	AddProductStockQuantityById(stockId string, quantity int) (*entities.ProductStock, error)
 
+	// ProductStock (transactional, ctx must come from ITransaction.WithTransaction)
+	CreateProductStockTx(ctx context.Context, param request.ProductStock) (*entities.ProductStock, error)
+	GetProductStockByIdTx(ctx context.Context, id string) (*entities.ProductStock, error)
+	GetProductStockBalanceTx(ctx context.Context, productId string, unitId string) int
+	RemoveProductStockQuantityByIdTx(ctx context.Context, stockId string, quantity int) (*entities.ProductStock, error)
+	RemoveProductStockQuantityIfAvailableByIdTx(ctx context.Context, stockId string, quantity int) (*entities.ProductStock, error)
+	AddProductStockQuantityByIdTx(ctx context.Context, stockId string, quantity int) (*entities.ProductStock, error)
+	UpdateProductStockByIdTx(ctx context.Context, id string, param request.UpdateProductStock) (*entities.ProductStock, error)
+	UpdateProductStockQuantityByIdTx(ctx context.Context, id string, quantity int) (*entities.ProductStock, error)
+	RemoveProductStockByIdTx(ctx context.Context, id string) (*entities.ProductStock, error)
+	RemoveQuantitySoldFirstByIdTx(ctx context.Context, id string, quantity int) (*entities.Product, error)
+	AddQuantitySoldFirstByIdTx(ctx context.Context, id string, quantity int) (*entities.Product, error)
+	GetSellableProductStocksTx(ctx context.Context, productId string, branchId string) ([]entities.ProductStock, error)
+	RecallProductStocksTx(ctx context.Context, productId string, lotNumbers []string) (int64, error)
+
 	// ProductHistory
 	CreateProductHistory(param request.ProductHistory) (*entities.ProductHistory, error)
+	CreateProductHistoryTx(ctx context.Context, param request.ProductHistory) (*entities.ProductHistory, error)
 	GetProductHistoryByProductId(productId string, branchId string) ([]entities.ProductHistory, error)
 	GetProductHistoryByDateRange(branchId string, startDate time.Time, endDate time.Time) ([]entities.ProductHistory, error)
 
+	// Valuation
+	CreateProductValuation(param request.ProductValuation) (*entities.ProductValuation, error)
+	CreateProductValuationTx(ctx context.Context, param request.ProductValuation) (*entities.ProductValuation, error)
+	AverageProductStockCost(param request.ProductValuation) (float64, error)
+	AverageProductStockCostTx(ctx context.Context, param request.ProductValuation) (float64, error)
+	GetSaleUnitCostsTx(ctx context.Context, orderId string) (map[string]float64, error)
+	ReturnProductStockQuantityByIdTx(ctx context.Context, stockId string, quantity int, unitCost float64) (*entities.ProductStock, error)
+	OpenProductValuationsTx(ctx context.Context, branchId string, createdBy string) (int, error)
+	GetProductValuationRange(form request.GetProductValuationRange) ([]entities.ProductValuation, error)
+	GetInventoryValuation(form request.GetInventoryValuation) ([]entities.InventoryValuation, error)
+	GetCostOfGoodsSold(form request.GetCostOfGoodsSold) ([]entities.CostOfGoodsSold, error)
+
 	// Reports
 	GetLowStockProducts(threshold int, branchId string) ([]entities.LowStockProduct, error)
 	GetStockReport(branchId string) ([]entities.StockReport, error)
@@ -96,15 +137,18 @@ func NewProductEntity(resource *db.Resource) IProduct {
 	productLotsRepo := resource.PosDb.Collection("product_lots")
 	productStockRepo := resource.PosDb.Collection("product_stocks")
 	productHistoryRepo := resource.PosDb.Collection("product_histories")
+	productValuationRepo := resource.PosDb.Collection("product_valuations")
 	entity := &productEntity{
-		productsRepo:       productsRepo,
-		productPricesRepo:  productPricesRepo,
-		productLotsRepo:    productLotsRepo,
-		productUnitsRepo:   productUnitsRepo,
-		productStockRepo:   productStockRepo,
-		productHistoryRepo: productHistoryRepo,
+		productsRepo:         productsRepo,
+		productPricesRepo:    productPricesRepo,
+		productLotsRepo:      productLotsRepo,
+		productUnitsRepo:     productUnitsRepo,
+		productStockRepo:     productStockRepo,
+		productHistoryRepo:   productHistoryRepo,
+		productValuationRepo: productValuationRepo,
 	}
 	ensureProductIndexes(productStockRepo, productHistoryRepo)
+	ensureProductValuationIndexes(productValuationRepo)
 	ensureProductCollectionIndexes(productsRepo, productUnitsRepo, productPricesRepo, productLotsRepo)
 	return entity
 }
@@ -153,6 +197,13 @@ func ensureProductCollectionIndexes(productsRepo, productUnitsRepo, productPrice
 		logrus.Error("failed to create product_units productId index: ", err)
 	}
 
+	_, err = productUnitsRepo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "barcode", Value: 1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create product_units barcode index: ", err)
+	}
+
 	_, err = productPricesRepo.Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys: bson.D{{Key: "productId", Value: 1}},
 	})
@@ -179,8 +230,19 @@ func toEntityDrugInfo(req *request.RequestDrugInfo) *entities.DrugInfo {
 	if req == nil {
 		return nil
 	}
+	var ingredients []entities.ProductIngredient
+	for _, ingredient := range req.Ingredients {
+		ingredientId, _ := primitive.ObjectIDFromHex(ingredient.IngredientId)
+		ingredients = append(ingredients, entities.ProductIngredient{
+			IngredientId: ingredientId,
+			Name:         ingredient.Name,
+			Strength:     ingredient.Strength,
+			Unit:         ingredient.Unit,
+		})
+	}
 	return &entities.DrugInfo{
 		GenericName:       req.GenericName,
+		Ingredients:       ingredients,
 		DrugType:          req.DrugType,
 		DosageForm:        req.DosageForm,
 		Strength:          req.Strength,
@@ -276,6 +338,8 @@ func (entity *productEntity) CreateProduct(param request.Product) (*entities.Pro
 	data.Category = param.Category
 	data.Status = param.Status
 	data.MinStock = param.MinStock
+	data.VatExempt = param.VatExempt
+	data.VatRate = param.VatRate
 	data.DrugInfo = toEntityDrugInfo(param.DrugInfo)
 	data.DrugRegistrations = param.DrugRegistrations
 	data.CreatedBy = param.CreatedBy
@@ -328,6 +392,36 @@ func (entity *productEntity) GetProductsByIds(ids []string) ([]entities.Product,
 	return items, nil
 }
 
+func (entity *productEntity) CountProductsByIngredientId(ingredientId string) (int64, error) {
+	logrus.Info("CountProductsByIngredientId")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(ingredientId)
+	if err != nil {
+		return 0, err
+	}
+	return entity.productsRepo.CountDocuments(ctx, bson.M{"drugInfo.ingredients.ingredientId": objId})
+}
+
+// RenameProductIngredient keeps the ingredient name copied on the linked products in step
+// with the master
+func (entity *productEntity) RenameProductIngredient(ingredientId string, name string) error {
+	logrus.Info("RenameProductIngredient")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(ingredientId)
+	if err != nil {
+		return err
+	}
+	opts := options.Update().SetArrayFilters(options.ArrayFilters{
+		Filters: []interface{}{bson.M{"ingredient.ingredientId": objId}},
+	})
+	_, err = entity.productsRepo.UpdateMany(ctx, bson.M{"drugInfo.ingredients.ingredientId": objId}, bson.M{
+		"$set": bson.M{"drugInfo.ingredients.$[ingredient].name": name},
+	}, opts)
+	return err
+}
+
 func (entity *productEntity) RemoveProductById(id string) (*entities.Product, error) {
 	logrus.Info("RemoveProductById")
 	ctx, cancel := utils.InitContext()
@@ -374,6 +468,8 @@ func (entity *productEntity) UpdateProductById(id string, param request.UpdatePr
 		"category":          param.Category,
 		"status":            param.Status,
 		"minStock":          param.MinStock,
+		"vatExempt":         param.VatExempt,
+		"vatRate":           param.VatRate,
 		"drugInfo":          toEntityDrugInfo(param.DrugInfo),
 		"drugRegistrations": param.DrugRegistrations,
 		"updatedBy":         param.UpdatedBy,
@@ -433,19 +529,14 @@ func (entity *productEntity) AddQuantityById(id string, quantity int) (*entities
 	return &data, nil
 }
 
-func (entity *productEntity) GetTotalCostPrice(id string, quantity int) float64 {
-	logrus.Info("GetTotalCostPrice")
-	data, err := entity.GetProductById(id)
-	if err != nil {
-		return 0
-	}
-	return data.CostPrice * float64(quantity)
-}
-
 func (entity *productEntity) RemoveQuantitySoldFirstById(id string, quantity int) (*entities.Product, error) {
-	logrus.Info("RemoveQuantitySoldFirstById")
 	ctx, cancel := utils.InitContext()
 	defer cancel()
+	return entity.RemoveQuantitySoldFirstByIdTx(ctx, id, quantity)
+}
+
+func (entity *productEntity) RemoveQuantitySoldFirstByIdTx(ctx context.Context, id string, quantity int) (*entities.Product, error) {
+	logrus.Info("RemoveQuantitySoldFirstById")
 	objId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, err
@@ -467,9 +558,13 @@ func (entity *productEntity) RemoveQuantitySoldFirstById(id string, quantity int
 }
 
 func (entity *productEntity) AddQuantitySoldFirstById(id string, quantity int) (*entities.Product, error) {
-	logrus.Info("AddQuantitySoldFirstById")
 	ctx, cancel := utils.InitContext()
 	defer cancel()
+	return entity.AddQuantitySoldFirstByIdTx(ctx, id, quantity)
+}
+
+func (entity *productEntity) AddQuantitySoldFirstByIdTx(ctx context.Context, id string, quantity int) (*entities.Product, error) {
+	logrus.Info("AddQuantitySoldFirstById")
 	objId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, err
@@ -875,9 +970,13 @@ func (entity *productEntity) GetProductStocksByProductAndUnitId(productId string
 }
 
 func (entity *productEntity) GetProductStockMaxSequence(productId string, unitId string) int {
-	logrus.Info("GetProductStockMaxSequence")
 	ctx, cancel := utils.InitContext()
 	defer cancel()
+	return entity.getProductStockMaxSequence(ctx, productId, unitId)
+}
+
+func (entity *productEntity) getProductStockMaxSequence(ctx context.Context, productId string, unitId string) int {
+	logrus.Info("GetProductStockMaxSequence")
 	product, _ := primitive.ObjectIDFromHex(productId)
 	unit, _ := primitive.ObjectIDFromHex(unitId)
 	opts := options.FindOne().SetSort(bson.D{{Key: "sequence", Value: -1}})
@@ -890,9 +989,13 @@ func (entity *productEntity) GetProductStockMaxSequence(productId string, unitId
 }
 
 func (entity *productEntity) AddProductStockQuantityById(stockId string, quantity int) (*entities.ProductStock, error) {
-	logrus.Info("AddProductStockQuantityById")
 	ctx, cancel := utils.InitContext()
 	defer cancel()
+	return entity.AddProductStockQuantityByIdTx(ctx, stockId, quantity)
+}
+
+func (entity *productEntity) AddProductStockQuantityByIdTx(ctx context.Context, stockId string, quantity int) (*entities.ProductStock, error) {
+	logrus.Info("AddProductStockQuantityById")
 	objId, err := primitive.ObjectIDFromHex(stockId)
 	if err != nil {
 		return nil, err
@@ -913,9 +1016,13 @@ func (entity *productEntity) AddProductStockQuantityById(stockId string, quantit
 }
 
 func (entity *productEntity) RemoveProductStockQuantityById(stockId string, quantity int) (*entities.ProductStock, error) {
-	logrus.Info("RemoveProductStockQuantityById")
 	ctx, cancel := utils.InitContext()
 	defer cancel()
+	return entity.RemoveProductStockQuantityByIdTx(ctx, stockId, quantity)
+}
+
+func (entity *productEntity) RemoveProductStockQuantityByIdTx(ctx context.Context, stockId string, quantity int) (*entities.ProductStock, error) {
+	logrus.Info("RemoveProductStockQuantityById")
 	objId, err := primitive.ObjectIDFromHex(stockId)
 	if err != nil {
 		return nil, err
@@ -935,6 +1042,36 @@ func (entity *productEntity) RemoveProductStockQuantityById(stockId string, quan
 	return &data, nil
 }
 
+// RemoveProductStockQuantityIfAvailableByIdTx decrements the lot only when it still holds
+// at least quantity, so concurrent sales can not push it below zero.
+func (entity *productEntity) RemoveProductStockQuantityIfAvailableByIdTx(ctx context.Context, stockId string, quantity int) (*entities.ProductStock, error) {
+	logrus.Info("RemoveProductStockQuantityIfAvailableById")
+	objId, err := primitive.ObjectIDFromHex(stockId)
+	if err != nil {
+		return nil, err
+	}
+
+	isReturnNewDoc := options.After
+	opts := &options.FindOneAndUpdateOptions{
+		ReturnDocument: &isReturnNewDoc,
+	}
+	var data entities.ProductStock
+	err = entity.productStockRepo.FindOneAndUpdate(ctx, bson.M{"_id": objId, "quantity": bson.M{"$gte": quantity}}, bson.M{
+		"$inc": bson.M{"quantity": -quantity},
+	}, opts).Decode(&data)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		count, cErr := entity.productStockRepo.CountDocuments(ctx, bson.M{"_id": objId})
+		if cErr == nil && count > 0 {
+			return nil, ErrInsufficientStock
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
 func (entity *productEntity) GetProductUnitsByProductId(productId string) (items []entities.ProductUnit, err error) {
 	logrus.Info("GetProductUnitsByProductId")
 	ctx, cancel := utils.InitContext()
@@ -1109,6 +1246,18 @@ func (entity *productEntity) GetProductUnitByUnit(productId string, unit string)
 	return &data, nil
 }
 
+func (entity *productEntity) GetProductUnitByBarcode(barcode string) (*entities.ProductUnit, error) {
+	logrus.Info("GetProductUnitByBarcode")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	data := entities.ProductUnit{}
+	err := entity.productUnitsRepo.FindOne(ctx, bson.M{"barcode": barcode}).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
 func (entity *productEntity) UpdateProductUnitById(id string, param request.ProductUnit) (*entities.ProductUnit, error) {
 	logrus.Info("UpdateProductUnitById")
 	ctx, cancel := utils.InitContext()
@@ -1161,15 +1310,19 @@ func (entity *productEntity) RemoveProductUnitById(id string) (*entities.Product
 }
 
 func (entity *productEntity) CreateProductStock(param request.ProductStock) (*entities.ProductStock, error) {
-	logrus.Info("CreateProductStock")
 	ctx, cancel := utils.InitContext()
 	defer cancel()
+	return entity.CreateProductStockTx(ctx, param)
+}
+
+func (entity *productEntity) CreateProductStockTx(ctx context.Context, param request.ProductStock) (*entities.ProductStock, error) {
+	logrus.Info("CreateProductStock")
 	data := entities.ProductStock{}
 	data.Id = primitive.NewObjectID()
 	data.BranchId, _ = primitive.ObjectIDFromHex(param.BranchId)
 	data.ProductId, _ = primitive.ObjectIDFromHex(param.ProductId)
 	data.UnitId, _ = primitive.ObjectIDFromHex(param.UnitId)
-	data.Sequence = entity.GetProductStockMaxSequence(param.ProductId, param.UnitId) + 1
+	data.Sequence = entity.getProductStockMaxSequence(ctx, param.ProductId, param.UnitId) + 1
 	data.LotNumber = param.LotNumber
 	data.CostPrice = param.CostPrice
 	data.Price = param.Price
@@ -1178,6 +1331,7 @@ func (entity *productEntity) CreateProductStock(param request.ProductStock) (*en
 	data.ExpireDate = param.ExpireDate
 	data.ImportDate = param.ImportDate
 	data.ReceiveCode = param.ReceiveCode
+	data.Status = param.Status
 	_, err := entity.productStockRepo.InsertOne(ctx, data)
 	if err != nil {
 		return nil, err
@@ -1186,9 +1340,13 @@ func (entity *productEntity) CreateProductStock(param request.ProductStock) (*en
 }
 
 func (entity *productEntity) GetProductStockById(id string) (*entities.ProductStock, error) {
-	logrus.Info("GetProductStockById")
 	ctx, cancel := utils.InitContext()
 	defer cancel()
+	return entity.GetProductStockByIdTx(ctx, id)
+}
+
+func (entity *productEntity) GetProductStockByIdTx(ctx context.Context, id string) (*entities.ProductStock, error) {
+	logrus.Info("GetProductStockById")
 	objId, _ := primitive.ObjectIDFromHex(id)
 	data := entities.ProductStock{}
 	err := entity.productStockRepo.FindOne(ctx, bson.M{"_id": objId}).Decode(&data)
@@ -1221,10 +1379,110 @@ func (entity *productEntity) GetProductStocksByProductId(productId string, branc
 	return items, nil
 }
 
+// GetSellableProductStocksTx returns the branch lots of a product that still have quantity
+// and have not expired, ordered First-Expired-First-Out. Lots without an expire date come last.
+func (entity *productEntity) GetSellableProductStocksTx(ctx context.Context, productId string, branchId string) (items []entities.ProductStock, err error) {
+	logrus.Info("GetSellableProductStocks")
+	product, err := primitive.ObjectIDFromHex(productId)
+	if err != nil {
+		return nil, err
+	}
+	branch, err := primitive.ObjectIDFromHex(branchId)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{
+		"productId": product,
+		"branchId":  branch,
+		"quantity":  bson.M{"$gt": 0},
+		"status":    bson.M{"$nin": constant.NonSellableStockStatuses()},
+		"$or": bson.A{
+			bson.M{"expireDate": bson.M{"$gt": time.Now()}},
+			bson.M{"expireDate": bson.M{"$lte": time.Time{}}},
+			bson.M{"expireDate": nil},
+		},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "expireDate", Value: 1}, {Key: "sequence", Value: 1}})
+	cursor, err := entity.productStockRepo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	items = []entities.ProductStock{}
+	if err = cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+
+	// Lots without an expire date sort first in MongoDB, move them behind the dated lots
+	dated := make([]entities.ProductStock, 0, len(items))
+	undated := make([]entities.ProductStock, 0)
+	for _, item := range items {
+		if item.ExpireDate.IsZero() {
+			undated = append(undated, item)
+		} else {
+			dated = append(dated, item)
+		}
+	}
+	return append(dated, undated...), nil
+}
+
+// GetProductStocksByLotNumbers returns the lots of a product with the lot numbers, of every branch when
+// branchId is empty
+func (entity *productEntity) GetProductStocksByLotNumbers(productId string, lotNumbers []string, branchId string) ([]entities.ProductStock, error) {
+	logrus.Info("GetProductStocksByLotNumbers")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	product, err := primitive.ObjectIDFromHex(productId)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{
+		"productId": product,
+		"lotNumber": bson.M{"$in": lotNumbers},
+	}
+	if branchId != "" {
+		branch, _ := primitive.ObjectIDFromHex(branchId)
+		filter["branchId"] = branch
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "branchId", Value: 1}, {Key: "expireDate", Value: 1}, {Key: "sequence", Value: 1}})
+	cursor, err := entity.productStockRepo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	items := []entities.ProductStock{}
+	if err = cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// RecallProductStocksTx blocks the lots of a product with the lot numbers in every branch, it returns
+// how many lots were blocked
+func (entity *productEntity) RecallProductStocksTx(ctx context.Context, productId string, lotNumbers []string) (int64, error) {
+	logrus.Info("RecallProductStocks")
+	product, err := primitive.ObjectIDFromHex(productId)
+	if err != nil {
+		return 0, err
+	}
+	result, err := entity.productStockRepo.UpdateMany(ctx, bson.M{
+		"productId": product,
+		"lotNumber": bson.M{"$in": lotNumbers},
+	}, bson.M{
+		"$set": bson.M{"status": constant.StockStatusRecalled},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
 func (entity *productEntity) UpdateProductStockById(id string, param request.UpdateProductStock) (*entities.ProductStock, error) {
-	logrus.Info("UpdateProductStockById")
 	ctx, cancel := utils.InitContext()
 	defer cancel()
+	return entity.UpdateProductStockByIdTx(ctx, id, param)
+}
+
+func (entity *productEntity) UpdateProductStockByIdTx(ctx context.Context, id string, param request.UpdateProductStock) (*entities.ProductStock, error) {
+	logrus.Info("UpdateProductStockById")
 	objId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, err
@@ -1248,10 +1506,57 @@ func (entity *productEntity) UpdateProductStockById(id string, param request.Upd
 	return &data, nil
 }
 
+// UpdateProductStockStatusById sets the status of a lot, it returns mongo.ErrNoDocuments for a recalled
+// lot, which only leaves stock through a return or a disposal
+func (entity *productEntity) UpdateProductStockStatusById(id string, status string) (*entities.ProductStock, error) {
+	logrus.Info("UpdateProductStockStatusById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	isReturnNewDoc := options.After
+	opts := &options.FindOneAndUpdateOptions{
+		ReturnDocument: &isReturnNewDoc,
+	}
+	var data entities.ProductStock
+	err = entity.productStockRepo.FindOneAndUpdate(ctx, bson.M{"_id": objId, "status": bson.M{"$ne": constant.StockStatusRecalled}}, bson.M{"$set": bson.M{
+		"status": status,
+	}}, opts).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// ExpireProductStocks marks the lots past their expire date as expired in every branch, recalled and
+// damaged lots keep their status. It returns how many lots were marked.
+func (entity *productEntity) ExpireProductStocks(now time.Time) (int64, error) {
+	logrus.Info("ExpireProductStocks")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	result, err := entity.productStockRepo.UpdateMany(ctx, bson.M{
+		"expireDate": bson.M{"$gt": time.Time{}, "$lte": now},
+		"status":     bson.M{"$nin": bson.A{constant.StockStatusExpired, constant.StockStatusRecalled, constant.StockStatusDamaged}},
+	}, bson.M{
+		"$set": bson.M{"status": constant.StockStatusExpired},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
 func (entity *productEntity) UpdateProductStockQuantityById(id string, quantity int) (*entities.ProductStock, error) {
-	logrus.Info("UpdateProductStockQuantityById")
 	ctx, cancel := utils.InitContext()
 	defer cancel()
+	return entity.UpdateProductStockQuantityByIdTx(ctx, id, quantity)
+}
+
+func (entity *productEntity) UpdateProductStockQuantityByIdTx(ctx context.Context, id string, quantity int) (*entities.ProductStock, error) {
+	logrus.Info("UpdateProductStockQuantityById")
 	objId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, err
@@ -1272,9 +1577,13 @@ func (entity *productEntity) UpdateProductStockQuantityById(id string, quantity
 }
 
 func (entity *productEntity) RemoveProductStockById(id string) (*entities.ProductStock, error) {
-	logrus.Info("RemoveProductStockById")
 	ctx, cancel := utils.InitContext()
 	defer cancel()
+	return entity.RemoveProductStockByIdTx(ctx, id)
+}
+
+func (entity *productEntity) RemoveProductStockByIdTx(ctx context.Context, id string) (*entities.ProductStock, error) {
+	logrus.Info("RemoveProductStockById")
 	objId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, err
@@ -1326,9 +1635,13 @@ func (entity *productEntity) UpdateProductStockSequence(param request.UpdateProd
 }
 
 func (entity *productEntity) CreateProductHistory(param request.ProductHistory) (*entities.ProductHistory, error) {
-	logrus.Info("CreateProductHistory")
 	ctx, cancel := utils.InitContext()
 	defer cancel()
+	return entity.CreateProductHistoryTx(ctx, param)
+}
+
+func (entity *productEntity) CreateProductHistoryTx(ctx context.Context, param request.ProductHistory) (*entities.ProductHistory, error) {
+	logrus.Info("CreateProductHistory")
 	data := entities.ProductHistory{}
 	data.Id = primitive.NewObjectID()
 	data.BranchId, _ = primitive.ObjectIDFromHex(param.BranchId)
@@ -1343,6 +1656,9 @@ func (entity *productEntity) CreateProductHistory(param request.ProductHistory)
 	data.CreatedBy = param.CreatedBy
 	data.CreatedDate = time.Now()
 	data.Balance = param.Balance
+	data.DocumentType = param.DocumentType
+	data.DocumentId = param.DocumentId
+	data.DocumentCode = param.DocumentCode
 
 	_, err := entity.productHistoryRepo.InsertOne(ctx, data)
 	if err != nil {
@@ -1352,9 +1668,13 @@ func (entity *productEntity) CreateProductHistory(param request.ProductHistory)
 }
 
 func (entity *productEntity) GetProductStockBalance(productId string, unitId string) int {
-	logrus.Info("GetProductStockBalance")
 	ctx, cancel := utils.InitContext()
 	defer cancel()
+	return entity.GetProductStockBalanceTx(ctx, productId, unitId)
+}
+
+func (entity *productEntity) GetProductStockBalanceTx(ctx context.Context, productId string, unitId string) int {
+	logrus.Info("GetProductStockBalance")
 	product, _ := primitive.ObjectIDFromHex(productId)
 	unit, _ := primitive.ObjectIDFromHex(unitId)
 	pipeline := []bson.M{
@@ -1438,7 +1758,8 @@ func (entity *productEntity) GetLowStockProducts(threshold int, branchId string)
 	ctx, cancel := utils.InitContext()
 	defer cancel()
 
-	matchStage := bson.M{}
+	// Blocked and expired lots cannot be sold, they do not cover the threshold
+	matchStage := bson.M{"status": bson.M{"$nin": constant.NonSellableStockStatuses()}}
 	if branchId != "" {
 		branchObjId, _ := primitive.ObjectIDFromHex(branchId)
 		matchStage["branchId"] = branchObjId
@@ -1487,7 +1808,8 @@ func (entity *productEntity) GetStockReport(branchId string) ([]entities.StockRe
 	ctx, cancel := utils.InitContext()
 	defer cancel()
 
-	matchStage := bson.M{}
+	// Blocked and expired lots are valued nothing until they are disposed of or returned
+	matchStage := bson.M{"status": bson.M{"$nin": constant.NonSellableStockStatuses()}}
 	if branchId != "" {
 		branchObjId, _ := primitive.ObjectIDFromHex(branchId)
 		matchStage["branchId"] = branchObjId
@@ -1532,6 +1854,102 @@ func (entity *productEntity) GetStockReport(branchId string) ([]entities.StockRe
 	return results, nil
 }
 
+// GetProductStocksForCount lists the lots of a branch to be counted with their product and unit names.
+// Picked lots are listed whatever they hold, otherwise only lots in stock, narrowed to one category when
+// category is set.
+func (entity *productEntity) GetProductStocksForCount(branchId string, category string, stockIds []string) ([]entities.ProductStockDetail, error) {
+	logrus.Info("GetProductStocksForCount")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+
+	branchObjId, err := primitive.ObjectIDFromHex(branchId)
+	if err != nil {
+		return nil, err
+	}
+	matchStage := bson.M{"branchId": branchObjId}
+	if len(stockIds) > 0 {
+		objIds := make([]primitive.ObjectID, 0, len(stockIds))
+		for _, id := range stockIds {
+			objId, err := primitive.ObjectIDFromHex(id)
+			if err == nil {
+				objIds = append(objIds, objId)
+			}
+		}
+		matchStage["_id"] = bson.M{"$in": objIds}
+	} else {
+		matchStage["quantity"] = bson.M{"$gt": 0}
+	}
+	productMatch := bson.M{"product.deletedDate": bson.M{"$exists": false}}
+	if category != "" {
+		productMatch["product.category"] = category
+	}
+	return entity.getProductStockDetails(ctx, matchStage, productMatch)
+}
+
+// GetProductStocksByStatus lists the lots of a branch in stock with one of the statuses, with their product
+// and unit names
+func (entity *productEntity) GetProductStocksByStatus(branchId string, statuses []string) ([]entities.ProductStockDetail, error) {
+	logrus.Info("GetProductStocksByStatus")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+
+	branchObjId, err := primitive.ObjectIDFromHex(branchId)
+	if err != nil {
+		return nil, err
+	}
+	matchStage := bson.M{
+		"branchId": branchObjId,
+		"quantity": bson.M{"$gt": 0},
+		"status":   bson.M{"$in": statuses},
+	}
+	return entity.getProductStockDetails(ctx, matchStage, bson.M{})
+}
+
+func (entity *productEntity) getProductStockDetails(ctx context.Context, matchStage bson.M, productMatch bson.M) ([]entities.ProductStockDetail, error) {
+	pipeline := []bson.M{
+		{"$match": matchStage},
+		{"$lookup": bson.M{
+			"from":         "products",
+			"localField":   "productId",
+			"foreignField": "_id",
+			"as":           "product",
+		}},
+		{"$unwind": "$product"},
+		{"$match": productMatch},
+		{"$lookup": bson.M{
+			"from":         "product_units",
+			"localField":   "unitId",
+			"foreignField": "_id",
+			"as":           "unit",
+		}},
+		{"$unwind": bson.M{"path": "$unit", "preserveNullAndEmptyArrays": true}},
+		{"$project": bson.M{
+			"_id":          1,
+			"productId":    1,
+			"unitId":       1,
+			"lotNumber":    1,
+			"costPrice":    1,
+			"quantity":     1,
+			"expireDate":   1,
+			"status":       1,
+			"productName":  "$product.name",
+			"serialNumber": "$product.serialNumber",
+			"unit":         "$unit.unit",
+		}},
+		{"$sort": bson.D{{Key: "productName", Value: 1}, {Key: "expireDate", Value: 1}}},
+	}
+
+	cursor, err := entity.productStockRepo.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.ProductStockDetail{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
 func (entity *productEntity) GetDeadStockProducts(days int, branchId string) ([]entities.DeadStockProduct, error) {
 	logrus.Info("GetDeadStockProducts")
 	ctx, cancel := utils.InitContext()
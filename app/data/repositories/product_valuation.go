@@ -0,0 +1,436 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"math"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrValuationOpened is returned when the opening balances of a branch are already in the ledger
+var ErrValuationOpened = errors.New("valuation ledger already opened")
+
+func ensureProductValuationIndexes(productValuationRepo *mongo.Collection) {
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+
+	_, err := productValuationRepo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "branchId", Value: 1}, {Key: "createdDate", Value: -1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create product_valuations branchId+createdDate index: ", err)
+	}
+
+	_, err = productValuationRepo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "stockId", Value: 1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create product_valuations stockId index: ", err)
+	}
+
+	// A lot is opened once, concurrent openings of a branch collide here
+	_, err = productValuationRepo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "branchId", Value: 1}, {Key: "stockId", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"type": constant.ValuationTypeOpening}),
+	})
+	if err != nil {
+		logrus.Error("failed to create product_valuations opening index: ", err)
+	}
+
+	_, err = productValuationRepo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "documentId", Value: 1}, {Key: "type", Value: 1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create product_valuations documentId+type index: ", err)
+	}
+}
+
+func (entity *productEntity) CreateProductValuation(param request.ProductValuation) (*entities.ProductValuation, error) {
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	return entity.CreateProductValuationTx(ctx, param)
+}
+
+func (entity *productEntity) CreateProductValuationTx(ctx context.Context, param request.ProductValuation) (*entities.ProductValuation, error) {
+	logrus.Info("CreateProductValuation")
+	data := toEntityProductValuation(param)
+	_, err := entity.productValuationRepo.InsertOne(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetSaleUnitCostsTx returns the unit cost each lot left the ledger at in the sale of the order, by stock id.
+// Orders sold before the ledger was kept have none.
+func (entity *productEntity) GetSaleUnitCostsTx(ctx context.Context, orderId string) (map[string]float64, error) {
+	logrus.Info("GetSaleUnitCosts")
+	cursor, err := entity.productValuationRepo.Find(ctx, bson.M{
+		"documentId": orderId,
+		"type":       constant.ValuationTypeSale,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var items []entities.ProductValuation
+	if err = cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	costs := make(map[string]float64)
+	for _, item := range items {
+		costs[item.StockId.Hex()] = item.UnitCost
+	}
+	return costs, nil
+}
+
+// ReturnProductStockQuantityByIdTx puts the quantity back into the lot at the unit cost it was sold at,
+// the cost of the lot becomes the weighted cost of what it holds and what comes back
+func (entity *productEntity) ReturnProductStockQuantityByIdTx(ctx context.Context, stockId string, quantity int, unitCost float64) (*entities.ProductStock, error) {
+	logrus.Info("ReturnProductStockQuantityById")
+	objId, err := primitive.ObjectIDFromHex(stockId)
+	if err != nil {
+		return nil, err
+	}
+
+	total := bson.M{"$add": bson.A{"$quantity", quantity}}
+	isReturnNewDoc := options.After
+	opts := &options.FindOneAndUpdateOptions{
+		ReturnDocument: &isReturnNewDoc,
+	}
+	var data entities.ProductStock
+	update := bson.A{
+		bson.M{"$set": bson.M{
+			"quantity": total,
+			"costPrice": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{total, 0}},
+				bson.M{"$round": bson.A{
+					bson.M{"$divide": bson.A{
+						bson.M{"$add": bson.A{bson.M{"$multiply": bson.A{"$quantity", "$costPrice"}}, float64(quantity) * unitCost}},
+						total,
+					}},
+					4,
+				}},
+				"$costPrice",
+			}},
+		}},
+	}
+	err = entity.productStockRepo.FindOneAndUpdate(ctx, bson.M{"_id": objId}, update, opts).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *productEntity) AverageProductStockCost(param request.ProductValuation) (float64, error) {
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	return entity.AverageProductStockCostTx(ctx, param)
+}
+
+// AverageProductStockCostTx reprices the sellable lots of the product unit in the branch to their moving
+// average cost and records the change of value of each lot as a revaluation under the document of param.
+// It returns the average, zero when the branch holds none of the unit.
+func (entity *productEntity) AverageProductStockCostTx(ctx context.Context, param request.ProductValuation) (float64, error) {
+	logrus.Info("AverageProductStockCost")
+	branchId, _ := primitive.ObjectIDFromHex(param.BranchId)
+	productId, _ := primitive.ObjectIDFromHex(param.ProductId)
+	unitId, _ := primitive.ObjectIDFromHex(param.UnitId)
+	cursor, err := entity.productStockRepo.Find(ctx, bson.M{
+		"branchId":  branchId,
+		"productId": productId,
+		"unitId":    unitId,
+		"quantity":  bson.M{"$gt": 0},
+		"status":    bson.M{"$nin": constant.NonSellableStockStatuses()},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var stocks []entities.ProductStock
+	if err = cursor.All(ctx, &stocks); err != nil {
+		return 0, err
+	}
+
+	var quantity int
+	var value float64
+	for _, stock := range stocks {
+		quantity += stock.Quantity
+		value += float64(stock.Quantity) * stock.CostPrice
+	}
+	if quantity == 0 {
+		return 0, nil
+	}
+	average := math.Round(value/float64(quantity)*10000) / 10000
+
+	for _, stock := range stocks {
+		if stock.CostPrice == average {
+			continue
+		}
+		if _, err := entity.productStockRepo.UpdateOne(ctx, bson.M{"_id": stock.Id}, bson.M{"$set": bson.M{
+			"costPrice": average,
+		}}); err != nil {
+			return 0, err
+		}
+		revalue := param
+		revalue.StockId = stock.Id.Hex()
+		revalue.LotNumber = stock.LotNumber
+		revalue.Type = constant.ValuationTypeRevalue
+		revalue.Quantity = 0
+		revalue.UnitCost = average
+		revalue.Amount = float64(stock.Quantity) * (average - stock.CostPrice)
+		if _, err := entity.productValuationRepo.InsertOne(ctx, toEntityProductValuation(revalue)); err != nil {
+			return 0, err
+		}
+	}
+	return average, nil
+}
+
+// OpenProductValuationsTx brings the ledger of a branch in line with its lots, each lot gets an opening
+// entry for what it holds beyond the movements already recorded. A branch is opened once, the unique
+// opening index rejects a concurrent opening and the transaction rolls it back.
+func (entity *productEntity) OpenProductValuationsTx(ctx context.Context, branchId string, createdBy string) (int, error) {
+	logrus.Info("OpenProductValuations")
+	branchObjId, err := primitive.ObjectIDFromHex(branchId)
+	if err != nil {
+		return 0, err
+	}
+
+	opened, err := entity.productValuationRepo.CountDocuments(ctx, bson.M{"branchId": branchObjId, "type": constant.ValuationTypeOpening})
+	if err != nil {
+		return 0, err
+	}
+	if opened > 0 {
+		return 0, ErrValuationOpened
+	}
+
+	type lotBalance struct {
+		StockId  primitive.ObjectID `bson:"_id"`
+		Quantity int                `bson:"quantity"`
+		Amount   float64            `bson:"amount"`
+	}
+	cursor, err := entity.productValuationRepo.Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"branchId": branchObjId}},
+		{"$group": bson.M{
+			"_id":      "$stockId",
+			"quantity": bson.M{"$sum": "$quantity"},
+			"amount":   bson.M{"$sum": "$amount"},
+		}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var balances []lotBalance
+	if err = cursor.All(ctx, &balances); err != nil {
+		return 0, err
+	}
+	recorded := make(map[primitive.ObjectID]lotBalance)
+	for _, balance := range balances {
+		recorded[balance.StockId] = balance
+	}
+
+	cursor, err = entity.productStockRepo.Find(ctx, bson.M{"branchId": branchObjId})
+	if err != nil {
+		return 0, err
+	}
+	var stocks []entities.ProductStock
+	if err = cursor.All(ctx, &stocks); err != nil {
+		return 0, err
+	}
+
+	var docs []interface{}
+	for i := range stocks {
+		stock := &stocks[i]
+		balance := recorded[stock.Id]
+		quantity := stock.Quantity - balance.Quantity
+		amount := float64(stock.Quantity)*stock.CostPrice - balance.Amount
+		if quantity == 0 && math.Abs(amount) < 0.005 {
+			continue
+		}
+		opening := request.StockValuation(stock, constant.ValuationTypeOpening, quantity, createdBy)
+		opening.Amount = amount
+		docs = append(docs, toEntityProductValuation(opening))
+	}
+	if len(docs) == 0 {
+		return 0, nil
+	}
+	if _, err := entity.productValuationRepo.InsertMany(ctx, docs); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return 0, ErrValuationOpened
+		}
+		return 0, err
+	}
+	return len(docs), nil
+}
+
+func (entity *productEntity) GetProductValuationRange(form request.GetProductValuationRange) ([]entities.ProductValuation, error) {
+	logrus.Info("GetProductValuationRange")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+
+	filter := bson.M{
+		"createdDate": bson.M{
+			"$gt": form.StartDate,
+			"$lt": form.EndDate,
+		},
+	}
+	if form.BranchId != "" {
+		branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+		filter["branchId"] = branchId
+	}
+	if form.ProductId != "" {
+		productId, _ := primitive.ObjectIDFromHex(form.ProductId)
+		filter["productId"] = productId
+	}
+	if form.Type != "" {
+		filter["type"] = form.Type
+	}
+	opts := options.Find().SetSort(bson.M{"createdDate": 1})
+	cursor, err := entity.productValuationRepo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.ProductValuation{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetInventoryValuation sums the ledger of the branch per product up to the date
+func (entity *productEntity) GetInventoryValuation(form request.GetInventoryValuation) ([]entities.InventoryValuation, error) {
+	logrus.Info("GetInventoryValuation")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+
+	matchStage := bson.M{"createdDate": bson.M{"$lt": form.Date}}
+	if form.BranchId != "" {
+		branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+		matchStage["branchId"] = branchId
+	}
+	pipeline := []bson.M{
+		{"$match": matchStage},
+		{"$group": bson.M{
+			"_id":      "$productId",
+			"quantity": bson.M{"$sum": "$quantity"},
+			"value":    bson.M{"$sum": "$amount"},
+		}},
+		{"$lookup": bson.M{
+			"from":         "products",
+			"localField":   "_id",
+			"foreignField": "_id",
+			"as":           "product",
+		}},
+		{"$unwind": "$product"},
+		{"$project": bson.M{
+			"_id":          1,
+			"quantity":     1,
+			"value":        1,
+			"name":         "$product.name",
+			"serialNumber": "$product.serialNumber",
+			"unit":         "$product.unit",
+		}},
+		{"$sort": bson.M{"name": 1}},
+	}
+
+	cursor, err := entity.productValuationRepo.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.InventoryValuation{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetCostOfGoodsSold sums the cost of the sales of the branch per product in the period, voids and
+// returns come off at the cost they went back to stock at
+func (entity *productEntity) GetCostOfGoodsSold(form request.GetCostOfGoodsSold) ([]entities.CostOfGoodsSold, error) {
+	logrus.Info("GetCostOfGoodsSold")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+
+	matchStage := bson.M{
+		"createdDate": bson.M{
+			"$gt": form.StartDate,
+			"$lt": form.EndDate,
+		},
+		"type": bson.M{"$in": bson.A{constant.ValuationTypeSale, constant.ValuationTypeVoid, constant.ValuationTypeReturn}},
+	}
+	if form.BranchId != "" {
+		branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+		matchStage["branchId"] = branchId
+	}
+	isSale := bson.M{"$eq": bson.A{"$type", constant.ValuationTypeSale}}
+	pipeline := []bson.M{
+		{"$match": matchStage},
+		{"$group": bson.M{
+			"_id":              "$productId",
+			"soldQuantity":     bson.M{"$sum": bson.M{"$cond": bson.A{isSale, bson.M{"$multiply": bson.A{"$quantity", -1}}, 0}}},
+			"soldCost":         bson.M{"$sum": bson.M{"$cond": bson.A{isSale, bson.M{"$multiply": bson.A{"$amount", -1}}, 0}}},
+			"returnedQuantity": bson.M{"$sum": bson.M{"$cond": bson.A{isSale, 0, "$quantity"}}},
+			"returnedCost":     bson.M{"$sum": bson.M{"$cond": bson.A{isSale, 0, "$amount"}}},
+		}},
+		{"$lookup": bson.M{
+			"from":         "products",
+			"localField":   "_id",
+			"foreignField": "_id",
+			"as":           "product",
+		}},
+		{"$unwind": "$product"},
+		{"$project": bson.M{
+			"_id":              1,
+			"soldQuantity":     1,
+			"soldCost":         1,
+			"returnedQuantity": 1,
+			"returnedCost":     1,
+			"quantity":         bson.M{"$subtract": bson.A{"$soldQuantity", "$returnedQuantity"}},
+			"cost":             bson.M{"$subtract": bson.A{"$soldCost", "$returnedCost"}},
+			"name":             "$product.name",
+			"serialNumber":     "$product.serialNumber",
+			"unit":             "$product.unit",
+		}},
+		{"$sort": bson.M{"name": 1}},
+	}
+
+	cursor, err := entity.productValuationRepo.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.CostOfGoodsSold{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func toEntityProductValuation(param request.ProductValuation) entities.ProductValuation {
+	data := entities.ProductValuation{
+		Id:           primitive.NewObjectID(),
+		LotNumber:    param.LotNumber,
+		Type:         param.Type,
+		Quantity:     param.Quantity,
+		UnitCost:     param.UnitCost,
+		Amount:       param.Amount,
+		DocumentType: param.DocumentType,
+		DocumentId:   param.DocumentId,
+		DocumentCode: param.DocumentCode,
+		CreatedBy:    param.CreatedBy,
+		CreatedDate:  time.Now(),
+	}
+	data.BranchId, _ = primitive.ObjectIDFromHex(param.BranchId)
+	data.ProductId, _ = primitive.ObjectIDFromHex(param.ProductId)
+	data.UnitId, _ = primitive.ObjectIDFromHex(param.UnitId)
+	data.StockId, _ = primitive.ObjectIDFromHex(param.StockId)
+	return data
+}
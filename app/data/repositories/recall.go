@@ -0,0 +1,218 @@
+package repositories
+
+import (
+	"context"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/domain/request"
+	"pos/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type recallEntity struct {
+	recallRepo       *mongo.Collection
+	recallReturnRepo *mongo.Collection
+}
+
+type IRecall interface {
+	GetRecallRange(form request.GetRecallRange) ([]entities.Recall, error)
+	GetRecallById(id string) (*entities.Recall, error)
+	IsLotRecalled(productId string, lotNumber string) bool
+
+	// Returns
+	GetRecallReturnsByRecallId(recallId string, branchId string) ([]entities.RecallReturn, error)
+	GetRecallReturnById(id string) (*entities.RecallReturn, error)
+
+	// Transactional, ctx must come from ITransaction.WithTransaction
+	CreateRecallTx(ctx context.Context, form request.Recall) (*entities.Recall, error)
+	CreateRecallReturnTx(ctx context.Context, form request.RecallReturn) (*entities.RecallReturn, error)
+}
+
+func NewRecallEntity(resource *db.Resource) IRecall {
+	recallRepo := resource.PosDb.Collection("recalls")
+	recallReturnRepo := resource.PosDb.Collection("recall_returns")
+	entity := &recallEntity{
+		recallRepo:       recallRepo,
+		recallReturnRepo: recallReturnRepo,
+	}
+	ensureRecallIndexes(recallRepo, recallReturnRepo)
+	return entity
+}
+
+func ensureRecallIndexes(recallRepo *mongo.Collection, recallReturnRepo *mongo.Collection) {
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	_, err := recallRepo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "productId", Value: 1}, {Key: "lotNumbers", Value: 1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create recalls productId index: ", err)
+	}
+	_, err = recallReturnRepo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "recallId", Value: 1}, {Key: "branchId", Value: 1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create recall_returns recallId index: ", err)
+	}
+}
+
+func (entity *recallEntity) CreateRecallTx(ctx context.Context, form request.Recall) (*entities.Recall, error) {
+	logrus.Info("CreateRecall")
+	productId, _ := primitive.ObjectIDFromHex(form.ProductId)
+	now := time.Now()
+	data := entities.Recall{
+		Id:           primitive.NewObjectID(),
+		Code:         form.Code,
+		ProductId:    productId,
+		ProductName:  form.ProductName,
+		SerialNumber: form.SerialNumber,
+		LotNumbers:   form.LotNumbers,
+		Reason:       form.Reason,
+		Reference:    form.Reference,
+		Note:         form.Note,
+		BlockedLots:  form.BlockedLots,
+		CreatedBy:    form.CreatedBy,
+		CreatedDate:  now,
+		UpdatedBy:    form.CreatedBy,
+		UpdatedDate:  now,
+	}
+	_, err := entity.recallRepo.InsertOne(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *recallEntity) GetRecallRange(form request.GetRecallRange) ([]entities.Recall, error) {
+	logrus.Info("GetRecallRange")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+
+	filter := bson.M{
+		"createdDate": bson.M{
+			"$gt": form.StartDate,
+			"$lt": form.EndDate,
+		},
+	}
+	if form.ProductId != "" {
+		productId, _ := primitive.ObjectIDFromHex(form.ProductId)
+		filter["productId"] = productId
+	}
+	opts := options.Find().SetSort(bson.M{"createdDate": -1})
+	cursor, err := entity.recallRepo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.Recall{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (entity *recallEntity) GetRecallById(id string) (*entities.Recall, error) {
+	logrus.Info("GetRecallById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	data := entities.Recall{}
+	err = entity.recallRepo.FindOne(ctx, bson.M{"_id": objId}).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// IsLotRecalled reports whether a recall names the lot of the product, lots received after the recall
+// are blocked as well
+func (entity *recallEntity) IsLotRecalled(productId string, lotNumber string) bool {
+	logrus.Info("IsLotRecalled")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(productId)
+	if err != nil || lotNumber == "" {
+		return false
+	}
+	count, err := entity.recallRepo.CountDocuments(ctx, bson.M{"productId": objId, "lotNumbers": lotNumber})
+	if err != nil {
+		logrus.Error("failed to look up recalls: ", err)
+		return false
+	}
+	return count > 0
+}
+
+func (entity *recallEntity) CreateRecallReturnTx(ctx context.Context, form request.RecallReturn) (*entities.RecallReturn, error) {
+	logrus.Info("CreateRecallReturn")
+	branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+	recallId, _ := primitive.ObjectIDFromHex(form.RecallId)
+	supplierId, _ := primitive.ObjectIDFromHex(form.SupplierId)
+	data := entities.RecallReturn{
+		Id:           primitive.NewObjectID(),
+		BranchId:     branchId,
+		Code:         form.Code,
+		RecallId:     recallId,
+		RecallCode:   form.RecallCode,
+		SupplierId:   supplierId,
+		SupplierName: form.SupplierName,
+		Items:        form.Items,
+		TotalCost:    form.TotalCost,
+		CreatedBy:    form.CreatedBy,
+		CreatedDate:  time.Now(),
+	}
+	_, err := entity.recallReturnRepo.InsertOne(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetRecallReturnsByRecallId returns the supplier returns of a recall, of every branch when branchId is empty
+func (entity *recallEntity) GetRecallReturnsByRecallId(recallId string, branchId string) ([]entities.RecallReturn, error) {
+	logrus.Info("GetRecallReturnsByRecallId")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(recallId)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{"recallId": objId}
+	if branchId != "" {
+		branch, _ := primitive.ObjectIDFromHex(branchId)
+		filter["branchId"] = branch
+	}
+	opts := options.Find().SetSort(bson.M{"createdDate": -1})
+	cursor, err := entity.recallReturnRepo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.RecallReturn{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (entity *recallEntity) GetRecallReturnById(id string) (*entities.RecallReturn, error) {
+	logrus.Info("GetRecallReturnById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	data := entities.RecallReturn{}
+	err = entity.recallReturnRepo.FindOne(ctx, bson.M{"_id": objId}).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
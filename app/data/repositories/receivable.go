@@ -0,0 +1,445 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"math"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+	"pos/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrInvoicePaid is returned when an invoice that already received payments would be voided
+var ErrInvoicePaid = errors.New("invoice has payments allocated")
+
+// ErrInsufficientBalance is returned when an allocation is more than the open balance of the invoice
+var ErrInsufficientBalance = errors.New("invoice balance is less than the allocation")
+
+type receivableEntity struct {
+	invoiceRepo  *mongo.Collection
+	receiptRepo  *mongo.Collection
+	customerRepo *mongo.Collection
+}
+
+type IReceivable interface {
+	GetOpenInvoices(branchId string) ([]entities.ReceivableInvoice, error)
+	GetInvoicesByCustomerId(branchId string, customerId string) ([]entities.ReceivableInvoice, error)
+	GetReceiptRange(form request.GetReceivableRange) ([]entities.ReceivableReceipt, error)
+	GetReceiptById(id string) (*entities.ReceivableReceipt, error)
+	GetReceiptsByCustomerId(branchId string, customerId string) ([]entities.ReceivableReceipt, error)
+	GetReceiptsByShiftId(shiftId string) ([]entities.ReceivableReceipt, error)
+	GetOutstandingBalance(customerId string) (float64, error)
+
+	// Transactional, ctx must come from ITransaction.WithTransaction
+	CreateInvoiceTx(ctx context.Context, form request.ReceivableInvoice) (*entities.ReceivableInvoice, error)
+	GetOutstandingBalanceTx(ctx context.Context, customerId string) (float64, error)
+	GetOpenInvoicesByCustomerIdTx(ctx context.Context, branchId string, customerId string) ([]entities.ReceivableInvoice, error)
+	AllocateInvoiceTx(ctx context.Context, invoiceId string, amount float64, updatedBy string) (*entities.ReceivableInvoice, error)
+	CreditInvoiceByOrderIdTx(ctx context.Context, orderId string, amount float64, updatedBy string) (float64, error)
+	VoidInvoiceByOrderIdTx(ctx context.Context, orderId string, updatedBy string) error
+	CreateReceiptTx(ctx context.Context, form request.ReceivableReceipt) (*entities.ReceivableReceipt, error)
+}
+
+func NewReceivableEntity(resource *db.Resource) IReceivable {
+	invoiceRepo := resource.PosDb.Collection("receivable_invoices")
+	receiptRepo := resource.PosDb.Collection("receivable_receipts")
+	customerRepo := resource.PosDb.Collection("customers")
+	entity := &receivableEntity{invoiceRepo: invoiceRepo, receiptRepo: receiptRepo, customerRepo: customerRepo}
+	ensureReceivableIndexes(invoiceRepo, receiptRepo)
+	return entity
+}
+
+func ensureReceivableIndexes(invoiceRepo *mongo.Collection, receiptRepo *mongo.Collection) {
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	_, err := invoiceRepo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "customerId", Value: 1}, {Key: "status", Value: 1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create receivable_invoices customerId index: ", err)
+	}
+	_, err = invoiceRepo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "orderId", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		logrus.Error("failed to create receivable_invoices orderId index: ", err)
+	}
+	_, err = receiptRepo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "branchId", Value: 1}, {Key: "createdDate", Value: -1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create receivable_receipts branchId index: ", err)
+	}
+	_, err = receiptRepo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "customerId", Value: 1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create receivable_receipts customerId index: ", err)
+	}
+	_, err = receiptRepo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "shiftId", Value: 1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create receivable_receipts shiftId index: ", err)
+	}
+}
+
+func (entity *receivableEntity) CreateInvoiceTx(ctx context.Context, form request.ReceivableInvoice) (*entities.ReceivableInvoice, error) {
+	logrus.Info("CreateInvoice")
+	branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+	customerId, _ := primitive.ObjectIDFromHex(form.CustomerId)
+	orderId, _ := primitive.ObjectIDFromHex(form.OrderId)
+	data := entities.ReceivableInvoice{
+		Id:           primitive.NewObjectID(),
+		BranchId:     branchId,
+		CustomerId:   customerId,
+		CustomerCode: form.CustomerCode,
+		CustomerName: form.CustomerName,
+		OrderId:      orderId,
+		OrderCode:    form.OrderCode,
+		Amount:       form.Amount,
+		Paid:         0,
+		Balance:      form.Amount,
+		DueDate:      form.DueDate,
+		Status:       constant.ReceivableStatusOpen,
+		CreatedBy:    form.CreatedBy,
+		CreatedDate:  time.Now(),
+		UpdatedBy:    form.CreatedBy,
+		UpdatedDate:  time.Now(),
+	}
+	if err := entity.touchCustomerTx(ctx, customerId); err != nil {
+		return nil, err
+	}
+	_, err := entity.invoiceRepo.InsertOne(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// touchCustomerTx writes to the customer whose balance moves. Checkouts read the outstanding balance
+// of the customer, the write makes concurrent transactions on the same customer conflict so that
+// WithTransaction retries them on the committed balance instead of both passing the credit limit.
+func (entity *receivableEntity) touchCustomerTx(ctx context.Context, customerId primitive.ObjectID) error {
+	_, err := entity.customerRepo.UpdateOne(ctx, bson.M{"_id": customerId}, bson.M{"$set": bson.M{
+		"creditUpdatedDate": time.Now(),
+	}})
+	return err
+}
+
+func (entity *receivableEntity) GetOutstandingBalance(customerId string) (float64, error) {
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	return entity.GetOutstandingBalanceTx(ctx, customerId)
+}
+
+// GetOutstandingBalanceTx sums the open balance of the customer over every branch. Every invoice write
+// touches the customer, a checkout creating an invoice conflicts with any concurrent movement.
+func (entity *receivableEntity) GetOutstandingBalanceTx(ctx context.Context, customerId string) (float64, error) {
+	logrus.Info("GetOutstandingBalance")
+	objId, err := primitive.ObjectIDFromHex(customerId)
+	if err != nil {
+		return 0, err
+	}
+	pipeline := []bson.M{
+		{"$match": bson.M{"customerId": objId, "status": constant.ReceivableStatusOpen}},
+		{"$group": bson.M{"_id": nil, "balance": bson.M{"$sum": "$balance"}}},
+	}
+	cursor, err := entity.invoiceRepo.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	var results []struct {
+		Balance float64 `bson:"balance"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return 0, err
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return results[0].Balance, nil
+}
+
+func (entity *receivableEntity) findInvoices(ctx context.Context, filter bson.M) ([]entities.ReceivableInvoice, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdDate", Value: 1}})
+	cursor, err := entity.invoiceRepo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.ReceivableInvoice{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (entity *receivableEntity) GetOpenInvoices(branchId string) ([]entities.ReceivableInvoice, error) {
+	logrus.Info("GetOpenInvoices")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	filter := bson.M{"status": constant.ReceivableStatusOpen}
+	if branchId != "" {
+		branchObjId, _ := primitive.ObjectIDFromHex(branchId)
+		filter["branchId"] = branchObjId
+	}
+	return entity.findInvoices(ctx, filter)
+}
+
+func (entity *receivableEntity) GetInvoicesByCustomerId(branchId string, customerId string) ([]entities.ReceivableInvoice, error) {
+	logrus.Info("GetInvoicesByCustomerId")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	branchObjId, _ := primitive.ObjectIDFromHex(branchId)
+	customerObjId, err := primitive.ObjectIDFromHex(customerId)
+	if err != nil {
+		return nil, err
+	}
+	return entity.findInvoices(ctx, bson.M{
+		"branchId":   branchObjId,
+		"customerId": customerObjId,
+		"status":     bson.M{"$ne": constant.ReceivableStatusVoided},
+	})
+}
+
+func (entity *receivableEntity) GetOpenInvoicesByCustomerIdTx(ctx context.Context, branchId string, customerId string) ([]entities.ReceivableInvoice, error) {
+	logrus.Info("GetOpenInvoicesByCustomerId")
+	branchObjId, _ := primitive.ObjectIDFromHex(branchId)
+	customerObjId, err := primitive.ObjectIDFromHex(customerId)
+	if err != nil {
+		return nil, err
+	}
+	return entity.findInvoices(ctx, bson.M{
+		"branchId":   branchObjId,
+		"customerId": customerObjId,
+		"status":     constant.ReceivableStatusOpen,
+	})
+}
+
+// AllocateInvoiceTx pays the amount off the invoice, only while its balance covers the amount, or
+// else ErrInsufficientBalance is returned. The invoice turns PAID once nothing is left.
+func (entity *receivableEntity) AllocateInvoiceTx(ctx context.Context, invoiceId string, amount float64, updatedBy string) (*entities.ReceivableInvoice, error) {
+	logrus.Info("AllocateInvoice")
+	objId, err := primitive.ObjectIDFromHex(invoiceId)
+	if err != nil {
+		return nil, err
+	}
+	isReturnNewDoc := options.After
+	opts := &options.FindOneAndUpdateOptions{
+		ReturnDocument: &isReturnNewDoc,
+	}
+	update := bson.A{
+		bson.M{"$set": bson.M{
+			"paid":        bson.M{"$round": bson.A{bson.M{"$add": bson.A{"$paid", amount}}, 2}},
+			"balance":     bson.M{"$round": bson.A{bson.M{"$subtract": bson.A{"$balance", amount}}, 2}},
+			"updatedBy":   updatedBy,
+			"updatedDate": time.Now(),
+		}},
+		bson.M{"$set": bson.M{
+			"status": bson.M{"$cond": bson.A{bson.M{"$lte": bson.A{"$balance", 0}}, constant.ReceivableStatusPaid, "$status"}},
+		}},
+	}
+	data := entities.ReceivableInvoice{}
+	err = entity.invoiceRepo.FindOneAndUpdate(ctx, bson.M{
+		"_id":     objId,
+		"status":  constant.ReceivableStatusOpen,
+		"balance": bson.M{"$gte": math.Round(amount*100) / 100},
+	}, update, opts).Decode(&data)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInsufficientBalance
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := entity.touchCustomerTx(ctx, data.CustomerId); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// CreditInvoiceByOrderIdTx takes a credit note refund off what the customer owes for the order and
+// returns the amount credited, at most the open balance. Nothing is credited when the order has no
+// open invoice, the rest was paid and must be refunded otherwise.
+func (entity *receivableEntity) CreditInvoiceByOrderIdTx(ctx context.Context, orderId string, amount float64, updatedBy string) (float64, error) {
+	logrus.Info("CreditInvoiceByOrderId")
+	objId, err := primitive.ObjectIDFromHex(orderId)
+	if err != nil {
+		return 0, err
+	}
+	data := entities.ReceivableInvoice{}
+	err = entity.invoiceRepo.FindOne(ctx, bson.M{"orderId": objId, "status": constant.ReceivableStatusOpen}).Decode(&data)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := entity.touchCustomerTx(ctx, data.CustomerId); err != nil {
+		return 0, err
+	}
+	credit := math.Round(math.Min(amount, data.Balance)*100) / 100
+	data.Amount = math.Round((data.Amount-credit)*100) / 100
+	data.Balance = math.Round((data.Balance-credit)*100) / 100
+	if data.Balance <= 0 {
+		data.Status = constant.ReceivableStatusPaid
+	}
+	_, err = entity.invoiceRepo.UpdateOne(ctx, bson.M{"_id": data.Id}, bson.M{"$set": bson.M{
+		"amount":      data.Amount,
+		"balance":     data.Balance,
+		"status":      data.Status,
+		"updatedBy":   updatedBy,
+		"updatedDate": time.Now(),
+	}})
+	if err != nil {
+		return 0, err
+	}
+	return credit, nil
+}
+
+// VoidInvoiceByOrderIdTx voids the invoice of a voided order, orders without invoice are ignored
+func (entity *receivableEntity) VoidInvoiceByOrderIdTx(ctx context.Context, orderId string, updatedBy string) error {
+	logrus.Info("VoidInvoiceByOrderId")
+	objId, err := primitive.ObjectIDFromHex(orderId)
+	if err != nil {
+		return err
+	}
+	data := entities.ReceivableInvoice{}
+	err = entity.invoiceRepo.FindOne(ctx, bson.M{"orderId": objId}).Decode(&data)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if data.Paid > 0 {
+		return ErrInvoicePaid
+	}
+	if err := entity.touchCustomerTx(ctx, data.CustomerId); err != nil {
+		return err
+	}
+	_, err = entity.invoiceRepo.UpdateOne(ctx, bson.M{"_id": data.Id}, bson.M{"$set": bson.M{
+		"balance":     0,
+		"status":      constant.ReceivableStatusVoided,
+		"updatedBy":   updatedBy,
+		"updatedDate": time.Now(),
+	}})
+	return err
+}
+
+func (entity *receivableEntity) CreateReceiptTx(ctx context.Context, form request.ReceivableReceipt) (*entities.ReceivableReceipt, error) {
+	logrus.Info("CreateReceipt")
+	branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+	customerId, _ := primitive.ObjectIDFromHex(form.CustomerId)
+	allocations := make([]entities.ReceivableAllocation, len(form.Allocations))
+	for i, allocation := range form.Allocations {
+		invoiceId, _ := primitive.ObjectIDFromHex(allocation.InvoiceId)
+		allocations[i] = entities.ReceivableAllocation{
+			InvoiceId: invoiceId,
+			OrderCode: allocation.OrderCode,
+			Amount:    allocation.Amount,
+		}
+	}
+	data := entities.ReceivableReceipt{
+		Id:           primitive.NewObjectID(),
+		BranchId:     branchId,
+		Code:         form.Code,
+		CustomerId:   customerId,
+		CustomerCode: form.CustomerCode,
+		CustomerName: form.CustomerName,
+		Amount:       form.Amount,
+		PaymentType:  form.PaymentType,
+		Reference:    form.Reference,
+		Note:         form.Note,
+		Allocations:  allocations,
+		ShiftId:      form.ShiftId,
+		Status:       constant.ACTIVE,
+		CreatedBy:    form.CreatedBy,
+		CreatedDate:  time.Now(),
+		UpdatedBy:    form.CreatedBy,
+		UpdatedDate:  time.Now(),
+	}
+	_, err := entity.receiptRepo.InsertOne(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *receivableEntity) findReceipts(ctx context.Context, filter bson.M) ([]entities.ReceivableReceipt, error) {
+	opts := options.Find().SetSort(bson.M{"createdDate": 1})
+	cursor, err := entity.receiptRepo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.ReceivableReceipt{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (entity *receivableEntity) GetReceiptRange(form request.GetReceivableRange) ([]entities.ReceivableReceipt, error) {
+	logrus.Info("GetReceiptRange")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	filter := bson.M{
+		"createdDate": bson.M{
+			"$gt": form.StartDate,
+			"$lt": form.EndDate,
+		},
+	}
+	if form.BranchId != "" {
+		branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+		filter["branchId"] = branchId
+	}
+	return entity.findReceipts(ctx, filter)
+}
+
+func (entity *receivableEntity) GetReceiptById(id string) (*entities.ReceivableReceipt, error) {
+	logrus.Info("GetReceiptById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	data := entities.ReceivableReceipt{}
+	err = entity.receiptRepo.FindOne(ctx, bson.M{"_id": objId}).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *receivableEntity) GetReceiptsByCustomerId(branchId string, customerId string) ([]entities.ReceivableReceipt, error) {
+	logrus.Info("GetReceiptsByCustomerId")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	branchObjId, _ := primitive.ObjectIDFromHex(branchId)
+	customerObjId, err := primitive.ObjectIDFromHex(customerId)
+	if err != nil {
+		return nil, err
+	}
+	return entity.findReceipts(ctx, bson.M{
+		"branchId":   branchObjId,
+		"customerId": customerObjId,
+		"status":     constant.ACTIVE,
+	})
+}
+
+func (entity *receivableEntity) GetReceiptsByShiftId(shiftId string) ([]entities.ReceivableReceipt, error) {
+	logrus.Info("GetReceiptsByShiftId")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	return entity.findReceipts(ctx, bson.M{"shiftId": shiftId, "status": constant.ACTIVE})
+}
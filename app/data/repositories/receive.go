@@ -1,11 +1,13 @@
 package repositories
 
 import (
+	"context"
 	"pos/app/core/utils"
 	"pos/app/data/entities"
 	"pos/app/domain/constant"
 	"pos/app/domain/request"
 	"pos/db"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -22,8 +24,10 @@ type receiveEntity struct {
 
 type IReceive interface {
 	GetReceives(form request.GetReceiveRange) ([]entities.Receive, error)
+	GetTaxInvoiceReceives(form request.GetReceiveRange) ([]entities.Receive, error)
 	CreateReceive(form request.Receive) (*entities.Receive, error)
 	GetReceiveById(id string) (*entities.Receive, error)
+	GetReceiveByCode(code string) (*entities.Receive, error)
 	RemoveReceiveById(id string) (*entities.Receive, error)
 	UpdateReceiveById(id string, form request.UpdateReceive) (*entities.Receive, error)
 	UpdateReceiveTotalCostById(id string, totalCost float64) (*entities.Receive, error)
@@ -32,6 +36,11 @@ type IReceive interface {
 	GetReceiveItemsByReceiveId(receiveId string) ([]entities.ReceiveItem, error)
 	GetReceiveItemByLotId(lotId string) (*entities.ReceiveItem, error)
 	RemoveReceiveItemByLotId(lotId string) (*entities.ReceiveItem, error)
+
+	// Transactional, ctx must come from ITransaction.WithTransaction
+	CreateReceiveTx(ctx context.Context, form request.Receive) (*entities.Receive, error)
+	CreateReceiveItemTx(ctx context.Context, receiveId string, productId string, form request.Product) (*entities.ReceiveItem, error)
+	UpdateReceiveTotalCostByIdTx(ctx context.Context, id string, totalCost float64) (*entities.Receive, error)
 }
 
 func NewReceiveEntity(resource *db.Resource) IReceive {
@@ -76,6 +85,13 @@ func ensureReceiveIndexes(receiveRepo *mongo.Collection, receiveItemsRepo *mongo
 	if err != nil {
 		logrus.Error("failed to create receives branchId+createdDate index: ", err)
 	}
+
+	_, err = receiveRepo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "branchId", Value: 1}, {Key: "taxInvoice.date", Value: 1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create receives branchId+taxInvoice.date index: ", err)
+	}
 }
 
 func (entity *receiveEntity) GetReceives(form request.GetReceiveRange) (items []entities.Receive, err error) {
@@ -104,10 +120,54 @@ func (entity *receiveEntity) GetReceives(form request.GetReceiveRange) (items []
 	return items, nil
 }
 
+// GetTaxInvoiceReceives returns the receives whose supplier tax invoice is dated in the range, oldest first
+func (entity *receiveEntity) GetTaxInvoiceReceives(form request.GetReceiveRange) ([]entities.Receive, error) {
+	logrus.Info("GetTaxInvoiceReceives")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+
+	filter := bson.M{
+		"taxInvoice.date": bson.M{
+			"$gte": form.StartDate,
+			"$lt":  form.EndDate,
+		},
+	}
+	if form.BranchId != "" {
+		branchObjId, _ := primitive.ObjectIDFromHex(form.BranchId)
+		filter["branchId"] = branchObjId
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "taxInvoice.date", Value: 1}, {Key: "code", Value: 1}})
+	cursor, err := entity.receiveRepo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	items := []entities.Receive{}
+	if err = cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func toEntityReceiveTaxInvoice(form *request.ReceiveTaxInvoice) *entities.ReceiveTaxInvoice {
+	if form == nil {
+		return nil
+	}
+	return &entities.ReceiveTaxInvoice{
+		Number:        strings.TrimSpace(form.Number),
+		Date:          form.Date,
+		TaxableAmount: form.TaxableAmount,
+		VatAmount:     form.VatAmount,
+	}
+}
+
 func (entity *receiveEntity) CreateReceive(form request.Receive) (*entities.Receive, error) {
-	logrus.Info("CreateReceive")
 	ctx, cancel := utils.InitContext()
 	defer cancel()
+	return entity.CreateReceiveTx(ctx, form)
+}
+
+func (entity *receiveEntity) CreateReceiveTx(ctx context.Context, form request.Receive) (*entities.Receive, error) {
+	logrus.Info("CreateReceive")
 	supplier, err := primitive.ObjectIDFromHex(form.SupplierId)
 	if err != nil {
 		return nil, err
@@ -119,6 +179,7 @@ func (entity *receiveEntity) CreateReceive(form request.Receive) (*entities.Rece
 		Code:        form.Code,
 		Reference:   form.Reference,
 		SupplierId:  supplier,
+		TaxInvoice:  toEntityReceiveTaxInvoice(form.TaxInvoice),
 		Items:       []entities.ReceiveItem{},
 		Status:      constant.ACTIVE,
 		CreatedBy:   form.UpdatedBy,
@@ -149,6 +210,18 @@ func (entity *receiveEntity) GetReceiveById(id string) (*entities.Receive, error
 	return &data, nil
 }
 
+func (entity *receiveEntity) GetReceiveByCode(code string) (*entities.Receive, error) {
+	logrus.Info("GetReceiveByCode")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	data := entities.Receive{}
+	err := entity.receiveRepo.FindOne(ctx, bson.M{"code": code}).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
 func (entity *receiveEntity) RemoveReceiveById(id string) (*entities.Receive, error) {
 	logrus.Info("RemoveReceiveById")
 	ctx, cancel := utils.InitContext()
@@ -170,9 +243,13 @@ func (entity *receiveEntity) RemoveReceiveById(id string) (*entities.Receive, er
 }
 
 func (entity *receiveEntity) UpdateReceiveTotalCostById(id string, totalCost float64) (*entities.Receive, error) {
-	logrus.Info("UpdateReceiveTotalCostById")
 	ctx, cancel := utils.InitContext()
 	defer cancel()
+	return entity.UpdateReceiveTotalCostByIdTx(ctx, id, totalCost)
+}
+
+func (entity *receiveEntity) UpdateReceiveTotalCostByIdTx(ctx context.Context, id string, totalCost float64) (*entities.Receive, error) {
+	logrus.Info("UpdateReceiveTotalCostById")
 	obId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, err
@@ -236,6 +313,7 @@ func (entity *receiveEntity) UpdateReceiveById(id string, form request.UpdateRec
 		"supplierId":  supplier,
 		"reference":   form.Reference,
 		"totalCost":   form.TotalCost,
+		"taxInvoice":  toEntityReceiveTaxInvoice(form.TaxInvoice),
 		"items":       items,
 		"updatedBy":   form.UpdatedBy,
 		"updatedDate": time.Now(),
@@ -293,9 +371,13 @@ func (entity *receiveEntity) UpdateReceiveItemsById(id string, form request.Upda
 }
 
 func (entity *receiveEntity) CreateReceiveItem(receiveId string, _ string, productId string, form request.Product) (*entities.ReceiveItem, error) {
-	logrus.Info("CreateReceiveItem")
 	ctx, cancel := utils.InitContext()
 	defer cancel()
+	return entity.CreateReceiveItemTx(ctx, receiveId, productId, form)
+}
+
+func (entity *receiveEntity) CreateReceiveItemTx(ctx context.Context, receiveId string, productId string, form request.Product) (*entities.ReceiveItem, error) {
+	logrus.Info("CreateReceiveItem")
 	product, err := primitive.ObjectIDFromHex(productId)
 	if err != nil {
 		return nil, err
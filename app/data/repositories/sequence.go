@@ -1,10 +1,13 @@
 package repositories
 
 import (
+	"context"
+	"errors"
 	"pos/app/core/utils"
 	"pos/app/data/entities"
 	"pos/app/domain/constant"
 	"pos/db"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -22,6 +25,9 @@ type ISequence interface {
 	NextSequence(field string) (*entities.Sequence, error)
 	CreateSequence(field string, value int) (*entities.Sequence, error)
 	GetSequenceByField(field string) (*entities.Sequence, error)
+
+	// Transactional, ctx must come from ITransaction.WithTransaction
+	NextSequenceTx(ctx context.Context, field string) (*entities.Sequence, error)
 }
 
 func NewSequenceEntity(resource *db.Resource) ISequence {
@@ -38,28 +44,7 @@ func (entity *sequenceEntity) CreateSequence(field string, value int) (*entities
 	} else {
 		ctx, cancel := utils.InitContext()
 		defer cancel()
-		data := entities.Sequence{}
-		data.Id = primitive.NewObjectID()
-		data.Field = field
-		data.Value = value
-		data.Format = 4
-		if field == constant.ORDER {
-			data.Prefix = "OD_"
-			data.Type = constant.DAILY
-		} else if field == constant.RECEIVE {
-			data.Prefix = "RC_"
-			data.Type = constant.DAILY
-		} else if field == constant.MEMBER {
-			data.Prefix = "MB_"
-			data.Type = constant.YEARLY
-		} else if field == constant.PRODUCT {
-			data.Prefix = "PD_"
-			data.Type = constant.NONE
-		} else {
-			data.Prefix = ""
-			data.Type = constant.NONE
-		}
-		data.Date = getSequenceDate()
+		data := newSequence(field, value)
 		_, err := entity.sequenceRepo.InsertOne(ctx, data)
 		if err != nil {
 			return nil, err
@@ -86,31 +71,7 @@ func (entity *sequenceEntity) NextSequence(field string) (*entities.Sequence, er
 	if data != nil {
 		ctx, cancel := utils.InitContext()
 		defer cancel()
-		var date = getSequenceDate()
-		if data.Type == constant.NONE {
-			data.Value = data.Value + 1
-		} else if data.Type == constant.DAILY {
-			if date == data.Date {
-				data.Value = data.Value + 1
-			} else {
-				data.Value = 1
-				data.Date = date
-			}
-		} else if data.Type == constant.MONTHLY {
-			if date[0:6] == data.Date[0:6] {
-				data.Value = data.Value + 1
-			} else {
-				data.Value = 1
-				data.Date = date
-			}
-		} else if data.Type == constant.YEARLY {
-			if date[0:4] == data.Date[0:4] {
-				data.Value = data.Value + 1
-			} else {
-				data.Value = 1
-				data.Date = date
-			}
-		}
+		advanceSequence(data)
 		isReturnNewDoc := options.After
 		opts := &options.FindOneAndUpdateOptions{
 			ReturnDocument: &isReturnNewDoc,
@@ -132,6 +93,120 @@ func (entity *sequenceEntity) NextSequence(field string) (*entities.Sequence, er
 	}
 }
 
+// NextSequenceTx draws the next value inside the transaction of the document it numbers, so a failed
+// document does not use up a number. Concurrent draws conflict and are retried by the transaction.
+func (entity *sequenceEntity) NextSequenceTx(ctx context.Context, field string) (*entities.Sequence, error) {
+	logrus.Info("NextSequenceTx")
+	var data entities.Sequence
+	err := entity.sequenceRepo.FindOne(ctx, bson.M{"field": field}).Decode(&data)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		data = newSequence(field, 1)
+		if _, err := entity.sequenceRepo.InsertOne(ctx, data); err != nil {
+			return nil, err
+		}
+		return &data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	advanceSequence(&data)
+	_, err = entity.sequenceRepo.UpdateOne(ctx, bson.M{"_id": data.Id}, bson.M{"$set": bson.M{
+		"value": data.Value,
+		"date":  data.Date,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// advanceSequence moves the series to its next value, restarting it when its period changed
+func advanceSequence(data *entities.Sequence) {
+	var date = getSequenceDate()
+	if data.Type == constant.NONE {
+		data.Value = data.Value + 1
+	} else if data.Type == constant.DAILY {
+		if date == data.Date {
+			data.Value = data.Value + 1
+		} else {
+			data.Value = 1
+			data.Date = date
+		}
+	} else if data.Type == constant.MONTHLY {
+		if date[0:6] == data.Date[0:6] {
+			data.Value = data.Value + 1
+		} else {
+			data.Value = 1
+			data.Date = date
+		}
+	} else if data.Type == constant.YEARLY {
+		if date[0:4] == data.Date[0:4] {
+			data.Value = data.Value + 1
+		} else {
+			data.Value = 1
+			data.Date = date
+		}
+	}
+}
+
+// newSequence sets up the series of a field, branch sequences share the format of their document type
+func newSequence(field string, value int) entities.Sequence {
+	data := entities.Sequence{}
+	data.Id = primitive.NewObjectID()
+	data.Field = field
+	data.Value = value
+	data.Format = 4
+	kind, _, _ := strings.Cut(field, ":")
+	if kind == constant.ORDER {
+		data.Prefix = "OD_"
+		data.Type = constant.DAILY
+	} else if kind == constant.RECEIVE {
+		data.Prefix = "RC_"
+		data.Type = constant.DAILY
+	} else if kind == constant.MEMBER {
+		data.Prefix = "MB_"
+		data.Type = constant.YEARLY
+	} else if kind == constant.PRODUCT {
+		data.Prefix = "PD_"
+		data.Type = constant.NONE
+	} else if kind == constant.CREDIT_NOTE {
+		data.Prefix = "CN_"
+		data.Type = constant.DAILY
+	} else if kind == constant.SHIFT {
+		data.Prefix = "SH_"
+		data.Type = constant.DAILY
+	} else if kind == constant.RECEIPT {
+		data.Prefix = "RV_"
+		data.Type = constant.MONTHLY
+	} else if kind == constant.ADJUSTMENT {
+		data.Prefix = "ADJ_"
+		data.Type = constant.MONTHLY
+	} else if kind == constant.STOCKTAKE {
+		data.Prefix = "STK_"
+		data.Type = constant.MONTHLY
+	} else if kind == constant.RECALL {
+		data.Prefix = "RCL_"
+		data.Type = constant.YEARLY
+	} else if kind == constant.RECALL_RETURN {
+		data.Prefix = "RTS_"
+		data.Type = constant.MONTHLY
+	} else if kind == constant.DISPOSAL {
+		data.Prefix = "DSP_"
+		data.Type = constant.MONTHLY
+	} else if kind == constant.TAX_INVOICE_ABB {
+		data.Prefix = "ABB_"
+		data.Type = constant.MONTHLY
+	} else if kind == constant.TAX_INVOICE_FULL {
+		data.Prefix = "INV_"
+		data.Type = constant.MONTHLY
+	} else {
+		data.Prefix = ""
+		data.Type = constant.NONE
+	}
+	data.Date = getSequenceDate()
+	return data
+}
+
 func getSequenceDate() string {
 	location := utils.GetLocation()
 	return time.Now().In(location).Format("20060102")
@@ -77,20 +77,40 @@ func (entity *settingEntity) UpsertSetting(form request.Setting) (*entities.Sett
 		Upsert:         boolPtr(true),
 	}
 
+	voidReasons := make([]entities.ReasonCode, len(form.VoidReasons))
+	for i, reason := range form.VoidReasons {
+		voidReasons[i] = entities.ReasonCode{
+			Code: reason.Code,
+			Name: reason.Name,
+		}
+	}
+
 	data := entities.Setting{}
 	err = entity.settingRepo.FindOneAndUpdate(ctx, bson.M{"branchId": branchId}, bson.M{
 		"$set": bson.M{
-			"branchId":       branchId,
-			"receiptFooter":  form.ReceiptFooter,
-			"companyName":    form.CompanyName,
-			"companyAddress": form.CompanyAddress,
-			"companyPhone":   form.CompanyPhone,
-			"companyTaxId":   form.CompanyTaxId,
-			"logoUrl":        form.LogoUrl,
-			"showCredit":     form.ShowCredit,
-			"promptPayId":    form.PromptPayId,
-			"updatedBy":      form.UpdatedBy,
-			"updatedDate":    time.Now(),
+			"branchId":           branchId,
+			"receiptFooter":      form.ReceiptFooter,
+			"companyName":        form.CompanyName,
+			"companyAddress":     form.CompanyAddress,
+			"companyPhone":       form.CompanyPhone,
+			"companyTaxId":       form.CompanyTaxId,
+			"logoUrl":            form.LogoUrl,
+			"showCredit":         form.ShowCredit,
+			"receiptPaper":       form.ReceiptPaper,
+			"receiptCodePage":    form.ReceiptCodePage,
+			"receiptPromptPayQr": form.ReceiptPromptPayQr,
+			"vatRegistered":      form.VatRegistered,
+			"vatRate":            form.VatRate,
+			"taxBranchNo":        form.TaxBranchNo,
+			"promptPayId":        form.PromptPayId,
+			"allowNegativeStock": form.AllowNegativeStock,
+			"adjustmentApproval": form.AdjustmentApproval,
+			"costingMethod":      form.CostingMethod,
+			"dayCloseTime":       form.DayCloseTime,
+			"cartReserveMinutes": form.CartReserveMinutes,
+			"voidReasons":        voidReasons,
+			"updatedBy":          form.UpdatedBy,
+			"updatedDate":        time.Now(),
 		},
 	}, opts).Decode(&data)
 	if err != nil {
@@ -0,0 +1,226 @@
+package repositories
+
+import (
+	"math"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+	"pos/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type shiftEntity struct {
+	repo *mongo.Collection
+}
+
+type IShift interface {
+	OpenShift(form request.OpenShift) (*entities.Shift, error)
+	GetShiftById(id string) (*entities.Shift, error)
+	GetOpenShiftByUserId(branchId string, userId string) (*entities.Shift, error)
+	GetShiftRange(form request.GetShiftRange) ([]entities.Shift, error)
+	AddCashMovementById(id string, form request.CashMovement) (*entities.Shift, error)
+	CloseShiftById(id string, form request.CloseShift) (*entities.Shift, error)
+}
+
+func NewShiftEntity(resource *db.Resource) IShift {
+	repo := resource.PosDb.Collection("shifts")
+	entity := &shiftEntity{repo: repo}
+	ensureShiftIndexes(repo)
+	return entity
+}
+
+func ensureShiftIndexes(repo *mongo.Collection) {
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	// A cashier holds at most one open shift per branch
+	_, err := repo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "branchId", Value: 1}, {Key: "userId", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"status": constant.ShiftStatusOpen}),
+	})
+	if err != nil {
+		logrus.Error("failed to create shifts open shift index: ", err)
+	}
+	_, err = repo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "branchId", Value: 1}, {Key: "openedDate", Value: -1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create shifts branchId index: ", err)
+	}
+}
+
+func (entity *shiftEntity) OpenShift(form request.OpenShift) (*entities.Shift, error) {
+	logrus.Info("OpenShift")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+	data := entities.Shift{
+		Id:            primitive.NewObjectID(),
+		BranchId:      branchId,
+		Code:          form.Code,
+		UserId:        form.UserId,
+		Status:        constant.ShiftStatusOpen,
+		OpeningFloat:  form.OpeningFloat,
+		CashMovements: []entities.CashMovement{},
+		Note:          form.Note,
+		OpenedDate:    time.Now(),
+		CreatedBy:     form.UserId,
+		CreatedDate:   time.Now(),
+		UpdatedBy:     form.UserId,
+		UpdatedDate:   time.Now(),
+	}
+	_, err := entity.repo.InsertOne(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *shiftEntity) GetShiftById(id string) (*entities.Shift, error) {
+	logrus.Info("GetShiftById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	data := entities.Shift{}
+	err = entity.repo.FindOne(ctx, bson.M{"_id": objId}).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *shiftEntity) GetOpenShiftByUserId(branchId string, userId string) (*entities.Shift, error) {
+	logrus.Info("GetOpenShiftByUserId")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	branchObjId, err := primitive.ObjectIDFromHex(branchId)
+	if err != nil {
+		return nil, err
+	}
+	data := entities.Shift{}
+	err = entity.repo.FindOne(ctx, bson.M{
+		"branchId": branchObjId,
+		"userId":   userId,
+		"status":   constant.ShiftStatusOpen,
+	}).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *shiftEntity) GetShiftRange(form request.GetShiftRange) ([]entities.Shift, error) {
+	logrus.Info("GetShiftRange")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	filter := bson.M{
+		"openedDate": bson.M{
+			"$gt": form.StartDate,
+			"$lt": form.EndDate,
+		},
+	}
+	if form.BranchId != "" {
+		branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+		filter["branchId"] = branchId
+	}
+	opts := options.Find().SetSort(bson.M{"openedDate": -1})
+	cursor, err := entity.repo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.Shift{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (entity *shiftEntity) AddCashMovementById(id string, form request.CashMovement) (*entities.Shift, error) {
+	logrus.Info("AddCashMovementById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	movement := entities.CashMovement{
+		Id:          primitive.NewObjectID(),
+		Type:        form.Type,
+		Amount:      form.Amount,
+		Reason:      form.Reason,
+		CreatedBy:   form.CreatedBy,
+		CreatedDate: time.Now(),
+	}
+	isReturnNewDoc := options.After
+	opts := &options.FindOneAndUpdateOptions{
+		ReturnDocument: &isReturnNewDoc,
+	}
+	data := entities.Shift{}
+	err = entity.repo.FindOneAndUpdate(ctx, bson.M{"_id": objId, "status": constant.ShiftStatusOpen}, bson.M{
+		"$push": bson.M{"cashMovements": movement},
+		"$set": bson.M{
+			"updatedBy":   form.CreatedBy,
+			"updatedDate": time.Now(),
+		},
+	}, opts).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *shiftEntity) CloseShiftById(id string, form request.CloseShift) (*entities.Shift, error) {
+	logrus.Info("CloseShiftById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var totalVariance float64
+	summary := make([]entities.ShiftPaymentSummary, len(form.Summary))
+	for i, line := range form.Summary {
+		summary[i] = entities.ShiftPaymentSummary{
+			Type:     line.Type,
+			Sales:    line.Sales,
+			Receipts: line.Receipts,
+			Refunds:  line.Refunds,
+			Expected: line.Expected,
+			Counted:  line.Counted,
+			Variance: line.Variance,
+		}
+		totalVariance += line.Variance
+	}
+	isReturnNewDoc := options.After
+	opts := &options.FindOneAndUpdateOptions{
+		ReturnDocument: &isReturnNewDoc,
+	}
+	now := time.Now()
+	data := entities.Shift{}
+	err = entity.repo.FindOneAndUpdate(ctx, bson.M{"_id": objId, "status": constant.ShiftStatusOpen}, bson.M{
+		"$set": bson.M{
+			"status":        constant.ShiftStatusClosed,
+			"summary":       summary,
+			"totalVariance": math.Round(totalVariance*100) / 100,
+			"closeNote":     form.Note,
+			"closedBy":      form.ClosedBy,
+			"closedDate":    now,
+			"updatedBy":     form.ClosedBy,
+			"updatedDate":   now,
+		},
+	}, opts).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
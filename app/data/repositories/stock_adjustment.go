@@ -0,0 +1,221 @@
+package repositories
+
+import (
+	"context"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+	"pos/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type stockAdjustmentEntity struct {
+	repo *mongo.Collection
+}
+
+type IStockAdjustment interface {
+	GetStockAdjustmentRange(form request.GetStockAdjustmentRange) ([]entities.StockAdjustment, error)
+	GetStockAdjustmentById(id string) (*entities.StockAdjustment, error)
+	RejectStockAdjustmentById(id string, form request.RejectStockAdjustment) (*entities.StockAdjustment, error)
+
+	// Transactional, ctx must come from ITransaction.WithTransaction
+	CreateStockAdjustmentTx(ctx context.Context, form request.StockAdjustment) (*entities.StockAdjustment, error)
+	ApproveStockAdjustmentTx(ctx context.Context, id string, approvedBy string) (*entities.StockAdjustment, error)
+	UpdateStockAdjustmentCostTx(ctx context.Context, id string, items []entities.StockAdjustmentItem, totalCost float64) error
+}
+
+func NewStockAdjustmentEntity(resource *db.Resource) IStockAdjustment {
+	repo := resource.PosDb.Collection("stock_adjustments")
+	entity := &stockAdjustmentEntity{repo: repo}
+	ensureStockAdjustmentIndexes(repo)
+	return entity
+}
+
+func ensureStockAdjustmentIndexes(repo *mongo.Collection) {
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	_, err := repo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "branchId", Value: 1}, {Key: "createdDate", Value: -1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create stock_adjustments branchId index: ", err)
+	}
+}
+
+func (entity *stockAdjustmentEntity) CreateStockAdjustmentTx(ctx context.Context, form request.StockAdjustment) (*entities.StockAdjustment, error) {
+	logrus.Info("CreateStockAdjustment")
+	branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+
+	items := make([]entities.StockAdjustmentItem, len(form.Items))
+	for i, item := range form.Items {
+		productId, _ := primitive.ObjectIDFromHex(item.ProductId)
+		unitId, _ := primitive.ObjectIDFromHex(item.UnitId)
+		items[i] = entities.StockAdjustmentItem{
+			ProductId:  productId,
+			UnitId:     unitId,
+			StockId:    item.StockId,
+			LotNumber:  item.LotNumber,
+			Quantity:   item.Quantity,
+			CostPrice:  item.CostPrice,
+			CostAmount: item.CostAmount,
+		}
+	}
+
+	now := time.Now()
+	data := entities.StockAdjustment{
+		Id:          primitive.NewObjectID(),
+		BranchId:    branchId,
+		Code:        form.Code,
+		Reason:      form.Reason,
+		Note:        form.Note,
+		Items:       items,
+		TotalCost:   form.TotalCost,
+		Status:      form.Status,
+		StocktakeId: form.StocktakeId,
+		CreatedBy:   form.CreatedBy,
+		CreatedDate: now,
+		UpdatedBy:   form.CreatedBy,
+		UpdatedDate: now,
+	}
+	if form.Status == constant.StockAdjustmentStatusApproved {
+		data.ApprovedBy = form.CreatedBy
+		data.ApprovedDate = &now
+	}
+	_, err := entity.repo.InsertOne(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// ApproveStockAdjustmentTx approves a pending adjustment, it returns mongo.ErrNoDocuments when the
+// adjustment was already approved or rejected
+func (entity *stockAdjustmentEntity) ApproveStockAdjustmentTx(ctx context.Context, id string, approvedBy string) (*entities.StockAdjustment, error) {
+	logrus.Info("ApproveStockAdjustment")
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	isReturnNewDoc := options.After
+	opts := &options.FindOneAndUpdateOptions{
+		ReturnDocument: &isReturnNewDoc,
+	}
+	now := time.Now()
+	data := entities.StockAdjustment{}
+	err = entity.repo.FindOneAndUpdate(ctx, bson.M{"_id": objId, "status": constant.StockAdjustmentStatusPending}, bson.M{
+		"$set": bson.M{
+			"status":       constant.StockAdjustmentStatusApproved,
+			"approvedBy":   approvedBy,
+			"approvedDate": now,
+			"updatedBy":    approvedBy,
+			"updatedDate":  now,
+		},
+	}, opts).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// UpdateStockAdjustmentCostTx stores the line costs of an adjustment valued when its stock moved
+func (entity *stockAdjustmentEntity) UpdateStockAdjustmentCostTx(ctx context.Context, id string, items []entities.StockAdjustmentItem, totalCost float64) error {
+	logrus.Info("UpdateStockAdjustmentCost")
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	_, err = entity.repo.UpdateOne(ctx, bson.M{"_id": objId}, bson.M{
+		"$set": bson.M{
+			"items":     items,
+			"totalCost": totalCost,
+		},
+	})
+	return err
+}
+
+// RejectStockAdjustmentById rejects a pending adjustment, it returns mongo.ErrNoDocuments when the
+// adjustment was already approved or rejected
+func (entity *stockAdjustmentEntity) RejectStockAdjustmentById(id string, form request.RejectStockAdjustment) (*entities.StockAdjustment, error) {
+	logrus.Info("RejectStockAdjustmentById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	isReturnNewDoc := options.After
+	opts := &options.FindOneAndUpdateOptions{
+		ReturnDocument: &isReturnNewDoc,
+	}
+	data := entities.StockAdjustment{}
+	err = entity.repo.FindOneAndUpdate(ctx, bson.M{"_id": objId, "status": constant.StockAdjustmentStatusPending}, bson.M{
+		"$set": bson.M{
+			"status":       constant.StockAdjustmentStatusRejected,
+			"rejectReason": form.Reason,
+			"updatedBy":    form.UpdatedBy,
+			"updatedDate":  time.Now(),
+		},
+	}, opts).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *stockAdjustmentEntity) GetStockAdjustmentRange(form request.GetStockAdjustmentRange) ([]entities.StockAdjustment, error) {
+	logrus.Info("GetStockAdjustmentRange")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+
+	filter := bson.M{
+		"createdDate": bson.M{
+			"$gt": form.StartDate,
+			"$lt": form.EndDate,
+		},
+	}
+	if form.BranchId != "" {
+		branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+		filter["branchId"] = branchId
+	}
+	if form.Reason != "" {
+		filter["reason"] = form.Reason
+	}
+	if form.Status != "" {
+		filter["status"] = form.Status
+	}
+	opts := options.Find().SetSort(bson.M{"createdDate": -1})
+	cursor, err := entity.repo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.StockAdjustment{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (entity *stockAdjustmentEntity) GetStockAdjustmentById(id string) (*entities.StockAdjustment, error) {
+	logrus.Info("GetStockAdjustmentById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	data := entities.StockAdjustment{}
+	err = entity.repo.FindOne(ctx, bson.M{"_id": objId}).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"pos/app/core/utils"
 	"pos/app/data/entities"
 	"pos/app/domain/request"
@@ -19,10 +20,12 @@ type stockTransferEntity struct {
 }
 
 type IStockTransfer interface {
-	CreateStockTransfer(form request.StockTransfer) (*entities.StockTransfer, error)
 	GetStockTransfers(branchId string) ([]entities.StockTransfer, error)
 	GetStockTransferById(id string) (*entities.StockTransfer, error)
-	UpdateStockTransferStatus(id string, form request.UpdateStockTransfer) (*entities.StockTransfer, error)
+
+	// Transactional, ctx must come from ITransaction.WithTransaction
+	CreateStockTransferTx(ctx context.Context, form request.StockTransfer) (*entities.StockTransfer, error)
+	UpdateStockTransferStatusTx(ctx context.Context, id string, form request.UpdateStockTransfer) (*entities.StockTransfer, error)
 }
 
 func NewStockTransferEntity(resource *db.Resource) IStockTransfer {
@@ -49,11 +52,8 @@ func ensureStockTransferIndexes(repo *mongo.Collection) {
 	}
 }
 
-func (entity *stockTransferEntity) CreateStockTransfer(form request.StockTransfer) (*entities.StockTransfer, error) {
+func (entity *stockTransferEntity) CreateStockTransferTx(ctx context.Context, form request.StockTransfer) (*entities.StockTransfer, error) {
 	logrus.Info("CreateStockTransfer")
-	ctx, cancel := utils.InitContext()
-	defer cancel()
-
 	fromBranchId, _ := primitive.ObjectIDFromHex(form.FromBranchId)
 	toBranchId, _ := primitive.ObjectIDFromHex(form.ToBranchId)
 
@@ -130,10 +130,10 @@ func (entity *stockTransferEntity) GetStockTransferById(id string) (*entities.St
 	return &data, nil
 }
 
-func (entity *stockTransferEntity) UpdateStockTransferStatus(id string, form request.UpdateStockTransfer) (*entities.StockTransfer, error) {
+// UpdateStockTransferStatusTx settles a pending transfer, it returns mongo.ErrNoDocuments when the
+// transfer was already approved or rejected
+func (entity *stockTransferEntity) UpdateStockTransferStatusTx(ctx context.Context, id string, form request.UpdateStockTransfer) (*entities.StockTransfer, error) {
 	logrus.Info("UpdateStockTransferStatus")
-	ctx, cancel := utils.InitContext()
-	defer cancel()
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,296 @@
+package repositories
+
+import (
+	"context"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+	"pos/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type stocktakeEntity struct {
+	stocktakeRepo      *mongo.Collection
+	stocktakeCountRepo *mongo.Collection
+}
+
+type IStocktake interface {
+	CreateStocktake(form request.Stocktake) (*entities.Stocktake, error)
+	GetStocktakeRange(form request.GetStocktakeRange) ([]entities.Stocktake, error)
+	GetStocktakeById(id string) (*entities.Stocktake, error)
+	CancelStocktakeById(id string, userId string) (*entities.Stocktake, error)
+
+	// Counts
+	SetStocktakeCount(form request.StocktakeCount) error
+	AddStocktakeCount(form request.StocktakeCount) (*entities.StocktakeCount, error)
+	GetStocktakeCounts(stocktakeId string) ([]entities.StocktakeCount, error)
+
+	// Transactional, ctx must come from ITransaction.WithTransaction
+	PostStocktakeTx(ctx context.Context, id string, form request.PostStocktake) (*entities.Stocktake, error)
+}
+
+func NewStocktakeEntity(resource *db.Resource) IStocktake {
+	stocktakeRepo := resource.PosDb.Collection("stocktakes")
+	stocktakeCountRepo := resource.PosDb.Collection("stocktake_counts")
+	entity := &stocktakeEntity{
+		stocktakeRepo:      stocktakeRepo,
+		stocktakeCountRepo: stocktakeCountRepo,
+	}
+	ensureStocktakeIndexes(stocktakeRepo, stocktakeCountRepo)
+	return entity
+}
+
+func ensureStocktakeIndexes(stocktakeRepo *mongo.Collection, stocktakeCountRepo *mongo.Collection) {
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	_, err := stocktakeRepo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "branchId", Value: 1}, {Key: "createdDate", Value: -1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create stocktakes branchId index: ", err)
+	}
+	// Each counter keeps one count per lot, so counters can count at the same time
+	_, err = stocktakeCountRepo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "stocktakeId", Value: 1}, {Key: "stockId", Value: 1}, {Key: "countedBy", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		logrus.Error("failed to create stocktake_counts stocktakeId index: ", err)
+	}
+}
+
+func (entity *stocktakeEntity) CreateStocktake(form request.Stocktake) (*entities.Stocktake, error) {
+	logrus.Info("CreateStocktake")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+
+	lines := make([]entities.StocktakeLine, len(form.Stocks))
+	for i, stock := range form.Stocks {
+		lines[i] = entities.StocktakeLine{
+			StockId:        stock.Id.Hex(),
+			ProductId:      stock.ProductId,
+			UnitId:         stock.UnitId,
+			ProductName:    stock.ProductName,
+			SerialNumber:   stock.SerialNumber,
+			Unit:           stock.Unit,
+			LotNumber:      stock.LotNumber,
+			ExpireDate:     stock.ExpireDate,
+			CostPrice:      stock.CostPrice,
+			SystemQuantity: stock.Quantity,
+		}
+	}
+
+	now := time.Now()
+	data := entities.Stocktake{
+		Id:          primitive.NewObjectID(),
+		BranchId:    branchId,
+		Code:        form.Code,
+		Scope:       form.Scope,
+		Category:    form.Category,
+		Blind:       form.Blind,
+		Note:        form.Note,
+		Status:      constant.StocktakeStatusCounting,
+		Lines:       lines,
+		CreatedBy:   form.CreatedBy,
+		CreatedDate: now,
+		UpdatedBy:   form.CreatedBy,
+		UpdatedDate: now,
+	}
+	data.ApplyCounts(nil, false)
+	_, err := entity.stocktakeRepo.InsertOne(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetStocktakeRange lists the stocktakes of a period without their lines
+func (entity *stocktakeEntity) GetStocktakeRange(form request.GetStocktakeRange) ([]entities.Stocktake, error) {
+	logrus.Info("GetStocktakeRange")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+
+	filter := bson.M{
+		"createdDate": bson.M{
+			"$gt": form.StartDate,
+			"$lt": form.EndDate,
+		},
+	}
+	if form.BranchId != "" {
+		branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+		filter["branchId"] = branchId
+	}
+	if form.Status != "" {
+		filter["status"] = form.Status
+	}
+	opts := options.Find().SetSort(bson.M{"createdDate": -1}).SetProjection(bson.M{"lines": 0})
+	cursor, err := entity.stocktakeRepo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.Stocktake{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (entity *stocktakeEntity) GetStocktakeById(id string) (*entities.Stocktake, error) {
+	logrus.Info("GetStocktakeById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	data := entities.Stocktake{}
+	err = entity.stocktakeRepo.FindOne(ctx, bson.M{"_id": objId}).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// CancelStocktakeById drops a stocktake that is still counting, it returns mongo.ErrNoDocuments when
+// the stocktake was already posted or cancelled
+func (entity *stocktakeEntity) CancelStocktakeById(id string, userId string) (*entities.Stocktake, error) {
+	logrus.Info("CancelStocktakeById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	isReturnNewDoc := options.After
+	opts := &options.FindOneAndUpdateOptions{
+		ReturnDocument: &isReturnNewDoc,
+	}
+	data := entities.Stocktake{}
+	err = entity.stocktakeRepo.FindOneAndUpdate(ctx, bson.M{"_id": objId, "status": constant.StocktakeStatusCounting}, bson.M{
+		"$set": bson.M{
+			"status":      constant.StocktakeStatusCancelled,
+			"updatedBy":   userId,
+			"updatedDate": time.Now(),
+		},
+	}, opts).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// SetStocktakeCount replaces what the counter counted of the lot
+func (entity *stocktakeEntity) SetStocktakeCount(form request.StocktakeCount) error {
+	logrus.Info("SetStocktakeCount")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	stocktakeId, err := primitive.ObjectIDFromHex(form.StocktakeId)
+	if err != nil {
+		return err
+	}
+	_, err = entity.stocktakeCountRepo.UpdateOne(ctx, bson.M{
+		"stocktakeId": stocktakeId,
+		"stockId":     form.StockId,
+		"countedBy":   form.CountedBy,
+	}, bson.M{
+		"$set":         bson.M{"quantity": form.Quantity, "countedDate": time.Now()},
+		"$setOnInsert": bson.M{"_id": primitive.NewObjectID()},
+	}, options.Update().SetUpsert(true))
+	return err
+}
+
+// AddStocktakeCount adds a scan to what the counter counted of the lot
+func (entity *stocktakeEntity) AddStocktakeCount(form request.StocktakeCount) (*entities.StocktakeCount, error) {
+	logrus.Info("AddStocktakeCount")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	stocktakeId, err := primitive.ObjectIDFromHex(form.StocktakeId)
+	if err != nil {
+		return nil, err
+	}
+
+	isReturnNewDoc := options.After
+	opts := &options.FindOneAndUpdateOptions{
+		ReturnDocument: &isReturnNewDoc,
+		Upsert:         boolPtr(true),
+	}
+	data := entities.StocktakeCount{}
+	err = entity.stocktakeCountRepo.FindOneAndUpdate(ctx, bson.M{
+		"stocktakeId": stocktakeId,
+		"stockId":     form.StockId,
+		"countedBy":   form.CountedBy,
+	}, bson.M{
+		"$inc":         bson.M{"quantity": form.Quantity},
+		"$set":         bson.M{"countedDate": time.Now()},
+		"$setOnInsert": bson.M{"_id": primitive.NewObjectID()},
+	}, opts).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *stocktakeEntity) GetStocktakeCounts(stocktakeId string) ([]entities.StocktakeCount, error) {
+	logrus.Info("GetStocktakeCounts")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(stocktakeId)
+	if err != nil {
+		return nil, err
+	}
+	opts := options.Find().SetSort(bson.M{"countedDate": 1})
+	cursor, err := entity.stocktakeCountRepo.Find(ctx, bson.M{"stocktakeId": objId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.StocktakeCount{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// PostStocktakeTx writes the counted lines into a stocktake that is still counting, it returns
+// mongo.ErrNoDocuments when the stocktake was already posted or cancelled
+func (entity *stocktakeEntity) PostStocktakeTx(ctx context.Context, id string, form request.PostStocktake) (*entities.Stocktake, error) {
+	logrus.Info("PostStocktake")
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	isReturnNewDoc := options.After
+	opts := &options.FindOneAndUpdateOptions{
+		ReturnDocument: &isReturnNewDoc,
+	}
+	now := time.Now()
+	data := entities.Stocktake{}
+	err = entity.stocktakeRepo.FindOneAndUpdate(ctx, bson.M{"_id": objId, "status": constant.StocktakeStatusCounting}, bson.M{
+		"$set": bson.M{
+			"status":          constant.StocktakeStatusPosted,
+			"lines":           form.Lines,
+			"systemCost":      form.SystemCost,
+			"countedCost":     form.CountedCost,
+			"varianceCost":    form.VarianceCost,
+			"uncountedLines":  form.UncountedLines,
+			"adjustmentCodes": form.AdjustmentCodes,
+			"postedBy":        form.PostedBy,
+			"postedDate":      now,
+			"updatedBy":       form.PostedBy,
+			"updatedDate":     now,
+		},
+	}, opts).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
@@ -124,6 +124,7 @@ func (entity *supplierEntity) CreateOrUpdateSupplierByClientId(id string, form r
 			"address":     form.Address,
 			"phone":       form.Phone,
 			"taxId":       form.TaxId,
+			"branchNo":    form.BranchNo,
 			"updatedBy":   form.UpdatedBy,
 			"updatedDate": now,
 		},
@@ -150,6 +151,7 @@ func (entity *supplierEntity) CreateSupplier(form request.Supplier) (*entities.S
 	data.Address = form.Address
 	data.Phone = form.Phone
 	data.TaxId = form.TaxId
+	data.BranchNo = form.BranchNo
 	data.CreatedBy = form.UpdatedBy
 	data.CreatedDate = time.Now()
 	data.UpdatedBy = form.UpdatedBy
@@ -180,6 +182,7 @@ func (entity *supplierEntity) UpdateSupplierById(id string, form request.Supplie
 		"address":     form.Address,
 		"phone":       form.Phone,
 		"taxId":       form.TaxId,
+		"branchNo":    form.BranchNo,
 		"updatedBy":   form.UpdatedBy,
 		"updatedDate": time.Now(),
 	}}, opts).Decode(&data)
@@ -0,0 +1,335 @@
+package repositories
+
+import (
+	"context"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+	"pos/db"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type taxInvoiceEntity struct {
+	repo *mongo.Collection
+}
+
+type ITaxInvoice interface {
+	GetTaxInvoiceRange(form request.GetTaxInvoiceRange) ([]entities.TaxInvoice, error)
+	GetCancelledTaxInvoiceRange(form request.GetTaxInvoiceRange) ([]entities.TaxInvoice, error)
+	GetTaxInvoiceById(id string) (*entities.TaxInvoice, error)
+	GetTaxInvoicesByOrderId(orderId string) ([]entities.TaxInvoice, error)
+	AddTaxInvoicePrintById(id string) (*entities.TaxInvoice, error)
+	AddTaxInvoiceReissueById(id string, form request.ReissueTaxInvoice) (*entities.TaxInvoice, error)
+
+	// Transactional, ctx must come from ITransaction.WithTransaction
+	CreateTaxInvoiceTx(ctx context.Context, form request.TaxInvoice) (*entities.TaxInvoice, error)
+	ReplaceTaxInvoiceTx(ctx context.Context, form request.TaxInvoice) (*entities.TaxInvoice, error)
+	CancelTaxInvoicesByOrderIdTx(ctx context.Context, orderId string, reason string, userId string) error
+}
+
+func NewTaxInvoiceEntity(resource *db.Resource) ITaxInvoice {
+	repo := resource.PosDb.Collection("tax_invoices")
+	entity := &taxInvoiceEntity{repo: repo}
+	ensureTaxInvoiceIndexes(repo)
+	return entity
+}
+
+func ensureTaxInvoiceIndexes(repo *mongo.Collection) {
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	_, err := repo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "branchId", Value: 1}, {Key: "type", Value: 1}, {Key: "code", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		logrus.Error("failed to create tax_invoices code index: ", err)
+	}
+	// An order is covered by at most one tax invoice in force
+	_, err = repo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "orderId", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"status": constant.TaxInvoiceStatusIssued}),
+	})
+	if err != nil {
+		logrus.Error("failed to create tax_invoices issued order index: ", err)
+	}
+	_, err = repo.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "branchId", Value: 1}, {Key: "issuedDate", Value: -1}},
+	})
+	if err != nil {
+		logrus.Error("failed to create tax_invoices branchId index: ", err)
+	}
+}
+
+func toEntityTaxInvoiceParty(party *request.TaxInvoiceParty) *entities.TaxInvoiceParty {
+	if party == nil {
+		return nil
+	}
+	return &entities.TaxInvoiceParty{
+		Name:     party.Name,
+		Address:  party.Address,
+		TaxId:    party.TaxId,
+		BranchNo: party.BranchNo,
+	}
+}
+
+func toEntityTaxInvoice(form request.TaxInvoice) entities.TaxInvoice {
+	branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+	orderId, _ := primitive.ObjectIDFromHex(form.OrderId)
+	items := make([]entities.TaxInvoiceItem, len(form.Items))
+	for i, item := range form.Items {
+		orderItemId, _ := primitive.ObjectIDFromHex(item.OrderItemId)
+		productId, _ := primitive.ObjectIDFromHex(item.ProductId)
+		items[i] = entities.TaxInvoiceItem{
+			OrderItemId: orderItemId,
+			ProductId:   productId,
+			Name:        item.Name,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Discount:    item.Discount,
+			Amount:      item.Amount,
+			VatRate:     item.VatRate,
+			VatExempt:   item.VatExempt,
+		}
+	}
+	data := entities.TaxInvoice{
+		Id:          primitive.NewObjectID(),
+		BranchId:    branchId,
+		Type:        form.Type,
+		Code:        form.Code,
+		OrderId:     orderId,
+		OrderCode:   form.OrderCode,
+		Status:      constant.TaxInvoiceStatusIssued,
+		Seller:      *toEntityTaxInvoiceParty(&form.Seller),
+		Buyer:       toEntityTaxInvoiceParty(form.Buyer),
+		Items:       items,
+		Discount:    form.Discount,
+		Reissues:    []entities.TaxInvoiceReissue{},
+		IssuedDate:  time.Now(),
+		CreatedBy:   form.CreatedBy,
+		CreatedDate: time.Now(),
+		UpdatedBy:   form.CreatedBy,
+		UpdatedDate: time.Now(),
+	}
+	if form.ReplacesId != "" {
+		replacesId, _ := primitive.ObjectIDFromHex(form.ReplacesId)
+		data.ReplacesId = &replacesId
+		data.ReplacesCode = form.ReplacesCode
+	}
+	data.ComputeVat()
+	return data
+}
+
+func (entity *taxInvoiceEntity) CreateTaxInvoiceTx(ctx context.Context, form request.TaxInvoice) (*entities.TaxInvoice, error) {
+	logrus.Info("CreateTaxInvoice")
+	data := toEntityTaxInvoice(form)
+	_, err := entity.repo.InsertOne(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// ReplaceTaxInvoiceTx issues the invoice in form in place of form.ReplacesId, which must still be in force
+func (entity *taxInvoiceEntity) ReplaceTaxInvoiceTx(ctx context.Context, form request.TaxInvoice) (*entities.TaxInvoice, error) {
+	logrus.Info("ReplaceTaxInvoice")
+	data := toEntityTaxInvoice(form)
+	// The old invoice is taken out of force first so the new one passes the issued order index
+	result, err := entity.repo.UpdateOne(ctx, bson.M{"_id": data.ReplacesId, "status": constant.TaxInvoiceStatusIssued}, bson.M{
+		"$set": bson.M{
+			"status":         constant.TaxInvoiceStatusReplaced,
+			"replacedById":   data.Id,
+			"replacedByCode": data.Code,
+			"updatedBy":      form.CreatedBy,
+			"updatedDate":    time.Now(),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	_, err = entity.repo.InsertOne(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *taxInvoiceEntity) CancelTaxInvoicesByOrderIdTx(ctx context.Context, orderId string, reason string, userId string) error {
+	logrus.Info("CancelTaxInvoicesByOrderId")
+	objId, err := primitive.ObjectIDFromHex(orderId)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	_, err = entity.repo.UpdateMany(ctx, bson.M{"orderId": objId, "status": constant.TaxInvoiceStatusIssued}, bson.M{
+		"$set": bson.M{
+			"status":        constant.TaxInvoiceStatusCancelled,
+			"cancelReason":  reason,
+			"cancelledDate": now,
+			"updatedBy":     userId,
+			"updatedDate":   now,
+		},
+	})
+	return err
+}
+
+func (entity *taxInvoiceEntity) GetTaxInvoiceRange(form request.GetTaxInvoiceRange) ([]entities.TaxInvoice, error) {
+	logrus.Info("GetTaxInvoiceRange")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	filter := bson.M{
+		"issuedDate": bson.M{
+			"$gt": form.StartDate,
+			"$lt": form.EndDate,
+		},
+	}
+	if form.BranchId != "" {
+		branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+		filter["branchId"] = branchId
+	}
+	if form.Type != "" {
+		filter["type"] = form.Type
+	}
+	if form.Status != "" {
+		filter["status"] = form.Status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "type", Value: 1}, {Key: "code", Value: 1}})
+	cursor, err := entity.repo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.TaxInvoice{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetCancelledTaxInvoiceRange returns the tax invoices cancelled in the range, whenever they were issued
+func (entity *taxInvoiceEntity) GetCancelledTaxInvoiceRange(form request.GetTaxInvoiceRange) ([]entities.TaxInvoice, error) {
+	logrus.Info("GetCancelledTaxInvoiceRange")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	filter := bson.M{
+		"status": constant.TaxInvoiceStatusCancelled,
+		"cancelledDate": bson.M{
+			"$gt": form.StartDate,
+			"$lt": form.EndDate,
+		},
+	}
+	if form.BranchId != "" {
+		branchId, _ := primitive.ObjectIDFromHex(form.BranchId)
+		filter["branchId"] = branchId
+	}
+	opts := options.Find().SetSort(bson.M{"cancelledDate": 1})
+	cursor, err := entity.repo.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.TaxInvoice{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (entity *taxInvoiceEntity) GetTaxInvoiceById(id string) (*entities.TaxInvoice, error) {
+	logrus.Info("GetTaxInvoiceById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	data := entities.TaxInvoice{}
+	err = entity.repo.FindOne(ctx, bson.M{"_id": objId}).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *taxInvoiceEntity) GetTaxInvoicesByOrderId(orderId string) ([]entities.TaxInvoice, error) {
+	logrus.Info("GetTaxInvoicesByOrderId")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(orderId)
+	if err != nil {
+		return nil, err
+	}
+	opts := options.Find().SetSort(bson.M{"issuedDate": 1})
+	cursor, err := entity.repo.Find(ctx, bson.M{"orderId": objId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.TaxInvoice{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// AddTaxInvoicePrintById counts a print and returns the invoice with the new count,
+// a count of 1 is the original and anything after it a copy
+func (entity *taxInvoiceEntity) AddTaxInvoicePrintById(id string) (*entities.TaxInvoice, error) {
+	logrus.Info("AddTaxInvoicePrintById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	isReturnNewDoc := options.After
+	opts := &options.FindOneAndUpdateOptions{
+		ReturnDocument: &isReturnNewDoc,
+	}
+	data := entities.TaxInvoice{}
+	err = entity.repo.FindOneAndUpdate(ctx, bson.M{"_id": objId}, bson.M{
+		"$inc": bson.M{"printCount": 1},
+	}, opts).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (entity *taxInvoiceEntity) AddTaxInvoiceReissueById(id string, form request.ReissueTaxInvoice) (*entities.TaxInvoice, error) {
+	logrus.Info("AddTaxInvoiceReissueById")
+	ctx, cancel := utils.InitContext()
+	defer cancel()
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	reissue := entities.TaxInvoiceReissue{
+		Reason:       form.Reason,
+		ReissuedBy:   form.ReissuedBy,
+		ReissuedDate: time.Now(),
+	}
+	isReturnNewDoc := options.After
+	opts := &options.FindOneAndUpdateOptions{
+		ReturnDocument: &isReturnNewDoc,
+	}
+	data := entities.TaxInvoice{}
+	err = entity.repo.FindOneAndUpdate(ctx, bson.M{"_id": objId, "status": constant.TaxInvoiceStatusIssued}, bson.M{
+		"$push": bson.M{"reissues": reissue},
+		"$set": bson.M{
+			"updatedBy":   form.ReissuedBy,
+			"updatedDate": time.Now(),
+		},
+	}, opts).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"context"
+	"pos/db"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type transactionEntity struct {
+	resource *db.Resource
+}
+
+type ITransaction interface {
+	WithTransaction(fn func(ctx context.Context) error) error
+}
+
+func NewTransactionEntity(resource *db.Resource) ITransaction {
+	entity := &transactionEntity{resource: resource}
+	return entity
+}
+
+// WithTransaction commits every write made through ctx only when fn returns nil.
+// fn may be retried on transient errors, so it must not keep state between attempts.
+func (entity *transactionEntity) WithTransaction(fn func(ctx context.Context) error) error {
+	logrus.Info("WithTransaction")
+	return entity.resource.WithTransaction(func(ctx mongo.SessionContext) error {
+		return fn(ctx)
+	})
+}
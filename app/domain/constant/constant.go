@@ -13,6 +13,55 @@ const (
 	PaymentTypeTransfer  = "TRANSFER"
 )
 
+func PaymentTypes() []string {
+	return []string{PaymentTypeCash, PaymentTypeCredit, PaymentTypePromptPay, PaymentTypeTransfer}
+}
+
+const (
+	DrugTypeOTC        = "OTC"
+	DrugTypeDangerous  = "DANGEROUS"
+	DrugTypeControlled = "CONTROLLED"
+	DrugTypePsycho     = "PSYCHO"
+	DrugTypeNarcotic   = "NARCOTIC"
+)
+
+// ControlledDrugTypes are the drug types whose sale must record the pharmacist, prescriber and buyer
+func ControlledDrugTypes() []string {
+	return []string{DrugTypeDangerous, DrugTypeControlled, DrugTypePsycho, DrugTypeNarcotic}
+}
+
+const (
+	CashMovementIn  = "IN"
+	CashMovementOut = "OUT"
+)
+
+const (
+	TaxInvoiceTypeAbbreviated = "ABBREVIATED"
+	TaxInvoiceTypeFull        = "FULL"
+)
+
+// Sources of an e-Tax document
+const (
+	EtaxSourceTaxInvoice = "TAX_INVOICE"
+	EtaxSourceCreditNote = "CREDIT_NOTE"
+)
+
+// DefaultVatRate is the Thai VAT rate in percent, used when the branch does not set one
+const DefaultVatRate = 7.0
+
+// HeadOfficeBranchNo is the Revenue Department branch number of the head office
+const HeadOfficeBranchNo = "00000"
+
+const (
+	ReceiptPaperA4   = "A4"
+	ReceiptPaper80mm = "80MM"
+	ReceiptPaper58mm = "58MM"
+)
+
+// DefaultReceiptCodePage selects Thai character code 11 (TIS-620) with ESC t on Epson
+// compatible printers
+const DefaultReceiptCodePage = 21
+
 func CustomerTypes() []string {
 	return []string{CustomerTypeGeneral, CustomerTypeWholesaler, CustomerTypeRegular}
 }
@@ -32,4 +81,136 @@ const (
 	HistoryTypeUpdateProductStockQuantity = "UpdateProductStockQuantity"
 	HistoryTypeAddOrderItemProduct        = "AddOrderItemProduct"
 	HistoryTypeRemoveOrderItemProduct     = "RemoveOrderItemProduct"
+	HistoryTypeReturnOrderItemProduct     = "ReturnOrderItemProduct"
+	HistoryTypeAdjustProductStock         = "AdjustProductStock"
+	HistoryTypeReturnProductStock         = "ReturnProductStock"
+	HistoryTypeUpdateProductStockStatus   = "UpdateProductStockStatus"
+	HistoryTypeDisposeProductStock        = "DisposeProductStock"
+)
+
+const (
+	AdjustmentReasonDamaged     = "DAMAGED"
+	AdjustmentReasonExpired     = "EXPIRED"
+	AdjustmentReasonLost        = "LOST"
+	AdjustmentReasonFound       = "FOUND"
+	AdjustmentReasonSample      = "SAMPLE"
+	AdjustmentReasonInternalUse = "INTERNAL_USE"
+)
+
+func AdjustmentReasons() []string {
+	return []string{AdjustmentReasonDamaged, AdjustmentReasonExpired, AdjustmentReasonLost, AdjustmentReasonFound, AdjustmentReasonSample, AdjustmentReasonInternalUse}
+}
+
+// AdjustmentReasonName returns the Thai name of an adjustment reason, as written in the product history
+func AdjustmentReasonName(reason string) string {
+	switch reason {
+	case AdjustmentReasonDamaged:
+		return "สินค้าชำรุด"
+	case AdjustmentReasonExpired:
+		return "ตัดสินค้าหมดอายุ"
+	case AdjustmentReasonLost:
+		return "สินค้าสูญหาย"
+	case AdjustmentReasonFound:
+		return "พบสินค้าเกิน"
+	case AdjustmentReasonSample:
+		return "สินค้าตัวอย่าง"
+	case AdjustmentReasonInternalUse:
+		return "เบิกใช้ภายใน"
+	}
+	return reason
+}
+
+// IsStockIncrease reports whether an adjustment with the reason adds to stock, every other reason takes stock out
+func IsStockIncrease(reason string) bool {
+	return reason == AdjustmentReasonFound
+}
+
+// Scopes of a stocktake, every lot of the branch, the lots of one category or the lots picked
+const (
+	StocktakeScopeAll      = "ALL"
+	StocktakeScopeCategory = "CATEGORY"
+	StocktakeScopeLots     = "LOTS"
+)
+
+// Where the contact of a recall comes from, the patient register, the customer of the order or the
+// buyer name written on a controlled drug sale
+const (
+	RecallContactPatient  = "PATIENT"
+	RecallContactCustomer = "CUSTOMER"
+	RecallContactBuyer    = "BUYER"
+)
+
+// Costing methods of a branch. Under FIFO every lot keeps the cost it came in at and a sale is costed
+// at the lots it consumes, under AVERAGE the sellable lots are repriced to the moving average whenever
+// stock comes in at another cost.
+const (
+	CostingMethodFIFO    = "FIFO"
+	CostingMethodAverage = "AVERAGE"
+)
+
+// Movements of the valuation ledger, Quantity and Amount of an entry are negative when stock leaves
+const (
+	ValuationTypeOpening        = "OPENING"
+	ValuationTypeReceive        = "RECEIVE"
+	ValuationTypeSale           = "SALE"
+	ValuationTypeVoid           = "VOID"
+	ValuationTypeReturn         = "RETURN"
+	ValuationTypeAdjustment     = "ADJUSTMENT"
+	ValuationTypeTransfer       = "TRANSFER"
+	ValuationTypeDisposal       = "DISPOSAL"
+	ValuationTypeSupplierReturn = "SUPPLIER_RETURN"
+	ValuationTypeManual         = "MANUAL"
+	ValuationTypeRevalue        = "REVALUE"
+)
+
+// How a disposal destroyed the stock, recorded for GPP inspections
+const (
+	DisposalMethodIncineration  = "INCINERATION"
+	DisposalMethodChemical      = "CHEMICAL"
+	DisposalMethodEncapsulation = "ENCAPSULATION"
+	DisposalMethodContractor    = "CONTRACTOR"
+	DisposalMethodOther         = "OTHER"
+)
+
+// DisposalMethodName returns the Thai name of a disposal method for documents
+func DisposalMethodName(method string) string {
+	switch method {
+	case DisposalMethodIncineration:
+		return "เผาทำลาย"
+	case DisposalMethodChemical:
+		return "ทำลายด้วยสารเคมี"
+	case DisposalMethodEncapsulation:
+		return "ห่อหุ้มและฝังกลบ"
+	case DisposalMethodContractor:
+		return "ส่งบริษัทรับกำจัด"
+	case DisposalMethodOther:
+		return "อื่น ๆ"
+	}
+	return method
+}
+
+const (
+	AllergySeverityMild     = "MILD"
+	AllergySeverityModerate = "MODERATE"
+	AllergySeveritySevere   = "SEVERE"
+)
+
+const (
+	InteractionSeverityMinor           = "MINOR"
+	InteractionSeverityModerate        = "MODERATE"
+	InteractionSeverityMajor           = "MAJOR"
+	InteractionSeverityContraindicated = "CONTRAINDICATED"
+	// InteractionSeverityUnrated marks interactions only found in the free text of a product, they warn
+	// without blocking the sale
+	InteractionSeverityUnrated = "UNRATED"
+)
+
+// InteractionSeverities are ordered from the most to the least severe
+func InteractionSeverities() []string {
+	return []string{InteractionSeverityContraindicated, InteractionSeverityMajor, InteractionSeverityModerate, InteractionSeverityMinor}
+}
+
+const (
+	ClinicalAlertAllergy     = "ALLERGY"
+	ClinicalAlertInteraction = "INTERACTION"
 )
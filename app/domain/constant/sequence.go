@@ -8,6 +8,18 @@ const (
 	BRANCH         = "BRANCH"
 	EMPLOYEE       = "EMPLOYEE"
 	STOCK_TRANSFER = "STOCK_TRANSFER"
+	CREDIT_NOTE    = "CREDIT_NOTE"
+	SHIFT          = "SHIFT"
+	RECEIPT        = "RECEIPT"
+	ADJUSTMENT     = "ADJUSTMENT"
+	STOCKTAKE      = "STOCKTAKE"
+	RECALL         = "RECALL"
+	RECALL_RETURN  = "RECALL_RETURN"
+	DISPOSAL       = "DISPOSAL"
+
+	// Tax invoices run a separate series per branch, see BranchSequence
+	TAX_INVOICE_ABB  = "TAX_INVOICE_ABB"
+	TAX_INVOICE_FULL = "TAX_INVOICE_FULL"
 )
 
 const (
@@ -16,3 +28,8 @@ const (
 	YEARLY  = "YEARLY"
 	NONE    = "NONE"
 )
+
+// BranchSequence names the running number of a document kept separately for each branch
+func BranchSequence(field string, branchId string) string {
+	return field + ":" + branchId
+}
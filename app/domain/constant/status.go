@@ -4,3 +4,70 @@ const (
 	ACTIVE   = "ACTIVE"
 	INACTIVE = "INACTIVE"
 )
+
+const (
+	StockStatusAvailable   = "AVAILABLE"
+	StockStatusQuarantined = "QUARANTINED"
+	StockStatusRecalled    = "RECALLED"
+	StockStatusExpired     = "EXPIRED"
+	StockStatusDamaged     = "DAMAGED"
+)
+
+// NonSellableStockStatuses keep a lot out of sales and out of the stock value, a lot without a status
+// is available
+func NonSellableStockStatuses() []string {
+	return []string{StockStatusQuarantined, StockStatusRecalled, StockStatusExpired, StockStatusDamaged}
+}
+
+func IsSellableStockStatus(status string) bool {
+	for _, s := range NonSellableStockStatuses() {
+		if s == status {
+			return false
+		}
+	}
+	return true
+}
+
+const (
+	OrderStatusVoided = "VOIDED"
+)
+
+const (
+	CartStatusOpen      = "OPEN"
+	CartStatusParked    = "PARKED"
+	CartStatusConverted = "CONVERTED"
+	CartStatusCancelled = "CANCELLED"
+)
+
+const (
+	ShiftStatusOpen   = "OPEN"
+	ShiftStatusClosed = "CLOSED"
+)
+
+const (
+	ReceivableStatusOpen   = "OPEN"
+	ReceivableStatusPaid   = "PAID"
+	ReceivableStatusVoided = "VOIDED"
+)
+
+const (
+	DispensingStatusVoided = "VOIDED"
+)
+
+const (
+	TaxInvoiceStatusIssued    = "ISSUED"
+	TaxInvoiceStatusReplaced  = "REPLACED"
+	TaxInvoiceStatusCancelled = "CANCELLED"
+)
+
+const (
+	StockAdjustmentStatusPending  = "PENDING"
+	StockAdjustmentStatusApproved = "APPROVED"
+	StockAdjustmentStatusRejected = "REJECTED"
+)
+
+const (
+	StocktakeStatusCounting  = "COUNTING"
+	StocktakeStatusPosted    = "POSTED"
+	StocktakeStatusCancelled = "CANCELLED"
+)
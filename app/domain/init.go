@@ -7,6 +7,8 @@ import (
 
 type Repository struct {
 	Session         repositories.ISession
+	Transaction     repositories.ITransaction
+	Idempotency     repositories.IIdempotency
 	Sequence        repositories.ISequence
 	Category        repositories.ICategory
 	Order           repositories.IOrder
@@ -22,11 +24,25 @@ type Repository struct {
 	Patient         repositories.IPatient
 	DispensingLog   repositories.IDispensingLog
 	StockTransfer   repositories.IStockTransfer
+	CreditNote      repositories.ICreditNote
+	Cart            repositories.ICart
+	Shift           repositories.IShift
+	Receivable      repositories.IReceivable
+	DrugInteraction repositories.IDrugInteraction
+	Ingredient      repositories.IIngredient
+	TaxInvoice      repositories.ITaxInvoice
+	EtaxDocument    repositories.IEtaxDocument
+	StockAdjustment repositories.IStockAdjustment
+	Stocktake       repositories.IStocktake
+	Recall          repositories.IRecall
+	Disposal        repositories.IDisposal
 }
 
 func InitRepository(resource *db.Resource) *Repository {
 	return &Repository{
 		Session:         repositories.NewSessionEntity(resource),
+		Transaction:     repositories.NewTransactionEntity(resource),
+		Idempotency:     repositories.NewIdempotencyEntity(resource),
 		Category:        repositories.NewCategoryEntity(resource),
 		Order:           repositories.NewOrderEntity(resource),
 		Sequence:        repositories.NewSequenceEntity(resource),
@@ -42,5 +58,17 @@ func InitRepository(resource *db.Resource) *Repository {
 		Patient:         repositories.NewPatientEntity(resource),
 		DispensingLog:   repositories.NewDispensingLogEntity(resource),
 		StockTransfer:   repositories.NewStockTransferEntity(resource),
+		CreditNote:      repositories.NewCreditNoteEntity(resource),
+		Cart:            repositories.NewCartEntity(resource),
+		Shift:           repositories.NewShiftEntity(resource),
+		Receivable:      repositories.NewReceivableEntity(resource),
+		DrugInteraction: repositories.NewDrugInteractionEntity(resource),
+		Ingredient:      repositories.NewIngredientEntity(resource),
+		TaxInvoice:      repositories.NewTaxInvoiceEntity(resource),
+		EtaxDocument:    repositories.NewEtaxDocumentEntity(resource),
+		StockAdjustment: repositories.NewStockAdjustmentEntity(resource),
+		Stocktake:       repositories.NewStocktakeEntity(resource),
+		Recall:          repositories.NewRecallEntity(resource),
+		Disposal:        repositories.NewDisposalEntity(resource),
 	}
 }
@@ -0,0 +1,28 @@
+package request
+
+type Cart struct {
+	Name          string     `json:"name"`
+	CustomerCode  string     `json:"customerCode"`
+	CustomerName  string     `json:"customerName"`
+	Discount      float64    `json:"discount"`
+	PromotionCode string     `json:"promotionCode"`
+	Note          string     `json:"note"`
+	Items         []CartItem `json:"items" binding:"dive"`
+	UserId        string
+	BranchId      string
+	UpdatedBy     string
+}
+
+type CartItem struct {
+	ProductId     string  `json:"productId" binding:"required"`
+	UnitId        string  `json:"unitId" binding:"required"`
+	Quantity      int     `json:"quantity" binding:"required,gt=0"`
+	Price         float64 `json:"price"`
+	Discount      float64 `json:"discount"`
+	PriceOverride bool    `json:"priceOverride"`
+	BaseQuantity  int
+}
+
+type TransferCart struct {
+	UserId string `json:"userId" binding:"required"`
+}
@@ -0,0 +1,49 @@
+package request
+
+import "time"
+
+type CreditNote struct {
+	OrderId      string           `json:"orderId" binding:"required"`
+	Reason       string           `json:"reason" binding:"required"`
+	Items        []CreditNoteItem `json:"items" binding:"required"`
+	Refunds      []CreditNoteRefund
+	Total        float64
+	TotalCost    float64
+	Code         string
+	OrderCode    string
+	CustomerCode string
+	CustomerName string
+	ShiftId      string
+	CreatedBy    string
+	BranchId     string
+}
+
+type CreditNoteItem struct {
+	OrderItemId string `json:"orderItemId" binding:"required"`
+	Quantity    int    `json:"quantity" binding:"required"`
+	Damaged     bool   `json:"damaged"`
+	ProductId   string
+	UnitId      string
+	Price       float64
+	CostPrice   float64
+	Stocks      []CreditNoteItemStock
+}
+
+type CreditNoteItemStock struct {
+	OrderStockId string
+	StockId      string
+	LotNumber    string
+	Quantity     int
+	Quarantined  bool
+}
+
+type CreditNoteRefund struct {
+	Type   string
+	Amount float64
+}
+
+type GetCreditNoteRange struct {
+	StartDate time.Time `form:"startDate" binding:"required"`
+	EndDate   time.Time `form:"endDate" binding:"required"`
+	BranchId  string
+}
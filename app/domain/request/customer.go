@@ -19,6 +19,12 @@ type UpdateCustomer struct {
 	UpdatedBy    string
 }
 
+type UpdateCustomerCredit struct {
+	CreditLimit float64 `json:"creditLimit" binding:"gte=0"`
+	CreditDays  int     `json:"creditDays" binding:"gte=0"`
+	UpdatedBy   string
+}
+
 type UpdateCustomerStatus struct {
 	Status    string `json:"status"`
 	UpdatedBy string
@@ -1,6 +1,7 @@
 package request
 
 type DispensingItem struct {
+	OrderItemId string
 	ProductId   string `json:"productId" binding:"required"`
 	ProductName string `json:"productName"`
 	GenericName string `json:"genericName"`
@@ -12,6 +13,7 @@ type DispensingItem struct {
 
 type DispensingLog struct {
 	OrderId        string           `json:"orderId" binding:"required"`
+	OrderCode      string
 	PatientId      string           `json:"patientId" binding:"required"`
 	Items          []DispensingItem `json:"items" binding:"required"`
 	PharmacistName string           `json:"pharmacistName" binding:"required"`
@@ -0,0 +1,37 @@
+package request
+
+import (
+	"pos/app/data/entities"
+	"time"
+)
+
+type Disposal struct {
+	Method       string            `json:"method" binding:"required,oneof=INCINERATION CHEMICAL ENCAPSULATION CONTRACTOR OTHER"`
+	Location     string            `json:"location" binding:"required"`
+	DisposedDate time.Time         `json:"disposedDate" binding:"required"`
+	Witnesses    []DisposalWitness `json:"witnesses" binding:"required,min=1,dive"`
+	Items        []DisposalItem    `json:"items" binding:"required,min=1,dive"`
+	Note         string            `json:"note"`
+	Code         string
+	Lots         []entities.DisposalItem
+	TotalCost    float64
+	BranchId     string
+	CreatedBy    string
+}
+
+type DisposalItem struct {
+	StockId  string `json:"stockId" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required,gt=0"`
+}
+
+type DisposalWitness struct {
+	Name      string `json:"name" binding:"required"`
+	Position  string `json:"position"`
+	LicenseNo string `json:"licenseNo"`
+}
+
+type GetDisposalRange struct {
+	StartDate time.Time `form:"startDate" binding:"required"`
+	EndDate   time.Time `form:"endDate" binding:"required"`
+	BranchId  string
+}
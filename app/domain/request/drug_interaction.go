@@ -0,0 +1,16 @@
+package request
+
+type DrugInteraction struct {
+	IngredientA string `json:"ingredientA" binding:"required"`
+	IngredientB string `json:"ingredientB" binding:"required"`
+	Severity    string `json:"severity" binding:"required,oneof=CONTRAINDICATED MAJOR MODERATE MINOR"`
+	Mechanism   string `json:"mechanism"`
+	Management  string `json:"management"`
+	Source      string `json:"source"`
+	UpdatedBy   string
+}
+
+type GetDrugInteractions struct {
+	Ingredient string `form:"ingredient"`
+	Severity   string `form:"severity"`
+}
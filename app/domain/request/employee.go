@@ -4,11 +4,15 @@ type Employee struct {
 	BranchId  string `json:"branchId" binding:"required"`
 	UserId    string `json:"userId" binding:"required"`
 	Role      string `json:"role" binding:"required"`
+	Name      string `json:"name"`
+	LicenseNo string `json:"licenseNo"`
 	CreatedBy string
 }
 
 type UpdateEmployee struct {
 	BranchId  string `json:"branchId" binding:"required"`
 	Role      string `json:"role" binding:"required"`
+	Name      string `json:"name"`
+	LicenseNo string `json:"licenseNo"`
 	UpdatedBy string
 }
@@ -0,0 +1,27 @@
+package request
+
+import "time"
+
+type EtaxDocument struct {
+	BranchId      string
+	SourceType    string
+	SourceId      string
+	TypeCode      string
+	Code          string
+	IssuedDate    time.Time
+	Total         float64
+	XmlDigest     string
+	SignerSubject string
+	SignerSerial  string
+	Xml           []byte
+	Pdf           []byte
+	CreatedBy     string
+	CreatedDate   time.Time
+}
+
+type GetEtaxDocumentRange struct {
+	StartDate  time.Time `form:"startDate" binding:"required"`
+	EndDate    time.Time `form:"endDate" binding:"required"`
+	SourceType string    `form:"sourceType" binding:"omitempty,oneof=TAX_INVOICE CREDIT_NOTE"`
+	BranchId   string
+}
@@ -0,0 +1,14 @@
+package request
+
+type Ingredient struct {
+	Name        string   `json:"name" binding:"required"`
+	Classes     []string `json:"classes"`
+	Unit        string   `json:"unit"`
+	Description string   `json:"description"`
+	UpdatedBy   string
+}
+
+type GetIngredients struct {
+	Keyword string `form:"keyword"`
+	Class   string `form:"class"`
+}
@@ -1,27 +1,46 @@
 package request
 
-import "time"
+import (
+	"pos/app/data/entities"
+	"time"
+)
 
 type Order struct {
-	Items          []OrderItem    `json:"items" binding:"required"`
-	Payments       []OrderPayment `json:"payments"`
-	Amount         float64        `json:"amount" binding:"required"`
-	Type           string         `json:"type" binding:"required"`
-	CustomerCode   string         `json:"customerCode"`
-	CustomerName   string         `json:"customerName"`
-	PatientId      string         `json:"patientId"`
-	PharmacistName string         `json:"pharmacistName"`
-	PrescriberName string         `json:"prescriberName"`
-	BuyerName      string         `json:"buyerName"`
-	BuyerIdCard    string         `json:"buyerIdCard"`
-	Total          float64        `json:"total" binding:"required"`
-	TotalCost      float64        `json:"totalCost"`
-	Discount       float64        `json:"discount"`
-	Change         float64        `json:"change"`
-	Message        string         `json:"message"`
-	CreatedBy      string
-	Code           string
-	BranchId       string
+	Items             []OrderItem       `json:"items" binding:"required,min=1,dive"`
+	Payments          []OrderPayment    `json:"payments"`
+	Amount            float64           `json:"amount" binding:"required"`
+	Type              string            `json:"type" binding:"required"`
+	CustomerCode      string            `json:"customerCode"`
+	CustomerName      string            `json:"customerName"`
+	PatientId         string            `json:"patientId"`
+	PharmacistId      string            `json:"pharmacistId"`
+	PharmacistName    string            `json:"pharmacistName"`
+	PrescriberName    string            `json:"prescriberName"`
+	BuyerName         string            `json:"buyerName"`
+	BuyerIdCard       string            `json:"buyerIdCard"`
+	Total             float64           `json:"total" binding:"required"`
+	Discount          float64           `json:"discount"`
+	PromotionCode     string            `json:"promotionCode"`
+	Change            float64           `json:"change"`
+	Message           string            `json:"message"`
+	CartId            string            `json:"cartId"`
+	ClinicalOverride  *ClinicalOverride `json:"clinicalOverride"`
+	CreatedBy         string
+	ShiftId           string
+	Code              string
+	BranchId          string
+	PromotionDiscount float64
+	LicenseNo         string
+	TotalCost         float64
+}
+
+// ClinicalOverride lets a pharmacist sell through blocking allergy or interaction alerts
+type ClinicalOverride struct {
+	Justification  string `json:"justification" binding:"required"`
+	Alerts         []entities.ClinicalAlert
+	PharmacistId   string
+	PharmacistName string
+	LicenseNo      string
 }
 
 type OrderPayment struct {
@@ -30,18 +49,23 @@ type OrderPayment struct {
 }
 
 type OrderItem struct {
-	ProductId string           `json:"productId" binding:"required"`
-	Quantity  int              `json:"quantity" binding:"required"`
-	UnitId    string           `json:"unitId" binding:"required"`
-	Price     float64          `json:"price" binding:"required"`
-	CostPrice float64          `json:"costPrice"`
-	Discount  float64          `json:"discount"`
-	Stocks    []OrderItemStock `json:"stocks" binding:"required"`
+	ProductId     string           `json:"productId" binding:"required"`
+	Quantity      int              `json:"quantity" binding:"required,gt=0"`
+	UnitId        string           `json:"unitId" binding:"required"`
+	Price         float64          `json:"price" binding:"required"`
+	Discount      float64          `json:"discount"`
+	Stocks        []OrderItemStock `json:"stocks" binding:"omitempty,dive"`
+	PriceOverride bool             `json:"priceOverride"`
+	ListPrice     float64
+	CostPrice     float64
+	Id            string
 }
 
 type OrderItemStock struct {
-	Quantity int    `json:"quantity" binding:"required"`
-	StockId  string `json:"stockId"`
+	Quantity   int    `json:"quantity" binding:"required,gt=0"`
+	StockId    string `json:"stockId"`
+	LotNumber  string
+	ExpireDate time.Time
 }
 
 type GetOrderRange struct {
@@ -53,3 +77,10 @@ type GetOrderRange struct {
 type UpdateCustomerCode struct {
 	CustomerCode string `json:"customerCode"`
 }
+
+type VoidOrder struct {
+	ReasonCode string `json:"reasonCode" binding:"required"`
+	Reason     string `json:"reason"`
+	VoidedBy   string
+	ApprovedBy string
+}
@@ -23,6 +23,8 @@ type Product struct {
 	ReceiveId         string           `json:"receiveId"`
 	Status            string           `json:"status"`
 	MinStock          int              `json:"minStock"`
+	VatExempt         bool             `json:"vatExempt"`
+	VatRate           float64          `json:"vatRate" binding:"gte=0,lte=100"`
 	DrugInfo          *RequestDrugInfo `json:"drugInfo"`
 	DrugRegistrations []string         `json:"drugRegistrations"`
 	ReceiveCode       string
@@ -41,6 +43,8 @@ type CreateProduct struct {
 	Category          string           `json:"category"`
 	Status            string           `json:"status"`
 	MinStock          int              `json:"minStock"`
+	VatExempt         bool             `json:"vatExempt"`
+	VatRate           float64          `json:"vatRate" binding:"gte=0,lte=100"`
 	DrugInfo          *RequestDrugInfo `json:"drugInfo"`
 	DrugRegistrations []string         `json:"drugRegistrations"`
 	CreatedBy         string
@@ -53,23 +57,33 @@ type UpdateProduct struct {
 	Category          string           `json:"category"`
 	Status            string           `json:"status"`
 	MinStock          int              `json:"minStock"`
+	VatExempt         bool             `json:"vatExempt"`
+	VatRate           float64          `json:"vatRate" binding:"gte=0,lte=100"`
 	DrugInfo          *RequestDrugInfo `json:"drugInfo"`
 	DrugRegistrations []string         `json:"drugRegistrations"`
 	UpdatedBy         string
 }
 
 type RequestDrugInfo struct {
-	GenericName       string   `json:"genericName"`
-	DrugType          string   `json:"drugType"`
-	DosageForm        string   `json:"dosageForm"`
-	Strength          string   `json:"strength"`
-	Indication        string   `json:"indication"`
-	Dosage            string   `json:"dosage"`
-	SideEffects       string   `json:"sideEffects"`
-	Contraindications string   `json:"contraindications"`
-	StorageCondition  string   `json:"storageCondition"`
-	Manufacturer      string   `json:"manufacturer"`
-	RegistrationNo    string   `json:"registrationNo"`
-	IsControlled      bool     `json:"isControlled"`
-	DrugInteractions  []string `json:"drugInteractions"`
+	GenericName       string                     `json:"genericName"`
+	Ingredients       []RequestProductIngredient `json:"ingredients" binding:"omitempty,dive"`
+	DrugType          string                     `json:"drugType"`
+	DosageForm        string                     `json:"dosageForm"`
+	Strength          string                     `json:"strength"`
+	Indication        string                     `json:"indication"`
+	Dosage            string                     `json:"dosage"`
+	SideEffects       string                     `json:"sideEffects"`
+	Contraindications string                     `json:"contraindications"`
+	StorageCondition  string                     `json:"storageCondition"`
+	Manufacturer      string                     `json:"manufacturer"`
+	RegistrationNo    string                     `json:"registrationNo"`
+	IsControlled      bool                       `json:"isControlled"`
+	DrugInteractions  []string                   `json:"drugInteractions"`
+}
+
+type RequestProductIngredient struct {
+	IngredientId string  `json:"ingredientId" binding:"required"`
+	Strength     float64 `json:"strength" binding:"gte=0"`
+	Unit         string  `json:"unit"`
+	Name         string
 }
@@ -7,17 +7,20 @@ import (
 )
 
 type ProductHistory struct {
-	ProductId   string  `json:"productId" binding:"required"`
-	Type        string  `json:"type"`
-	Description string  `json:"description"`
-	Unit        string  `json:"unit"`
-	Import      int     `json:"import"`
-	Quantity    int     `json:"quantity"`
-	CostPrice   float64 `json:"costPrice"`
-	Price       float64 `json:"price"`
-	Balance     int     `json:"balance"`
-	CreatedBy   string  `json:"createdBy"`
-	BranchId    string
+	ProductId    string  `json:"productId" binding:"required"`
+	Type         string  `json:"type"`
+	Description  string  `json:"description"`
+	Unit         string  `json:"unit"`
+	Import       int     `json:"import"`
+	Quantity     int     `json:"quantity"`
+	CostPrice    float64 `json:"costPrice"`
+	Price        float64 `json:"price"`
+	Balance      int     `json:"balance"`
+	CreatedBy    string  `json:"createdBy"`
+	BranchId     string
+	DocumentType string
+	DocumentId   string
+	DocumentCode string
 }
 
 func AddProductHistory(productId string, product Product) ProductHistory {
@@ -177,3 +180,79 @@ func RemoveOrderItemProductHistory(productId string, unit string, item *entities
 		CreatedBy:   createdBy,
 	}
 }
+
+func ReturnOrderItemProductHistory(productId string, unit string, item CreditNoteItem, balance int, createdBy string) ProductHistory {
+	description := "รับคืนสินค้า" + " จำนวน " + strconv.Itoa(item.Quantity) + " " + unit
+	if item.Damaged {
+		description += " (ชำรุด กักกันสินค้า)"
+	}
+	return ProductHistory{
+		ProductId:   productId,
+		Type:        constant.HistoryTypeReturnOrderItemProduct,
+		Description: description,
+		Unit:        unit,
+		Quantity:    item.Quantity,
+		CostPrice:   item.CostPrice,
+		Price:       item.Price,
+		Balance:     balance,
+		CreatedBy:   createdBy,
+	}
+}
+
+// AdjustProductStockHistory records one lot of a stock adjustment, Quantity is negative when stock is written off
+func AdjustProductStockHistory(productId string, unit string, reason string, item entities.StockAdjustmentItem, balance int, createdBy string) ProductHistory {
+	quantity := item.Quantity
+	if !constant.IsStockIncrease(reason) {
+		quantity = -quantity
+	}
+	return ProductHistory{
+		ProductId:   productId,
+		Type:        constant.HistoryTypeAdjustProductStock,
+		Description: "ปรับสต็อกสินค้า (" + constant.AdjustmentReasonName(reason) + ") ล็อต " + item.LotNumber + " จำนวน " + strconv.Itoa(quantity) + " " + unit,
+		Unit:        unit,
+		Quantity:    quantity,
+		CostPrice:   item.CostPrice,
+		Balance:     balance,
+		CreatedBy:   createdBy,
+	}
+}
+
+// ReturnProductStockHistory records one recalled lot sent back to the supplier
+func ReturnProductStockHistory(productId string, unit string, recallCode string, item entities.RecallReturnItem, balance int, createdBy string) ProductHistory {
+	return ProductHistory{
+		ProductId:   productId,
+		Type:        constant.HistoryTypeReturnProductStock,
+		Description: "คืนสินค้าผู้จำหน่าย (เรียกคืน " + recallCode + ") ล็อต " + item.LotNumber + " จำนวน " + strconv.Itoa(-item.Quantity) + " " + unit,
+		Unit:        unit,
+		Quantity:    -item.Quantity,
+		CostPrice:   item.CostPrice,
+		Balance:     balance,
+		CreatedBy:   createdBy,
+	}
+}
+
+// UpdateProductStockStatusHistory records a lot moved in or out of sale, stock quantities do not change
+func UpdateProductStockStatusHistory(productId string, unit string, stock *entities.ProductStock, status string, createdBy string) ProductHistory {
+	return ProductHistory{
+		ProductId:   productId,
+		Type:        constant.HistoryTypeUpdateProductStockStatus,
+		Description: "เปลี่ยนสถานะสต็อกสินค้า " + stock.LotNumber + " เป็น " + status,
+		Unit:        unit,
+		Balance:     stock.Quantity,
+		CreatedBy:   createdBy,
+	}
+}
+
+// DisposeProductStockHistory records one lot destroyed by a disposal
+func DisposeProductStockHistory(productId string, unit string, disposalCode string, item entities.DisposalItem, balance int, createdBy string) ProductHistory {
+	return ProductHistory{
+		ProductId:   productId,
+		Type:        constant.HistoryTypeDisposeProductStock,
+		Description: "ทำลายสินค้า (" + disposalCode + ") ล็อต " + item.LotNumber + " จำนวน " + strconv.Itoa(-item.Quantity) + " " + unit,
+		Unit:        unit,
+		Quantity:    -item.Quantity,
+		CostPrice:   item.CostPrice,
+		Balance:     balance,
+		CreatedBy:   createdBy,
+	}
+}
@@ -14,6 +14,7 @@ type ProductStock struct {
 	ImportDate  time.Time `json:"importDate" binding:"required"`
 	UpdatedBy   string
 	BranchId    string
+	Status      string
 }
 
 type UpdateProductStock struct {
@@ -40,3 +41,9 @@ type ProductStockSequence struct {
 	StockId  string `json:"stockId" binding:"required"`
 	Sequence int    `json:"sequence" binding:"required"`
 }
+
+// UpdateProductStockStatus sets a lot aside or back on sale, recalled lots only change through a recall
+type UpdateProductStockStatus struct {
+	Status    string `json:"status" binding:"required,oneof=AVAILABLE QUARANTINED EXPIRED DAMAGED"`
+	UpdatedBy string
+}
@@ -0,0 +1,57 @@
+package request
+
+import (
+	"pos/app/data/entities"
+	"time"
+)
+
+type ProductValuation struct {
+	BranchId     string
+	ProductId    string
+	UnitId       string
+	StockId      string
+	LotNumber    string
+	Type         string
+	Quantity     int
+	UnitCost     float64
+	Amount       float64
+	DocumentType string
+	DocumentId   string
+	DocumentCode string
+	CreatedBy    string
+}
+
+type GetProductValuationRange struct {
+	StartDate time.Time `form:"startDate" binding:"required"`
+	EndDate   time.Time `form:"endDate" binding:"required"`
+	ProductId string    `form:"productId"`
+	Type      string    `form:"type"`
+	BranchId  string
+}
+
+type GetInventoryValuation struct {
+	Date     time.Time `form:"date" binding:"required"`
+	BranchId string
+}
+
+type GetCostOfGoodsSold struct {
+	StartDate time.Time `form:"startDate" binding:"required"`
+	EndDate   time.Time `form:"endDate" binding:"required"`
+	BranchId  string
+}
+
+// StockValuation values quantity of the lot at its current cost, quantity is negative when stock leaves
+func StockValuation(stock *entities.ProductStock, valuationType string, quantity int, createdBy string) ProductValuation {
+	return ProductValuation{
+		BranchId:  stock.BranchId.Hex(),
+		ProductId: stock.ProductId.Hex(),
+		UnitId:    stock.UnitId.Hex(),
+		StockId:   stock.Id.Hex(),
+		LotNumber: stock.LotNumber,
+		Type:      valuationType,
+		Quantity:  quantity,
+		UnitCost:  stock.CostPrice,
+		Amount:    float64(quantity) * stock.CostPrice,
+		CreatedBy: createdBy,
+	}
+}
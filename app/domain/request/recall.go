@@ -0,0 +1,37 @@
+package request
+
+import (
+	"pos/app/data/entities"
+	"time"
+)
+
+type Recall struct {
+	ProductId    string   `json:"productId" binding:"required"`
+	LotNumbers   []string `json:"lotNumbers" binding:"required,min=1,dive,required"`
+	Reason       string   `json:"reason" binding:"required"`
+	Reference    string   `json:"reference"`
+	Note         string   `json:"note"`
+	Code         string
+	ProductName  string
+	SerialNumber string
+	BlockedLots  int64
+	CreatedBy    string
+}
+
+type GetRecallRange struct {
+	StartDate time.Time `form:"startDate" binding:"required"`
+	EndDate   time.Time `form:"endDate" binding:"required"`
+	ProductId string    `form:"productId"`
+}
+
+type RecallReturn struct {
+	Code         string
+	RecallId     string
+	RecallCode   string
+	SupplierId   string
+	SupplierName string
+	Items        []entities.RecallReturnItem
+	TotalCost    float64
+	BranchId     string
+	CreatedBy    string
+}
@@ -0,0 +1,42 @@
+package request
+
+import "time"
+
+type ReceivableInvoice struct {
+	CustomerId   string
+	CustomerCode string
+	CustomerName string
+	OrderId      string
+	OrderCode    string
+	Amount       float64
+	DueDate      time.Time
+	BranchId     string
+	CreatedBy    string
+}
+
+type ReceivableReceipt struct {
+	CustomerId   string                 `json:"customerId" binding:"required"`
+	Amount       float64                `json:"amount" binding:"required,gt=0"`
+	PaymentType  string                 `json:"paymentType" binding:"required"`
+	Reference    string                 `json:"reference"`
+	Note         string                 `json:"note"`
+	Allocations  []ReceivableAllocation `json:"allocations" binding:"dive"`
+	Code         string
+	CustomerCode string
+	CustomerName string
+	ShiftId      string
+	BranchId     string
+	CreatedBy    string
+}
+
+type ReceivableAllocation struct {
+	InvoiceId string  `json:"invoiceId" binding:"required"`
+	Amount    float64 `json:"amount" binding:"required,gt=0"`
+	OrderCode string
+}
+
+type GetReceivableRange struct {
+	StartDate time.Time `form:"startDate" binding:"required"`
+	EndDate   time.Time `form:"endDate" binding:"required"`
+	BranchId  string
+}
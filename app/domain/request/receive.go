@@ -9,22 +9,31 @@ type GetReceiveRange struct {
 }
 
 type Receive struct {
-	SupplierId string        `json:"supplierId" binding:"required"`
-	Reference  string        `json:"reference"`
-	Items      []ReceiveItem `json:"items"`
+	SupplierId string             `json:"supplierId" binding:"required"`
+	Reference  string             `json:"reference"`
+	TaxInvoice *ReceiveTaxInvoice `json:"taxInvoice"`
+	Items      []ReceiveItem      `json:"items"`
 	Code       string
 	UpdatedBy  string
 	BranchId   string
 }
 
 type UpdateReceive struct {
-	SupplierId   string        `json:"supplierId" binding:"required"`
-	Reference    string        `json:"reference"`
-	TotalCost    float64       `json:"totalCost"`
-	ReceiveItems []ReceiveItem `json:"items"`
+	SupplierId   string             `json:"supplierId" binding:"required"`
+	Reference    string             `json:"reference"`
+	TotalCost    float64            `json:"totalCost"`
+	TaxInvoice   *ReceiveTaxInvoice `json:"taxInvoice"`
+	ReceiveItems []ReceiveItem      `json:"items"`
 	UpdatedBy    string
 }
 
+type ReceiveTaxInvoice struct {
+	Number        string    `json:"number" binding:"required"`
+	Date          time.Time `json:"date" binding:"required"`
+	TaxableAmount float64   `json:"taxableAmount" binding:"gte=0"`
+	VatAmount     float64   `json:"vatAmount" binding:"gte=0"`
+}
+
 type UpdateReceiveTotalCode struct {
 	TotalCost float64 `json:"totalCost"`
 }
@@ -1,15 +1,32 @@
 package request
 
 type Setting struct {
-	BranchId       string          `json:"branchId"`
-	ReceiptFooter  string          `json:"receiptFooter"`
-	CompanyName    string          `json:"companyName"`
-	CompanyAddress string          `json:"companyAddress"`
-	CompanyPhone   string          `json:"companyPhone"`
-	CompanyTaxId   string          `json:"companyTaxId"`
-	LogoUrl        string          `json:"logoUrl"`
-	ShowCredit     bool            `json:"showCredit"`
-	PromptPayId    string          `json:"promptPayId"`
-	Features       map[string]bool `json:"features"`
-	UpdatedBy      string
+	BranchId           string          `json:"branchId"`
+	ReceiptFooter      string          `json:"receiptFooter"`
+	CompanyName        string          `json:"companyName"`
+	CompanyAddress     string          `json:"companyAddress"`
+	CompanyPhone       string          `json:"companyPhone"`
+	CompanyTaxId       string          `json:"companyTaxId"`
+	LogoUrl            string          `json:"logoUrl"`
+	ShowCredit         bool            `json:"showCredit"`
+	ReceiptPaper       string          `json:"receiptPaper" binding:"omitempty,oneof=A4 80MM 58MM"`
+	ReceiptCodePage    int             `json:"receiptCodePage" binding:"gte=0,lte=255"`
+	ReceiptPromptPayQr bool            `json:"receiptPromptPayQr"`
+	VatRegistered      bool            `json:"vatRegistered"`
+	VatRate            float64         `json:"vatRate" binding:"gte=0,lte=100"`
+	TaxBranchNo        string          `json:"taxBranchNo" binding:"omitempty,len=5,numeric"`
+	PromptPayId        string          `json:"promptPayId"`
+	AllowNegativeStock bool            `json:"allowNegativeStock"`
+	AdjustmentApproval bool            `json:"adjustmentApproval"`
+	CostingMethod      string          `json:"costingMethod" binding:"omitempty,oneof=FIFO AVERAGE"`
+	DayCloseTime       string          `json:"dayCloseTime"`
+	CartReserveMinutes int             `json:"cartReserveMinutes" binding:"gte=0"`
+	VoidReasons        []ReasonCode    `json:"voidReasons" binding:"dive"`
+	Features           map[string]bool `json:"features"`
+	UpdatedBy          string
+}
+
+type ReasonCode struct {
+	Code string `json:"code" binding:"required"`
+	Name string `json:"name" binding:"required"`
 }
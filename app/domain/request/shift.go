@@ -0,0 +1,46 @@
+package request
+
+import "time"
+
+type OpenShift struct {
+	OpeningFloat float64 `json:"openingFloat" binding:"gte=0"`
+	Note         string  `json:"note"`
+	Code         string
+	UserId       string
+	BranchId     string
+}
+
+type CashMovement struct {
+	Type      string  `json:"type" binding:"required,oneof=IN OUT"`
+	Amount    float64 `json:"amount" binding:"required,gt=0"`
+	Reason    string  `json:"reason" binding:"required"`
+	CreatedBy string
+}
+
+type CloseShift struct {
+	Counts   []ShiftCount `json:"counts" binding:"required,dive"`
+	Note     string       `json:"note"`
+	Summary  []ShiftPaymentSummary
+	ClosedBy string
+}
+
+type ShiftCount struct {
+	Type   string  `json:"type" binding:"required"`
+	Amount float64 `json:"amount" binding:"gte=0"`
+}
+
+type ShiftPaymentSummary struct {
+	Type     string
+	Sales    float64
+	Receipts float64
+	Refunds  float64
+	Expected float64
+	Counted  float64
+	Variance float64
+}
+
+type GetShiftRange struct {
+	StartDate time.Time `form:"startDate" binding:"required"`
+	EndDate   time.Time `form:"endDate" binding:"required"`
+	BranchId  string
+}
@@ -0,0 +1,38 @@
+package request
+
+import "time"
+
+type StockAdjustment struct {
+	Reason      string                `json:"reason" binding:"required,oneof=DAMAGED EXPIRED LOST FOUND SAMPLE INTERNAL_USE"`
+	Note        string                `json:"note"`
+	Items       []StockAdjustmentItem `json:"items" binding:"required,min=1,dive"`
+	Code        string
+	TotalCost   float64
+	Status      string
+	StocktakeId string
+	BranchId    string
+	CreatedBy   string
+}
+
+type StockAdjustmentItem struct {
+	StockId    string `json:"stockId" binding:"required"`
+	Quantity   int    `json:"quantity" binding:"required,gt=0"`
+	ProductId  string
+	UnitId     string
+	LotNumber  string
+	CostPrice  float64
+	CostAmount float64
+}
+
+type RejectStockAdjustment struct {
+	Reason    string `json:"reason" binding:"required"`
+	UpdatedBy string
+}
+
+type GetStockAdjustmentRange struct {
+	StartDate time.Time `form:"startDate" binding:"required"`
+	EndDate   time.Time `form:"endDate" binding:"required"`
+	Reason    string    `form:"reason" binding:"omitempty,oneof=DAMAGED EXPIRED LOST FOUND SAMPLE INTERNAL_USE"`
+	Status    string    `form:"status" binding:"omitempty,oneof=PENDING APPROVED REJECTED"`
+	BranchId  string
+}
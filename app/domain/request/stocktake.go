@@ -0,0 +1,59 @@
+package request
+
+import (
+	"pos/app/data/entities"
+	"time"
+)
+
+type Stocktake struct {
+	Scope     string   `json:"scope" binding:"required,oneof=ALL CATEGORY LOTS"`
+	Category  string   `json:"category"`
+	StockIds  []string `json:"stockIds"`
+	Blind     bool     `json:"blind"`
+	Note      string   `json:"note"`
+	Code      string
+	Stocks    []entities.ProductStockDetail
+	BranchId  string
+	CreatedBy string
+}
+
+type StocktakeCounts struct {
+	Items []StocktakeCountItem `json:"items" binding:"required,min=1,dive"`
+}
+
+type StocktakeCountItem struct {
+	StockId  string `json:"stockId" binding:"required"`
+	Quantity int    `json:"quantity" binding:"gte=0"`
+}
+
+// StocktakeScan is one scan of a barcode, LotNumber picks the lot when the product has several
+type StocktakeScan struct {
+	Barcode   string `json:"barcode" binding:"required"`
+	LotNumber string `json:"lotNumber"`
+	Quantity  int    `json:"quantity" binding:"gte=0"`
+}
+
+type StocktakeCount struct {
+	StocktakeId string
+	StockId     string
+	CountedBy   string
+	Quantity    int
+}
+
+type PostStocktake struct {
+	ZeroUncounted   bool `json:"zeroUncounted"`
+	Lines           []entities.StocktakeLine
+	SystemCost      float64
+	CountedCost     float64
+	VarianceCost    float64
+	UncountedLines  int
+	AdjustmentCodes []string
+	PostedBy        string
+}
+
+type GetStocktakeRange struct {
+	StartDate time.Time `form:"startDate" binding:"required"`
+	EndDate   time.Time `form:"endDate" binding:"required"`
+	Status    string    `form:"status" binding:"omitempty,oneof=COUNTING POSTED CANCELLED"`
+	BranchId  string
+}
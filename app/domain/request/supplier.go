@@ -5,5 +5,6 @@ type Supplier struct {
 	Address   string `json:"address" binding:"required"`
 	Phone     string `json:"phone"`
 	TaxId     string `json:"taxId"`
+	BranchNo  string `json:"branchNo" binding:"omitempty,len=5,numeric"`
 	UpdatedBy string
 }
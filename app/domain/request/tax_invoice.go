@@ -0,0 +1,60 @@
+package request
+
+import "time"
+
+type TaxInvoiceParty struct {
+	Name     string `json:"name" binding:"required"`
+	Address  string `json:"address" binding:"required"`
+	TaxId    string `json:"taxId" binding:"omitempty,len=13,numeric"`
+	BranchNo string `json:"branchNo" binding:"omitempty,len=5,numeric"`
+}
+
+type IssueTaxInvoice struct {
+	OrderId string           `json:"orderId" binding:"required"`
+	Type    string           `json:"type" binding:"required,oneof=ABBREVIATED FULL"`
+	Buyer   *TaxInvoiceParty `json:"buyer"`
+}
+
+type ConvertTaxInvoice struct {
+	Buyer TaxInvoiceParty `json:"buyer" binding:"required"`
+}
+
+type ReissueTaxInvoice struct {
+	Reason     string `json:"reason" binding:"required"`
+	ReissuedBy string
+}
+
+type TaxInvoice struct {
+	Type         string
+	Code         string
+	OrderId      string
+	OrderCode    string
+	Seller       TaxInvoiceParty
+	Buyer        *TaxInvoiceParty
+	Items        []TaxInvoiceItem
+	Discount     float64
+	ReplacesId   string
+	ReplacesCode string
+	CreatedBy    string
+	BranchId     string
+}
+
+type TaxInvoiceItem struct {
+	OrderItemId string
+	ProductId   string
+	Name        string
+	Quantity    int
+	UnitPrice   float64
+	Discount    float64
+	Amount      float64
+	VatRate     float64
+	VatExempt   bool
+}
+
+type GetTaxInvoiceRange struct {
+	StartDate time.Time `form:"startDate" binding:"required"`
+	EndDate   time.Time `form:"endDate" binding:"required"`
+	Type      string    `form:"type" binding:"omitempty,oneof=ABBREVIATED FULL"`
+	Status    string    `form:"status" binding:"omitempty,oneof=ISSUED REPLACED CANCELLED"`
+	BranchId  string
+}
@@ -0,0 +1,72 @@
+package cart
+
+import (
+	"pos/app/domain"
+	"pos/app/featues/cart/usecase"
+	"pos/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ApplyCartAPI(
+	route *gin.RouterGroup,
+	repository *domain.Repository,
+) {
+	cartRoute := route.Group("carts")
+
+	cartRoute.POST("",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.CreateCart(repository.Cart, repository.Product),
+	)
+
+	cartRoute.GET("",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetCarts(repository.Cart),
+	)
+
+	cartRoute.GET("/:cartId",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetCartById(repository.Cart),
+	)
+
+	cartRoute.PUT("/:cartId",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.UpdateCartById(repository.Cart, repository.Product),
+	)
+
+	cartRoute.PATCH("/:cartId/park",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.ParkCart(repository.Transaction, repository.Cart, repository.Product, repository.Setting),
+	)
+
+	cartRoute.PATCH("/:cartId/resume",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.ResumeCart(repository.Cart),
+	)
+
+	cartRoute.PATCH("/:cartId/transfer",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.TransferCart(repository.Cart, repository.Employee),
+	)
+
+	cartRoute.DELETE("/:cartId",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.CancelCartById(repository.Cart),
+	)
+}
@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"fmt"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CreateCart(cartEntity repositories.ICart, productEntity repositories.IProduct) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.Cart{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CT_BAD_REQUEST_001, err.Error())
+			return
+		}
+		req.UserId = utils.GetUserId(ctx)
+		req.BranchId = utils.GetBranchId(ctx)
+		req.UpdatedBy = req.UserId
+		if err := fillBaseQuantities(productEntity, req.Items); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CT_BAD_REQUEST_001, err.Error())
+			return
+		}
+
+		result, err := cartEntity.CreateCart(req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CT_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetCarts(cartEntity repositories.ICart) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, err := cartEntity.GetCartsByUserId(utils.GetBranchId(ctx), utils.GetUserId(ctx))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CT_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetCartById(cartEntity repositories.ICart) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, ok := getOwnCart(ctx, cartEntity)
+		if !ok {
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func UpdateCartById(cartEntity repositories.ICart, productEntity repositories.IProduct) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.Cart{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CT_BAD_REQUEST_001, err.Error())
+			return
+		}
+		cart, ok := getOwnCart(ctx, cartEntity)
+		if !ok {
+			return
+		}
+		req.UpdatedBy = utils.GetUserId(ctx)
+		if err := fillBaseQuantities(productEntity, req.Items); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CT_BAD_REQUEST_001, err.Error())
+			return
+		}
+
+		result, err := cartEntity.UpdateCartById(cart.Id.Hex(), req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CT_BAD_REQUEST_002, "cart is not open, resume it first")
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func CancelCartById(cartEntity repositories.ICart) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		cart, ok := getOwnCart(ctx, cartEntity)
+		if !ok {
+			return
+		}
+		result, err := cartEntity.CancelCartById(cart.Id.Hex(), utils.GetUserId(ctx))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CT_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+// getOwnCart loads the cart from the path and aborts unless it is in the branch and held by the user
+func getOwnCart(ctx *gin.Context, cartEntity repositories.ICart) (*entities.Cart, bool) {
+	cart, err := cartEntity.GetCartById(ctx.Param("cartId"))
+	if err != nil || cart.BranchId.Hex() != utils.GetBranchId(ctx) {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.CT_BAD_REQUEST_002, "cart not found")
+		return nil, false
+	}
+	if cart.UserId != utils.GetUserId(ctx) {
+		errcode.Abort(ctx, http.StatusForbidden, errcode.CT_FORBIDDEN_001, "cart belongs to another cashier")
+		return nil, false
+	}
+	return cart, true
+}
+
+// fillBaseQuantities converts every line to the base unit of the product, used for stock reservation
+func fillBaseQuantities(productEntity repositories.IProduct, items []request.CartItem) error {
+	for i, item := range items {
+		unit, err := productEntity.GetProductUnitById(item.UnitId)
+		if err != nil || unit.ProductId.Hex() != item.ProductId {
+			return fmt.Errorf("unit %s not found for product %s", item.UnitId, item.ProductId)
+		}
+		items[i].BaseQuantity = item.Quantity * unit.BaseSize()
+	}
+	return nil
+}
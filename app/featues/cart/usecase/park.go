@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/request"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ParkCart puts the cart aside. When the branch sets CartReserveMinutes the lines are soft
+// reserved until then, so other tabs cannot sell the same units. Lines that are not covered
+// by unreserved stock are returned as warnings and the cart is parked without a reservation.
+func ParkCart(
+	transactionEntity repositories.ITransaction,
+	cartEntity repositories.ICart,
+	productEntity repositories.IProduct,
+	settingEntity repositories.ISetting,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		cart, ok := getOwnCart(ctx, cartEntity)
+		if !ok {
+			return
+		}
+		userId := utils.GetUserId(ctx)
+
+		reserveMinutes := 0
+		if setting, _ := settingEntity.GetSettingByBranchId(cart.BranchId.Hex()); setting != nil {
+			reserveMinutes = setting.CartReserveMinutes
+		}
+
+		var result *entities.Cart
+		var warnings []entities.StockShortage
+		err := transactionEntity.WithTransaction(func(txCtx context.Context) error {
+			warnings = nil
+			var reservedUntil *time.Time
+			if reserveMinutes > 0 && len(cart.Items) > 0 {
+				shortages, err := checkReservation(txCtx, cartEntity, productEntity, cart)
+				if err != nil {
+					return err
+				}
+				warnings = shortages
+				if len(shortages) == 0 {
+					until := time.Now().Add(time.Duration(reserveMinutes) * time.Minute)
+					reservedUntil = &until
+				}
+			}
+
+			var err error
+			result, err = cartEntity.ParkCartByIdTx(txCtx, cart.Id.Hex(), reservedUntil, userId)
+			return err
+		})
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CT_BAD_REQUEST_002, err.Error())
+			return
+		}
+
+		response := gin.H{"data": result}
+		if len(warnings) > 0 {
+			response["warnings"] = warnings
+		}
+		ctx.JSON(http.StatusOK, response)
+	}
+}
+
+func ResumeCart(cartEntity repositories.ICart) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		cart, ok := getOwnCart(ctx, cartEntity)
+		if !ok {
+			return
+		}
+		result, err := cartEntity.ResumeCartById(cart.Id.Hex(), utils.GetUserId(ctx))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CT_BAD_REQUEST_002, "cart is not parked")
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func TransferCart(cartEntity repositories.ICart, employeeEntity repositories.IEmployee) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.TransferCart{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CT_BAD_REQUEST_001, err.Error())
+			return
+		}
+		cart, ok := getOwnCart(ctx, cartEntity)
+		if !ok {
+			return
+		}
+		employee, err := employeeEntity.GetEmployeeByUserId(req.UserId)
+		if err != nil || employee.BranchId != cart.BranchId {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CT_BAD_REQUEST_001, "cashier is not an employee of this branch")
+			return
+		}
+
+		result, err := cartEntity.TransferCartById(cart.Id.Hex(), req.UserId, utils.GetUserId(ctx))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CT_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+// checkReservation returns the products of the cart not covered by sellable stock less what
+// other parked carts hold, quantities are in the base unit. The reservation counters of the
+// products are bumped first, two carts parking the same product conflict and the later one
+// is retried against the reservation of the first.
+func checkReservation(
+	ctx context.Context,
+	cartEntity repositories.ICart,
+	productEntity repositories.IProduct,
+	cart *entities.Cart,
+) ([]entities.StockShortage, error) {
+	branchId := cart.BranchId.Hex()
+	needed := make(map[string]int)
+	productIds := make([]string, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		productId := item.ProductId.Hex()
+		if _, ok := needed[productId]; !ok {
+			productIds = append(productIds, productId)
+		}
+		needed[productId] += item.BaseQuantity
+	}
+
+	if err := cartEntity.LockReservationsTx(ctx, branchId, productIds); err != nil {
+		return nil, err
+	}
+	reserved, err := cartEntity.GetReservedQuantitiesTx(ctx, branchId, cart.Id.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	var shortages []entities.StockShortage
+	for _, item := range cart.Items {
+		productId := item.ProductId.Hex()
+		if _, ok := needed[productId]; !ok {
+			continue
+		}
+		available, err := sellableQuantity(ctx, productEntity, productId, branchId)
+		if err != nil {
+			return nil, err
+		}
+		available -= reserved[productId]
+		if available < needed[productId] {
+			if available < 0 {
+				available = 0
+			}
+			shortages = append(shortages, entities.StockShortage{
+				ProductId: productId,
+				UnitId:    item.UnitId.Hex(),
+				Quantity:  needed[productId],
+				Available: available,
+			})
+		}
+		delete(needed, productId)
+	}
+	return shortages, nil
+}
+
+// sellableQuantity sums the sellable lots of the product in the branch in the base unit
+func sellableQuantity(ctx context.Context, productEntity repositories.IProduct, productId string, branchId string) (int, error) {
+	lots, err := productEntity.GetSellableProductStocksTx(ctx, productId, branchId)
+	if err != nil {
+		return 0, err
+	}
+	units := make(map[string]*entities.ProductUnit)
+	productUnits, _ := productEntity.GetProductUnitsByProductId(productId)
+	for i := range productUnits {
+		units[productUnits[i].Id.Hex()] = &productUnits[i]
+	}
+	var total int
+	for _, lot := range lots {
+		total += lot.Quantity * entities.UnitSize(units, lot.UnitId.Hex())
+	}
+	return total, nil
+}
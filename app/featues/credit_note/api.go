@@ -0,0 +1,45 @@
+package credit_note
+
+import (
+	"pos/app/domain"
+	"pos/app/featues/credit_note/usecase"
+	"pos/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ApplyCreditNoteAPI(
+	route *gin.RouterGroup,
+	repository *domain.Repository,
+) {
+	cnRoute := route.Group("credit-notes")
+
+	cnRoute.POST("",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireIdempotency(repository.Idempotency),
+		usecase.CreateCreditNote(repository.Transaction, repository.CreditNote, repository.Order, repository.Product, repository.Sequence, repository.Shift, repository.Receivable, repository.DispensingLog, repository.Setting),
+	)
+
+	cnRoute.GET("",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetCreditNotes(repository.CreditNote),
+	)
+
+	cnRoute.GET("/:id",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetCreditNoteById(repository.CreditNote),
+	)
+
+	cnRoute.GET("/orders/:orderId",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetCreditNotesByOrderId(repository.CreditNote),
+	)
+}
@@ -0,0 +1,381 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CreateCreditNote(
+	transactionEntity repositories.ITransaction,
+	creditNoteEntity repositories.ICreditNote,
+	orderEntity repositories.IOrder,
+	productEntity repositories.IProduct,
+	sequenceEntity repositories.ISequence,
+	shiftEntity repositories.IShift,
+	receivableEntity repositories.IReceivable,
+	dispensingLogEntity repositories.IDispensingLog,
+	settingEntity repositories.ISetting,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.CreditNote{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CN_BAD_REQUEST_001, err.Error())
+			return
+		}
+		req.CreatedBy = utils.GetUserId(ctx)
+		req.BranchId = utils.GetBranchId(ctx)
+		if shift, _ := shiftEntity.GetOpenShiftByUserId(req.BranchId, req.CreatedBy); shift != nil {
+			req.ShiftId = shift.Id.Hex()
+		}
+
+		order, err := orderEntity.GetOrderDetailById(req.OrderId)
+		if err != nil || order.BranchId.Hex() != req.BranchId {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CN_BAD_REQUEST_001, "order not found")
+			return
+		}
+		if order.Status == constant.OrderStatusVoided {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CN_BAD_REQUEST_001, "order is voided")
+			return
+		}
+		payments, _ := orderEntity.GetPaymentsByOrderId(req.OrderId)
+
+		orderItems := make(map[string]*entities.OrderItemProductDetail)
+		var subtotal float64
+		for i, item := range order.Items {
+			orderItems[item.Id.Hex()] = &order.Items[i]
+			subtotal += item.Price - item.Discount
+		}
+		units := make(map[string]*entities.ProductUnit)
+		for _, item := range order.Items {
+			productUnits, _ := productEntity.GetProductUnitsByProductId(item.ProductId.Hex())
+			for i := range productUnits {
+				units[productUnits[i].Id.Hex()] = &productUnits[i]
+			}
+		}
+
+		req.OrderCode = order.Code
+		req.CustomerCode = order.CustomerCode
+		req.CustomerName = order.CustomerName
+		sequence, _ := sequenceEntity.NextSequence(constant.CREDIT_NOTE)
+		if sequence != nil {
+			req.Code = sequence.GenerateCode()
+		}
+		setting, _ := settingEntity.GetSettingByBranchId(req.BranchId)
+		costingMethod := setting.GetCostingMethod()
+
+		var result *entities.CreditNote
+		err = transactionEntity.WithTransaction(func(txCtx context.Context) error {
+			var valuations []request.ProductValuation
+			form := req
+			form.Items = make([]request.CreditNoteItem, len(req.Items))
+			copy(form.Items, req.Items)
+
+			// Quantities already returned by earlier credit notes, per order item and per lot
+			previous, err := creditNoteEntity.GetCreditNotesByOrderIdTx(txCtx, form.OrderId)
+			if err != nil {
+				return err
+			}
+			returned := make(map[string]int)
+			returnedStocks := make(map[string]int)
+			for _, note := range previous {
+				for _, item := range note.Items {
+					returned[item.OrderItemId.Hex()] += item.Quantity
+					for _, stock := range item.Stocks {
+						returnedStocks[item.OrderItemId.Hex()+stock.OrderStockId] += stock.Quantity
+					}
+				}
+			}
+
+			// Returns come off COGS at the cost the sale booked
+			saleCosts, err := productEntity.GetSaleUnitCostsTx(txCtx, form.OrderId)
+			if err != nil {
+				return err
+			}
+
+			form.Total = 0
+			form.TotalCost = 0
+			for i, item := range form.Items {
+				orderItem := orderItems[item.OrderItemId]
+				if orderItem == nil {
+					return fmt.Errorf("order item not found: %s", item.OrderItemId)
+				}
+				if item.Quantity <= 0 || item.Quantity > orderItem.Quantity-returned[item.OrderItemId] {
+					return fmt.Errorf("return quantity of %s exceeds %d", orderItem.Product.Name, orderItem.Quantity-returned[item.OrderItemId])
+				}
+				returned[item.OrderItemId] += item.Quantity
+
+				// Refund the line net price, less its share of the bill discount
+				ratio := float64(item.Quantity) / float64(orderItem.Quantity)
+				price := (orderItem.Price - orderItem.Discount) * ratio
+				if subtotal > 0 {
+					price = price * order.Total / subtotal
+				}
+				form.Items[i].ProductId = orderItem.ProductId.Hex()
+				form.Items[i].UnitId = orderItem.UnitId.Hex()
+				form.Items[i].Price = utils.Round2(price)
+				form.Total += form.Items[i].Price
+
+				stocks, itemValuations, err := returnItemStocks(txCtx, productEntity, orderItem, form.Items[i], units, returnedStocks, saleCosts, form.Code, form.BranchId, form.CreatedBy)
+				if err != nil {
+					return err
+				}
+				form.Items[i].Stocks = stocks
+
+				// The cost comes off COGS at what the lots take back, the share of the sale cost when
+				// part of the line was sold without a lot
+				form.Items[i].CostPrice = utils.Round2(orderItem.CostPrice * ratio)
+				if len(itemValuations) == len(stocks) {
+					var cost float64
+					for _, valuation := range itemValuations {
+						cost += valuation.Amount
+					}
+					form.Items[i].CostPrice = utils.Round2(cost)
+				}
+				form.TotalCost += form.Items[i].CostPrice
+				valuations = append(valuations, itemValuations...)
+			}
+			form.Total = utils.Round2(form.Total)
+			form.TotalCost = utils.Round2(form.TotalCost)
+			form.Refunds = splitRefund(form.Total, payments)
+
+			// Refunds of a credit sale lower what the customer owes, what the customer already paid
+			// of the invoice is refunded in cash
+			for i, refund := range form.Refunds {
+				if refund.Type != constant.PaymentTypeCredit {
+					continue
+				}
+				credited, err := receivableEntity.CreditInvoiceByOrderIdTx(txCtx, form.OrderId, refund.Amount, form.CreatedBy)
+				if err != nil {
+					return err
+				}
+				form.Refunds[i].Amount = credited
+				form.Refunds = addRefund(form.Refunds, constant.PaymentTypeCash, utils.Round2(refund.Amount-credited))
+			}
+			refunds := form.Refunds[:0]
+			for _, refund := range form.Refunds {
+				if refund.Amount > 0 {
+					refunds = append(refunds, refund)
+				}
+			}
+			form.Refunds = refunds
+
+			result, err = creditNoteEntity.CreateCreditNoteTx(txCtx, form)
+			if err != nil {
+				return err
+			}
+
+			for _, valuation := range valuations {
+				valuation.DocumentType = constant.CREDIT_NOTE
+				valuation.DocumentId = result.Id.Hex()
+				valuation.DocumentCode = result.Code
+				if _, err := productEntity.CreateProductValuationTx(txCtx, valuation); err != nil {
+					return err
+				}
+				if costingMethod == constant.CostingMethodAverage {
+					if _, err := productEntity.AverageProductStockCostTx(txCtx, valuation); err != nil {
+						return err
+					}
+				}
+			}
+
+			// Returned drugs come off the dispensing register of the order
+			for _, item := range form.Items {
+				if err := dispensingLogEntity.ReturnDispensingItemTx(txCtx, form.OrderId, item.OrderItemId, item.Quantity); err != nil {
+					return err
+				}
+			}
+
+			// Add product history linked to the credit note
+			for _, item := range form.Items {
+				unit := units[item.UnitId]
+				if unit == nil {
+					continue
+				}
+				balance := productEntity.GetProductStockBalanceTx(txCtx, item.ProductId, unit.Id.Hex())
+				history := request.ReturnOrderItemProductHistory(item.ProductId, unit.Unit, item, balance, form.CreatedBy)
+				history.BranchId = form.BranchId
+				history.DocumentType = constant.CREDIT_NOTE
+				history.DocumentId = result.Id.Hex()
+				history.DocumentCode = result.Code
+				if _, err := productEntity.CreateProductHistoryTx(txCtx, history); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CN_BAD_REQUEST_002, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+// returnItemStocks puts the returned quantity back to the lots the order line was cut from,
+// latest consumed lot first. Damaged goods go to a new quarantined lot instead. Lots take the stock
+// back at the unit cost of the sale in saleCosts, orders sold before the ledger was kept at the cost
+// of the lot. It returns the ledger entries of the lots taking the stock back, without the document.
+func returnItemStocks(
+	ctx context.Context,
+	productEntity repositories.IProduct,
+	orderItem *entities.OrderItemProductDetail,
+	item request.CreditNoteItem,
+	units map[string]*entities.ProductUnit,
+	returnedStocks map[string]int,
+	saleCosts map[string]float64,
+	code string,
+	branchId string,
+	createdBy string,
+) ([]request.CreditNoteItemStock, []request.ProductValuation, error) {
+	var stocks []request.CreditNoteItemStock
+	var valuations []request.ProductValuation
+	remaining := item.Quantity * entities.UnitSize(units, item.UnitId)
+
+	for i := len(orderItem.Stocks) - 1; i >= 0 && remaining > 0; i-- {
+		orderStock := orderItem.Stocks[i]
+		key := item.OrderItemId + orderStock.StockId
+		lotSize := entities.UnitSize(units, item.UnitId)
+		var lot *entities.ProductStock
+		if orderStock.StockId != "" {
+			lot, _ = productEntity.GetProductStockByIdTx(ctx, orderStock.StockId)
+			if lot != nil {
+				lotSize = entities.UnitSize(units, lot.UnitId.Hex())
+			}
+		}
+		quantity := orderStock.Quantity - returnedStocks[key]
+		if need := (remaining + lotSize - 1) / lotSize; quantity > need {
+			quantity = need
+		}
+		if quantity <= 0 {
+			continue
+		}
+		returnedStocks[key] += quantity
+		remaining -= quantity * lotSize
+
+		stock := request.CreditNoteItemStock{
+			OrderStockId: orderStock.StockId,
+			StockId:      orderStock.StockId,
+			LotNumber:    orderStock.LotNumber,
+			Quantity:     quantity,
+			Quarantined:  item.Damaged,
+		}
+		if item.Damaged {
+			quarantine := request.ProductStock{
+				ProductId:   item.ProductId,
+				UnitId:      item.UnitId,
+				ReceiveCode: code,
+				Quantity:    quantity,
+				BranchId:    branchId,
+				Status:      constant.StockStatusQuarantined,
+			}
+			if orderItem.Quantity > 0 {
+				quarantine.CostPrice = orderItem.CostPrice / float64(orderItem.Quantity)
+			}
+			if lot != nil {
+				quarantine.UnitId = lot.UnitId.Hex()
+				quarantine.LotNumber = lot.LotNumber
+				quarantine.CostPrice = lot.CostPrice
+				quarantine.Price = lot.Price
+				quarantine.ExpireDate = lot.ExpireDate
+				quarantine.ImportDate = lot.ImportDate
+			}
+			if unitCost, ok := saleCosts[orderStock.StockId]; ok {
+				quarantine.CostPrice = unitCost
+			}
+			created, err := productEntity.CreateProductStockTx(ctx, quarantine)
+			if err != nil {
+				return nil, nil, err
+			}
+			stock.StockId = created.Id.Hex()
+			valuations = append(valuations, request.StockValuation(created, constant.ValuationTypeReturn, quantity, createdBy))
+		} else if orderStock.StockId != "" {
+			var restored *entities.ProductStock
+			var err error
+			unitCost, sold := saleCosts[orderStock.StockId]
+			if sold {
+				restored, err = productEntity.ReturnProductStockQuantityByIdTx(ctx, orderStock.StockId, quantity, unitCost)
+			} else {
+				restored, err = productEntity.AddProductStockQuantityByIdTx(ctx, orderStock.StockId, quantity)
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			valuation := request.StockValuation(restored, constant.ValuationTypeReturn, quantity, createdBy)
+			if sold {
+				valuation.UnitCost = unitCost
+				valuation.Amount = float64(quantity) * unitCost
+			}
+			valuations = append(valuations, valuation)
+		} else {
+			if _, err := productEntity.AddQuantitySoldFirstByIdTx(ctx, item.ProductId, quantity); err != nil {
+				return nil, nil, err
+			}
+		}
+		stocks = append(stocks, stock)
+	}
+	return stocks, valuations, nil
+}
+
+// splitRefund refunds through the payment types of the order in proportion to what each paid
+func splitRefund(total float64, payments []entities.Payment) []request.CreditNoteRefund {
+	paid := make([]float64, len(payments))
+	var sum float64
+	changeApplied := false
+	for i, payment := range payments {
+		paid[i] = payment.Amount
+		if payment.Type == constant.PaymentTypeCash && !changeApplied {
+			paid[i] -= payment.Change
+			changeApplied = true
+		}
+		if paid[i] < 0 {
+			paid[i] = 0
+		}
+		sum += paid[i]
+	}
+	if sum <= 0 {
+		return []request.CreditNoteRefund{{Type: constant.PaymentTypeCash, Amount: total}}
+	}
+
+	var refunds []request.CreditNoteRefund
+	left := total
+	for i, payment := range payments {
+		if paid[i] == 0 {
+			continue
+		}
+		amount := utils.Round2(total * paid[i] / sum)
+		if i == len(payments)-1 || amount > left {
+			amount = left
+		}
+		left = utils.Round2(left - amount)
+		refunds = append(refunds, request.CreditNoteRefund{Type: payment.Type, Amount: amount})
+	}
+	if left > 0 && len(refunds) > 0 {
+		refunds[len(refunds)-1].Amount = utils.Round2(refunds[len(refunds)-1].Amount + left)
+	}
+	return refunds
+}
+
+// addRefund adds the amount to the refund of the payment type, creating it when the order was not
+// paid that way
+func addRefund(refunds []request.CreditNoteRefund, paymentType string, amount float64) []request.CreditNoteRefund {
+	if amount <= 0 {
+		return refunds
+	}
+	for i := range refunds {
+		if refunds[i].Type == paymentType {
+			refunds[i].Amount = utils.Round2(refunds[i].Amount + amount)
+			return refunds
+		}
+	}
+	return append(refunds, request.CreditNoteRefund{Type: paymentType, Amount: amount})
+}
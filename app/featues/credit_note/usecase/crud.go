@@ -0,0 +1,51 @@
+package usecase
+
+import (
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/data/repositories"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetCreditNotes(entity repositories.ICreditNote) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.GetCreditNoteRange{}
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CN_BAD_REQUEST_001, err.Error())
+			return
+		}
+		req.BranchId = ctx.GetString("BranchId")
+		result, err := entity.GetCreditNoteRange(req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CN_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetCreditNoteById(entity repositories.ICreditNote) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		result, err := entity.GetCreditNoteById(id)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CN_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetCreditNotesByOrderId(entity repositories.ICreditNote) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		orderId := ctx.Param("orderId")
+		result, err := entity.GetCreditNotesByOrderId(orderId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.CN_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
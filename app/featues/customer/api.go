@@ -2,6 +2,7 @@ package customer
 
 import (
 	"github.com/gin-gonic/gin"
+	"pos/app/core/constant"
 	"pos/app/domain"
 	"pos/app/featues/customer/usecase"
 	"pos/middlewares"
@@ -43,6 +44,13 @@ func ApplyCustomerAPI(
 		usecase.UpdateCustomerStatusById(repository.Customer),
 	)
 
+	customerRoute.PATCH("/:customerId/credit",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.UpdateCustomerCreditById(repository.Customer),
+	)
+
 	customerRoute.DELETE("/:customerId",
 		middlewares.RequireAuthenticated(),
 		middlewares.RequireSession(repository.Session),
@@ -0,0 +1,54 @@
+package disposal
+
+import (
+	"pos/app/core/constant"
+	"pos/app/domain"
+	"pos/app/featues/disposal/usecase"
+	"pos/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ApplyDisposalAPI(
+	route *gin.RouterGroup,
+	repository *domain.Repository,
+) {
+	disposalRoute := route.Group("disposals")
+
+	disposalRoute.POST("",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		middlewares.RequireIdempotency(repository.Idempotency),
+		usecase.CreateDisposal(repository.Transaction, repository.Disposal, repository.Product, repository.Sequence),
+	)
+
+	disposalRoute.GET("",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetDisposals(repository.Disposal),
+	)
+
+	disposalRoute.GET("/candidates",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetDisposalCandidates(repository.Product),
+	)
+
+	disposalRoute.GET("/:id",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetDisposalById(repository.Disposal),
+	)
+
+	disposalRoute.GET("/:id/pdf",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetDisposalPDF(repository.Disposal, repository.Setting),
+	)
+}
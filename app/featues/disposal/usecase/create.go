@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateDisposal takes destroyed lots out of stock. Only lots that may not be sold can be disposed of,
+// sellable stock leaves through a stock adjustment.
+func CreateDisposal(
+	transactionEntity repositories.ITransaction,
+	entity repositories.IDisposal,
+	productEntity repositories.IProduct,
+	sequenceEntity repositories.ISequence,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.Disposal{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.DP_BAD_REQUEST_001, err.Error())
+			return
+		}
+		req.BranchId = utils.GetBranchId(ctx)
+		req.CreatedBy = utils.GetUserId(ctx)
+
+		req.Lots = []entities.DisposalItem{}
+		seen := make(map[string]bool)
+		for _, item := range req.Items {
+			if seen[item.StockId] {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.DP_BAD_REQUEST_001, "stock "+item.StockId+" is listed twice")
+				return
+			}
+			seen[item.StockId] = true
+
+			stock, err := productEntity.GetProductStockById(item.StockId)
+			if err != nil || stock.BranchId.Hex() != req.BranchId {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.DP_BAD_REQUEST_003, "stock "+item.StockId+" not found")
+				return
+			}
+			if stock.IsSellable() {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.DP_BAD_REQUEST_004, "lot "+stock.LotNumber+" is sellable, quarantine it first")
+				return
+			}
+			if item.Quantity > stock.Quantity {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.DP_BAD_REQUEST_004, fmt.Sprintf("lot %s holds less than %d", stock.LotNumber, item.Quantity))
+				return
+			}
+			status := stock.Status
+			if constant.IsSellableStockStatus(status) {
+				// Past its expire date but not yet marked by the expiry job
+				status = constant.StockStatusExpired
+			}
+
+			lot := entities.DisposalItem{
+				StockId:    item.StockId,
+				ProductId:  stock.ProductId,
+				UnitId:     stock.UnitId,
+				LotNumber:  stock.LotNumber,
+				ExpireDate: stock.ExpireDate,
+				Status:     status,
+				Quantity:   item.Quantity,
+				CostPrice:  stock.CostPrice,
+				CostAmount: math.Round(stock.CostPrice*float64(item.Quantity)*100) / 100,
+			}
+			if product, _ := productEntity.GetProductById(stock.ProductId.Hex()); product != nil {
+				lot.ProductName = product.Name
+				lot.SerialNumber = product.SerialNumber
+			}
+			if unit, _ := productEntity.GetProductUnitById(stock.UnitId.Hex()); unit != nil {
+				lot.Unit = unit.Unit
+			}
+			req.Lots = append(req.Lots, lot)
+			req.TotalCost = math.Round((req.TotalCost+lot.CostAmount)*100) / 100
+		}
+
+		sequence, _ := sequenceEntity.NextSequence(constant.DISPOSAL)
+		if sequence != nil {
+			req.Code = sequence.GenerateCode()
+		}
+
+		var result *entities.Disposal
+		err := transactionEntity.WithTransaction(func(txCtx context.Context) error {
+			var err error
+			result, err = entity.CreateDisposalTx(txCtx, req)
+			if err != nil {
+				return err
+			}
+			return applyDisposal(txCtx, productEntity, result)
+		})
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.DP_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+// applyDisposal takes the destroyed lots out of stock and records them in the product history under the
+// disposal code
+func applyDisposal(ctx context.Context, productEntity repositories.IProduct, disposal *entities.Disposal) error {
+	for _, item := range disposal.Items {
+		stock, err := productEntity.RemoveProductStockQuantityIfAvailableByIdTx(ctx, item.StockId, item.Quantity)
+		if errors.Is(err, repositories.ErrInsufficientStock) {
+			return fmt.Errorf("lot %s holds less than %d", item.LotNumber, item.Quantity)
+		}
+		if err != nil {
+			return err
+		}
+
+		valuation := request.StockValuation(stock, constant.ValuationTypeDisposal, -item.Quantity, disposal.CreatedBy)
+		valuation.DocumentType = constant.DISPOSAL
+		valuation.DocumentId = disposal.Id.Hex()
+		valuation.DocumentCode = disposal.Code
+		if _, err := productEntity.CreateProductValuationTx(ctx, valuation); err != nil {
+			return err
+		}
+
+		balance := productEntity.GetProductStockBalanceTx(ctx, item.ProductId.Hex(), item.UnitId.Hex())
+		history := request.DisposeProductStockHistory(item.ProductId.Hex(), item.Unit, disposal.Code, item, balance, disposal.CreatedBy)
+		history.BranchId = disposal.BranchId.Hex()
+		history.DocumentType = constant.DISPOSAL
+		history.DocumentId = disposal.Id.Hex()
+		history.DocumentCode = disposal.Code
+		if _, err := productEntity.CreateProductHistoryTx(ctx, history); err != nil {
+			return err
+		}
+	}
+	return nil
+}
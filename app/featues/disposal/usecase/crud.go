@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetDisposals(entity repositories.IDisposal) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.GetDisposalRange{}
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.DP_BAD_REQUEST_001, err.Error())
+			return
+		}
+		req.BranchId = utils.GetBranchId(ctx)
+		result, err := entity.GetDisposalRange(req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.DP_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetDisposalById(entity repositories.IDisposal) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, ok := getBranchDisposal(ctx, entity)
+		if !ok {
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+// GetDisposalCandidates lists the lots of the branch that are in stock but may not be sold
+func GetDisposalCandidates(productEntity repositories.IProduct) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, err := productEntity.GetProductStocksByStatus(utils.GetBranchId(ctx), constant.NonSellableStockStatuses())
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.DP_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func getBranchDisposal(ctx *gin.Context, entity repositories.IDisposal) (*entities.Disposal, bool) {
+	result, err := entity.GetDisposalById(ctx.Param("id"))
+	if err != nil || result.BranchId.Hex() != utils.GetBranchId(ctx) {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.DP_BAD_REQUEST_003, "disposal not found")
+		return nil, false
+	}
+	return result, true
+}
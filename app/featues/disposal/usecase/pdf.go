@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"fmt"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/pdf"
+	"pos/app/core/utils"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDisposalPDF prints the destruction record for GPP inspections, signed by the witnesses
+func GetDisposalPDF(entity repositories.IDisposal, settingEntity repositories.ISetting) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		disposal, ok := getBranchDisposal(ctx, entity)
+		if !ok {
+			return
+		}
+		setting, err := settingEntity.GetSettingByBranchId(disposal.BranchId.Hex())
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.DP_INTERNAL_001, err.Error())
+			return
+		}
+
+		doc := pdf.NewPDF()
+		doc.AddPage()
+		pdf.AddHeader(doc, setting.CompanyName, setting.CompanyAddress, setting.CompanyPhone, "บันทึกการทำลายยา / Drug Destruction Record")
+
+		doc.SetFont(pdf.FontFamily, "", pdf.FontSize)
+		lines := []string{
+			fmt.Sprintf("เลขที่ / No: %s    วันที่ทำลาย / Disposed: %s", disposal.Code, disposal.DisposedDate.In(utils.GetLocation()).Format("02/01/2006")),
+			fmt.Sprintf("วิธีทำลาย / Method: %s", constant.DisposalMethodName(disposal.Method)),
+			fmt.Sprintf("สถานที่ / Location: %s", disposal.Location),
+		}
+		if disposal.Note != "" {
+			lines = append(lines, fmt.Sprintf("หมายเหตุ / Note: %s", disposal.Note))
+		}
+		for _, line := range lines {
+			doc.CellFormat(0, 6, line, "", 1, "L", false, 0, "")
+		}
+		doc.Ln(3)
+
+		headers := []string{"#", "สินค้า / Product", "ล็อต / Lot", "หมดอายุ / Expire", "สถานะ / Status", "จำนวน / Qty", "มูลค่า / Amount"}
+		widths := []float64{10, 58, 26, 24, 24, 22, 26}
+		aligns := []string{"C", "L", "L", "C", "C", "R", "R"}
+		pdf.AddTableHeader(doc, headers, widths)
+		for i, item := range disposal.Items {
+			expireDate := ""
+			if !item.ExpireDate.IsZero() {
+				expireDate = item.ExpireDate.In(utils.GetLocation()).Format("02/01/2006")
+			}
+			pdf.AddTableRow(doc, []string{
+				fmt.Sprintf("%d", i+1),
+				item.ProductName,
+				item.LotNumber,
+				expireDate,
+				item.Status,
+				fmt.Sprintf("%d %s", item.Quantity, item.Unit),
+				fmt.Sprintf("%.2f", item.CostAmount),
+			}, widths, aligns)
+		}
+
+		doc.Ln(3)
+		pdf.AddSummaryLine(doc, "รวม / Total:", fmt.Sprintf("%.2f", disposal.TotalCost), float64(190))
+
+		// One signature line for each witness
+		doc.Ln(10)
+		for _, witness := range disposal.Witnesses {
+			doc.CellFormat(0, 6, "ลงชื่อ / Signature ______________________________ พยาน / Witness", "", 1, "L", false, 0, "")
+			name := witness.Name
+			if witness.Position != "" {
+				name += "  " + witness.Position
+			}
+			if witness.LicenseNo != "" {
+				name += "  (ใบอนุญาต / License " + witness.LicenseNo + ")"
+			}
+			doc.CellFormat(0, 6, "( "+name+" )", "", 1, "L", false, 0, "")
+			doc.Ln(6)
+		}
+		pdf.AddFooter(doc, "", setting.ShowCredit)
+
+		ctx.Header("Content-Type", "application/pdf")
+		ctx.Header("Content-Disposition", fmt.Sprintf("inline; filename=%s.pdf", disposal.Code))
+		if err := doc.Output(ctx.Writer); err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.DP_INTERNAL_001, err.Error())
+			return
+		}
+	}
+}
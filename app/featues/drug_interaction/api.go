@@ -0,0 +1,58 @@
+package drug_interaction
+
+import (
+	"pos/app/core/constant"
+	"pos/app/domain"
+	"pos/app/featues/drug_interaction/usecase"
+	"pos/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ApplyDrugInteractionAPI(
+	route *gin.RouterGroup,
+	repository *domain.Repository,
+) {
+	drugInteractionRoute := route.Group("drug-interactions")
+
+	drugInteractionRoute.POST("",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.CreateDrugInteraction(repository.DrugInteraction),
+	)
+
+	drugInteractionRoute.GET("",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		usecase.GetDrugInteractions(repository.DrugInteraction),
+	)
+
+	drugInteractionRoute.GET("/:id",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		usecase.GetDrugInteractionById(repository.DrugInteraction),
+	)
+
+	drugInteractionRoute.PUT("/:id",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.UpdateDrugInteractionById(repository.DrugInteraction),
+	)
+
+	drugInteractionRoute.DELETE("/:id",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.DeleteDrugInteractionById(repository.DrugInteraction),
+	)
+
+	// Import CSV
+	drugInteractionRoute.POST("/import",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.ImportCSV(repository.DrugInteraction),
+	)
+}
@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func CreateDrugInteraction(entity repositories.IDrugInteraction) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.DrugInteraction{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IX_BAD_REQUEST_001, err.Error())
+			return
+		}
+		if !validPair(ctx, req) {
+			return
+		}
+		req.UpdatedBy = utils.GetUserId(ctx)
+		result, err := entity.CreateDrugInteraction(req)
+		if mongo.IsDuplicateKeyError(err) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.IX_CONFLICT_001, "interaction of this ingredient pair already exists")
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IX_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetDrugInteractions(entity repositories.IDrugInteraction) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.GetDrugInteractions{}
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IX_BAD_REQUEST_001, err.Error())
+			return
+		}
+		result, err := entity.GetDrugInteractions(req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IX_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetDrugInteractionById(entity repositories.IDrugInteraction) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, err := entity.GetDrugInteractionById(ctx.Param("id"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IX_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func UpdateDrugInteractionById(entity repositories.IDrugInteraction) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.DrugInteraction{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IX_BAD_REQUEST_001, err.Error())
+			return
+		}
+		if !validPair(ctx, req) {
+			return
+		}
+		req.UpdatedBy = utils.GetUserId(ctx)
+		result, err := entity.UpdateDrugInteractionById(ctx.Param("id"), req)
+		if mongo.IsDuplicateKeyError(err) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.IX_CONFLICT_001, "interaction of this ingredient pair already exists")
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IX_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func DeleteDrugInteractionById(entity repositories.IDrugInteraction) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, err := entity.RemoveDrugInteractionById(ctx.Param("id"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IX_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+// validPair rejects entries whose ingredients are empty or the same once normalized
+func validPair(ctx *gin.Context, req request.DrugInteraction) bool {
+	ingredientA, ingredientB := entities.IngredientPair(req.IngredientA, req.IngredientB)
+	if ingredientA == "" || ingredientA == ingredientB {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.IX_BAD_REQUEST_001, "an interaction needs two different ingredients")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"encoding/csv"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CSVImportResult struct {
+	Total   int      `json:"total"`
+	Success int      `json:"success"`
+	Failed  int      `json:"failed"`
+	Errors  []string `json:"errors"`
+}
+
+// ImportCSV loads interactions in bulk, an existing ingredient pair is updated in place
+func ImportCSV(entity repositories.IDrugInteraction) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		file, _, err := ctx.Request.FormFile("file")
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IX_BAD_REQUEST_001, "ไม่พบไฟล์ CSV")
+			return
+		}
+		defer file.Close()
+
+		reader := csv.NewReader(file)
+		reader.FieldsPerRecord = -1
+		records, err := reader.ReadAll()
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IX_BAD_REQUEST_001, "อ่านไฟล์ CSV ไม่สำเร็จ: "+err.Error())
+			return
+		}
+
+		if len(records) < 2 {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IX_BAD_REQUEST_001, "ไฟล์ CSV ต้องมีอย่างน้อย 1 แถวข้อมูล (ไม่นับ header)")
+			return
+		}
+
+		userId := utils.GetUserId(ctx)
+		result := CSVImportResult{Total: len(records) - 1}
+		errs := []string{}
+
+		// Expected CSV columns: ingredientA, ingredientB, severity, mechanism, management, source
+		for i, row := range records[1:] {
+			rowNum := i + 2
+			if len(row) < 3 {
+				errs = append(errs, "แถว "+strconv.Itoa(rowNum)+": ข้อมูลไม่ครบ (ต้องมีอย่างน้อย 3 คอลัมน์)")
+				result.Failed++
+				continue
+			}
+			column := func(index int) string {
+				if len(row) > index {
+					return strings.TrimSpace(row[index])
+				}
+				return ""
+			}
+
+			form := request.DrugInteraction{
+				IngredientA: column(0),
+				IngredientB: column(1),
+				Severity:    strings.ToUpper(column(2)),
+				Mechanism:   column(3),
+				Management:  column(4),
+				Source:      column(5),
+				UpdatedBy:   userId,
+			}
+			ingredientA, ingredientB := entities.IngredientPair(form.IngredientA, form.IngredientB)
+			if ingredientA == "" || ingredientA == ingredientB {
+				errs = append(errs, "แถว "+strconv.Itoa(rowNum)+": ต้องระบุตัวยาสองชนิดที่ต่างกัน")
+				result.Failed++
+				continue
+			}
+			if !utils.InArrayString(form.Severity, constant.InteractionSeverities()) {
+				errs = append(errs, "แถว "+strconv.Itoa(rowNum)+": ระดับความรุนแรงไม่ถูกต้อง "+form.Severity)
+				result.Failed++
+				continue
+			}
+
+			if _, err := entity.UpsertDrugInteraction(form); err != nil {
+				errs = append(errs, "แถว "+strconv.Itoa(rowNum)+": "+err.Error())
+				result.Failed++
+				continue
+			}
+			result.Success++
+		}
+
+		result.Errors = errs
+		ctx.JSON(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,74 @@
+package etax
+
+import (
+	"pos/app/domain"
+	"pos/app/featues/etax/usecase"
+	"pos/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ApplyEtaxAPI(
+	route *gin.RouterGroup,
+	repository *domain.Repository,
+) {
+	etaxRoute := route.Group("etax")
+
+	etaxRoute.GET("/tax-invoices/:id/preview",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.PreviewTaxInvoice(repository.TaxInvoice, repository.Order),
+	)
+
+	etaxRoute.POST("/tax-invoices/:id",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireIdempotency(repository.Idempotency),
+		usecase.ExportTaxInvoice(repository.EtaxDocument, repository.TaxInvoice, repository.Order, repository.Setting),
+	)
+
+	etaxRoute.GET("/credit-notes/:id/preview",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.PreviewCreditNote(repository.CreditNote, repository.TaxInvoice, repository.Order),
+	)
+
+	etaxRoute.POST("/credit-notes/:id",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireIdempotency(repository.Idempotency),
+		usecase.ExportCreditNote(repository.EtaxDocument, repository.CreditNote, repository.TaxInvoice, repository.Order, repository.Setting),
+	)
+
+	etaxRoute.GET("/documents",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetEtaxDocuments(repository.EtaxDocument),
+	)
+
+	etaxRoute.GET("/documents/:id",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetEtaxDocumentById(repository.EtaxDocument),
+	)
+
+	etaxRoute.GET("/documents/:id/xml",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetEtaxDocumentXML(repository.EtaxDocument),
+	)
+
+	etaxRoute.GET("/documents/:id/pdf",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetEtaxDocumentPDF(repository.EtaxDocument),
+	)
+}
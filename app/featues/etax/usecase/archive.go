@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"fmt"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetEtaxDocuments(entity repositories.IEtaxDocument) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.GetEtaxDocumentRange{}
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.ET_BAD_REQUEST_001, err.Error())
+			return
+		}
+		req.BranchId = utils.GetBranchId(ctx)
+		result, err := entity.GetEtaxDocumentRange(req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.ET_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetEtaxDocumentById(entity repositories.IEtaxDocument) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, ok := getArchivedDocument(ctx, entity)
+		if !ok {
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+// GetEtaxDocumentXML returns the signed XML exactly as it was archived
+func GetEtaxDocumentXML(entity repositories.IEtaxDocument) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, ok := getArchivedDocument(ctx, entity)
+		if !ok {
+			return
+		}
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.xml", result.Code))
+		ctx.Data(http.StatusOK, "application/xml; charset=utf-8", result.Xml)
+	}
+}
+
+// GetEtaxDocumentPDF returns the archived PDF/A-3 with the signed XML attached
+func GetEtaxDocumentPDF(entity repositories.IEtaxDocument) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, ok := getArchivedDocument(ctx, entity)
+		if !ok {
+			return
+		}
+		ctx.Header("Content-Disposition", fmt.Sprintf("inline; filename=%s.pdf", result.Code))
+		ctx.Data(http.StatusOK, "application/pdf", result.Pdf)
+	}
+}
+
+func getArchivedDocument(ctx *gin.Context, entity repositories.IEtaxDocument) (*entities.EtaxDocument, bool) {
+	result, err := entity.GetEtaxDocumentById(ctx.Param("id"))
+	if err != nil || result.BranchId.Hex() != utils.GetBranchId(ctx) {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.ET_BAD_REQUEST_003, "e-Tax document not found")
+		return nil, false
+	}
+	return result, true
+}
@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"pos/app/core/etax"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"time"
+)
+
+// taxInvoiceTypeCode picks the ETDA type of a tax invoice, a sale on credit is invoiced and one paid
+// at the counter is also the receipt
+func taxInvoiceTypeCode(orderEntity repositories.IOrder, invoice *entities.TaxInvoice) string {
+	onCredit := false
+	payments, _ := orderEntity.GetPaymentsByOrderId(invoice.OrderId.Hex())
+	for _, payment := range payments {
+		if payment.Type == constant.PaymentTypeCredit {
+			onCredit = true
+		}
+	}
+	switch {
+	case invoice.IsFull() && onCredit:
+		return etax.TypeInvoiceTaxInvoice
+	case invoice.IsFull():
+		return etax.TypeReceiptTaxInvoice
+	case onCredit:
+		return etax.TypeAbbreviatedTaxInvoice
+	}
+	return etax.TypeReceiptAbbreviatedInvoice
+}
+
+func taxInvoiceDocument(invoice *entities.TaxInvoice, typeCode string, now time.Time) *etax.Document {
+	doc := &etax.Document{
+		TypeCode:     typeCode,
+		Name:         etax.TypeName(typeCode),
+		Code:         invoice.Code,
+		IssuedDate:   invoice.IssuedDate,
+		CreatedDate:  now,
+		Seller:       documentParty(invoice.Seller),
+		CurrencyCode: "THB",
+	}
+	if invoice.Buyer != nil {
+		buyer := documentParty(*invoice.Buyer)
+		doc.Buyer = &buyer
+	}
+	documentAmounts(doc, invoice)
+	return doc
+}
+
+// creditNoteDocument writes the credit note against the invoice it corrects, its lines are the
+// returned goods as they were invoiced
+func creditNoteDocument(note *entities.CreditNote, invoice *entities.TaxInvoice, invoiceTypeCode string, now time.Time) *etax.Document {
+	credit := invoice.CreditNoteInvoice(note)
+	doc := taxInvoiceDocument(&credit, etax.TypeCreditNote, now)
+	doc.IssuedDate = note.CreatedDate
+	doc.Purpose = note.Reason
+	doc.PurposeCode = etax.PurposeGoodsReturned
+	doc.References = []etax.Reference{{
+		Code:       invoice.Code,
+		IssuedDate: invoice.IssuedDate,
+		TypeCode:   invoiceTypeCode,
+	}}
+	doc.Original = utils.Round2(invoice.ExemptAmount + invoice.TaxableAmount)
+	doc.Difference = doc.LineTotal
+	doc.LineTotal = utils.Round2(doc.Original - doc.Difference)
+	return doc
+}
+
+func documentParty(party entities.TaxInvoiceParty) etax.Party {
+	return etax.Party{
+		Name:     party.Name,
+		TaxId:    party.TaxId,
+		BranchNo: party.BranchNo,
+		Address:  party.Address,
+		Postcode: etax.Postcode(party.Address),
+	}
+}
+
+// documentAmounts fills the lines and the summation from a tax invoice. Invoice amounts include
+// VAT, so each line is taken back to its value before VAT and the bill discount becomes the
+// allowance between the line total and the tax basis.
+func documentAmounts(doc *etax.Document, invoice *entities.TaxInvoice) {
+	lineTotal := 0.0
+	for _, item := range invoice.Items {
+		amount := item.Amount
+		if !item.VatExempt {
+			amount = utils.Round2(amount * 100 / (100 + item.VatRate))
+		}
+		line := etax.Line{
+			Name:      item.Name,
+			Quantity:  float64(item.Quantity),
+			Amount:    amount,
+			VatRate:   item.VatRate,
+			VatExempt: item.VatExempt,
+		}
+		if item.Quantity > 0 {
+			line.UnitPrice = utils.Round2(amount / float64(item.Quantity))
+		}
+		doc.Lines = append(doc.Lines, line)
+		lineTotal += amount
+	}
+
+	doc.TaxBasis = utils.Round2(invoice.ExemptAmount + invoice.TaxableAmount)
+	allowance := utils.Round2(lineTotal - doc.TaxBasis)
+	if invoice.Discount == 0 && allowance != 0 && len(doc.Lines) > 0 {
+		// Taking VAT out line by line can miss the basis by a few satang, the last line absorbs it
+		last := &doc.Lines[len(doc.Lines)-1]
+		last.Amount = utils.Round2(last.Amount - allowance)
+		lineTotal -= allowance
+		allowance = 0
+	}
+	doc.LineTotal = utils.Round2(lineTotal)
+	doc.Allowance = allowance
+
+	if invoice.ExemptAmount > 0 {
+		doc.Taxes = append(doc.Taxes, etax.Tax{Exempt: true, Basis: invoice.ExemptAmount})
+	}
+	for _, rate := range invoice.VatRates {
+		doc.Taxes = append(doc.Taxes, etax.Tax{Rate: rate.Rate, Basis: rate.Amount, Amount: rate.Vat})
+	}
+	doc.TaxTotal = invoice.VatAmount
+	doc.GrandTotal = invoice.Total
+}
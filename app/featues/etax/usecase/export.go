@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/etax"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PreviewTaxInvoice returns the unsigned XML of a tax invoice, or the fields that fail validation
+func PreviewTaxInvoice(taxInvoiceEntity repositories.ITaxInvoice, orderEntity repositories.IOrder) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		doc, _, ok := loadTaxInvoiceDocument(ctx, taxInvoiceEntity, orderEntity)
+		if !ok {
+			return
+		}
+		previewDocument(ctx, doc)
+	}
+}
+
+// ExportTaxInvoice signs a tax invoice as an e-Tax document and archives it with its PDF/A-3
+func ExportTaxInvoice(
+	etaxDocumentEntity repositories.IEtaxDocument,
+	taxInvoiceEntity repositories.ITaxInvoice,
+	orderEntity repositories.IOrder,
+	settingEntity repositories.ISetting,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		doc, invoice, ok := loadTaxInvoiceDocument(ctx, taxInvoiceEntity, orderEntity)
+		if !ok {
+			return
+		}
+		form := request.EtaxDocument{
+			SourceType: constant.EtaxSourceTaxInvoice,
+			SourceId:   invoice.Id.Hex(),
+		}
+		exportDocument(ctx, etaxDocumentEntity, settingEntity, doc, form)
+	}
+}
+
+// PreviewCreditNote returns the unsigned XML of a credit note, or the fields that fail validation
+func PreviewCreditNote(creditNoteEntity repositories.ICreditNote, taxInvoiceEntity repositories.ITaxInvoice, orderEntity repositories.IOrder) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		doc, _, ok := loadCreditNoteDocument(ctx, creditNoteEntity, taxInvoiceEntity, orderEntity)
+		if !ok {
+			return
+		}
+		previewDocument(ctx, doc)
+	}
+}
+
+// ExportCreditNote signs a credit note as an e-Tax document and archives it with its PDF/A-3
+func ExportCreditNote(
+	etaxDocumentEntity repositories.IEtaxDocument,
+	creditNoteEntity repositories.ICreditNote,
+	taxInvoiceEntity repositories.ITaxInvoice,
+	orderEntity repositories.IOrder,
+	settingEntity repositories.ISetting,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		doc, note, ok := loadCreditNoteDocument(ctx, creditNoteEntity, taxInvoiceEntity, orderEntity)
+		if !ok {
+			return
+		}
+		form := request.EtaxDocument{
+			SourceType: constant.EtaxSourceCreditNote,
+			SourceId:   note.Id.Hex(),
+		}
+		exportDocument(ctx, etaxDocumentEntity, settingEntity, doc, form)
+	}
+}
+
+func loadTaxInvoiceDocument(ctx *gin.Context, taxInvoiceEntity repositories.ITaxInvoice, orderEntity repositories.IOrder) (*etax.Document, *entities.TaxInvoice, bool) {
+	invoice, err := taxInvoiceEntity.GetTaxInvoiceById(ctx.Param("id"))
+	if err != nil || invoice.BranchId.Hex() != utils.GetBranchId(ctx) {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.ET_BAD_REQUEST_003, "tax invoice not found")
+		return nil, nil, false
+	}
+	if invoice.Status != constant.TaxInvoiceStatusIssued {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.ET_BAD_REQUEST_002, "only a tax invoice in force can be sent as e-Tax")
+		return nil, nil, false
+	}
+	return taxInvoiceDocument(invoice, taxInvoiceTypeCode(orderEntity, invoice), time.Now()), invoice, true
+}
+
+func loadCreditNoteDocument(
+	ctx *gin.Context,
+	creditNoteEntity repositories.ICreditNote,
+	taxInvoiceEntity repositories.ITaxInvoice,
+	orderEntity repositories.IOrder,
+) (*etax.Document, *entities.CreditNote, bool) {
+	note, err := creditNoteEntity.GetCreditNoteById(ctx.Param("id"))
+	if err != nil || note.BranchId.Hex() != utils.GetBranchId(ctx) {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.ET_BAD_REQUEST_003, "credit note not found")
+		return nil, nil, false
+	}
+	if note.Status != constant.ACTIVE {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.ET_BAD_REQUEST_002, "credit note is not active")
+		return nil, nil, false
+	}
+	invoices, _ := taxInvoiceEntity.GetTaxInvoicesByOrderId(note.OrderId.Hex())
+	invoice := entities.CreditedTaxInvoice(invoices)
+	if invoice == nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.ET_BAD_REQUEST_002, "the sale has no tax invoice to credit")
+		return nil, nil, false
+	}
+	return creditNoteDocument(note, invoice, taxInvoiceTypeCode(orderEntity, invoice), time.Now()), note, true
+}
+
+func previewDocument(ctx *gin.Context, doc *etax.Document) {
+	if err := doc.Validate(); err != nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.ET_BAD_REQUEST_002, err.Error())
+		return
+	}
+	ctx.Data(http.StatusOK, "application/xml; charset=utf-8", etax.Marshal(etax.Build(doc)))
+}
+
+func exportDocument(
+	ctx *gin.Context,
+	etaxDocumentEntity repositories.IEtaxDocument,
+	settingEntity repositories.ISetting,
+	doc *etax.Document,
+	form request.EtaxDocument,
+) {
+	if existing, err := etaxDocumentEntity.GetEtaxDocumentBySourceId(form.SourceId); err == nil {
+		errcode.Abort(ctx, http.StatusConflict, errcode.ET_CONFLICT_001, "already sent as e-Tax document "+existing.Id.Hex())
+		return
+	}
+	branchId := utils.GetBranchId(ctx)
+	setting, _ := settingEntity.GetSettingByBranchId(branchId)
+	if setting == nil || !setting.VatRegistered {
+		errcode.Abort(ctx, http.StatusForbidden, errcode.ET_FORBIDDEN_001, "branch is not VAT registered")
+		return
+	}
+	if err := doc.Validate(); err != nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.ET_BAD_REQUEST_002, err.Error())
+		return
+	}
+	signer, err := etax.DefaultSigner()
+	if err != nil {
+		errcode.Abort(ctx, http.StatusInternalServerError, errcode.ET_INTERNAL_001, "e-Tax signer: "+err.Error())
+		return
+	}
+	if signer == nil {
+		errcode.Abort(ctx, http.StatusForbidden, errcode.ET_FORBIDDEN_001, "e-Tax signing is not configured")
+		return
+	}
+
+	root := etax.Build(doc)
+	if err := etax.Sign(root, signer, doc.CreatedDate); err != nil {
+		errcode.Abort(ctx, http.StatusInternalServerError, errcode.ET_INTERNAL_001, err.Error())
+		return
+	}
+	xml := etax.Marshal(root)
+	pdfData, err := renderDocumentPDF(doc, xml, setting)
+	if err != nil {
+		errcode.Abort(ctx, http.StatusInternalServerError, errcode.ET_INTERNAL_001, err.Error())
+		return
+	}
+
+	digest := sha256.Sum256(xml)
+	cert := signer.Certificate()
+	form.BranchId = branchId
+	form.TypeCode = doc.TypeCode
+	form.Code = doc.Code
+	form.IssuedDate = doc.IssuedDate
+	form.Total = doc.GrandTotal
+	form.XmlDigest = hex.EncodeToString(digest[:])
+	form.SignerSubject = cert.Subject.String()
+	form.SignerSerial = cert.SerialNumber.String()
+	form.Xml = xml
+	form.Pdf = pdfData
+	form.CreatedBy = utils.GetUserId(ctx)
+	form.CreatedDate = doc.CreatedDate
+
+	result, err := etaxDocumentEntity.CreateEtaxDocument(form)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.ET_CONFLICT_001, "already sent as e-Tax")
+			return
+		}
+		errcode.Abort(ctx, http.StatusInternalServerError, errcode.ET_INTERNAL_001, err.Error())
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"bytes"
+	"fmt"
+	"pos/app/core/etax"
+	"pos/app/core/pdf"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/domain/constant"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// renderDocumentPDF prints the e-Tax document and carries its signed XML as a PDF/A-3 attachment
+func renderDocumentPDF(doc *etax.Document, xml []byte, setting *entities.Setting) ([]byte, error) {
+	out := pdf.NewPDF()
+	pdf.SetPDFA3(out, doc.Name+" "+doc.Code, doc.CreatedDate, fpdf.Attachment{
+		Content:     xml,
+		Filename:    doc.Code + ".xml",
+		Description: "ETDA e-Tax Invoice XML",
+	})
+	out.AddPage()
+	pdf.AddHeader(out, doc.Seller.Name, doc.Seller.Address, setting.CompanyPhone, doc.Name+" / e-Tax")
+
+	out.SetFont(pdf.FontFamily, "", 9)
+	out.CellFormat(0, 5, fmt.Sprintf("เลขประจำตัวผู้เสียภาษี / Tax ID: %s  %s", doc.Seller.TaxId, taxBranchLabel(doc.Seller.BranchNo)), "", 1, "C", false, 0, "")
+	out.Ln(2)
+
+	out.CellFormat(95, 5, fmt.Sprintf("เลขที่ / No: %s", doc.Code), "", 0, "L", false, 0, "")
+	out.CellFormat(95, 5, fmt.Sprintf("วันที่ / Date: %s", doc.IssuedDate.In(utils.GetLocation()).Format("02/01/2006")), "", 1, "R", false, 0, "")
+	for _, ref := range doc.References {
+		out.CellFormat(0, 5, fmt.Sprintf("อ้างอิง %s / Ref: %s  %s", etax.TypeName(ref.TypeCode), ref.Code, ref.IssuedDate.In(utils.GetLocation()).Format("02/01/2006")), "", 1, "L", false, 0, "")
+	}
+	if doc.Purpose != "" {
+		out.CellFormat(0, 5, fmt.Sprintf("เหตุผล / Reason: %s", doc.Purpose), "", 1, "L", false, 0, "")
+	}
+	if doc.Buyer != nil {
+		out.Ln(2)
+		out.CellFormat(0, 5, fmt.Sprintf("ผู้ซื้อ / Buyer: %s", doc.Buyer.Name), "", 1, "L", false, 0, "")
+		for _, line := range pdf.SplitText(out, "ที่อยู่ / Address: "+doc.Buyer.Address, 190) {
+			out.CellFormat(0, 5, line, "", 1, "L", false, 0, "")
+		}
+		if doc.Buyer.TaxId != "" {
+			out.CellFormat(0, 5, fmt.Sprintf("เลขประจำตัวผู้เสียภาษี / Tax ID: %s  %s", doc.Buyer.TaxId, taxBranchLabel(doc.Buyer.BranchNo)), "", 1, "L", false, 0, "")
+		}
+	}
+	out.Ln(3)
+
+	headers := []string{"#", "Description", "Qty", "Unit Price", "Amount"}
+	widths := []float64{10, 80, 20, 40, 40}
+	aligns := []string{"C", "L", "C", "R", "R"}
+	pdf.AddTableHeader(out, headers, widths)
+	hasExempt := false
+	for i, line := range doc.Lines {
+		name := line.Name
+		if line.VatExempt {
+			name += " *"
+			hasExempt = true
+		}
+		pdf.AddTableRow(out, []string{
+			fmt.Sprintf("%d", i+1),
+			name,
+			fmt.Sprintf("%g", line.Quantity),
+			fmt.Sprintf("%.2f", line.UnitPrice),
+			fmt.Sprintf("%.2f", line.Amount),
+		}, widths, aligns)
+	}
+
+	out.Ln(3)
+	totalWidth := float64(190)
+	if doc.IsNote() {
+		pdf.AddSummaryLine(out, "มูลค่าตามเอกสารเดิม / Original:", fmt.Sprintf("%.2f", doc.Original), totalWidth)
+		pdf.AddSummaryLine(out, "มูลค่าที่ถูกต้อง / Corrected:", fmt.Sprintf("%.2f", doc.LineTotal), totalWidth)
+		pdf.AddSummaryLine(out, "ผลต่าง / Difference:", fmt.Sprintf("%.2f", doc.Difference), totalWidth)
+	} else {
+		pdf.AddSummaryLine(out, "รวม / Line Total:", fmt.Sprintf("%.2f", doc.LineTotal), totalWidth)
+	}
+	if doc.Allowance != 0 {
+		pdf.AddSummaryLine(out, "ส่วนลด / Discount:", fmt.Sprintf("-%.2f", doc.Allowance), totalWidth)
+	}
+	for _, tax := range doc.Taxes {
+		if tax.Exempt {
+			pdf.AddSummaryLine(out, "มูลค่ายกเว้นภาษี / VAT Exempt:", fmt.Sprintf("%.2f", tax.Basis), totalWidth)
+			continue
+		}
+		pdf.AddSummaryLine(out, fmt.Sprintf("มูลค่าสินค้า / Before VAT %g%%:", tax.Rate), fmt.Sprintf("%.2f", tax.Basis), totalWidth)
+		pdf.AddSummaryLine(out, fmt.Sprintf("ภาษีมูลค่าเพิ่ม / VAT %g%%:", tax.Rate), fmt.Sprintf("%.2f", tax.Amount), totalWidth)
+	}
+	pdf.AddSummaryLine(out, "Grand Total:", fmt.Sprintf("%.2f", doc.GrandTotal), totalWidth)
+	if hasExempt {
+		out.SetFont(pdf.FontFamily, "", 8)
+		out.CellFormat(0, 5, "* สินค้าได้รับยกเว้นภาษีมูลค่าเพิ่ม / VAT exempt item", "", 1, "L", false, 0, "")
+	}
+
+	footer := "เอกสารนี้ลงลายมือชื่อดิจิทัล ข้อมูลต้นฉบับแนบอยู่ในไฟล์ / Digitally signed, the original XML is attached"
+	pdf.AddFooter(out, footer, setting.ShowCredit)
+
+	buf := &bytes.Buffer{}
+	if err := out.Output(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// taxBranchLabel prints the Revenue Department branch of a VAT registrant
+func taxBranchLabel(branchNo string) string {
+	if branchNo == "" {
+		return ""
+	}
+	if branchNo == constant.HeadOfficeBranchNo {
+		return "สำนักงานใหญ่ / Head Office"
+	}
+	return "สาขาที่ / Branch " + branchNo
+}
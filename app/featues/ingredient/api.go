@@ -0,0 +1,50 @@
+package ingredient
+
+import (
+	"pos/app/core/constant"
+	"pos/app/domain"
+	"pos/app/featues/ingredient/usecase"
+	"pos/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ApplyIngredientAPI(
+	route *gin.RouterGroup,
+	repository *domain.Repository,
+) {
+	ingredientRoute := route.Group("ingredients")
+
+	ingredientRoute.POST("",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.CreateIngredient(repository.Ingredient),
+	)
+
+	ingredientRoute.GET("",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		usecase.GetIngredients(repository.Ingredient),
+	)
+
+	ingredientRoute.GET("/:id",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		usecase.GetIngredientById(repository.Ingredient),
+	)
+
+	ingredientRoute.PUT("/:id",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.UpdateIngredientById(repository.Ingredient, repository.Product),
+	)
+
+	ingredientRoute.DELETE("/:id",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.DeleteIngredientById(repository.Ingredient, repository.Product),
+	)
+}
@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func CreateIngredient(ingredientEntity repositories.IIngredient) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.Ingredient{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_001, err.Error())
+			return
+		}
+		if entities.NormalizeIngredient(req.Name) == "" {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_001, "ingredient name is required")
+			return
+		}
+		req.UpdatedBy = utils.GetUserId(ctx)
+		result, err := ingredientEntity.CreateIngredient(req)
+		if mongo.IsDuplicateKeyError(err) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.IG_CONFLICT_001, "ingredient already exists: "+req.Name)
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetIngredients(ingredientEntity repositories.IIngredient) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.GetIngredients{}
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_001, err.Error())
+			return
+		}
+		result, err := ingredientEntity.GetIngredients(req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetIngredientById(ingredientEntity repositories.IIngredient) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, err := ingredientEntity.GetIngredientById(ctx.Param("id"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_003, "ingredient not found")
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func UpdateIngredientById(ingredientEntity repositories.IIngredient, productEntity repositories.IProduct) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		req := request.Ingredient{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_001, err.Error())
+			return
+		}
+		if entities.NormalizeIngredient(req.Name) == "" {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_001, "ingredient name is required")
+			return
+		}
+		req.UpdatedBy = utils.GetUserId(ctx)
+		result, err := ingredientEntity.UpdateIngredientById(id, req)
+		if mongo.IsDuplicateKeyError(err) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.IG_CONFLICT_001, "ingredient already exists: "+req.Name)
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_002, err.Error())
+			return
+		}
+		if err := productEntity.RenameProductIngredient(id, result.Name); err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.IG_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func DeleteIngredientById(ingredientEntity repositories.IIngredient, productEntity repositories.IProduct) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		linked, err := productEntity.CountProductsByIngredientId(id)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_003, "ingredient not found")
+			return
+		}
+		if linked > 0 {
+			errcode.Abort(ctx, http.StatusConflict, errcode.IG_CONFLICT_001, "ingredient is still linked to products")
+			return
+		}
+		result, err := ingredientEntity.RemoveIngredientById(id)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.IG_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
@@ -19,7 +19,8 @@ func ApplyOrderAPI(
 		middlewares.RequireAuthenticated(),
 		middlewares.RequireSession(repository.Session),
 		middlewares.RequireBranch(repository.Employee, repository.Branch),
-		usecase.CreateOrder(repository.Order, repository.Product, repository.Sequence),
+		middlewares.RequireIdempotency(repository.Idempotency),
+		usecase.CreateOrder(repository.Transaction, repository.Order, repository.Product, repository.Sequence, repository.Customer, repository.Promotion, repository.Setting, repository.Cart, repository.Shift, repository.Receivable, repository.Employee, repository.DispensingLog, repository.Patient, repository.DrugInteraction, repository.Ingredient),
 	)
 
 	orderRoute.GET("",
@@ -36,20 +37,19 @@ func ApplyOrderAPI(
 		usecase.GetOrderById(repository.Order),
 	)
 
-	orderRoute.DELETE("/:orderId",
+	orderRoute.PATCH("/:orderId/void",
 		middlewares.RequireAuthenticated(),
 		middlewares.RequireSession(repository.Session),
 		middlewares.RequireBranch(repository.Employee, repository.Branch),
 		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
-		usecase.DeleteOrderById(repository.Order, repository.Product),
+		usecase.VoidOrderById(repository.Transaction, repository.Order, repository.Product, repository.CreditNote, repository.Setting, repository.Receivable, repository.DispensingLog, repository.TaxInvoice),
 	)
 
-	orderRoute.DELETE("/:orderId/products/:productId",
+	orderRoute.GET("/void-reasons",
 		middlewares.RequireAuthenticated(),
 		middlewares.RequireSession(repository.Session),
 		middlewares.RequireBranch(repository.Employee, repository.Branch),
-		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
-		usecase.DeleteOrderItemByOrderProductId(repository.Order, repository.Product),
+		usecase.GetVoidReasons(repository.Setting),
 	)
 
 	orderRoute.PATCH("/:orderId/customer-code",
@@ -80,14 +80,6 @@ func ApplyOrderAPI(
 		usecase.GetOrderItemById(repository.Order),
 	)
 
-	orderRoute.DELETE("/items/:itemId",
-		middlewares.RequireAuthenticated(),
-		middlewares.RequireSession(repository.Session),
-		middlewares.RequireBranch(repository.Employee, repository.Branch),
-		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
-		usecase.DeleteOrderItemById(repository.Order, repository.Product),
-	)
-
 	orderRoute.GET("/items/products/:productId",
 		middlewares.RequireAuthenticated(),
 		middlewares.RequireSession(repository.Session),
@@ -0,0 +1,202 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/request"
+	"strings"
+)
+
+// needStockAllocation reports whether the line must be cut from stock by the server,
+// that is the client did not pick every lot itself.
+func needStockAllocation(item request.OrderItem) bool {
+	if len(item.Stocks) == 0 {
+		return true
+	}
+	for _, stock := range item.Stocks {
+		if stock.StockId == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// pickedQuantity sums the lots the client picked for the line in the base unit, every lot being
+// counted in its own unit
+func pickedQuantity(productEntity repositories.IProduct, item request.OrderItem, units map[string]*entities.ProductUnit) (int, error) {
+	var picked int
+	for _, itemStock := range item.Stocks {
+		stock, err := productEntity.GetProductStockById(itemStock.StockId)
+		if err != nil || stock.ProductId.Hex() != item.ProductId {
+			return 0, fmt.Errorf("stock %s does not belong to product %s", itemStock.StockId, item.ProductId)
+		}
+		picked += itemStock.Quantity * entities.UnitSize(units, stock.UnitId.Hex())
+	}
+	return picked, nil
+}
+
+// stockShortageError aborts the checkout transaction when lines are not covered by stock
+type stockShortageError struct {
+	Lines []entities.StockShortage
+}
+
+func (e *stockShortageError) Error() string {
+	return "insufficient stock"
+}
+
+// unsellableLotError aborts the checkout transaction when a picked lot is recalled, quarantined,
+// damaged or expired
+type unsellableLotError struct {
+	ProductId string `json:"productId"`
+	StockId   string `json:"stockId"`
+	LotNumber string `json:"lotNumber"`
+	Status    string `json:"status,omitempty"`
+}
+
+func (e *unsellableLotError) Error() string {
+	if e.Status == "" {
+		return "lot " + e.LotNumber + " is expired"
+	}
+	return "lot " + e.LotNumber + " is " + strings.ToLower(e.Status)
+}
+
+// allocateStocks cuts the line quantity across the sellable branch lots First-Expired-First-Out.
+// The sold quantity is converted to the base unit with ProductUnit.Size and every lot is
+// consumed in its own unit, so only whole lot units are taken. When the lots do not cover
+// the line a shortage is returned, and with allowNegative the rest is charged to the first
+// lot so that it goes below zero.
+func allocateStocks(
+	ctx context.Context,
+	productEntity repositories.IProduct,
+	item request.OrderItem,
+	units map[string]*entities.ProductUnit,
+	branchId string,
+	allowNegative bool,
+) ([]request.OrderItemStock, *entities.StockShortage, error) {
+	size := entities.UnitSize(units, item.UnitId)
+	remaining := item.Quantity * size
+	if remaining <= 0 {
+		return nil, nil, fmt.Errorf("invalid quantity for product %s", item.ProductId)
+	}
+
+	lots, err := productEntity.GetSellableProductStocksTx(ctx, item.ProductId, branchId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var stocks []request.OrderItemStock
+	var available int
+	for _, lot := range lots {
+		lotSize := entities.UnitSize(units, lot.UnitId.Hex())
+		available += lot.Quantity * lotSize
+		if remaining == 0 {
+			continue
+		}
+		take := lot.Quantity * lotSize
+		if take > remaining {
+			take = remaining - remaining%lotSize
+		}
+		if take <= 0 {
+			continue
+		}
+		stocks = append(stocks, request.OrderItemStock{
+			Quantity:   take / lotSize,
+			StockId:    lot.Id.Hex(),
+			LotNumber:  lot.LotNumber,
+			ExpireDate: lot.ExpireDate,
+		})
+		remaining -= take
+	}
+
+	if remaining == 0 {
+		return stocks, nil, nil
+	}
+
+	shortage := &entities.StockShortage{
+		ProductId: item.ProductId,
+		UnitId:    item.UnitId,
+		Quantity:  item.Quantity,
+		Available: available / size,
+	}
+	if !allowNegative {
+		return nil, shortage, nil
+	}
+
+	// Charge the rest to the first lot, or to the latest lot of the branch when none is sellable
+	target := lots
+	if len(target) == 0 {
+		target, _ = productEntity.GetProductStocksByProductId(item.ProductId, branchId)
+		if len(target) > 0 {
+			target = target[len(target)-1:]
+		}
+	}
+	if len(target) == 0 {
+		return nil, shortage, nil
+	}
+	lot := target[0]
+	lotSize := entities.UnitSize(units, lot.UnitId.Hex())
+	quantity := (remaining + lotSize - 1) / lotSize
+	merged := false
+	for i := range stocks {
+		if stocks[i].StockId == lot.Id.Hex() {
+			stocks[i].Quantity += quantity
+			merged = true
+		}
+	}
+	if !merged {
+		stocks = append(stocks, request.OrderItemStock{
+			Quantity:   quantity,
+			StockId:    lot.Id.Hex(),
+			LotNumber:  lot.LotNumber,
+			ExpireDate: lot.ExpireDate,
+		})
+	}
+	return stocks, shortage, nil
+}
+
+// reservedShortages returns the lines that would eat into units soft reserved by parked carts.
+// Sellable lots and reservations are compared in the base unit, per product.
+func reservedShortages(
+	ctx context.Context,
+	productEntity repositories.IProduct,
+	items []request.OrderItem,
+	units map[string]*entities.ProductUnit,
+	branchId string,
+	reserved map[string]int,
+) ([]entities.StockShortage, error) {
+	var shortages []entities.StockShortage
+	available := make(map[string]int)
+	for _, item := range items {
+		if reserved[item.ProductId] == 0 {
+			continue
+		}
+		if _, ok := available[item.ProductId]; !ok {
+			lots, err := productEntity.GetSellableProductStocksTx(ctx, item.ProductId, branchId)
+			if err != nil {
+				return nil, err
+			}
+			var total int
+			for _, lot := range lots {
+				total += lot.Quantity * entities.UnitSize(units, lot.UnitId.Hex())
+			}
+			available[item.ProductId] = total - reserved[item.ProductId]
+		}
+		size := entities.UnitSize(units, item.UnitId)
+		if available[item.ProductId] < item.Quantity*size {
+			free := available[item.ProductId]
+			if free < 0 {
+				free = 0
+			}
+			shortages = append(shortages, entities.StockShortage{
+				ProductId: item.ProductId,
+				UnitId:    item.UnitId,
+				Quantity:  item.Quantity,
+				Available: free / size,
+			})
+		}
+		available[item.ProductId] -= item.Quantity * size
+	}
+	return shortages, nil
+}
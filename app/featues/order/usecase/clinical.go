@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+	"strings"
+)
+
+// clinicalCheckError blocks a sale on alerts that were not overridden by a pharmacist
+type clinicalCheckError struct {
+	Alerts []entities.ClinicalAlert `json:"alerts"`
+}
+
+func (e *clinicalCheckError) Error() string {
+	return "sale blocked by allergy or drug interaction, a pharmacist override is required"
+}
+
+// clinicalAlerts runs the allergy check against the patient of the order and the interaction
+// check between the drugs on it. Allergies other than MILD or MODERATE block the sale, as do
+// CONTRAINDICATED and MAJOR interactions from the knowledge base. classes expands drug class
+// allergies to the ingredients of the drugs.
+func clinicalAlerts(form request.Order, products map[string]*entities.Product, patient *entities.Patient, classes map[string][]string, known []entities.DrugInteraction) []entities.ClinicalAlert {
+	alerts := []entities.ClinicalAlert{}
+	drugs := []*entities.Product{}
+	for _, item := range form.Items {
+		product := products[item.ProductId]
+		if product == nil || product.DrugInfo == nil || containsProduct(drugs, product) {
+			continue
+		}
+		drugs = append(drugs, product)
+	}
+
+	if patient != nil {
+		for _, product := range drugs {
+			allergy := patient.FindAllergy(product, classes)
+			if allergy == nil {
+				continue
+			}
+			severity := strings.ToUpper(allergy.Severity)
+			alerts = append(alerts, entities.ClinicalAlert{
+				Type:        constant.ClinicalAlertAllergy,
+				ProductId:   product.Id.Hex(),
+				ProductName: product.Name,
+				DrugName:    allergy.DrugName,
+				Reaction:    allergy.Reaction,
+				Severity:    allergy.Severity,
+				Blocking:    severity != constant.AllergySeverityMild && severity != constant.AllergySeverityModerate,
+			})
+		}
+	}
+
+	for _, match := range entities.MatchDrugInteractions(drugs, known) {
+		alerts = append(alerts, entities.ClinicalAlert{
+			Type:             constant.ClinicalAlertInteraction,
+			ProductId:        match.ProductAId,
+			ProductName:      match.ProductAName,
+			OtherProductId:   match.ProductBId,
+			OtherProductName: match.ProductBName,
+			DrugName:         match.Interaction,
+			Reaction:         match.Mechanism,
+			Management:       match.Management,
+			Severity:         match.Severity,
+			Blocking:         match.Severity == constant.InteractionSeverityContraindicated || match.Severity == constant.InteractionSeverityMajor,
+		})
+	}
+	return alerts
+}
+
+// orderIngredients returns the active ingredients of the drugs on the order
+func orderIngredients(form request.Order, products map[string]*entities.Product) []string {
+	results := []string{}
+	for _, item := range form.Items {
+		results = append(results, products[item.ProductId].Ingredients()...)
+	}
+	return results
+}
+
+func blockingAlerts(alerts []entities.ClinicalAlert) []entities.ClinicalAlert {
+	results := []entities.ClinicalAlert{}
+	for _, alert := range alerts {
+		if alert.Blocking {
+			results = append(results, alert)
+		}
+	}
+	return results
+}
+
+func containsProduct(products []*entities.Product, product *entities.Product) bool {
+	for _, p := range products {
+		if p.Id == product.Id {
+			return true
+		}
+	}
+	return false
+}
+
+// registeredPharmacist returns the employee record of the user when it is a licensed
+// pharmacist of the branch
+func registeredPharmacist(employeeEntity repositories.IEmployee, branchId string, userId string) *entities.Employee {
+	employee, _ := employeeEntity.GetEmployeeByUserId(userId)
+	if employee == nil || employee.BranchId.Hex() != branchId || employee.LicenseNo == "" {
+		return nil
+	}
+	return employee
+}
@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+	"sort"
+	"strings"
+)
+
+// complianceError lists the missing or invalid controlled drug fields, keyed by json field name
+type complianceError struct {
+	Fields   map[string]string `json:"fields"`
+	Products []string          `json:"products"`
+}
+
+func (e *complianceError) Error() string {
+	return "controlled drug sale requires pharmacist, prescriber and buyer details"
+}
+
+// controlledProducts returns the names of the products on the order that are controlled drugs
+func controlledProducts(products map[string]*entities.Product) []string {
+	names := []string{}
+	for _, product := range products {
+		if product.DrugInfo != nil && utils.InArrayString(product.DrugInfo.DrugType, constant.ControlledDrugTypes()) {
+			names = append(names, product.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkCompliance validates the record a controlled drug sale must carry. The pharmacist is
+// an employee of the branch with a license number who is on duty, either ringing up the sale
+// or holding an open shift. The pharmacist name and license are filled from the employee.
+func checkCompliance(
+	form *request.Order,
+	products map[string]*entities.Product,
+	employeeEntity repositories.IEmployee,
+	shiftEntity repositories.IShift,
+) *complianceError {
+	names := controlledProducts(products)
+	if len(names) == 0 {
+		return nil
+	}
+	fields := make(map[string]string)
+
+	form.PharmacistId = strings.TrimSpace(form.PharmacistId)
+	if form.PharmacistId == "" {
+		fields["pharmacistId"] = "pharmacist is required"
+	} else {
+		pharmacist, _ := employeeEntity.GetEmployeeByUserId(form.PharmacistId)
+		switch {
+		case pharmacist == nil || pharmacist.BranchId.Hex() != form.BranchId:
+			fields["pharmacistId"] = "pharmacist is not an employee of this branch"
+		case pharmacist.LicenseNo == "":
+			fields["pharmacistId"] = "pharmacist has no registered license number"
+		case pharmacist.UserId != form.CreatedBy && !isOnDuty(shiftEntity, form.BranchId, pharmacist.UserId):
+			fields["pharmacistId"] = "pharmacist is not on duty"
+		default:
+			if pharmacist.Name != "" {
+				form.PharmacistName = pharmacist.Name
+			}
+			form.LicenseNo = pharmacist.LicenseNo
+		}
+	}
+	if strings.TrimSpace(form.PharmacistName) == "" && fields["pharmacistId"] == "" {
+		fields["pharmacistName"] = "pharmacist name is required"
+	}
+	if strings.TrimSpace(form.PrescriberName) == "" {
+		fields["prescriberName"] = "prescriber name is required"
+	}
+	if strings.TrimSpace(form.BuyerName) == "" {
+		fields["buyerName"] = "buyer name is required"
+	}
+	form.BuyerIdCard = strings.ReplaceAll(strings.TrimSpace(form.BuyerIdCard), "-", "")
+	if form.BuyerIdCard == "" {
+		fields["buyerIdCard"] = "buyer ID card is required"
+	} else if !utils.IsValidThaiId(form.BuyerIdCard) {
+		fields["buyerIdCard"] = "buyer ID card is not a valid 13 digit Thai ID"
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	return &complianceError{Fields: fields, Products: names}
+}
+
+func isOnDuty(shiftEntity repositories.IShift, branchId string, userId string) bool {
+	shift, _ := shiftEntity.GetOpenShiftByUserId(branchId, userId)
+	return shift != nil
+}
@@ -1,7 +1,11 @@
 package usecase
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	coreConstant "pos/app/core/constant"
 	"pos/app/core/errcode"
 	"pos/app/core/utils"
 	"pos/app/data/entities"
@@ -10,12 +14,25 @@ import (
 	"pos/app/domain/request"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func CreateOrder(
+	transactionEntity repositories.ITransaction,
 	orderEntity repositories.IOrder,
 	productEntity repositories.IProduct,
 	sequenceEntity repositories.ISequence,
+	customerEntity repositories.ICustomer,
+	promotionEntity repositories.IPromotion,
+	settingEntity repositories.ISetting,
+	cartEntity repositories.ICart,
+	shiftEntity repositories.IShift,
+	receivableEntity repositories.IReceivable,
+	employeeEntity repositories.IEmployee,
+	dispensingLogEntity repositories.IDispensingLog,
+	patientEntity repositories.IPatient,
+	drugInteractionEntity repositories.IDrugInteraction,
+	ingredientEntity repositories.IIngredient,
 ) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		req := request.Order{}
@@ -27,45 +44,370 @@ func CreateOrder(
 		userId := utils.GetUserId(ctx)
 		req.CreatedBy = userId
 		req.BranchId = utils.GetBranchId(ctx)
+		if shift, _ := shiftEntity.GetOpenShiftByUserId(req.BranchId, userId); shift != nil {
+			req.ShiftId = shift.Id.Hex()
+		}
+
+		// Manual price overrides are restricted to ADMIN/SUPER
+		role := ctx.GetString("Role")
+		for _, item := range req.Items {
+			if item.PriceOverride && role != coreConstant.ADMIN && role != coreConstant.SUPER {
+				errcode.Abort(ctx, http.StatusForbidden, errcode.OR_FORBIDDEN_001, "manual price override requires ADMIN or SUPER")
+				return
+			}
+		}
+
+		// A cart checked out is converted with the order, it must still be open or parked
+		if req.CartId != "" {
+			cart, err := cartEntity.GetCartById(req.CartId)
+			if err != nil || cart.BranchId.Hex() != req.BranchId || cart.UserId != userId ||
+				(cart.Status != constant.CartStatusOpen && cart.Status != constant.CartStatusParked) {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.OR_BAD_REQUEST_001, "cart not found or already checked out")
+				return
+			}
+		}
+
+		// Products on the order and their units keyed by unit id, used to convert lots to the base unit
+		products := make(map[string]*entities.Product)
+		units := make(map[string]*entities.ProductUnit)
+		for _, item := range req.Items {
+			if _, ok := products[item.ProductId]; ok {
+				continue
+			}
+			product, err := productEntity.GetProductById(item.ProductId)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.OR_BAD_REQUEST_003, "product not found: "+item.ProductId)
+				return
+			}
+			products[item.ProductId] = product
+			productUnits, _ := productEntity.GetProductUnitsByProductId(item.ProductId)
+			for i := range productUnits {
+				units[productUnits[i].Id.Hex()] = &productUnits[i]
+			}
+			if _, ok := units[item.UnitId]; !ok {
+				unit, _ := productEntity.GetProductUnitById(item.UnitId)
+				if unit != nil {
+					units[item.UnitId] = unit
+				}
+			}
+		}
+
+		// Lots picked by the client must cover the line exactly, in the base unit
+		for _, item := range req.Items {
+			if needStockAllocation(item) {
+				continue
+			}
+			picked, err := pickedQuantity(productEntity, item, units)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.OR_BAD_REQUEST_001, err.Error())
+				return
+			}
+			if picked != item.Quantity*entities.UnitSize(units, item.UnitId) {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.OR_BAD_REQUEST_001,
+					fmt.Sprintf("lots picked for product %s cover %d of %d base units", item.ProductId, picked, item.Quantity*entities.UnitSize(units, item.UnitId)))
+				return
+			}
+		}
+
+		if err := priceOrder(&req, productEntity, customerEntity, promotionEntity, units); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.OR_BAD_REQUEST_003, err.Error())
+			return
+		}
+
+		// Controlled drugs are sold only with the pharmacist, prescriber and buyer on record
+		if complianceErr := checkCompliance(&req, products, employeeEntity, shiftEntity); complianceErr != nil {
+			errcode.AbortWithData(ctx, http.StatusBadRequest, errcode.OR_BAD_REQUEST_006, complianceErr.Error(), complianceErr)
+			return
+		}
+		if req.LicenseNo == "" && req.PharmacistId != "" {
+			if pharmacist := registeredPharmacist(employeeEntity, req.BranchId, req.PharmacistId); pharmacist != nil {
+				if pharmacist.Name != "" {
+					req.PharmacistName = pharmacist.Name
+				}
+				req.LicenseNo = pharmacist.LicenseNo
+			}
+		}
+
+		// Allergies of the patient and interactions between the drugs stop the sale,
+		// unless a pharmacist overrides them with a justification
+		var patient *entities.Patient
+		if req.PatientId != "" {
+			var err error
+			patient, err = patientEntity.GetPatientById(req.PatientId)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.OR_BAD_REQUEST_001, "patient not found")
+				return
+			}
+		}
+		ingredients := orderIngredients(req, products)
+		var classes map[string][]string
+		if patient != nil && len(patient.Allergies) > 0 {
+			masters, err := ingredientEntity.GetIngredientsByNames(ingredients)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.OR_INTERNAL_001, err.Error())
+				return
+			}
+			classes = entities.IngredientClasses(masters)
+		}
+		knownInteractions, err := drugInteractionEntity.GetDrugInteractionsByIngredients(ingredients)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.OR_INTERNAL_001, err.Error())
+			return
+		}
+		alerts := clinicalAlerts(req, products, patient, classes, knownInteractions)
+		if blocking := blockingAlerts(alerts); len(blocking) > 0 {
+			if req.ClinicalOverride == nil {
+				clinicalErr := &clinicalCheckError{Alerts: blocking}
+				errcode.AbortWithData(ctx, http.StatusBadRequest, errcode.OR_BAD_REQUEST_007, clinicalErr.Error(), clinicalErr)
+				return
+			}
+			pharmacist := registeredPharmacist(employeeEntity, req.BranchId, userId)
+			if pharmacist == nil {
+				errcode.Abort(ctx, http.StatusForbidden, errcode.OR_FORBIDDEN_003, "clinical override requires a registered pharmacist of this branch")
+				return
+			}
+			req.ClinicalOverride.Alerts = blocking
+			req.ClinicalOverride.PharmacistId = pharmacist.UserId
+			req.ClinicalOverride.PharmacistName = pharmacist.Name
+			req.ClinicalOverride.LicenseNo = pharmacist.LicenseNo
+		} else {
+			req.ClinicalOverride = nil
+		}
+
+		// The CREDIT part of the order is owed by a registered customer, up to its credit limit
+		creditAmount := creditPaymentAmount(req)
+		var creditCustomer *entities.Customer
+		if creditAmount > 0 {
+			creditCustomer, _ = customerEntity.GetCustomerByCode(req.CustomerCode)
+			if req.CustomerCode == "" || creditCustomer == nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.OR_BAD_REQUEST_005, "credit sale requires a registered customer")
+				return
+			}
+		}
 
 		sequence, _ := sequenceEntity.NextSequence(constant.ORDER)
 		if sequence != nil {
 			req.Code = sequence.GenerateCode()
 		}
 
-		result, err := orderEntity.CreateOrder(req)
-		if err != nil {
-			errcode.Abort(ctx, http.StatusBadRequest, errcode.OR_BAD_REQUEST_002, err.Error())
-			return
+		allowNegative := false
+		if setting, _ := settingEntity.GetSettingByBranchId(req.BranchId); setting != nil {
+			allowNegative = setting.AllowNegativeStock
 		}
 
-		// Update product stock
+		var result *entities.Order
 		var stocks []entities.ProductStock
-		for _, item := range req.Items {
-			if len(item.Stocks) > 0 {
-				// Update stock quantity
-				for _, itemStock := range item.Stocks {
-					if itemStock.StockId != "" {
-						stock, err := productEntity.RemoveProductStockQuantityById(itemStock.StockId, itemStock.Quantity)
-						if err == nil && stock != nil {
-							stocks = append(stocks, *stock)
+		var warnings []entities.StockShortage
+		err = transactionEntity.WithTransaction(func(txCtx context.Context) error {
+			stocks = nil
+			var valuations []request.ProductValuation
+			blocked := false
+			form := req
+			form.Items = make([]request.OrderItem, len(req.Items))
+			copy(form.Items, req.Items)
+			for i := range form.Items {
+				form.Items[i].Id = primitive.NewObjectID().Hex()
+			}
+
+			if creditCustomer != nil {
+				outstanding, err := receivableEntity.GetOutstandingBalanceTx(txCtx, creditCustomer.Id.Hex())
+				if err != nil {
+					return err
+				}
+				if outstanding+creditAmount > creditCustomer.CreditLimit {
+					return &creditLimitError{
+						CreditLimit: creditCustomer.CreditLimit,
+						Outstanding: utils.Round2(outstanding),
+						Amount:      creditAmount,
+					}
+				}
+			}
+
+			// Cut product stock, lots are allocated FEFO when the client did not pick them.
+			// Lots are decremented only while they hold the quantity, short lines block the
+			// order unless the branch allows negative stock. Cost is taken from the lots consumed, at the
+			// cost of the lot under FIFO and at the moving average the lots carry under AVERAGE.
+			var shortages []entities.StockShortage
+			form.TotalCost = 0
+
+			// Units soft reserved by other parked carts are not for sale
+			reserved, err := cartEntity.GetReservedQuantitiesTx(txCtx, form.BranchId, form.CartId)
+			if err != nil {
+				return err
+			}
+			if len(reserved) > 0 {
+				reservedShort, err := reservedShortages(txCtx, productEntity, form.Items, units, form.BranchId, reserved)
+				if err != nil {
+					return err
+				}
+				shortages = append(shortages, reservedShort...)
+				if len(reservedShort) > 0 && !allowNegative {
+					warnings = shortages
+					return &stockShortageError{Lines: shortages}
+				}
+			}
+
+			for i, item := range form.Items {
+				itemStocks := item.Stocks
+				allocated := needStockAllocation(item)
+				if allocated {
+					lots, shortage, err := allocateStocks(txCtx, productEntity, item, units, form.BranchId, allowNegative)
+					if err != nil {
+						return err
+					}
+					if shortage != nil {
+						shortages = append(shortages, *shortage)
+						if lots == nil {
+							blocked = true
+							continue
 						}
-					} else {
-						_, _ = productEntity.RemoveQuantitySoldFirstById(item.ProductId, itemStock.Quantity)
 					}
+					itemStocks = lots
 				}
+				var costPrice float64
+				consumed := make([]request.OrderItemStock, 0, len(itemStocks))
+				for _, itemStock := range itemStocks {
+					stock, err := productEntity.RemoveProductStockQuantityIfAvailableByIdTx(txCtx, itemStock.StockId, itemStock.Quantity)
+					if errors.Is(err, repositories.ErrInsufficientStock) {
+						if !allocated {
+							current, _ := productEntity.GetProductStockByIdTx(txCtx, itemStock.StockId)
+							shortage := entities.StockShortage{
+								ProductId: item.ProductId,
+								UnitId:    item.UnitId,
+								StockId:   itemStock.StockId,
+								Quantity:  itemStock.Quantity,
+							}
+							if current != nil {
+								shortage.LotNumber = current.LotNumber
+								shortage.Available = current.Quantity
+							}
+							shortages = append(shortages, shortage)
+						}
+						if !allowNegative {
+							blocked = true
+							continue
+						}
+						stock, err = productEntity.RemoveProductStockQuantityByIdTx(txCtx, itemStock.StockId, itemStock.Quantity)
+					}
+					if err != nil {
+						return err
+					}
+					if stock.ProductId.Hex() != item.ProductId || stock.BranchId.Hex() != form.BranchId {
+						return fmt.Errorf("stock %s does not belong to product %s", itemStock.StockId, item.ProductId)
+					}
+					if !stock.IsSellable() {
+						return &unsellableLotError{ProductId: item.ProductId, StockId: itemStock.StockId, LotNumber: stock.LotNumber, Status: stock.Status}
+					}
+					stocks = append(stocks, *stock)
+					consumed = append(consumed, request.OrderItemStock{
+						Quantity:   itemStock.Quantity,
+						StockId:    itemStock.StockId,
+						LotNumber:  stock.LotNumber,
+						ExpireDate: stock.ExpireDate,
+					})
+					costPrice += stock.CostPrice * float64(itemStock.Quantity)
+					valuations = append(valuations, request.StockValuation(stock, constant.ValuationTypeSale, -itemStock.Quantity, form.CreatedBy))
+				}
+				form.Items[i].Stocks = consumed
+				form.Items[i].CostPrice = utils.Round2(costPrice)
+				form.TotalCost += form.Items[i].CostPrice
+			}
+			warnings = shortages
+			if blocked {
+				return &stockShortageError{Lines: shortages}
+			}
+			form.TotalCost = utils.Round2(form.TotalCost)
 
-				// Add product history
-				unit, _ := productEntity.GetProductUnitById(item.UnitId)
+			result, err = orderEntity.CreateOrderTx(txCtx, form)
+			if err != nil {
+				return err
+			}
+
+			// The lots consumed leave the valuation ledger at the cost of the sale
+			for _, valuation := range valuations {
+				valuation.DocumentType = constant.ORDER
+				valuation.DocumentId = result.Id.Hex()
+				valuation.DocumentCode = result.Code
+				if _, err := productEntity.CreateProductValuationTx(txCtx, valuation); err != nil {
+					return err
+				}
+			}
+
+			if creditCustomer != nil {
+				if _, err := receivableEntity.CreateInvoiceTx(txCtx, request.ReceivableInvoice{
+					CustomerId:   creditCustomer.Id.Hex(),
+					CustomerCode: creditCustomer.Code,
+					CustomerName: creditCustomer.Name,
+					OrderId:      result.Id.Hex(),
+					OrderCode:    result.Code,
+					Amount:       creditAmount,
+					DueDate:      result.CreatedDate.AddDate(0, 0, creditCustomer.CreditDays),
+					BranchId:     form.BranchId,
+					CreatedBy:    form.CreatedBy,
+				}); err != nil {
+					return err
+				}
+			}
+
+			// Drug lines go to the dispensing register, linked both ways with the order
+			if dispensing := buildDispensingLog(form, result, products, units); dispensing != nil {
+				log, err := dispensingLogEntity.CreateDispensingLogTx(txCtx, *dispensing)
+				if err != nil {
+					return err
+				}
+				if err := orderEntity.UpdateDispensingLogIdOrderByIdTx(txCtx, result.Id.Hex(), log.Id.Hex()); err != nil {
+					return err
+				}
+				result.DispensingLogId = log.Id.Hex()
+			}
+
+			if form.CartId != "" {
+				if _, err := cartEntity.ConvertCartByIdTx(txCtx, form.CartId, result, form.CreatedBy); err != nil {
+					return fmt.Errorf("cart %s is already checked out", form.CartId)
+				}
+			}
+
+			// Add product history
+			for _, item := range form.Items {
+				unit := units[item.UnitId]
 				if unit != nil {
-					balance := productEntity.GetProductStockBalance(item.ProductId, unit.Id.Hex())
-					history := request.AddOrderItemProductHistory(item.ProductId, unit.Unit, item, balance, req.CreatedBy)
-					history.BranchId = req.BranchId
-					_, _ = productEntity.CreateProductHistory(history)
+					balance := productEntity.GetProductStockBalanceTx(txCtx, item.ProductId, unit.Id.Hex())
+					history := request.AddOrderItemProductHistory(item.ProductId, unit.Unit, item, balance, form.CreatedBy)
+					history.BranchId = form.BranchId
+					if _, err := productEntity.CreateProductHistoryTx(txCtx, history); err != nil {
+						return err
+					}
 				}
 			}
+			return nil
+		})
+		var limitErr *creditLimitError
+		if errors.As(err, &limitErr) {
+			errcode.AbortWithData(ctx, http.StatusBadRequest, errcode.OR_BAD_REQUEST_005, err.Error(), limitErr)
+			return
+		}
+		var unsellableErr *unsellableLotError
+		if errors.As(err, &unsellableErr) {
+			errcode.AbortWithData(ctx, http.StatusConflict, errcode.OR_CONFLICT_002, err.Error(), unsellableErr)
+			return
+		}
+		var shortageErr *stockShortageError
+		if errors.As(err, &shortageErr) {
+			errcode.AbortWithData(ctx, http.StatusConflict, errcode.OR_CONFLICT_001, err.Error(), shortageErr.Lines)
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.OR_BAD_REQUEST_002, err.Error())
+			return
 		}
 
-		ctx.JSON(http.StatusOK, gin.H{"data": result, "stocks": stocks})
+		response := gin.H{"data": result, "stocks": stocks}
+		if len(warnings) > 0 {
+			response["warnings"] = warnings
+		}
+		if len(alerts) > 0 {
+			response["clinicalAlerts"] = alerts
+		}
+		ctx.JSON(http.StatusOK, response)
 	}
 }
@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"fmt"
+	"pos/app/core/utils"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+)
+
+// creditLimitError aborts the checkout when the credit sale would take the customer over its limit
+type creditLimitError struct {
+	CreditLimit float64 `json:"creditLimit"`
+	Outstanding float64 `json:"outstanding"`
+	Amount      float64 `json:"amount"`
+}
+
+func (e *creditLimitError) Error() string {
+	return fmt.Sprintf("credit limit exceeded, limit %.2f outstanding %.2f sale %.2f", e.CreditLimit, e.Outstanding, e.Amount)
+}
+
+// creditPaymentAmount returns the part of the order paid on CREDIT, capped at the order total
+func creditPaymentAmount(form request.Order) float64 {
+	var amount float64
+	if len(form.Payments) > 0 {
+		for _, payment := range form.Payments {
+			if payment.Type == constant.PaymentTypeCredit {
+				amount += payment.Amount
+			}
+		}
+	} else if form.Type == constant.PaymentTypeCredit {
+		amount = form.Total
+	}
+	if amount > form.Total {
+		amount = form.Total
+	}
+	return utils.Round2(amount)
+}
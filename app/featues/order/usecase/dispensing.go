@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"pos/app/data/entities"
+	"pos/app/domain/request"
+	"strings"
+)
+
+// buildDispensingLog lists the drug lines of the order for the dispensing register,
+// nil when the order has no product with drug info. Lines carry the lots they were cut from.
+func buildDispensingLog(
+	form request.Order,
+	order *entities.Order,
+	products map[string]*entities.Product,
+	units map[string]*entities.ProductUnit,
+) *request.DispensingLog {
+	items := []request.DispensingItem{}
+	for _, item := range form.Items {
+		product := products[item.ProductId]
+		if product == nil || product.DrugInfo == nil {
+			continue
+		}
+		unitName := ""
+		if unit := units[item.UnitId]; unit != nil {
+			unitName = unit.Unit
+		}
+		lots := []string{}
+		for _, stock := range item.Stocks {
+			if stock.LotNumber != "" {
+				lots = append(lots, stock.LotNumber)
+			}
+		}
+		items = append(items, request.DispensingItem{
+			OrderItemId: item.Id,
+			ProductId:   item.ProductId,
+			ProductName: product.Name,
+			GenericName: product.DrugInfo.IngredientLabel(),
+			Quantity:    item.Quantity,
+			Unit:        unitName,
+			Dosage:      product.DrugInfo.Dosage,
+			LotNumber:   strings.Join(lots, ", "),
+		})
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return &request.DispensingLog{
+		OrderId:        order.Id.Hex(),
+		OrderCode:      order.Code,
+		PatientId:      form.PatientId,
+		Items:          items,
+		PharmacistName: form.PharmacistName,
+		LicenseNo:      form.LicenseNo,
+		CreatedBy:      form.CreatedBy,
+		BranchId:       form.BranchId,
+	}
+}
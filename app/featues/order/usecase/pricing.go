@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+)
+
+// priceTolerance is the largest difference allowed between client and server amounts
+const priceTolerance = 0.05
+
+// unitPrice returns the selling price of one unit for the customer type, falling back to
+// the General price of the unit and then to the product price multiplied by the unit size.
+func unitPrice(product *entities.Product, prices []entities.ProductPrice, unit *entities.ProductUnit, customerType string) (float64, bool) {
+	var general *entities.ProductPrice
+	for i, price := range prices {
+		if price.UnitId != unit.Id {
+			continue
+		}
+		if price.CustomerType == customerType {
+			return price.Price, true
+		}
+		if price.CustomerType == constant.CustomerTypeGeneral {
+			general = &prices[i]
+		}
+	}
+	if general != nil {
+		return general.Price, true
+	}
+	if product != nil && product.Price > 0 {
+		size := unit.Size
+		if size <= 0 {
+			size = 1
+		}
+		return product.Price * float64(size), true
+	}
+	return 0, false
+}
+
+// priceOrder recomputes every line price from ProductPrice, applies the line, bill and
+// promotion discounts and rejects the order when the client amounts differ from the server.
+// Lines flagged as price override keep the client price, the caller must check the role.
+func priceOrder(
+	form *request.Order,
+	productEntity repositories.IProduct,
+	customerEntity repositories.ICustomer,
+	promotionEntity repositories.IPromotion,
+	units map[string]*entities.ProductUnit,
+) error {
+	customerType := constant.CustomerTypeGeneral
+	if form.CustomerCode != "" {
+		customer, _ := customerEntity.GetCustomerByCode(form.CustomerCode)
+		if customer != nil && customer.CustomerType != "" {
+			customerType = customer.CustomerType
+		}
+	}
+
+	products := make(map[string]*entities.Product)
+	prices := make(map[string][]entities.ProductPrice)
+	var subtotal float64
+	lineTotals := make(map[string]float64)
+	for i, item := range form.Items {
+		if item.Quantity <= 0 {
+			return fmt.Errorf("invalid quantity for product %s", item.ProductId)
+		}
+		if _, ok := products[item.ProductId]; !ok {
+			product, err := productEntity.GetProductById(item.ProductId)
+			if err != nil {
+				return fmt.Errorf("product not found: %s", item.ProductId)
+			}
+			products[item.ProductId] = product
+			prices[item.ProductId], _ = productEntity.GetProductPricesByProductId(item.ProductId)
+		}
+		product := products[item.ProductId]
+
+		unit := units[item.UnitId]
+		if unit == nil || unit.ProductId != product.Id {
+			return fmt.Errorf("invalid unit for product %s", product.Name)
+		}
+
+		price, found := unitPrice(product, prices[item.ProductId], unit, customerType)
+		expected := utils.Round2(price * float64(item.Quantity))
+		if item.PriceOverride {
+			if found {
+				form.Items[i].ListPrice = expected
+			}
+		} else {
+			if !found {
+				return fmt.Errorf("no price for %s (%s)", product.Name, unit.Unit)
+			}
+			if math.Abs(item.Price-expected) > priceTolerance {
+				return fmt.Errorf("price mismatch for %s: expected %.2f, got %.2f", product.Name, expected, item.Price)
+			}
+			form.Items[i].Price = expected
+			form.Items[i].ListPrice = expected
+		}
+
+		if item.Discount < 0 || item.Discount > form.Items[i].Price {
+			return fmt.Errorf("invalid discount for %s", product.Name)
+		}
+		net := form.Items[i].Price - item.Discount
+		subtotal += net
+		lineTotals[item.ProductId] += net
+	}
+
+	if form.Discount < 0 || form.Discount > subtotal+priceTolerance {
+		return errors.New("invalid bill discount")
+	}
+	billDiscount := math.Min(form.Discount, subtotal)
+
+	var promotionDiscount float64
+	if form.PromotionCode != "" {
+		promo, err := promotionEntity.GetPromotionByCode(form.PromotionCode, form.BranchId)
+		if err != nil {
+			return errors.New("promotion not found or expired")
+		}
+		if promo.MinPurchase > 0 && subtotal < promo.MinPurchase {
+			return errors.New("order total below minimum purchase")
+		}
+		base := subtotal
+		if len(promo.ProductIds) > 0 {
+			base = 0
+			for _, pid := range promo.ProductIds {
+				base += lineTotals[pid.Hex()]
+			}
+			if base == 0 {
+				return errors.New("no matching products for this promotion")
+			}
+		}
+		promotionDiscount = utils.Round2(math.Min(promo.CalculateDiscount(base), subtotal-billDiscount))
+	}
+
+	total := utils.Round2(subtotal - billDiscount - promotionDiscount)
+	if math.Abs(form.Total-total) > priceTolerance {
+		return fmt.Errorf("total mismatch: expected %.2f, got %.2f", total, form.Total)
+	}
+
+	form.Total = total
+	form.Discount = utils.Round2(billDiscount + promotionDiscount)
+	form.PromotionDiscount = promotionDiscount
+	return nil
+}
@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	coreConstant "pos/app/core/constant"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func VoidOrderById(
+	transactionEntity repositories.ITransaction,
+	orderEntity repositories.IOrder,
+	productEntity repositories.IProduct,
+	creditNoteEntity repositories.ICreditNote,
+	settingEntity repositories.ISetting,
+	receivableEntity repositories.IReceivable,
+	dispensingLogEntity repositories.IDispensingLog,
+	taxInvoiceEntity repositories.ITaxInvoice,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.VoidOrder{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.OR_BAD_REQUEST_001, err.Error())
+			return
+		}
+		orderId := ctx.Param("orderId")
+		userId := utils.GetUserId(ctx)
+		branchId := utils.GetBranchId(ctx)
+		req.VoidedBy = userId
+
+		order, err := orderEntity.GetOrderById(orderId)
+		if err != nil || order.BranchId.Hex() != branchId {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.OR_BAD_REQUEST_004, "order not found")
+			return
+		}
+		if order.Status == constant.OrderStatusVoided {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.OR_BAD_REQUEST_004, "order is already voided")
+			return
+		}
+
+		setting, _ := settingEntity.GetSettingByBranchId(branchId)
+		reason := setting.FindVoidReason(req.ReasonCode)
+		if reason == nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.OR_BAD_REQUEST_001, "unknown void reason code: "+req.ReasonCode)
+			return
+		}
+
+		// Once the business day of the order is closed only SUPER can void it
+		dayCloseTime := ""
+		if setting != nil {
+			dayCloseTime = setting.DayCloseTime
+		}
+		if isBusinessDayClosed(order.CreatedDate, time.Now(), dayCloseTime) {
+			if ctx.GetString("Role") != coreConstant.SUPER {
+				errcode.Abort(ctx, http.StatusForbidden, errcode.OR_FORBIDDEN_002, "the business day of this order is closed, voiding needs SUPER approval")
+				return
+			}
+			req.ApprovedBy = userId
+		}
+
+		var result *entities.OrderDetail
+		err = transactionEntity.WithTransaction(func(txCtx context.Context) error {
+			// Returned items are already back in stock, the rest must go through a credit note
+			notes, err := creditNoteEntity.GetCreditNotesByOrderIdTx(txCtx, orderId)
+			if err != nil {
+				return err
+			}
+			if len(notes) > 0 {
+				return errors.New("order has credit notes, return the remaining items instead")
+			}
+
+			result, err = orderEntity.VoidOrderByIdTx(txCtx, orderId, req)
+			if err != nil {
+				return err
+			}
+
+			// A credit sale is no longer owed, unless the customer already paid against it
+			if err := receivableEntity.VoidInvoiceByOrderIdTx(txCtx, orderId, userId); err != nil {
+				return err
+			}
+
+			// The sale did not happen, its tax invoice is cancelled rather than credited
+			if err := taxInvoiceEntity.CancelTaxInvoicesByOrderIdTx(txCtx, orderId, reason.Name, userId); err != nil {
+				return err
+			}
+
+			// The drugs were never handed over, take them off the registers
+			if err := dispensingLogEntity.VoidDispensingLogByOrderIdTx(txCtx, orderId); err != nil {
+				return err
+			}
+
+			// The sale is reversed at the cost it was booked at, orders sold before the ledger was kept
+			// come back at the cost the lots carry now
+			saleCosts, err := productEntity.GetSaleUnitCostsTx(txCtx, orderId)
+			if err != nil {
+				return err
+			}
+
+			for _, item := range result.Items {
+				// Put the quantity back to the lots it was cut from
+				for _, itemStock := range item.Stocks {
+					if itemStock.StockId != "" {
+						var stock *entities.ProductStock
+						unitCost, sold := saleCosts[itemStock.StockId]
+						if sold {
+							stock, err = productEntity.ReturnProductStockQuantityByIdTx(txCtx, itemStock.StockId, itemStock.Quantity, unitCost)
+						} else {
+							stock, err = productEntity.AddProductStockQuantityByIdTx(txCtx, itemStock.StockId, itemStock.Quantity)
+						}
+						if err != nil {
+							return err
+						}
+						if !sold {
+							unitCost = stock.CostPrice
+						}
+						valuation := request.StockValuation(stock, constant.ValuationTypeVoid, itemStock.Quantity, userId)
+						valuation.UnitCost = unitCost
+						valuation.Amount = float64(itemStock.Quantity) * unitCost
+						valuation.DocumentType = constant.ORDER
+						valuation.DocumentId = result.Id.Hex()
+						valuation.DocumentCode = result.Code
+						if _, err := productEntity.CreateProductValuationTx(txCtx, valuation); err != nil {
+							return err
+						}
+						if setting.GetCostingMethod() == constant.CostingMethodAverage && stock.IsSellable() {
+							if _, err := productEntity.AverageProductStockCostTx(txCtx, valuation); err != nil {
+								return err
+							}
+						}
+					} else {
+						if _, err := productEntity.AddQuantitySoldFirstByIdTx(txCtx, item.ProductId.Hex(), itemStock.Quantity); err != nil {
+							return err
+						}
+					}
+				}
+
+				// Add product history linked to the voided order
+				unit, _ := productEntity.GetProductUnitById(item.UnitId.Hex())
+				if unit != nil {
+					balance := productEntity.GetProductStockBalanceTx(txCtx, item.ProductId.Hex(), unit.Id.Hex())
+					h := request.RemoveOrderItemProductHistory(item.ProductId.Hex(), unit.Unit, &item, balance, userId)
+					h.BranchId = branchId
+					h.DocumentType = constant.ORDER
+					h.DocumentId = result.Id.Hex()
+					h.DocumentCode = result.Code
+					if _, err := productEntity.CreateProductHistoryTx(txCtx, h); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.OR_BAD_REQUEST_004, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetVoidReasons(settingEntity repositories.ISetting) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		setting, _ := settingEntity.GetSettingByBranchId(utils.GetBranchId(ctx))
+		ctx.JSON(http.StatusOK, setting.GetVoidReasons())
+	}
+}
+
+// isBusinessDayClosed reports whether the business day the order was sold in has ended.
+// A day ends at dayCloseTime (HH:mm, Bangkok time), at midnight when it is not set;
+// sales made after the close time belong to the next business day.
+func isBusinessDayClosed(createdDate time.Time, now time.Time, dayCloseTime string) bool {
+	location := utils.GetLocation()
+	day := utils.Bod(createdDate.In(location))
+	closing := day.AddDate(0, 0, 1)
+	if closeTime, err := time.Parse("15:04", dayCloseTime); err == nil {
+		closing = day.Add(time.Duration(closeTime.Hour())*time.Hour + time.Duration(closeTime.Minute())*time.Minute)
+		if !createdDate.Before(closing) {
+			closing = closing.AddDate(0, 0, 1)
+		}
+	}
+	return !now.Before(closing)
+}
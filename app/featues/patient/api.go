@@ -59,6 +59,6 @@ func ApplyPatientAPI(
 		middlewares.RequireAuthenticated(),
 		middlewares.RequireSession(repository.Session),
 		middlewares.RequireBranch(repository.Employee, repository.Branch),
-		usecase.AllergyCheck(repository.Patient, repository.Product),
+		usecase.AllergyCheck(repository.Patient, repository.Product, repository.Ingredient),
 	)
 }
@@ -3,14 +3,14 @@ package usecase
 import (
 	"net/http"
 	"pos/app/core/errcode"
+	"pos/app/data/entities"
 	"pos/app/data/repositories"
 	"pos/app/domain/request"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AllergyCheck(patientEntity repositories.IPatient, productEntity repositories.IProduct) gin.HandlerFunc {
+func AllergyCheck(patientEntity repositories.IPatient, productEntity repositories.IProduct, ingredientEntity repositories.IIngredient) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		patientId := ctx.Param("id")
 		req := request.AllergyCheck{}
@@ -30,38 +30,29 @@ func AllergyCheck(patientEntity repositories.IPatient, productEntity repositorie
 			return
 		}
 
-		allergyMap := make(map[string]int)
-		for i, a := range patient.Allergies {
-			allergyMap[strings.ToLower(a.DrugName)] = i
-		}
-
 		products, _ := productEntity.GetProductsByIds(req.ProductIds)
 
+		// Drug class allergies such as "penicillins" cover every member ingredient
+		names := []string{}
+		for i := range products {
+			names = append(names, products[i].Ingredients()...)
+		}
+		ingredients, _ := ingredientEntity.GetIngredientsByNames(names)
+		classes := entities.IngredientClasses(ingredients)
+
 		var warnings []request.AllergyCheckResult
-		for _, p := range products {
-			if p.DrugInfo == nil {
+		for i := range products {
+			allergy := patient.FindAllergy(&products[i], classes)
+			if allergy == nil {
 				continue
 			}
-			pid := p.Id.Hex()
-			namesToCheck := []string{
-				strings.ToLower(p.Name),
-				strings.ToLower(p.DrugInfo.GenericName),
-			}
-			for _, name := range namesToCheck {
-				if name == "" {
-					continue
-				}
-				if idx, ok := allergyMap[name]; ok {
-					warnings = append(warnings, request.AllergyCheckResult{
-						ProductId:   pid,
-						ProductName: p.Name,
-						DrugName:    patient.Allergies[idx].DrugName,
-						Reaction:    patient.Allergies[idx].Reaction,
-						Severity:    patient.Allergies[idx].Severity,
-					})
-					break
-				}
-			}
+			warnings = append(warnings, request.AllergyCheckResult{
+				ProductId:   products[i].Id.Hex(),
+				ProductName: products[i].Name,
+				DrugName:    allergy.DrugName,
+				Reaction:    allergy.Reaction,
+				Severity:    allergy.Severity,
+			})
 		}
 
 		if warnings == nil {
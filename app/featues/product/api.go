@@ -28,7 +28,7 @@ func ApplyProductAPI(
 		middlewares.RequireSession(repository.Session),
 		middlewares.RequireBranch(repository.Employee, repository.Branch),
 		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
-		usecase.CreateProduct(repository.Product),
+		usecase.CreateProduct(repository.Product, repository.Ingredient),
 	)
 
 	productRoute.POST("/receive",
@@ -36,7 +36,7 @@ func ApplyProductAPI(
 		middlewares.RequireSession(repository.Session),
 		middlewares.RequireBranch(repository.Employee, repository.Branch),
 		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
-		usecase.CreateProductReceive(repository.Product, repository.Receive),
+		usecase.CreateProductReceive(repository.Transaction, repository.Product, repository.Receive, repository.Ingredient, repository.Setting),
 	)
 
 	productRoute.GET("/:productId",
@@ -50,7 +50,7 @@ func ApplyProductAPI(
 		middlewares.RequireSession(repository.Session),
 		middlewares.RequireBranch(repository.Employee, repository.Branch),
 		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
-		usecase.UpdateProductById(repository.Product),
+		usecase.UpdateProductById(repository.Product, repository.Ingredient),
 	)
 
 	productRoute.DELETE("/:productId",
@@ -79,7 +79,7 @@ func ApplyProductAPI(
 		usecase.GenerateSerialNumber(repository.Sequence),
 	)
 
-	// Product Stock
+	// Product Stock, quantities are changed through stock adjustments and only SUPER may edit them directly
 	productRoute.GET("/:productId/stocks",
 		middlewares.RequireAuthenticated(),
 		middlewares.RequireSession(repository.Session),
@@ -91,28 +91,40 @@ func ApplyProductAPI(
 		middlewares.RequireAuthenticated(),
 		middlewares.RequireSession(repository.Session),
 		middlewares.RequireBranch(repository.Employee, repository.Branch),
-		usecase.CreateProductStock(repository.Product),
+		middlewares.RequireAuthorization(constant.SUPER),
+		usecase.CreateProductStock(repository.Transaction, repository.Product, repository.Setting),
 	)
 
 	productRoute.PUT("/stocks/:stockId",
 		middlewares.RequireAuthenticated(),
 		middlewares.RequireSession(repository.Session),
 		middlewares.RequireBranch(repository.Employee, repository.Branch),
-		usecase.UpdateProductStockById(repository.Product),
+		middlewares.RequireAuthorization(constant.SUPER),
+		usecase.UpdateProductStockById(repository.Transaction, repository.Product),
 	)
 
 	productRoute.DELETE("/stocks/:stockId",
 		middlewares.RequireAuthenticated(),
 		middlewares.RequireSession(repository.Session),
 		middlewares.RequireBranch(repository.Employee, repository.Branch),
-		usecase.RemoveProductStockById(repository.Product),
+		middlewares.RequireAuthorization(constant.SUPER),
+		usecase.RemoveProductStockById(repository.Transaction, repository.Product),
 	)
 
 	productRoute.PATCH("/stocks/:stockId/quantity",
 		middlewares.RequireAuthenticated(),
 		middlewares.RequireSession(repository.Session),
 		middlewares.RequireBranch(repository.Employee, repository.Branch),
-		usecase.UpdateProductStockQuantityById(repository.Product),
+		middlewares.RequireAuthorization(constant.SUPER),
+		usecase.UpdateProductStockQuantityById(repository.Transaction, repository.Product),
+	)
+
+	productRoute.PATCH("/stocks/:stockId/status",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.UpdateProductStockStatusById(repository.Product),
 	)
 
 	productRoute.PATCH("/stocks/sequence",
@@ -248,7 +260,7 @@ func ApplyProductAPI(
 	productRoute.POST("/drug-interaction-check",
 		middlewares.RequireAuthenticated(),
 		middlewares.RequireSession(repository.Session),
-		usecase.CheckDrugInteractions(repository.Product),
+		usecase.CheckDrugInteractions(repository.Product, repository.DrugInteraction),
 	)
 
 }
@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"errors"
+	"pos/app/core/utils"
+	"pos/app/data/repositories"
+	"pos/app/domain/request"
+	"strings"
+)
+
+// resolveIngredients checks the linked ingredients against the master and copies their names
+// and default units onto the request. The generic name is written from them when left empty.
+func resolveIngredients(ingredientEntity repositories.IIngredient, drugInfo *request.RequestDrugInfo) error {
+	if drugInfo == nil {
+		return nil
+	}
+	ids := []string{}
+	names := []string{}
+	for i := range drugInfo.Ingredients {
+		line := &drugInfo.Ingredients[i]
+		ingredient, err := ingredientEntity.GetIngredientById(line.IngredientId)
+		if err != nil {
+			return errors.New("ingredient not found: " + line.IngredientId)
+		}
+		if utils.InArrayString(line.IngredientId, ids) {
+			return errors.New("ingredient is linked twice: " + ingredient.Name)
+		}
+		ids = append(ids, line.IngredientId)
+		line.Name = ingredient.Name
+		if line.Unit == "" {
+			line.Unit = ingredient.Unit
+		}
+		names = append(names, ingredient.Name)
+	}
+	if drugInfo.GenericName == "" && len(names) > 0 {
+		drugInfo.GenericName = strings.Join(names, " + ")
+	}
+	return nil
+}
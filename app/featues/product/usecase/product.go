@@ -1,8 +1,10 @@
 package usecase
 
 import (
+	"context"
 	"net/http"
 	"pos/app/core/errcode"
+	"pos/app/data/entities"
 	"pos/app/data/repositories"
 	"pos/app/domain/constant"
 	"pos/app/domain/request"
@@ -23,13 +25,17 @@ func GenerateSerialNumber(sequenceEntity repositories.ISequence) gin.HandlerFunc
 	}
 }
 
-func CreateProduct(productEntity repositories.IProduct) gin.HandlerFunc {
+func CreateProduct(productEntity repositories.IProduct, ingredientEntity repositories.IIngredient) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		req := request.CreateProduct{}
 		if err := ctx.ShouldBind(&req); err != nil {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.PD_BAD_REQUEST_001, err.Error())
 			return
 		}
+		if err := resolveIngredients(ingredientEntity, req.DrugInfo); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PD_BAD_REQUEST_001, err.Error())
+			return
+		}
 		userId := ctx.GetString("UserId")
 		req.CreatedBy = userId
 
@@ -44,6 +50,9 @@ func CreateProduct(productEntity repositories.IProduct) gin.HandlerFunc {
 				NameEn:            req.NameEn,
 				Status:            req.Status,
 				MinStock:          req.MinStock,
+				VatExempt:         req.VatExempt,
+				VatRate:           req.VatRate,
+				DrugInfo:          req.DrugInfo,
 				DrugRegistrations: req.DrugRegistrations,
 				UpdatedBy:         userId,
 			}
@@ -65,6 +74,9 @@ func CreateProduct(productEntity repositories.IProduct) gin.HandlerFunc {
 				Name:              req.Name,
 				NameEn:            req.NameEn,
 				Unit:              req.Unit,
+				VatExempt:         req.VatExempt,
+				VatRate:           req.VatRate,
+				DrugInfo:          req.DrugInfo,
 				DrugRegistrations: req.DrugRegistrations,
 				CreatedBy:         userId,
 			}
@@ -109,13 +121,17 @@ func CreateProduct(productEntity repositories.IProduct) gin.HandlerFunc {
 	}
 }
 
-func CreateProductReceive(productEntity repositories.IProduct, receiveEntity repositories.IReceive) gin.HandlerFunc {
+func CreateProductReceive(transactionEntity repositories.ITransaction, productEntity repositories.IProduct, receiveEntity repositories.IReceive, ingredientEntity repositories.IIngredient, settingEntity repositories.ISetting) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		req := request.Product{}
 		if err := ctx.ShouldBind(&req); err != nil {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.PD_BAD_REQUEST_001, err.Error())
 			return
 		}
+		if err := resolveIngredients(ingredientEntity, req.DrugInfo); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PD_BAD_REQUEST_001, err.Error())
+			return
+		}
 		userId := ctx.GetString("UserId")
 		req.CreatedBy = userId
 		req.BranchId = ctx.GetString("BranchId")
@@ -129,6 +145,8 @@ func CreateProductReceive(productEntity repositories.IProduct, receiveEntity rep
 				Category:    req.Category,
 				Name:        req.Name,
 				NameEn:      req.NameEn,
+				VatExempt:   req.VatExempt,
+				VatRate:     req.VatRate,
 				UpdatedBy:   userId,
 			}
 			product, err = productEntity.UpdateProductById(product.Id.Hex(), updateProduct)
@@ -188,22 +206,43 @@ func CreateProductReceive(productEntity repositories.IProduct, receiveEntity rep
 				ReceiveCode: req.ReceiveCode,
 				Quantity:    req.Quantity,
 				Price:       0,
-				CostPrice:   0,
+				CostPrice:   req.CostPrice,
 				ExpireDate:  req.ExpireDate,
 				LotNumber:   req.LotNumber,
 				ImportDate:  time.Now(),
 				UpdatedBy:   userId,
 				BranchId:    req.BranchId,
 			}
-			stock, _ := productEntity.CreateProductStock(productStock)
-
-			if stock != nil {
-				// Add product history
-				balance := productEntity.GetProductStockBalance(stock.ProductId.Hex(), stock.UnitId.Hex())
-				stockHistory := request.AddProductStockHistory(stock.ProductId.Hex(), req.Unit, productStock, balance)
-				stockHistory.BranchId = req.BranchId
-				_, _ = productEntity.CreateProductHistory(stockHistory)
+			setting, _ := settingEntity.GetSettingByBranchId(req.BranchId)
+			var stock *entities.ProductStock
+			err := transactionEntity.WithTransaction(func(txCtx context.Context) error {
+				var err error
+				stock, err = productEntity.CreateProductStockTx(txCtx, productStock)
+				if err != nil {
+					return err
+				}
+				valuation := request.StockValuation(stock, constant.ValuationTypeReceive, stock.Quantity, userId)
+				valuation.DocumentType = constant.RECEIVE
+				valuation.DocumentId = req.ReceiveId
+				valuation.DocumentCode = req.ReceiveCode
+				if _, err := productEntity.CreateProductValuationTx(txCtx, valuation); err != nil {
+					return err
+				}
+				if setting.GetCostingMethod() == constant.CostingMethodAverage {
+					_, err = productEntity.AverageProductStockCostTx(txCtx, valuation)
+				}
+				return err
+			})
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.PD_BAD_REQUEST_002, err.Error())
+				return
 			}
+
+			// Add product history
+			balance := productEntity.GetProductStockBalance(stock.ProductId.Hex(), stock.UnitId.Hex())
+			stockHistory := request.AddProductStockHistory(stock.ProductId.Hex(), req.Unit, productStock, balance)
+			stockHistory.BranchId = req.BranchId
+			_, _ = productEntity.CreateProductHistory(stockHistory)
 		}
 
 		ctx.JSON(http.StatusOK, product)
@@ -266,7 +305,7 @@ func GetProductById(productEntity repositories.IProduct) gin.HandlerFunc {
 	}
 }
 
-func UpdateProductById(productEntity repositories.IProduct) gin.HandlerFunc {
+func UpdateProductById(productEntity repositories.IProduct, ingredientEntity repositories.IIngredient) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		id := ctx.Param("productId")
 		req := request.UpdateProduct{}
@@ -274,6 +313,10 @@ func UpdateProductById(productEntity repositories.IProduct) gin.HandlerFunc {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.PD_BAD_REQUEST_001, err.Error())
 			return
 		}
+		if err := resolveIngredients(ingredientEntity, req.DrugInfo); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PD_BAD_REQUEST_001, err.Error())
+			return
+		}
 		userId := ctx.GetString("UserId")
 		req.UpdatedBy = userId
 		result, err := productEntity.UpdateProductById(id, req)
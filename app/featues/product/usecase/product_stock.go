@@ -1,15 +1,20 @@
 package usecase
 
 import (
+	"context"
 	"net/http"
 	"pos/app/core/errcode"
+	"pos/app/data/entities"
 	"pos/app/data/repositories"
+	"pos/app/domain/constant"
 	"pos/app/domain/request"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func CreateProductStock(productEntity repositories.IProduct) gin.HandlerFunc {
+func CreateProductStock(transactionEntity repositories.ITransaction, productEntity repositories.IProduct, settingEntity repositories.ISetting) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		req := request.ProductStock{}
 		if err := ctx.ShouldBind(&req); err != nil {
@@ -19,7 +24,25 @@ func CreateProductStock(productEntity repositories.IProduct) gin.HandlerFunc {
 		userId := ctx.GetString("UserId")
 		req.UpdatedBy = userId
 		req.BranchId = ctx.GetString("BranchId")
-		stock, err := productEntity.CreateProductStock(req)
+
+		// Add the lot to the valuation ledger, under AVERAGE it is priced with the lots of its unit
+		setting, _ := settingEntity.GetSettingByBranchId(req.BranchId)
+		var stock *entities.ProductStock
+		err := transactionEntity.WithTransaction(func(txCtx context.Context) error {
+			var err error
+			stock, err = productEntity.CreateProductStockTx(txCtx, req)
+			if err != nil {
+				return err
+			}
+			valuation := request.StockValuation(stock, constant.ValuationTypeManual, stock.Quantity, userId)
+			if _, err := productEntity.CreateProductValuationTx(txCtx, valuation); err != nil {
+				return err
+			}
+			if setting.GetCostingMethod() == constant.CostingMethodAverage && stock.IsSellable() {
+				_, err = productEntity.AverageProductStockCostTx(txCtx, valuation)
+			}
+			return err
+		})
 		if err != nil {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.PD_BAD_REQUEST_002, err.Error())
 			return
@@ -52,7 +75,7 @@ func GetProductStocksByProductId(productEntity repositories.IProduct) gin.Handle
 
 }
 
-func UpdateProductStockById(productEntity repositories.IProduct) gin.HandlerFunc {
+func UpdateProductStockById(transactionEntity repositories.ITransaction, productEntity repositories.IProduct) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		req := request.UpdateProductStock{}
 		id := ctx.Param("stockId")
@@ -63,7 +86,24 @@ func UpdateProductStockById(productEntity repositories.IProduct) gin.HandlerFunc
 		userId := ctx.GetString("UserId")
 		req.UpdatedBy = userId
 
-		stock, err := productEntity.UpdateProductStockById(id, req)
+		var stock *entities.ProductStock
+		err := transactionEntity.WithTransaction(func(txCtx context.Context) error {
+			current, err := productEntity.GetProductStockByIdTx(txCtx, id)
+			if err != nil {
+				return err
+			}
+			stock, err = productEntity.UpdateProductStockByIdTx(txCtx, id, req)
+			if err != nil {
+				return err
+			}
+			// A new cost revalues what the lot holds
+			if current.CostPrice != stock.CostPrice {
+				revalue := request.StockValuation(stock, constant.ValuationTypeRevalue, 0, userId)
+				revalue.Amount = float64(stock.Quantity) * (stock.CostPrice - current.CostPrice)
+				_, err = productEntity.CreateProductValuationTx(txCtx, revalue)
+			}
+			return err
+		})
 		if err != nil {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.PD_BAD_REQUEST_002, err.Error())
 			return
@@ -81,7 +121,7 @@ func UpdateProductStockById(productEntity repositories.IProduct) gin.HandlerFunc
 	}
 }
 
-func UpdateProductStockQuantityById(productEntity repositories.IProduct) gin.HandlerFunc {
+func UpdateProductStockQuantityById(transactionEntity repositories.ITransaction, productEntity repositories.IProduct) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		req := request.UpdateProductStockQuantity{}
 		id := ctx.Param("stockId")
@@ -92,7 +132,21 @@ func UpdateProductStockQuantityById(productEntity repositories.IProduct) gin.Han
 		userId := ctx.GetString("UserId")
 		req.UpdatedBy = userId
 
-		stock, err := productEntity.UpdateProductStockQuantityById(id, req.Quantity)
+		var stock *entities.ProductStock
+		err := transactionEntity.WithTransaction(func(txCtx context.Context) error {
+			current, err := productEntity.GetProductStockByIdTx(txCtx, id)
+			if err != nil {
+				return err
+			}
+			stock, err = productEntity.UpdateProductStockQuantityByIdTx(txCtx, id, req.Quantity)
+			if err != nil {
+				return err
+			}
+			if current.Quantity != stock.Quantity {
+				_, err = productEntity.CreateProductValuationTx(txCtx, request.StockValuation(stock, constant.ValuationTypeManual, stock.Quantity-current.Quantity, userId))
+			}
+			return err
+		})
 		if err != nil {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.PD_BAD_REQUEST_002, err.Error())
 			return
@@ -110,12 +164,67 @@ func UpdateProductStockQuantityById(productEntity repositories.IProduct) gin.Han
 	}
 }
 
-func RemoveProductStockById(productEntity repositories.IProduct) gin.HandlerFunc {
+// UpdateProductStockStatusById quarantines a lot or puts it back on sale. A lot past its expire date
+// cannot be made available again and a recalled lot keeps its status.
+func UpdateProductStockStatusById(productEntity repositories.IProduct) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.UpdateProductStockStatus{}
+		id := ctx.Param("stockId")
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PD_BAD_REQUEST_001, err.Error())
+			return
+		}
+		req.UpdatedBy = ctx.GetString("UserId")
+		branchId := ctx.GetString("BranchId")
+
+		current, err := productEntity.GetProductStockById(id)
+		if err != nil || current.BranchId.Hex() != branchId {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PD_BAD_REQUEST_002, "stock not found")
+			return
+		}
+		if req.Status == constant.StockStatusAvailable && !current.ExpireDate.IsZero() && !current.ExpireDate.After(time.Now()) {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PD_BAD_REQUEST_002, "lot "+current.LotNumber+" is expired")
+			return
+		}
+
+		stock, err := productEntity.UpdateProductStockStatusById(id, req.Status)
+		if err == mongo.ErrNoDocuments {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PD_BAD_REQUEST_002, "lot "+current.LotNumber+" is recalled")
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.PD_BAD_REQUEST_002, err.Error())
+			return
+		}
+		// Add product history
+		unit, _ := productEntity.GetProductUnitById(stock.UnitId.Hex())
+		if unit != nil {
+			statusHistory := request.UpdateProductStockStatusHistory(stock.ProductId.Hex(), unit.Unit, stock, req.Status, req.UpdatedBy)
+			statusHistory.BranchId = branchId
+			_, _ = productEntity.CreateProductHistory(statusHistory)
+		}
+
+		ctx.JSON(http.StatusOK, stock)
+	}
+}
+
+func RemoveProductStockById(transactionEntity repositories.ITransaction, productEntity repositories.IProduct) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		id := ctx.Param("stockId")
 		userId := ctx.GetString("UserId")
 
-		result, err := productEntity.RemoveProductStockById(id)
+		var result *entities.ProductStock
+		err := transactionEntity.WithTransaction(func(txCtx context.Context) error {
+			var err error
+			result, err = productEntity.RemoveProductStockByIdTx(txCtx, id)
+			if err != nil {
+				return err
+			}
+			if result.Quantity != 0 {
+				_, err = productEntity.CreateProductValuationTx(txCtx, request.StockValuation(result, constant.ValuationTypeManual, -result.Quantity, userId))
+			}
+			return err
+		})
 		if err != nil {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.PD_BAD_REQUEST_002, err.Error())
 			return
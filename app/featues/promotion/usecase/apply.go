@@ -47,21 +47,7 @@ func ApplyPromotion(entity repositories.IPromotion) gin.HandlerFunc {
 			}
 		}
 
-		var discount float64
-		switch promo.Type {
-		case "PERCENTAGE":
-			discount = req.OrderTotal * promo.Value / 100
-			if promo.MaxDiscount > 0 && discount > promo.MaxDiscount {
-				discount = promo.MaxDiscount
-			}
-		case "FIXED":
-			discount = promo.Value
-			if discount > req.OrderTotal {
-				discount = req.OrderTotal
-			}
-		default:
-			discount = 0
-		}
+		discount := promo.CalculateDiscount(req.OrderTotal)
 
 		result := request.ApplyPromotionResult{
 			PromotionId: promo.Id.Hex(),
@@ -0,0 +1,80 @@
+package recall
+
+import (
+	"pos/app/core/constant"
+	"pos/app/domain"
+	"pos/app/featues/recall/usecase"
+	"pos/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ApplyRecallAPI(
+	route *gin.RouterGroup,
+	repository *domain.Repository,
+) {
+	recallRoute := route.Group("recalls")
+
+	recallRoute.POST("",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		middlewares.RequireIdempotency(repository.Idempotency),
+		usecase.CreateRecall(repository.Transaction, repository.Recall, repository.Product, repository.Sequence),
+	)
+
+	recallRoute.GET("",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetRecalls(repository.Recall),
+	)
+
+	recallRoute.GET("/:id",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetRecallById(repository.Recall),
+	)
+
+	// Tracing crosses branches and reads patient contacts
+	recallRoute.GET("/:id/trace",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.GetRecallTrace(repository.Recall, repository.Product, repository.Order, repository.DispensingLog, repository.Patient, repository.Customer),
+	)
+
+	recallRoute.GET("/:id/contacts/xlsx",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.GetRecallContactsExcel(repository.Recall, repository.Product, repository.Order, repository.DispensingLog, repository.Patient, repository.Customer),
+	)
+
+	recallRoute.POST("/:id/returns",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		middlewares.RequireIdempotency(repository.Idempotency),
+		usecase.CreateRecallReturns(repository.Transaction, repository.Recall, repository.Product, repository.Receive, repository.Supplier, repository.Sequence),
+	)
+
+	recallRoute.GET("/:id/returns",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetRecallReturns(repository.Recall),
+	)
+
+	recallRoute.GET("/:id/returns/:returnId/pdf",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetRecallReturnPDF(repository.Recall, repository.Product, repository.Setting),
+	)
+}
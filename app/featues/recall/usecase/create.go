@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateRecall records the recall and blocks the lots in every branch at once, checkout no longer
+// allocates them and refuses them when they are picked
+func CreateRecall(
+	transactionEntity repositories.ITransaction,
+	entity repositories.IRecall,
+	productEntity repositories.IProduct,
+	sequenceEntity repositories.ISequence,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.Recall{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RL_BAD_REQUEST_001, err.Error())
+			return
+		}
+		product, err := productEntity.GetProductById(req.ProductId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RL_BAD_REQUEST_003, "product not found: "+req.ProductId)
+			return
+		}
+		req.ProductName = product.Name
+		req.SerialNumber = product.SerialNumber
+		req.LotNumbers = uniqueLotNumbers(req.LotNumbers)
+		req.CreatedBy = utils.GetUserId(ctx)
+
+		sequence, _ := sequenceEntity.NextSequence(constant.RECALL)
+		if sequence != nil {
+			req.Code = sequence.GenerateCode()
+		}
+
+		var result *entities.Recall
+		err = transactionEntity.WithTransaction(func(txCtx context.Context) error {
+			blocked, err := productEntity.RecallProductStocksTx(txCtx, req.ProductId, req.LotNumbers)
+			if err != nil {
+				return err
+			}
+			req.BlockedLots = blocked
+			result, err = entity.CreateRecallTx(txCtx, req)
+			return err
+		})
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RL_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func uniqueLotNumbers(lotNumbers []string) []string {
+	seen := make(map[string]bool)
+	results := make([]string, 0, len(lotNumbers))
+	for _, lotNumber := range lotNumbers {
+		lotNumber = strings.TrimSpace(lotNumber)
+		if lotNumber == "" || seen[lotNumber] {
+			continue
+		}
+		seen[lotNumber] = true
+		results = append(results, lotNumber)
+	}
+	return results
+}
@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetRecalls(entity repositories.IRecall) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.GetRecallRange{}
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RL_BAD_REQUEST_001, err.Error())
+			return
+		}
+		result, err := entity.GetRecallRange(req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.RL_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetRecallById(entity repositories.IRecall) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, ok := getRecall(ctx, entity)
+		if !ok {
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+// GetRecallReturns lists the supplier returns the branch made for the recall
+func GetRecallReturns(entity repositories.IRecall) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		recall, ok := getRecall(ctx, entity)
+		if !ok {
+			return
+		}
+		result, err := entity.GetRecallReturnsByRecallId(recall.Id.Hex(), utils.GetBranchId(ctx))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.RL_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+// getRecall loads the recall of the id param, recalls cover every branch
+func getRecall(ctx *gin.Context, entity repositories.IRecall) (*entities.Recall, bool) {
+	recall, err := entity.GetRecallById(ctx.Param("id"))
+	if err != nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.RL_BAD_REQUEST_003, "recall not found")
+		return nil, false
+	}
+	return recall, true
+}
@@ -0,0 +1,237 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/pdf"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateRecallReturns takes everything the branch still holds of the recalled lots out of stock, with one
+// return document for each supplier that delivered the lots. Lots whose receive is unknown, such as lots
+// that came in by transfer, go on a document without a supplier.
+func CreateRecallReturns(
+	transactionEntity repositories.ITransaction,
+	entity repositories.IRecall,
+	productEntity repositories.IProduct,
+	receiveEntity repositories.IReceive,
+	supplierEntity repositories.ISupplier,
+	sequenceEntity repositories.ISequence,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		recall, ok := getRecall(ctx, entity)
+		if !ok {
+			return
+		}
+		branchId := utils.GetBranchId(ctx)
+		userId := utils.GetUserId(ctx)
+
+		stocks, err := productEntity.GetProductStocksByLotNumbers(recall.ProductId.Hex(), recall.LotNumbers, branchId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.RL_INTERNAL_001, err.Error())
+			return
+		}
+
+		var forms []*request.RecallReturn
+		bySupplier := make(map[string]*request.RecallReturn)
+		for _, stock := range stocks {
+			if stock.Quantity <= 0 || stock.Status != constant.StockStatusRecalled {
+				continue
+			}
+			supplierId := ""
+			if receive, _ := receiveEntity.GetReceiveByCode(stock.ReceiveCode); receive != nil && stock.ReceiveCode != "" {
+				supplierId = receive.SupplierId.Hex()
+			}
+			form, ok := bySupplier[supplierId]
+			if !ok {
+				form = &request.RecallReturn{
+					RecallId:   recall.Id.Hex(),
+					RecallCode: recall.Code,
+					SupplierId: supplierId,
+					BranchId:   branchId,
+					CreatedBy:  userId,
+				}
+				if supplier, _ := supplierEntity.GetSupplierById(supplierId); supplier != nil {
+					form.SupplierName = supplier.Name
+				}
+				bySupplier[supplierId] = form
+				forms = append(forms, form)
+			}
+			cost := math.Round(stock.CostPrice*float64(stock.Quantity)*100) / 100
+			form.Items = append(form.Items, entities.RecallReturnItem{
+				ProductId:   stock.ProductId,
+				UnitId:      stock.UnitId,
+				StockId:     stock.Id.Hex(),
+				LotNumber:   stock.LotNumber,
+				ReceiveCode: stock.ReceiveCode,
+				ExpireDate:  stock.ExpireDate,
+				Quantity:    stock.Quantity,
+				CostPrice:   stock.CostPrice,
+				CostAmount:  cost,
+			})
+			form.TotalCost = math.Round((form.TotalCost+cost)*100) / 100
+		}
+		if len(forms) == 0 {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RL_BAD_REQUEST_004, "the branch holds none of the recalled lots")
+			return
+		}
+		for _, form := range forms {
+			sequence, _ := sequenceEntity.NextSequence(constant.RECALL_RETURN)
+			if sequence != nil {
+				form.Code = sequence.GenerateCode()
+			}
+		}
+
+		units := make(map[string]string)
+		for _, stock := range stocks {
+			unitId := stock.UnitId.Hex()
+			if _, ok := units[unitId]; ok {
+				continue
+			}
+			units[unitId] = ""
+			if unit, _ := productEntity.GetProductUnitById(unitId); unit != nil {
+				units[unitId] = unit.Unit
+			}
+		}
+
+		var results []entities.RecallReturn
+		err = transactionEntity.WithTransaction(func(txCtx context.Context) error {
+			results = nil
+			for _, form := range forms {
+				result, err := entity.CreateRecallReturnTx(txCtx, *form)
+				if err != nil {
+					return err
+				}
+				if err := applyRecallReturn(txCtx, productEntity, result, units); err != nil {
+					return err
+				}
+				results = append(results, *result)
+			}
+			return nil
+		})
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RL_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, results)
+	}
+}
+
+// applyRecallReturn takes the returned lots out of stock and records them in the product history under
+// the return code
+func applyRecallReturn(ctx context.Context, productEntity repositories.IProduct, recallReturn *entities.RecallReturn, units map[string]string) error {
+	for _, item := range recallReturn.Items {
+		stock, err := productEntity.RemoveProductStockQuantityIfAvailableByIdTx(ctx, item.StockId, item.Quantity)
+		if errors.Is(err, repositories.ErrInsufficientStock) {
+			return fmt.Errorf("lot %s holds less than %d", item.LotNumber, item.Quantity)
+		}
+		if err != nil {
+			return err
+		}
+
+		valuation := request.StockValuation(stock, constant.ValuationTypeSupplierReturn, -item.Quantity, recallReturn.CreatedBy)
+		valuation.DocumentType = constant.RECALL_RETURN
+		valuation.DocumentId = recallReturn.Id.Hex()
+		valuation.DocumentCode = recallReturn.Code
+		if _, err := productEntity.CreateProductValuationTx(ctx, valuation); err != nil {
+			return err
+		}
+
+		balance := productEntity.GetProductStockBalanceTx(ctx, item.ProductId.Hex(), item.UnitId.Hex())
+		history := request.ReturnProductStockHistory(item.ProductId.Hex(), units[item.UnitId.Hex()], recallReturn.RecallCode, item, balance, recallReturn.CreatedBy)
+		history.BranchId = recallReturn.BranchId.Hex()
+		history.DocumentType = constant.RECALL_RETURN
+		history.DocumentId = recallReturn.Id.Hex()
+		history.DocumentCode = recallReturn.Code
+		if _, err := productEntity.CreateProductHistoryTx(ctx, history); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetRecallReturnPDF prints the return document to go with the goods to the supplier
+func GetRecallReturnPDF(entity repositories.IRecall, productEntity repositories.IProduct, settingEntity repositories.ISetting) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		recall, ok := getRecall(ctx, entity)
+		if !ok {
+			return
+		}
+		branchId := utils.GetBranchId(ctx)
+		recallReturn, err := entity.GetRecallReturnById(ctx.Param("returnId"))
+		if err != nil || recallReturn.RecallId != recall.Id || recallReturn.BranchId.Hex() != branchId {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RL_BAD_REQUEST_003, "return not found")
+			return
+		}
+		setting, err := settingEntity.GetSettingByBranchId(branchId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.RL_INTERNAL_001, err.Error())
+			return
+		}
+
+		doc := pdf.NewPDF()
+		doc.AddPage()
+		pdf.AddHeader(doc, setting.CompanyName, setting.CompanyAddress, setting.CompanyPhone, "ใบส่งคืนสินค้าเรียกคืน / Recall Return to Supplier")
+
+		doc.SetFont(pdf.FontFamily, "", pdf.FontSize)
+		lines := []string{
+			fmt.Sprintf("เลขที่ / No: %s    วันที่ / Date: %s", recallReturn.Code, recallReturn.CreatedDate.In(utils.GetLocation()).Format("02/01/2006")),
+			fmt.Sprintf("ผู้จำหน่าย / Supplier: %s", recallReturn.SupplierName),
+			fmt.Sprintf("เรียกคืน / Recall: %s  %s", recall.Code, recall.Reference),
+			fmt.Sprintf("สินค้า / Product: %s %s", recall.SerialNumber, recall.ProductName),
+			fmt.Sprintf("เหตุผล / Reason: %s", recall.Reason),
+		}
+		for _, line := range lines {
+			doc.CellFormat(0, 6, line, "", 1, "L", false, 0, "")
+		}
+		doc.Ln(3)
+
+		headers := []string{"#", "ล็อต / Lot", "หมดอายุ / Expire", "ใบรับ / Receive", "หน่วย / Unit", "จำนวน / Qty", "ทุน / Cost", "มูลค่า / Amount"}
+		widths := []float64{10, 30, 24, 32, 20, 22, 24, 28}
+		aligns := []string{"C", "L", "C", "L", "C", "R", "R", "R"}
+		pdf.AddTableHeader(doc, headers, widths)
+		units := make(map[string]string)
+		for i, item := range recallReturn.Items {
+			unitId := item.UnitId.Hex()
+			if _, ok := units[unitId]; !ok {
+				if unit, _ := productEntity.GetProductUnitById(unitId); unit != nil {
+					units[unitId] = unit.Unit
+				}
+			}
+			pdf.AddTableRow(doc, []string{
+				fmt.Sprintf("%d", i+1),
+				item.LotNumber,
+				item.ExpireDate.In(utils.GetLocation()).Format("02/01/2006"),
+				item.ReceiveCode,
+				units[unitId],
+				fmt.Sprintf("%d", item.Quantity),
+				fmt.Sprintf("%.2f", item.CostPrice),
+				fmt.Sprintf("%.2f", item.CostAmount),
+			}, widths, aligns)
+		}
+
+		doc.Ln(3)
+		pdf.AddSummaryLine(doc, "รวม / Total:", fmt.Sprintf("%.2f", recallReturn.TotalCost), float64(190))
+		doc.Ln(15)
+		doc.CellFormat(95, 6, "ผู้ส่งคืน / Returned by ____________________", "", 0, "C", false, 0, "")
+		doc.CellFormat(95, 6, "ผู้รับคืน / Received by ____________________", "", 1, "C", false, 0, "")
+		pdf.AddFooter(doc, "", setting.ShowCredit)
+
+		ctx.Header("Content-Type", "application/pdf")
+		ctx.Header("Content-Disposition", fmt.Sprintf("inline; filename=%s.pdf", recallReturn.Code))
+		if err := doc.Output(ctx.Writer); err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.RL_INTERNAL_001, err.Error())
+			return
+		}
+	}
+}
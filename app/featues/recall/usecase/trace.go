@@ -0,0 +1,300 @@
+package usecase
+
+import (
+	"fmt"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// GetRecallTrace follows the recalled lots through the orders and dispensing logs of every branch to
+// the people who received them, along with what the branches still hold
+func GetRecallTrace(
+	entity repositories.IRecall,
+	productEntity repositories.IProduct,
+	orderEntity repositories.IOrder,
+	dispensingLogEntity repositories.IDispensingLog,
+	patientEntity repositories.IPatient,
+	customerEntity repositories.ICustomer,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		recall, ok := getRecall(ctx, entity)
+		if !ok {
+			return
+		}
+		result, err := buildRecallTrace(recall, entity, productEntity, orderEntity, dispensingLogEntity, patientEntity, customerEntity)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.RL_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetRecallContactsExcel(
+	entity repositories.IRecall,
+	productEntity repositories.IProduct,
+	orderEntity repositories.IOrder,
+	dispensingLogEntity repositories.IDispensingLog,
+	patientEntity repositories.IPatient,
+	customerEntity repositories.ICustomer,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		recall, ok := getRecall(ctx, entity)
+		if !ok {
+			return
+		}
+		trace, err := buildRecallTrace(recall, entity, productEntity, orderEntity, dispensingLogEntity, patientEntity, customerEntity)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.RL_INTERNAL_001, err.Error())
+			return
+		}
+
+		f := excelize.NewFile()
+		sheet := recall.Code
+		f.SetSheetName("Sheet1", sheet)
+		f.SetCellValue(sheet, "A1", "รายชื่อผู้ได้รับสินค้าเรียกคืน / Recall Contact List")
+		f.SetCellValue(sheet, "A2", fmt.Sprintf("%s  %s %s  ล็อต / Lots: %s", recall.Code, recall.SerialNumber, recall.ProductName, strings.Join(recall.LotNumbers, ", ")))
+		f.SetCellValue(sheet, "A3", fmt.Sprintf("%s  %s", recall.Reference, recall.Reason))
+
+		headers := []string{"#", "ประเภท / Type", "ชื่อ / Name", "โทร / Phone", "อีเมล / Email", "ที่อยู่ / Address", "ล็อต / Lots", "เลขที่ขาย / Orders", "จำนวน / Quantity", "ล่าสุด / Last date"}
+		headerRow := 5
+		cell, _ := excelize.CoordinatesToCellName(1, headerRow)
+		f.SetSheetRow(sheet, cell, &headers)
+		style, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+		last, _ := excelize.CoordinatesToCellName(len(headers), headerRow)
+		f.SetCellStyle(sheet, fmt.Sprintf("A%d", headerRow), last, style)
+		f.SetCellStyle(sheet, "A1", "A1", style)
+
+		for i, contact := range trace.Contacts {
+			values := []interface{}{
+				i + 1,
+				contact.Type,
+				contact.Name,
+				contact.Phone,
+				contact.Email,
+				contact.Address,
+				strings.Join(contact.LotNumbers, ", "),
+				strings.Join(contact.OrderCodes, ", "),
+				contact.Quantity,
+				contact.LastDate.In(utils.GetLocation()).Format("02/01/2006"),
+			}
+			cell, _ := excelize.CoordinatesToCellName(1, headerRow+1+i)
+			f.SetSheetRow(sheet, cell, &values)
+		}
+
+		for i := range headers {
+			col, _ := excelize.ColumnNumberToName(i + 1)
+			f.SetColWidth(sheet, col, col, 18)
+		}
+
+		ctx.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-contacts.xlsx", recall.Code))
+		if err := f.Write(ctx.Writer); err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.RL_INTERNAL_001, err.Error())
+			return
+		}
+	}
+}
+
+func buildRecallTrace(
+	recall *entities.Recall,
+	entity repositories.IRecall,
+	productEntity repositories.IProduct,
+	orderEntity repositories.IOrder,
+	dispensingLogEntity repositories.IDispensingLog,
+	patientEntity repositories.IPatient,
+	customerEntity repositories.ICustomer,
+) (*entities.RecallTrace, error) {
+	productId := recall.ProductId.Hex()
+	recalled := make(map[string]bool)
+	for _, lotNumber := range recall.LotNumbers {
+		recalled[lotNumber] = true
+	}
+	trace := &entities.RecallTrace{
+		Recall:      *recall,
+		Orders:      []entities.RecallOrder{},
+		Dispensings: []entities.RecallDispensing{},
+	}
+
+	items, err := orderEntity.GetOrderItemOrderDetailsByLotNumbers(productId, recall.LotNumbers)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		for _, stock := range item.Stocks {
+			if !recalled[stock.LotNumber] {
+				continue
+			}
+			trace.Orders = append(trace.Orders, entities.RecallOrder{
+				OrderId:      item.Order.Id,
+				OrderCode:    item.Order.Code,
+				BranchId:     item.Order.BranchId,
+				CustomerCode: item.Order.CustomerCode,
+				CustomerName: item.Order.CustomerName,
+				PatientId:    item.Order.PatientId,
+				BuyerName:    item.Order.BuyerName,
+				LotNumber:    stock.LotNumber,
+				Quantity:     stock.Quantity,
+				CreatedDate:  item.Order.CreatedDate,
+			})
+		}
+	}
+
+	logs, err := dispensingLogEntity.GetDispensingLogsByLotNumbers(productId, recall.LotNumbers)
+	if err != nil {
+		return nil, err
+	}
+	for _, log := range logs {
+		for _, item := range log.Items {
+			if item.ProductId != recall.ProductId || !recalled[item.LotNumber] || item.NetQuantity() <= 0 {
+				continue
+			}
+			trace.Dispensings = append(trace.Dispensings, entities.RecallDispensing{
+				DispensingLogId: log.Id,
+				OrderCode:       log.OrderCode,
+				BranchId:        log.BranchId,
+				PatientId:       log.PatientId,
+				PharmacistName:  log.PharmacistName,
+				LotNumber:       item.LotNumber,
+				Quantity:        item.NetQuantity(),
+				CreatedDate:     log.CreatedDate,
+			})
+		}
+	}
+
+	trace.Contacts = recallContacts(trace, patientEntity, customerEntity)
+
+	trace.Stocks, err = productEntity.GetProductStocksByLotNumbers(productId, recall.LotNumbers, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, stock := range trace.Stocks {
+		if stock.Quantity > 0 {
+			trace.Remaining += stock.Quantity
+		}
+	}
+	trace.Returns, err = entity.GetRecallReturnsByRecallId(recall.Id.Hex(), "")
+	if err != nil {
+		return nil, err
+	}
+	return trace, nil
+}
+
+// recallContacts turns the sales and dispensing lines into one contact per patient, registered customer
+// or named buyer. A sale that is both in the orders and the dispensing register is counted once.
+func recallContacts(trace *entities.RecallTrace, patientEntity repositories.IPatient, customerEntity repositories.ICustomer) []entities.RecallContact {
+	contacts := make(map[string]*entities.RecallContact)
+	counted := make(map[string]bool)
+	var keys []string
+	add := func(key string, load func() *entities.RecallContact, lotNumber string, orderCode string, quantity int, date time.Time) {
+		contact, ok := contacts[key]
+		if !ok {
+			contact = load()
+			if contact == nil {
+				return
+			}
+			contacts[key] = contact
+			keys = append(keys, key)
+		}
+		line := key + "|" + orderCode + "|" + lotNumber
+		if counted[line] {
+			return
+		}
+		counted[line] = true
+		contact.Quantity += quantity
+		contact.LotNumbers = appendUnique(contact.LotNumbers, lotNumber)
+		contact.OrderCodes = appendUnique(contact.OrderCodes, orderCode)
+		if date.After(contact.LastDate) {
+			contact.LastDate = date
+		}
+	}
+	patient := func(id string) func() *entities.RecallContact {
+		return func() *entities.RecallContact {
+			found, err := patientEntity.GetPatientById(id)
+			if err != nil {
+				return nil
+			}
+			return &entities.RecallContact{
+				Type:    constant.RecallContactPatient,
+				Id:      id,
+				Name:    strings.TrimSpace(found.FirstName + " " + found.LastName),
+				Phone:   found.Phone,
+				Email:   found.Email,
+				Address: found.Address,
+			}
+		}
+	}
+
+	for _, order := range trace.Orders {
+		var key string
+		var load func() *entities.RecallContact
+		switch {
+		case order.PatientId != "":
+			key, load = "P:"+order.PatientId, patient(order.PatientId)
+		case order.CustomerCode != "":
+			code := order.CustomerCode
+			key = "C:" + code
+			load = func() *entities.RecallContact {
+				found, err := customerEntity.GetCustomerByCode(code)
+				if err != nil {
+					return nil
+				}
+				return &entities.RecallContact{
+					Type:    constant.RecallContactCustomer,
+					Id:      code,
+					Name:    found.Name,
+					Phone:   found.Phone,
+					Email:   found.Email,
+					Address: found.Address,
+				}
+			}
+		case order.BuyerName != "":
+			name := order.BuyerName
+			key = "B:" + name
+			load = func() *entities.RecallContact {
+				return &entities.RecallContact{Type: constant.RecallContactBuyer, Name: name}
+			}
+		default:
+			continue
+		}
+		add(key, load, order.LotNumber, order.OrderCode, order.Quantity, order.CreatedDate)
+	}
+	for _, dispensing := range trace.Dispensings {
+		if dispensing.PatientId.IsZero() {
+			continue
+		}
+		id := dispensing.PatientId.Hex()
+		add("P:"+id, patient(id), dispensing.LotNumber, dispensing.OrderCode, dispensing.Quantity, dispensing.CreatedDate)
+	}
+
+	results := make([]entities.RecallContact, 0, len(keys))
+	for _, key := range keys {
+		results = append(results, *contacts[key])
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].LastDate.After(results[j].LastDate)
+	})
+	return results
+}
+
+func appendUnique(values []string, value string) []string {
+	if value == "" {
+		return values
+	}
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,59 @@
+package receivable
+
+import (
+	"pos/app/domain"
+	"pos/app/featues/receivable/usecase"
+	"pos/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ApplyReceivableAPI(
+	route *gin.RouterGroup,
+	repository *domain.Repository,
+) {
+	receivableRoute := route.Group("receivables")
+
+	receivableRoute.POST("/receipts",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireIdempotency(repository.Idempotency),
+		usecase.CreateReceipt(repository.Transaction, repository.Receivable, repository.Customer, repository.Sequence, repository.Shift),
+	)
+
+	receivableRoute.GET("/receipts",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetReceipts(repository.Receivable),
+	)
+
+	receivableRoute.GET("/receipts/:receiptId",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetReceiptById(repository.Receivable),
+	)
+
+	receivableRoute.GET("/aging",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetAgingReport(repository.Receivable),
+	)
+
+	receivableRoute.GET("/customers/:customerId",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetCustomerReceivable(repository.Receivable, repository.Customer),
+	)
+
+	receivableRoute.GET("/customers/:customerId/statement/pdf",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetStatementPDF(repository.Receivable, repository.Customer, repository.Setting),
+	)
+}
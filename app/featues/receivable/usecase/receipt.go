@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateReceipt records a payment from a customer and allocates it against its open invoices,
+// oldest first unless the allocations are given
+func CreateReceipt(
+	transactionEntity repositories.ITransaction,
+	receivableEntity repositories.IReceivable,
+	customerEntity repositories.ICustomer,
+	sequenceEntity repositories.ISequence,
+	shiftEntity repositories.IShift,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.ReceivableReceipt{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.AR_BAD_REQUEST_001, err.Error())
+			return
+		}
+		if !utils.InArrayString(req.PaymentType, constant.PaymentTypes()) || req.PaymentType == constant.PaymentTypeCredit {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.AR_BAD_REQUEST_001, "invalid payment type: "+req.PaymentType)
+			return
+		}
+		customer, err := customerEntity.GetCustomerById(req.CustomerId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.AR_BAD_REQUEST_002, "customer not found")
+			return
+		}
+		userId := utils.GetUserId(ctx)
+		req.BranchId = utils.GetBranchId(ctx)
+		req.CreatedBy = userId
+		req.CustomerCode = customer.Code
+		req.CustomerName = customer.Name
+		req.Amount = utils.Round2(req.Amount)
+		if shift, _ := shiftEntity.GetOpenShiftByUserId(req.BranchId, userId); shift != nil {
+			req.ShiftId = shift.Id.Hex()
+		}
+
+		var result *entities.ReceivableReceipt
+		err = transactionEntity.WithTransaction(func(txCtx context.Context) error {
+			invoices, err := receivableEntity.GetOpenInvoicesByCustomerIdTx(txCtx, req.BranchId, req.CustomerId)
+			if err != nil {
+				return err
+			}
+			allocations, err := allocateReceipt(req.Amount, req.Allocations, invoices)
+			if err != nil {
+				return err
+			}
+			for i, allocation := range allocations {
+				invoice, err := receivableEntity.AllocateInvoiceTx(txCtx, allocation.InvoiceId, allocation.Amount, userId)
+				if errors.Is(err, repositories.ErrInsufficientBalance) {
+					return fmt.Errorf("invoice %s balance is less than %.2f: %w", allocation.OrderCode, allocation.Amount, err)
+				}
+				if err != nil {
+					return err
+				}
+				allocations[i].OrderCode = invoice.OrderCode
+			}
+			req.Allocations = allocations
+
+			sequence, err := sequenceEntity.NextSequenceTx(txCtx, constant.RECEIPT)
+			if err != nil {
+				return err
+			}
+			req.Code = sequence.GenerateCode()
+
+			result, err = receivableEntity.CreateReceiptTx(txCtx, req)
+			return err
+		})
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.AR_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetReceipts(receivableEntity repositories.IReceivable) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.GetReceivableRange{}
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.AR_BAD_REQUEST_001, err.Error())
+			return
+		}
+		req.BranchId = utils.GetBranchId(ctx)
+		result, err := receivableEntity.GetReceiptRange(req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.AR_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetReceiptById(receivableEntity repositories.IReceivable) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, err := receivableEntity.GetReceiptById(ctx.Param("receiptId"))
+		if err != nil || result.BranchId.Hex() != utils.GetBranchId(ctx) {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.AR_BAD_REQUEST_002, "receipt not found")
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+// allocateReceipt checks the requested allocations against the open invoices of the customer,
+// or spreads the amount over them oldest first. The whole amount must be allocated.
+func allocateReceipt(
+	amount float64,
+	requested []request.ReceivableAllocation,
+	invoices []entities.ReceivableInvoice,
+) ([]request.ReceivableAllocation, error) {
+	balances := make(map[string]entities.ReceivableInvoice)
+	var outstanding float64
+	for _, invoice := range invoices {
+		balances[invoice.Id.Hex()] = invoice
+		outstanding += invoice.Balance
+	}
+	if amount > utils.Round2(outstanding) {
+		return nil, fmt.Errorf("amount %.2f is more than the outstanding balance %.2f", amount, outstanding)
+	}
+
+	if len(requested) > 0 {
+		var total float64
+		for i, allocation := range requested {
+			invoice, ok := balances[allocation.InvoiceId]
+			if !ok {
+				return nil, fmt.Errorf("invoice %s is not open for this customer", allocation.InvoiceId)
+			}
+			requested[i].Amount = utils.Round2(allocation.Amount)
+			requested[i].OrderCode = invoice.OrderCode
+			total += requested[i].Amount
+		}
+		if utils.Round2(total) != amount {
+			return nil, fmt.Errorf("allocations total %.2f does not match the amount %.2f", total, amount)
+		}
+		return requested, nil
+	}
+
+	results := []request.ReceivableAllocation{}
+	remaining := amount
+	for _, invoice := range invoices {
+		if remaining <= 0 {
+			break
+		}
+		allocated := utils.Round2(math.Min(remaining, invoice.Balance))
+		results = append(results, request.ReceivableAllocation{
+			InvoiceId: invoice.Id.Hex(),
+			Amount:    allocated,
+			OrderCode: invoice.OrderCode,
+		})
+		remaining = utils.Round2(remaining - allocated)
+	}
+	return results, nil
+}
@@ -0,0 +1,228 @@
+package usecase
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/pdf"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/request"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCustomerReceivable returns what the customer owes in the branch and the credit left
+func GetCustomerReceivable(
+	receivableEntity repositories.IReceivable,
+	customerEntity repositories.ICustomer,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		customerId := ctx.Param("customerId")
+		customer, err := customerEntity.GetCustomerById(customerId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.AR_BAD_REQUEST_002, "customer not found")
+			return
+		}
+		invoices, err := receivableEntity.GetInvoicesByCustomerId(utils.GetBranchId(ctx), customerId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.AR_BAD_REQUEST_002, err.Error())
+			return
+		}
+		// The credit limit applies over every branch
+		outstanding, err := receivableEntity.GetOutstandingBalance(customerId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.AR_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, entities.ReceivableCustomer{
+			Customer:        *customer,
+			Outstanding:     utils.Round2(outstanding),
+			AvailableCredit: utils.Round2(math.Max(customer.CreditLimit-outstanding, 0)),
+			Invoices:        invoices,
+		})
+	}
+}
+
+// GetAgingReport buckets the open balances of the branch by invoice age per customer
+func GetAgingReport(receivableEntity repositories.IReceivable) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		invoices, err := receivableEntity.GetOpenInvoices(utils.GetBranchId(ctx))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.AR_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, buildAging(invoices, time.Now()))
+	}
+}
+
+func buildAging(invoices []entities.ReceivableInvoice, asOf time.Time) []entities.ReceivableAging {
+	rows := make(map[string]*entities.ReceivableAging)
+	results := []entities.ReceivableAging{}
+	for _, invoice := range invoices {
+		customerId := invoice.CustomerId.Hex()
+		row, ok := rows[customerId]
+		if !ok {
+			row = &entities.ReceivableAging{
+				CustomerId:   customerId,
+				CustomerCode: invoice.CustomerCode,
+				CustomerName: invoice.CustomerName,
+			}
+			rows[customerId] = row
+		}
+		days := int(asOf.Sub(invoice.CreatedDate).Hours() / 24)
+		switch {
+		case days <= 30:
+			row.Current += invoice.Balance
+		case days <= 60:
+			row.Days31To60 += invoice.Balance
+		case days <= 90:
+			row.Days61To90 += invoice.Balance
+		default:
+			row.Over90 += invoice.Balance
+		}
+		row.Total += invoice.Balance
+	}
+	for _, row := range rows {
+		row.Current = utils.Round2(row.Current)
+		row.Days31To60 = utils.Round2(row.Days31To60)
+		row.Days61To90 = utils.Round2(row.Days61To90)
+		row.Over90 = utils.Round2(row.Over90)
+		row.Total = utils.Round2(row.Total)
+		results = append(results, *row)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Total > results[j].Total
+	})
+	return results
+}
+
+// statementLine is one invoice or receipt on a customer statement
+type statementLine struct {
+	Date     time.Time
+	Document string
+	Debit    float64
+	Credit   float64
+}
+
+func GetStatementPDF(
+	receivableEntity repositories.IReceivable,
+	customerEntity repositories.ICustomer,
+	settingEntity repositories.ISetting,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.GetReceivableRange{}
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.AR_BAD_REQUEST_001, err.Error())
+			return
+		}
+		branchId := utils.GetBranchId(ctx)
+		customerId := ctx.Param("customerId")
+		customer, err := customerEntity.GetCustomerById(customerId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.AR_BAD_REQUEST_002, "customer not found")
+			return
+		}
+		invoices, err := receivableEntity.GetInvoicesByCustomerId(branchId, customerId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.AR_BAD_REQUEST_002, err.Error())
+			return
+		}
+		receipts, err := receivableEntity.GetReceiptsByCustomerId(branchId, customerId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.AR_BAD_REQUEST_002, err.Error())
+			return
+		}
+
+		var opening float64
+		lines := []statementLine{}
+		for _, invoice := range invoices {
+			if invoice.CreatedDate.Before(req.StartDate) {
+				opening += invoice.Amount
+			} else if invoice.CreatedDate.Before(req.EndDate) {
+				lines = append(lines, statementLine{Date: invoice.CreatedDate, Document: "Invoice " + invoice.OrderCode, Debit: invoice.Amount})
+			}
+		}
+		for _, receipt := range receipts {
+			if receipt.CreatedDate.Before(req.StartDate) {
+				opening -= receipt.Amount
+			} else if receipt.CreatedDate.Before(req.EndDate) {
+				lines = append(lines, statementLine{Date: receipt.CreatedDate, Document: "Receipt " + receipt.Code, Credit: receipt.Amount})
+			}
+		}
+		sort.Slice(lines, func(i, j int) bool {
+			return lines[i].Date.Before(lines[j].Date)
+		})
+
+		setting, _ := settingEntity.GetSettingByBranchId(branchId)
+		companyName := "POS System"
+		companyAddress := ""
+		companyPhone := ""
+		showCredit := true
+		if setting != nil {
+			if setting.CompanyName != "" {
+				companyName = setting.CompanyName
+			}
+			companyAddress = setting.CompanyAddress
+			companyPhone = setting.CompanyPhone
+			showCredit = setting.ShowCredit
+		}
+
+		doc := pdf.NewPDF()
+		doc.AddPage()
+		pdf.AddHeader(doc, companyName, companyAddress, companyPhone, "Customer Statement")
+
+		doc.SetFont(pdf.FontFamily, "", 9)
+		doc.CellFormat(0, 5, fmt.Sprintf("Customer: %s %s", customer.Code, customer.Name), "", 1, "L", false, 0, "")
+		doc.CellFormat(0, 5, fmt.Sprintf("Period: %s - %s", utils.ToFormat(req.StartDate), utils.ToFormat(req.EndDate)), "", 1, "L", false, 0, "")
+		doc.Ln(3)
+
+		headers := []string{"Date", "Document", "Debit", "Credit", "Balance"}
+		widths := []float64{40, 60, 30, 30, 30}
+		aligns := []string{"L", "L", "R", "R", "R"}
+		pdf.AddTableHeader(doc, headers, widths)
+		balance := utils.Round2(opening)
+		pdf.AddTableRow(doc, []string{utils.ToFormat(req.StartDate), "Opening Balance", "", "", fmt.Sprintf("%.2f", balance)}, widths, aligns)
+		var totalDebit, totalCredit float64
+		for _, line := range lines {
+			balance = utils.Round2(balance + line.Debit - line.Credit)
+			totalDebit += line.Debit
+			totalCredit += line.Credit
+			debit := ""
+			credit := ""
+			if line.Debit > 0 {
+				debit = fmt.Sprintf("%.2f", line.Debit)
+			}
+			if line.Credit > 0 {
+				credit = fmt.Sprintf("%.2f", line.Credit)
+			}
+			pdf.AddTableRow(doc, []string{utils.ToFormat(line.Date), line.Document, debit, credit, fmt.Sprintf("%.2f", balance)}, widths, aligns)
+		}
+
+		doc.Ln(5)
+		totalWidth := float64(190)
+		pdf.AddSummaryLine(doc, "Opening Balance:", fmt.Sprintf("%.2f", utils.Round2(opening)), totalWidth)
+		pdf.AddSummaryLine(doc, "Invoiced:", fmt.Sprintf("%.2f", utils.Round2(totalDebit)), totalWidth)
+		pdf.AddSummaryLine(doc, "Received:", fmt.Sprintf("%.2f", utils.Round2(totalCredit)), totalWidth)
+		pdf.AddSummaryLine(doc, "Closing Balance:", fmt.Sprintf("%.2f", balance), totalWidth)
+		pdf.AddSummaryLine(doc, "Credit Limit:", fmt.Sprintf("%.2f", customer.CreditLimit), totalWidth)
+
+		creditText := ""
+		if showCredit {
+			creditText = "Powered by POS System"
+		}
+		pdf.AddFooter(doc, creditText, false)
+
+		ctx.Header("Content-Type", "application/pdf")
+		ctx.Header("Content-Disposition", fmt.Sprintf("inline; filename=statement-%s.pdf", customer.Code))
+		err = doc.Output(ctx.Writer)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.AR_INTERNAL_001, err.Error())
+			return
+		}
+	}
+}
@@ -20,7 +20,8 @@ func ApplyReceiveAPI(
 		middlewares.RequireSession(repository.Session),
 		middlewares.RequireBranch(repository.Employee, repository.Branch),
 		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
-		usecase.CreateReceive(repository.Receive, repository.Sequence, repository.Product),
+		middlewares.RequireIdempotency(repository.Idempotency),
+		usecase.CreateReceive(repository.Transaction, repository.Receive, repository.Sequence, repository.Product, repository.Recall, repository.Setting),
 	)
 
 	receiveRoute.GET("",
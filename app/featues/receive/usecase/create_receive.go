@@ -1,19 +1,21 @@
 package usecase
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"pos/app/core/errcode"
 	"pos/app/core/utils"
+	"pos/app/data/entities"
 	"pos/app/data/repositories"
 	"pos/app/domain/constant"
 	"pos/app/domain/request"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/sirupsen/logrus"
 )
 
-func CreateReceive(receiveEntity repositories.IReceive, sequenceEntity repositories.ISequence, productEntity repositories.IProduct) gin.HandlerFunc {
+func CreateReceive(transactionEntity repositories.ITransaction, receiveEntity repositories.IReceive, sequenceEntity repositories.ISequence, productEntity repositories.IProduct, recallEntity repositories.IRecall, settingEntity repositories.ISetting) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		req := request.Receive{}
 		if err := ctx.ShouldBind(&req); err != nil {
@@ -24,59 +26,86 @@ func CreateReceive(receiveEntity repositories.IReceive, sequenceEntity repositor
 		userId := utils.GetUserId(ctx)
 		branchId := utils.GetBranchId(ctx)
 
+		// Validate every item before writing anything
+		products := make(map[string]*entities.Product)
+		units := make(map[string]*entities.ProductUnit)
+		for _, item := range req.Items {
+			if item.ProductId == "" || item.Quantity <= 0 {
+				continue
+			}
+			if _, ok := products[item.ProductId]; ok {
+				continue
+			}
+			product, err := productEntity.GetProductById(item.ProductId)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.RC_BAD_REQUEST_001, "product not found: "+item.ProductId)
+				return
+			}
+			unit, err := productEntity.GetProductUnitByUnit(item.ProductId, product.Unit)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.RC_BAD_REQUEST_001, "product unit not found: "+product.Name)
+				return
+			}
+			products[item.ProductId] = product
+			units[item.ProductId] = unit
+		}
+
 		sequence, _ := sequenceEntity.NextSequence(constant.RECEIVE)
 		if sequence != nil {
 			req.Code = sequence.GenerateCode()
 		}
 		req.UpdatedBy = userId
 		req.BranchId = branchId
+		setting, _ := settingEntity.GetSettingByBranchId(branchId)
+		costingMethod := setting.GetCostingMethod()
 
-		result, err := receiveEntity.CreateReceive(req)
-		if err != nil {
-			errcode.Abort(ctx, http.StatusBadRequest, errcode.RC_BAD_REQUEST_002, err.Error())
-			return
-		}
-
-		receiveId := result.Id.Hex()
-		var totalCost float64
-
-		for _, item := range req.Items {
-			if item.ProductId == "" || item.Quantity <= 0 {
-				continue
+		var result *entities.Receive
+		err := transactionEntity.WithTransaction(func(txCtx context.Context) error {
+			var err error
+			result, err = receiveEntity.CreateReceiveTx(txCtx, req)
+			if err != nil {
+				return err
 			}
 
-			product, pErr := productEntity.GetProductById(item.ProductId)
-			if pErr != nil || product == nil {
-				logrus.Warnf("CreateReceive: product %s not found, skipping", item.ProductId)
-				continue
-			}
+			receiveId := result.Id.Hex()
+			var totalCost float64
 
-			productReq := request.Product{
-				Name:         product.Name,
-				SerialNumber: product.SerialNumber,
-				Price:        product.Price,
-				CostPrice:    item.CostPrice,
-				Unit:         product.Unit,
-				Quantity:     item.Quantity,
-				LotNumber:    item.LotNumber,
-				ExpireDate:   time.Time{},
-				ReceiveId:    receiveId,
-				ReceiveCode:  req.Code,
-				CreatedBy:    userId,
-				BranchId:     branchId,
-			}
-			if item.ExpireDate != "" {
-				if t, tErr := time.Parse(time.RFC3339, item.ExpireDate); tErr == nil {
-					productReq.ExpireDate = t
-				} else if t, tErr := time.Parse("2006-01-02", item.ExpireDate); tErr == nil {
-					productReq.ExpireDate = t
+			for _, item := range req.Items {
+				if item.ProductId == "" || item.Quantity <= 0 {
+					continue
+				}
+				product := products[item.ProductId]
+				unit := units[item.ProductId]
+				if product == nil || unit == nil {
+					return errors.New("product not found: " + item.ProductId)
+				}
+
+				productReq := request.Product{
+					Name:         product.Name,
+					SerialNumber: product.SerialNumber,
+					Price:        product.Price,
+					CostPrice:    item.CostPrice,
+					Unit:         product.Unit,
+					Quantity:     item.Quantity,
+					LotNumber:    item.LotNumber,
+					ExpireDate:   time.Time{},
+					ReceiveId:    receiveId,
+					ReceiveCode:  req.Code,
+					CreatedBy:    userId,
+					BranchId:     branchId,
+				}
+				if item.ExpireDate != "" {
+					if t, tErr := time.Parse(time.RFC3339, item.ExpireDate); tErr == nil {
+						productReq.ExpireDate = t
+					} else if t, tErr := time.Parse("2006-01-02", item.ExpireDate); tErr == nil {
+						productReq.ExpireDate = t
+					}
 				}
-			}
 
-			_, _ = receiveEntity.CreateReceiveItem(receiveId, "", item.ProductId, productReq)
+				if _, err := receiveEntity.CreateReceiveItemTx(txCtx, receiveId, item.ProductId, productReq); err != nil {
+					return err
+				}
 
-			unit, _ := productEntity.GetProductUnitByUnit(item.ProductId, product.Unit)
-			if unit != nil && item.Quantity > 0 {
 				stock := request.ProductStock{
 					ProductId:   item.ProductId,
 					UnitId:      unit.Id.Hex(),
@@ -89,20 +118,50 @@ func CreateReceive(receiveEntity repositories.IReceive, sequenceEntity repositor
 					UpdatedBy:   userId,
 					BranchId:    branchId,
 				}
-				created, _ := productEntity.CreateProductStock(stock)
-				if created != nil {
-					balance := productEntity.GetProductStockBalance(created.ProductId.Hex(), created.UnitId.Hex())
-					hist := request.AddProductStockHistory(created.ProductId.Hex(), product.Unit, stock, balance)
-					hist.BranchId = branchId
-					_, _ = productEntity.CreateProductHistory(hist)
+				// A lot delivered after its recall is blocked on arrival, ready to be returned
+				if recallEntity.IsLotRecalled(item.ProductId, item.LotNumber) {
+					stock.Status = constant.StockStatusRecalled
+				}
+				created, err := productEntity.CreateProductStockTx(txCtx, stock)
+				if err != nil {
+					return err
+				}
+				balance := productEntity.GetProductStockBalanceTx(txCtx, created.ProductId.Hex(), created.UnitId.Hex())
+				hist := request.AddProductStockHistory(created.ProductId.Hex(), product.Unit, stock, balance)
+				hist.BranchId = branchId
+				if _, err := productEntity.CreateProductHistoryTx(txCtx, hist); err != nil {
+					return err
 				}
-			}
 
-			totalCost += item.CostPrice * float64(item.Quantity)
-		}
+				// The lot enters the valuation ledger at its cost, under AVERAGE the lots of the unit are
+				// then repriced to the new moving average
+				valuation := request.StockValuation(created, constant.ValuationTypeReceive, created.Quantity, userId)
+				valuation.DocumentType = constant.RECEIVE
+				valuation.DocumentId = receiveId
+				valuation.DocumentCode = req.Code
+				if _, err := productEntity.CreateProductValuationTx(txCtx, valuation); err != nil {
+					return err
+				}
+				if costingMethod == constant.CostingMethodAverage && created.IsSellable() {
+					if _, err := productEntity.AverageProductStockCostTx(txCtx, valuation); err != nil {
+						return err
+					}
+				}
+
+				totalCost += item.CostPrice * float64(item.Quantity)
+			}
 
-		if totalCost > 0 {
-			_, _ = receiveEntity.UpdateReceiveTotalCostById(receiveId, totalCost)
+			if totalCost > 0 {
+				result, err = receiveEntity.UpdateReceiveTotalCostByIdTx(txCtx, receiveId, totalCost)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RC_BAD_REQUEST_002, err.Error())
+			return
 		}
 
 		ctx.JSON(http.StatusOK, result)
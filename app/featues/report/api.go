@@ -22,11 +22,57 @@ func ApplyReportAPI(
 		usecase.GetReceiptPDF(repository.Order, repository.Setting),
 	)
 
+	reportRoute.GET("/receipt/:orderId/escpos",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetReceiptEscPos(repository.Order, repository.Setting),
+	)
+
 	reportRoute.GET("/tax-invoice/:orderId/pdf",
 		middlewares.RequireAuthenticated(),
 		middlewares.RequireSession(repository.Session),
 		middlewares.RequireBranch(repository.Employee, repository.Branch),
-		usecase.GetTaxInvoicePDF(repository.Order, repository.Setting),
+		usecase.GetTaxInvoicePDF(repository.TaxInvoice, repository.Setting),
+	)
+
+	reportRoute.GET("/tax-invoices/:id/pdf",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetTaxInvoicePDFById(repository.TaxInvoice, repository.Setting),
+	)
+
+	reportRoute.GET("/vat/output/pdf",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.GetOutputVatReportPDF(repository.TaxInvoice, repository.CreditNote, repository.Setting),
+	)
+
+	reportRoute.GET("/vat/output/excel",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.GetOutputVatReportExcel(repository.TaxInvoice, repository.CreditNote, repository.Setting),
+	)
+
+	reportRoute.GET("/vat/input/pdf",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.GetInputVatReportPDF(repository.Receive, repository.Supplier, repository.Setting),
+	)
+
+	reportRoute.GET("/vat/input/excel",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.GetInputVatReportExcel(repository.Receive, repository.Supplier, repository.Setting),
 	)
 
 	reportRoute.GET("/sales/pdf",
@@ -60,6 +106,22 @@ func ApplyReportAPI(
 		usecase.GetDrugLabelPDF(repository.DispensingLog, repository.Setting),
 	)
 
+	reportRoute.GET("/pharmacy/clinical-interventions",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.GetClinicalInterventions(repository.Order),
+	)
+
+	reportRoute.GET("/pharmacy/clinical-interventions/pdf",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.GetClinicalInterventionsPDF(repository.Order, repository.Setting),
+	)
+
 	reportRoute.GET("/pharmacy/khy9",
 		middlewares.RequireAuthenticated(),
 		middlewares.RequireSession(repository.Session),
@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"fmt"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/pdf"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/request"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func getClinicalInterventions(ctx *gin.Context, orderEntity repositories.IOrder, req pharmacyReportRange) ([]entities.ClinicalIntervention, error) {
+	orders, err := orderEntity.GetClinicalOverrides(request.GetOrderRange{
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		BranchId:  ctx.GetString("BranchId"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	results := []entities.ClinicalIntervention{}
+	for _, order := range orders {
+		if order.ClinicalOverride == nil {
+			continue
+		}
+		results = append(results, entities.ClinicalIntervention{
+			OrderId:     order.Id.Hex(),
+			OrderCode:   order.Code,
+			PatientId:   order.PatientId,
+			Status:      order.Status,
+			CreatedDate: order.CreatedDate,
+			Override:    *order.ClinicalOverride,
+		})
+	}
+	return results, nil
+}
+
+// GetClinicalInterventions lists the sales a pharmacist let through allergy or interaction alerts
+func GetClinicalInterventions(orderEntity repositories.IOrder) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := pharmacyReportRange{}
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RP_BAD_REQUEST_001, err.Error())
+			return
+		}
+		results, err := getClinicalInterventions(ctx, orderEntity, req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RP_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, results)
+	}
+}
+
+func GetClinicalInterventionsPDF(orderEntity repositories.IOrder, settingEntity repositories.ISetting) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := pharmacyReportRange{}
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RP_BAD_REQUEST_001, err.Error())
+			return
+		}
+		results, err := getClinicalInterventions(ctx, orderEntity, req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RP_BAD_REQUEST_002, err.Error())
+			return
+		}
+
+		setting, _ := settingEntity.GetSettingByBranchId(ctx.GetString("BranchId"))
+		companyName := "Pharmacy"
+		if setting != nil && setting.CompanyName != "" {
+			companyName = setting.CompanyName
+		}
+
+		doc := pdf.NewPDF()
+		doc.AddPage()
+		pdf.AddHeader(doc, companyName, "", "", "Clinical Interventions")
+		doc.SetFont(pdf.FontFamily, "", 9)
+		doc.CellFormat(0, 5, fmt.Sprintf("Period: %s - %s", req.StartDate.Format("02/01/2006"), req.EndDate.Format("02/01/2006")), "", 1, "C", false, 0, "")
+		doc.Ln(3)
+
+		headers := []string{"#", "Date", "Order", "Alert", "Pharmacist", "License", "Justification"}
+		widths := []float64{8, 20, 27, 50, 25, 20, 40}
+		aligns := []string{"C", "L", "L", "L", "L", "L", "L"}
+		pdf.AddTableHeader(doc, headers, widths)
+
+		for i, row := range results {
+			alerts := make([]string, len(row.Override.Alerts))
+			for j, alert := range row.Override.Alerts {
+				if alert.OtherProductName != "" {
+					alerts[j] = fmt.Sprintf("%s %s + %s", alert.Type, alert.ProductName, alert.OtherProductName)
+				} else {
+					alerts[j] = fmt.Sprintf("%s %s (%s)", alert.Type, alert.ProductName, alert.Severity)
+				}
+			}
+			pdf.AddTableRow(doc, []string{
+				fmt.Sprintf("%d", i+1),
+				row.CreatedDate.Format("02/01/2006"),
+				row.OrderCode,
+				strings.Join(alerts, "; "),
+				row.Override.PharmacistName,
+				row.Override.LicenseNo,
+				row.Override.Justification,
+			}, widths, aligns)
+		}
+
+		ctx.Header("Content-Type", "application/pdf")
+		ctx.Header("Content-Disposition", "inline; filename=clinical-interventions.pdf")
+		doc.Output(ctx.Writer)
+	}
+}
@@ -4,10 +4,10 @@ import (
 	"fmt"
 	"net/http"
 	"pos/app/core/errcode"
+	"pos/app/core/pdf"
 	"pos/app/data/repositories"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-pdf/fpdf"
 )
 
 func GetDrugLabelPDF(dispensingEntity repositories.IDispensingLog, settingEntity repositories.ISetting) gin.HandlerFunc {
@@ -36,7 +36,7 @@ func GetDrugLabelPDF(dispensingEntity repositories.IDispensingLog, settingEntity
 		gapX := 5.0
 		gapY := 2.0
 
-		doc := fpdf.New("P", "mm", "A4", "")
+		doc := pdf.NewPDF()
 		doc.SetAutoPageBreak(false, 0)
 		doc.AddPage()
 
@@ -50,40 +50,46 @@ func GetDrugLabelPDF(dispensingEntity repositories.IDispensingLog, settingEntity
 					x := marginX + float64(c)*(labelW+gapX)
 					y := marginY + float64(r)*(labelH+gapY)
 
-					doc.SetFont("Arial", "B", 7)
+					doc.SetFont(pdf.FontFamily, "B", 7)
 					doc.SetXY(x+1, y+1)
 					doc.CellFormat(labelW-2, 4, companyName, "", 1, "C", false, 0, "")
 
-					doc.SetFont("Arial", "", 6)
+					doc.SetFont(pdf.FontFamily, "", 6)
 					doc.SetX(x + 1)
 					doc.CellFormat(labelW-2, 3, fmt.Sprintf("Pharmacist: %s (Lic: %s)", dispLog.PharmacistName, dispLog.LicenseNo), "", 1, "L", false, 0, "")
 
-					doc.SetFont("Arial", "B", 7)
+					doc.SetFont(pdf.FontFamily, "B", 7)
 					doc.SetX(x + 1)
-					doc.CellFormat(labelW-2, 4, item.ProductName, "", 1, "L", false, 0, "")
+					doc.CellFormat(labelW-2, 4, pdf.Truncate(doc, item.ProductName, labelW-4), "", 1, "L", false, 0, "")
 
-					doc.SetFont("Arial", "", 6)
+					doc.SetFont(pdf.FontFamily, "", 6)
 					if item.GenericName != "" {
 						doc.SetX(x + 1)
-						doc.CellFormat(labelW-2, 3, fmt.Sprintf("(%s)", item.GenericName), "", 1, "L", false, 0, "")
+						doc.CellFormat(labelW-2, 3, pdf.Truncate(doc, fmt.Sprintf("(%s)", item.GenericName), labelW-4), "", 1, "L", false, 0, "")
 					}
 
 					doc.SetX(x + 1)
 					doc.CellFormat(labelW-2, 3, fmt.Sprintf("Qty: %d %s", item.Quantity, item.Unit), "", 1, "L", false, 0, "")
 
 					if item.Dosage != "" {
-						doc.SetFont("Arial", "B", 6)
-						doc.SetX(x + 1)
-						doc.CellFormat(labelW-2, 3, item.Dosage, "", 1, "L", false, 0, "")
+						// Directions are often Thai and long, wrap them onto two lines at most
+						doc.SetFont(pdf.FontFamily, "B", 6)
+						for i, line := range pdf.SplitText(doc, item.Dosage, labelW-4) {
+							if i == 2 {
+								break
+							}
+							doc.SetX(x + 1)
+							doc.CellFormat(labelW-2, 3, line, "", 1, "L", false, 0, "")
+						}
 					}
 
 					if item.LotNumber != "" {
-						doc.SetFont("Arial", "", 5)
+						doc.SetFont(pdf.FontFamily, "", 5)
 						doc.SetX(x + 1)
 						doc.CellFormat(labelW-2, 3, fmt.Sprintf("Lot: %s", item.LotNumber), "", 1, "L", false, 0, "")
 					}
 
-					doc.SetFont("Arial", "", 5)
+					doc.SetFont(pdf.FontFamily, "", 5)
 					doc.SetX(x + 1)
 					doc.CellFormat(labelW-2, 3, fmt.Sprintf("Date: %s", dispLog.CreatedDate.Format("02/01/2006")), "", 1, "L", false, 0, "")
 
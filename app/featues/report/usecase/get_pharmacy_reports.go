@@ -14,8 +14,15 @@ import (
 )
 
 type pharmacyReportRange struct {
-	StartDate time.Time `form:"startDate" binding:"required"`
-	EndDate   time.Time `form:"endDate" binding:"required"`
+	StartDate  time.Time `form:"startDate" binding:"required"`
+	EndDate    time.Time `form:"endDate" binding:"required"`
+	Ingredient string    `form:"ingredient"`
+}
+
+// includes reports whether the drug belongs on the register, which may be kept for a single
+// active ingredient across every product containing it
+func (req pharmacyReportRange) includes(product *entities.Product) bool {
+	return req.Ingredient == "" || product.HasIngredient(req.Ingredient)
 }
 
 func GetKHY9PDF(receiveEntity repositories.IReceive, productEntity repositories.IProduct, settingEntity repositories.ISetting) gin.HandlerFunc {
@@ -46,9 +53,12 @@ func GetKHY9PDF(receiveEntity repositories.IReceive, productEntity repositories.
 
 		doc := pdf.NewPDF()
 		doc.AddPage()
-		pdf.AddHeader(doc, companyName, "", "", "KHY.9 - Drug Purchase Record")
-		doc.SetFont("Arial", "", 9)
+		pdf.AddHeader(doc, companyName, "", "", "ข.ย.9 บัญชีการซื้อยา (KHY.9 Drug Purchase Record)")
+		doc.SetFont(pdf.FontFamily, "", 9)
 		doc.CellFormat(0, 5, fmt.Sprintf("Period: %s - %s", req.StartDate.Format("02/01/2006"), req.EndDate.Format("02/01/2006")), "", 1, "C", false, 0, "")
+		if req.Ingredient != "" {
+			doc.CellFormat(0, 5, "Ingredient: "+req.Ingredient, "", 1, "C", false, 0, "")
+		}
 		doc.Ln(3)
 
 		headers := []string{"#", "Date", "Code", "Product", "Lot", "Qty", "Cost"}
@@ -76,7 +86,7 @@ func GetKHY9PDF(receiveEntity repositories.IReceive, productEntity repositories.
 		for _, recv := range receives {
 			for _, item := range recv.Items {
 				product, ok := productMap[item.ProductId.Hex()]
-				if !ok || product.DrugInfo == nil {
+				if !ok || product.DrugInfo == nil || !req.includes(product) {
 					continue
 				}
 				pdf.AddTableRow(doc, []string{
@@ -106,7 +116,7 @@ func GetKHY10PDF(dispensingEntity repositories.IDispensingLog, productEntity rep
 			return
 		}
 		branchId := ctx.GetString("BranchId")
-		generateDispensingReport(ctx, dispensingEntity, productEntity, settingEntity, branchId, req, "KHY.10 - Specially Controlled Drug Sales Record", "CONTROLLED")
+		generateDispensingReport(ctx, dispensingEntity, productEntity, settingEntity, branchId, req, "ข.ย.10 บัญชีการขายยาควบคุมพิเศษ (KHY.10)", "CONTROLLED")
 	}
 }
 
@@ -118,7 +128,7 @@ func GetKHY11PDF(dispensingEntity repositories.IDispensingLog, productEntity rep
 			return
 		}
 		branchId := ctx.GetString("BranchId")
-		generateDispensingReport(ctx, dispensingEntity, productEntity, settingEntity, branchId, req, "KHY.11 - Dangerous Drug Sales Record", "DANGEROUS")
+		generateDispensingReport(ctx, dispensingEntity, productEntity, settingEntity, branchId, req, "ข.ย.11 บัญชีการขายยาอันตราย (KHY.11)", "DANGEROUS")
 	}
 }
 
@@ -138,8 +148,11 @@ func generateDispensingReport(ctx *gin.Context, dispensingEntity repositories.ID
 	doc := pdf.NewPDF()
 	doc.AddPage()
 	pdf.AddHeader(doc, companyName, "", "", title)
-	doc.SetFont("Arial", "", 9)
+	doc.SetFont(pdf.FontFamily, "", 9)
 	doc.CellFormat(0, 5, fmt.Sprintf("Period: %s - %s", req.StartDate.Format("02/01/2006"), req.EndDate.Format("02/01/2006")), "", 1, "C", false, 0, "")
+	if req.Ingredient != "" {
+		doc.CellFormat(0, 5, "Ingredient: "+req.Ingredient, "", 1, "C", false, 0, "")
+	}
 	doc.Ln(3)
 
 	headers := []string{"#", "Date", "Drug Name", "Generic Name", "Qty", "Pharmacist", "License"}
@@ -167,7 +180,7 @@ func generateDispensingReport(ctx *gin.Context, dispensingEntity repositories.ID
 	for _, log := range logs {
 		for _, item := range log.Items {
 			product, ok := logProductMap[item.ProductId.Hex()]
-			if !ok || product.DrugInfo == nil || product.DrugInfo.DrugType != drugType {
+			if !ok || product.DrugInfo == nil || product.DrugInfo.DrugType != drugType || !req.includes(product) || item.NetQuantity() <= 0 {
 				continue
 			}
 			pdf.AddTableRow(doc, []string{
@@ -175,7 +188,7 @@ func generateDispensingReport(ctx *gin.Context, dispensingEntity repositories.ID
 				log.CreatedDate.Format("02/01/2006"),
 				item.ProductName,
 				item.GenericName,
-				fmt.Sprintf("%d", item.Quantity),
+				fmt.Sprintf("%d", item.NetQuantity()),
 				log.PharmacistName,
 				log.LicenseNo,
 			}, widths, aligns)
@@ -196,7 +209,7 @@ func GetKHY12PDF(dispensingEntity repositories.IDispensingLog, productEntity rep
 			return
 		}
 		branchId := ctx.GetString("BranchId")
-		generateDispensingReport(ctx, dispensingEntity, productEntity, settingEntity, branchId, req, "KHY.12 - Prescription Drug Sales Record", "PSYCHO")
+		generateDispensingReport(ctx, dispensingEntity, productEntity, settingEntity, branchId, req, "ข.ย.12 บัญชีการขายยาตามใบสั่งของผู้ประกอบวิชาชีพฯ (KHY.12)", "PSYCHO")
 	}
 }
 
@@ -208,6 +221,6 @@ func GetKHY13PDF(dispensingEntity repositories.IDispensingLog, productEntity rep
 			return
 		}
 		branchId := ctx.GetString("BranchId")
-		generateDispensingReport(ctx, dispensingEntity, productEntity, settingEntity, branchId, req, "KHY.13 - FDA-Mandated Drug Sales Report", "NARCOTIC")
+		generateDispensingReport(ctx, dispensingEntity, productEntity, settingEntity, branchId, req, "ข.ย.13 รายงานการขายยาตามที่เลขาธิการ อย. กำหนด (KHY.13)", "NARCOTIC")
 	}
 }
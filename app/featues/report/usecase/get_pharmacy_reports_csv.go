@@ -60,7 +60,7 @@ func GetKHY9CSV(receiveEntity repositories.IReceive, productEntity repositories.
 		for _, recv := range receives {
 			for _, item := range recv.Items {
 				product, ok := productMap[item.ProductId.Hex()]
-				if !ok || product.DrugInfo == nil {
+				if !ok || product.DrugInfo == nil || !req.includes(product) {
 					continue
 				}
 				w.Write([]string{
@@ -113,7 +113,7 @@ func generateDispensingCSV(ctx *gin.Context, dispensingEntity repositories.IDisp
 	for _, log := range logs {
 		for _, item := range log.Items {
 			product, ok := logProductMap[item.ProductId.Hex()]
-			if !ok || product.DrugInfo == nil || product.DrugInfo.DrugType != drugType {
+			if !ok || product.DrugInfo == nil || product.DrugInfo.DrugType != drugType || !req.includes(product) || item.NetQuantity() <= 0 {
 				continue
 			}
 			w.Write([]string{
@@ -121,7 +121,7 @@ func generateDispensingCSV(ctx *gin.Context, dispensingEntity repositories.IDisp
 				log.CreatedDate.Format("02/01/2006"),
 				item.ProductName,
 				item.GenericName,
-				fmt.Sprintf("%d", item.Quantity),
+				fmt.Sprintf("%d", item.NetQuantity()),
 				log.PharmacistName,
 				log.LicenseNo,
 			})
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"fmt"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/escpos"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetReceiptEscPos returns the receipt as a raw ESC/POS stream for the counter printer,
+// 80 mm unless the branch prints on 58 mm paper
+func GetReceiptEscPos(orderEntity repositories.IOrder, settingEntity repositories.ISetting) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		orderId := ctx.Param("orderId")
+		branchId := ctx.GetString("BranchId")
+
+		order, err := orderEntity.GetOrderDetailById(orderId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RP_BAD_REQUEST_002, err.Error())
+			return
+		}
+
+		setting, _ := settingEntity.GetSettingByBranchId(branchId)
+		model := buildReceipt(order, setting)
+
+		columns, dots := 48, 576
+		if setting.GetReceiptPaper() == constant.ReceiptPaper58mm {
+			columns, dots = 32, 384
+		}
+		printer := escpos.New(columns, dots, setting.GetReceiptCodePage())
+		renderReceiptEscPos(printer, model)
+
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=receipt-%s.bin", order.Code))
+		ctx.Data(http.StatusOK, "application/octet-stream", printer.Bytes())
+	}
+}
+
+func renderReceiptEscPos(printer *escpos.Printer, model receipt) {
+	printer.Align(escpos.AlignCenter)
+	if model.Logo != nil {
+		printer.Image(model.Logo)
+	}
+	printer.Bold(true)
+	printer.DoubleSize(true)
+	printer.Line(model.CompanyName)
+	printer.DoubleSize(false)
+	printer.Bold(false)
+	if model.CompanyAddress != "" {
+		printer.Line(model.CompanyAddress)
+	}
+	if model.CompanyPhone != "" {
+		printer.Line("Tel: " + model.CompanyPhone)
+	}
+	if model.CompanyTaxId != "" {
+		printer.Line("Tax ID: " + model.CompanyTaxId)
+	}
+	printer.Bold(true)
+	printer.Line("ใบเสร็จรับเงิน / Receipt")
+	if model.Voided {
+		printer.Line("*** VOIDED ***")
+	}
+	printer.Bold(false)
+
+	printer.Align(escpos.AlignLeft)
+	printer.Separator()
+	printer.Row(model.Code, model.Date.Format("02/01/2006 15:04"))
+	if model.CustomerCode != "" {
+		printer.Line(fmt.Sprintf("Customer: %s (%s)", model.CustomerName, model.CustomerCode))
+	}
+	printer.Separator()
+
+	for _, line := range model.Lines {
+		printer.Line(line.Name)
+		printer.Row(fmt.Sprintf("  %d x %.2f", line.Quantity, line.UnitPrice), fmt.Sprintf("%.2f", line.Price))
+		if line.Discount > 0 {
+			printer.Row("  Discount", fmt.Sprintf("-%.2f", line.Discount))
+		}
+	}
+	printer.Separator()
+
+	if model.Discount > 0 {
+		printer.Row("Subtotal", fmt.Sprintf("%.2f", model.Subtotal))
+		printer.Row("Discount", fmt.Sprintf("-%.2f", model.Discount))
+	}
+	printer.Bold(true)
+	printer.Row("Total", fmt.Sprintf("%.2f", model.Total))
+	printer.Bold(false)
+	printer.Row("Paid "+model.PaymentType, fmt.Sprintf("%.2f", model.Paid))
+	printer.Row("Change", fmt.Sprintf("%.2f", model.Change))
+
+	printer.Align(escpos.AlignCenter)
+	if len(model.PromptPayQr) > 0 {
+		printer.Separator()
+		printer.QR(model.PromptPayQr, printer.Dots/2/len(model.PromptPayQr))
+		printer.Line("PromptPay")
+	}
+	if model.Footer != "" || model.ShowCredit {
+		printer.Separator()
+	}
+	if model.Footer != "" {
+		printer.Line(model.Footer)
+	}
+	if model.ShowCredit {
+		printer.Line("Powered by POS System")
+	}
+	printer.Feed(3)
+	printer.Cut()
+}
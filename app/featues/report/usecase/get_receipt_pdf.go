@@ -1,15 +1,20 @@
 package usecase
 
 import (
+	"bytes"
 	"fmt"
+	"image/png"
 	"net/http"
 	"pos/app/core/errcode"
 	"pos/app/core/pdf"
 	"pos/app/data/repositories"
+	"pos/app/domain/constant"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-pdf/fpdf"
 )
 
+// GetReceiptPDF renders the receipt on the paper profile of the branch, A4 or 58/80 mm roll
 func GetReceiptPDF(orderEntity repositories.IOrder, settingEntity repositories.ISetting) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		orderId := ctx.Param("orderId")
@@ -22,72 +27,234 @@ func GetReceiptPDF(orderEntity repositories.IOrder, settingEntity repositories.I
 		}
 
 		setting, _ := settingEntity.GetSettingByBranchId(branchId)
-		companyName := "POS System"
-		companyAddress := ""
-		companyPhone := ""
-		footerText := ""
-		showCredit := true
-		if setting != nil {
-			if setting.CompanyName != "" {
-				companyName = setting.CompanyName
-			}
-			companyAddress = setting.CompanyAddress
-			companyPhone = setting.CompanyPhone
-			footerText = setting.ReceiptFooter
-			showCredit = setting.ShowCredit
+		model := buildReceipt(order, setting)
+
+		var doc *fpdf.Fpdf
+		switch setting.GetReceiptPaper() {
+		case constant.ReceiptPaper80mm:
+			doc = renderReceiptRoll(model, 80)
+		case constant.ReceiptPaper58mm:
+			doc = renderReceiptRoll(model, 58)
+		default:
+			doc = renderReceiptA4(model)
+		}
+
+		ctx.Header("Content-Type", "application/pdf")
+		ctx.Header("Content-Disposition", fmt.Sprintf("inline; filename=receipt-%s.pdf", order.Code))
+		err = doc.Output(ctx.Writer)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.RP_INTERNAL_001, err.Error())
+			return
 		}
+	}
+}
 
-		doc := pdf.NewPDF()
-		doc.AddPage()
+func renderReceiptA4(model receipt) *fpdf.Fpdf {
+	doc := pdf.NewPDF()
+	doc.AddPage()
 
-		pdf.AddHeader(doc, companyName, companyAddress, companyPhone, "Receipt / Invoice")
+	if addReceiptLogo(doc, model, 85, 10, 40) {
+		doc.SetY(doc.GetY() + 2)
+	}
+	pdf.AddHeader(doc, model.CompanyName, model.CompanyAddress, model.CompanyPhone, "Receipt / Invoice")
 
-		// Order info
-		doc.SetFont("Arial", "", 9)
-		doc.CellFormat(95, 5, fmt.Sprintf("Order: %s", order.Code), "", 0, "L", false, 0, "")
-		doc.CellFormat(95, 5, fmt.Sprintf("Date: %s", order.CreatedDate.Format("02/01/2006 15:04")), "", 1, "R", false, 0, "")
-		if order.CustomerCode != "" {
-			doc.CellFormat(0, 5, fmt.Sprintf("Customer: %s (%s)", order.CustomerName, order.CustomerCode), "", 1, "L", false, 0, "")
-		}
+	// Order info
+	doc.SetFont(pdf.FontFamily, "", 9)
+	if model.Voided {
+		doc.SetFont(pdf.FontFamily, "B", 12)
+		doc.CellFormat(0, 6, "*** VOIDED ***", "", 1, "C", false, 0, "")
+		doc.SetFont(pdf.FontFamily, "", 9)
+	}
+	doc.CellFormat(95, 5, fmt.Sprintf("Order: %s", model.Code), "", 0, "L", false, 0, "")
+	doc.CellFormat(95, 5, fmt.Sprintf("Date: %s", model.Date.Format("02/01/2006 15:04")), "", 1, "R", false, 0, "")
+	if model.CustomerCode != "" {
+		doc.CellFormat(0, 5, fmt.Sprintf("Customer: %s (%s)", model.CustomerName, model.CustomerCode), "", 1, "L", false, 0, "")
+	}
+	doc.Ln(3)
+
+	// Items table
+	headers := []string{"#", "Item", "Qty", "Price", "Discount", "Total"}
+	widths := []float64{10, 70, 20, 30, 30, 30}
+	aligns := []string{"C", "L", "C", "R", "R", "R"}
+	pdf.AddTableHeader(doc, headers, widths)
+
+	for i, line := range model.Lines {
+		pdf.AddTableRow(doc, []string{
+			fmt.Sprintf("%d", i+1),
+			line.Name,
+			fmt.Sprintf("%d", line.Quantity),
+			fmt.Sprintf("%.2f", line.Price),
+			fmt.Sprintf("%.2f", line.Discount),
+			fmt.Sprintf("%.2f", line.Total),
+		}, widths, aligns)
+	}
+
+	doc.Ln(3)
+	totalWidth := float64(190)
+
+	if model.Discount > 0 {
+		pdf.AddSummaryLine(doc, "Subtotal:", fmt.Sprintf("%.2f", model.Subtotal), totalWidth)
+		pdf.AddSummaryLine(doc, "Discount:", fmt.Sprintf("-%.2f", model.Discount), totalWidth)
+	}
+	pdf.AddSummaryLine(doc, "Total:", fmt.Sprintf("%.2f", model.Total), totalWidth)
+	pdf.AddSummaryLine(doc, "Paid:", fmt.Sprintf("%.2f", model.Paid), totalWidth)
+	pdf.AddSummaryLine(doc, "Change:", fmt.Sprintf("%.2f", model.Change), totalWidth)
+
+	if len(model.PromptPayQr) > 0 {
 		doc.Ln(3)
+		drawQR(doc, model.PromptPayQr, 85, doc.GetY(), 40)
+		doc.SetY(doc.GetY() + 41)
+		doc.CellFormat(0, 5, "PromptPay", "", 1, "C", false, 0, "")
+	}
 
-		// Items table
-		headers := []string{"#", "Item", "Qty", "Price", "Discount", "Total"}
-		widths := []float64{10, 70, 20, 30, 30, 30}
-		aligns := []string{"C", "L", "C", "R", "R", "R"}
-		pdf.AddTableHeader(doc, headers, widths)
-
-		for i, item := range order.Items {
-			itemTotal := item.Price - item.Discount
-			pdf.AddTableRow(doc, []string{
-				fmt.Sprintf("%d", i+1),
-				item.Product.Name,
-				fmt.Sprintf("%d", item.Quantity),
-				fmt.Sprintf("%.2f", item.Price),
-				fmt.Sprintf("%.2f", item.Discount),
-				fmt.Sprintf("%.2f", itemTotal),
-			}, widths, aligns)
+	pdf.AddFooter(doc, model.Footer, model.ShowCredit)
+	return doc
+}
+
+// renderReceiptRoll lays the receipt out on roll paper. The page is as long as the receipt,
+// so it is laid out once to measure it and again on a page of that length.
+func renderReceiptRoll(model receipt, width float64) *fpdf.Fpdf {
+	measure := pdf.NewRollPDF(width, 2000)
+	length := layoutReceiptRoll(measure, model, width)
+	doc := pdf.NewRollPDF(width, length+5)
+	layoutReceiptRoll(doc, model, width)
+	return doc
+}
+
+func layoutReceiptRoll(doc *fpdf.Fpdf, model receipt, width float64) float64 {
+	doc.AddPage()
+	left, _, right, _ := doc.GetMargins()
+	lineWidth := width - left - right
+	small := 7.0
+	normal := 8.0
+	if width >= 80 {
+		small, normal = 8, 9
+	}
+
+	centered := func(text string, style string, size float64) {
+		doc.SetFont(pdf.FontFamily, style, size)
+		for _, line := range pdf.SplitText(doc, text, lineWidth) {
+			doc.CellFormat(lineWidth, size*0.45, line, "", 1, "C", false, 0, "")
+		}
+	}
+	row := func(label string, value string, style string) {
+		doc.SetFont(pdf.FontFamily, style, normal)
+		valueWidth := doc.GetStringWidth(value) + 1
+		lines := pdf.SplitText(doc, label, lineWidth-valueWidth)
+		for i, line := range lines {
+			if i < len(lines)-1 {
+				doc.CellFormat(lineWidth, normal*0.45, line, "", 1, "L", false, 0, "")
+				continue
+			}
+			doc.CellFormat(lineWidth-valueWidth, normal*0.45, line, "", 0, "L", false, 0, "")
+			doc.CellFormat(valueWidth, normal*0.45, value, "", 1, "R", false, 0, "")
 		}
+	}
+	separator := func() {
+		y := doc.GetY() + 1
+		doc.SetDashPattern([]float64{0.8, 0.8}, 0)
+		doc.Line(left, y, width-right, y)
+		doc.SetDashPattern([]float64{}, 0)
+		doc.SetY(y + 1)
+	}
 
-		doc.Ln(3)
-		totalWidth := float64(190)
+	logoWidth := lineWidth * 0.5
+	if addReceiptLogo(doc, model, (width-logoWidth)/2, doc.GetY(), logoWidth) {
+		doc.SetY(doc.GetY() + 1)
+	}
+	centered(model.CompanyName, "B", normal+2)
+	if model.CompanyAddress != "" {
+		centered(model.CompanyAddress, "", small)
+	}
+	if model.CompanyPhone != "" {
+		centered("Tel: "+model.CompanyPhone, "", small)
+	}
+	if model.CompanyTaxId != "" {
+		centered("Tax ID: "+model.CompanyTaxId, "", small)
+	}
+	doc.Ln(1)
+	centered("ใบเสร็จรับเงิน / Receipt", "B", normal)
+	if model.Voided {
+		centered("*** VOIDED ***", "B", normal+2)
+	}
+	separator()
+	row(model.Code, model.Date.Format("02/01/2006 15:04"), "")
+	if model.CustomerCode != "" {
+		row(fmt.Sprintf("Customer: %s (%s)", model.CustomerName, model.CustomerCode), "", "")
+	}
+	separator()
 
-		if order.Discount > 0 {
-			pdf.AddSummaryLine(doc, "Subtotal:", fmt.Sprintf("%.2f", order.Total+order.Discount), totalWidth)
-			pdf.AddSummaryLine(doc, "Discount:", fmt.Sprintf("-%.2f", order.Discount), totalWidth)
+	for _, line := range model.Lines {
+		doc.SetFont(pdf.FontFamily, "", normal)
+		for _, text := range pdf.SplitText(doc, line.Name, lineWidth) {
+			doc.CellFormat(lineWidth, normal*0.45, text, "", 1, "L", false, 0, "")
 		}
-		pdf.AddSummaryLine(doc, "Total:", fmt.Sprintf("%.2f", order.Total), totalWidth)
-		pdf.AddSummaryLine(doc, "Paid:", fmt.Sprintf("%.2f", order.Payment.Amount), totalWidth)
-		pdf.AddSummaryLine(doc, "Change:", fmt.Sprintf("%.2f", order.Payment.Change), totalWidth)
+		row(fmt.Sprintf("  %d x %.2f", line.Quantity, line.UnitPrice), fmt.Sprintf("%.2f", line.Price), "")
+		if line.Discount > 0 {
+			row("  Discount", fmt.Sprintf("-%.2f", line.Discount), "")
+		}
+	}
+	separator()
 
-		pdf.AddFooter(doc, footerText, showCredit)
+	if model.Discount > 0 {
+		row("Subtotal", fmt.Sprintf("%.2f", model.Subtotal), "")
+		row("Discount", fmt.Sprintf("-%.2f", model.Discount), "")
+	}
+	row("Total", fmt.Sprintf("%.2f", model.Total), "B")
+	row("Paid "+model.PaymentType, fmt.Sprintf("%.2f", model.Paid), "")
+	row("Change", fmt.Sprintf("%.2f", model.Change), "")
 
-		ctx.Header("Content-Type", "application/pdf")
-		ctx.Header("Content-Disposition", fmt.Sprintf("inline; filename=receipt-%s.pdf", order.Code))
-		err = doc.Output(ctx.Writer)
-		if err != nil {
-			errcode.Abort(ctx, http.StatusInternalServerError, errcode.RP_INTERNAL_001, err.Error())
-			return
+	if len(model.PromptPayQr) > 0 {
+		separator()
+		size := lineWidth * 0.6
+		drawQR(doc, model.PromptPayQr, (width-size)/2, doc.GetY()+1, size)
+		doc.SetY(doc.GetY() + size + 2)
+		centered("PromptPay", "", small)
+	}
+
+	if model.Footer != "" || model.ShowCredit {
+		separator()
+	}
+	if model.Footer != "" {
+		centered(model.Footer, "", small)
+	}
+	if model.ShowCredit {
+		centered("Powered by POS System", "", small)
+	}
+	return doc.GetY()
+}
+
+// addReceiptLogo draws the branch logo at the given width and moves below it
+func addReceiptLogo(doc *fpdf.Fpdf, model receipt, x float64, y float64, width float64) bool {
+	if model.Logo == nil {
+		return false
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, model.Logo); err != nil {
+		return false
+	}
+	options := fpdf.ImageOptions{ImageType: "PNG"}
+	info := doc.RegisterImageOptionsReader("logo", options, &buf)
+	if info == nil || doc.Err() {
+		doc.ClearError()
+		return false
+	}
+	height := width * info.Height() / info.Width()
+	doc.ImageOptions("logo", x, y, width, height, false, options, 0, "")
+	doc.SetY(y + height)
+	return true
+}
+
+// drawQR draws the QR modules as filled squares so no image is needed
+func drawQR(doc *fpdf.Fpdf, bitmap [][]bool, x float64, y float64, size float64) {
+	module := size / float64(len(bitmap))
+	doc.SetFillColor(0, 0, 0)
+	for row, modules := range bitmap {
+		for col, dark := range modules {
+			if dark {
+				doc.Rect(x+float64(col)*module, y+float64(row)*module, module, module, "F")
+			}
 		}
 	}
+	doc.SetFillColor(255, 255, 255)
 }
@@ -26,6 +26,7 @@ func GetSalesReportExcel(orderEntity repositories.IOrder) gin.HandlerFunc {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.RP_BAD_REQUEST_002, err.Error())
 			return
 		}
+		orders = excludeVoidedOrders(orders)
 
 		summary, _ := orderEntity.GetOrderSummary(req)
 
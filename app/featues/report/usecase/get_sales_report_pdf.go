@@ -5,7 +5,9 @@ import (
 	"net/http"
 	"pos/app/core/errcode"
 	"pos/app/core/pdf"
+	"pos/app/data/entities"
 	"pos/app/data/repositories"
+	"pos/app/domain/constant"
 	"pos/app/domain/request"
 
 	"github.com/gin-gonic/gin"
@@ -26,6 +28,7 @@ func GetSalesReportPDF(orderEntity repositories.IOrder, settingEntity repositori
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.RP_BAD_REQUEST_002, err.Error())
 			return
 		}
+		orders = excludeVoidedOrders(orders)
 
 		summary, _ := orderEntity.GetOrderSummary(req)
 
@@ -50,7 +53,7 @@ func GetSalesReportPDF(orderEntity repositories.IOrder, settingEntity repositori
 			req.StartDate.Format("02/01/2006"),
 			req.EndDate.Format("02/01/2006"))
 		pdf.AddHeader(doc, companyName, companyAddress, companyPhone, "Sales Report")
-		doc.SetFont("Arial", "", 9)
+		doc.SetFont(pdf.FontFamily, "", 9)
 		doc.CellFormat(0, 5, fmt.Sprintf("Period: %s", dateRange), "", 1, "C", false, 0, "")
 		doc.Ln(3)
 
@@ -95,3 +98,14 @@ func GetSalesReportPDF(orderEntity repositories.IOrder, settingEntity repositori
 		}
 	}
 }
+
+// excludeVoidedOrders drops voided orders so the listed rows add up to the summary
+func excludeVoidedOrders(orders []entities.Order) []entities.Order {
+	results := []entities.Order{}
+	for _, order := range orders {
+		if order.Status != constant.OrderStatusVoided {
+			results = append(results, order)
+		}
+	}
+	return results
+}
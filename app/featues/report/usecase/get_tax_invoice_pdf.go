@@ -5,100 +5,191 @@ import (
 	"net/http"
 	"pos/app/core/errcode"
 	"pos/app/core/pdf"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
 	"pos/app/data/repositories"
+	"pos/app/domain/constant"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-pdf/fpdf"
 )
 
-func GetTaxInvoicePDF(orderEntity repositories.IOrder, settingEntity repositories.ISetting) gin.HandlerFunc {
+// GetTaxInvoicePDF prints the tax invoice in force for an order
+func GetTaxInvoicePDF(taxInvoiceEntity repositories.ITaxInvoice, settingEntity repositories.ISetting) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		orderId := ctx.Param("orderId")
-		branchId := ctx.GetString("BranchId")
-
-		order, err := orderEntity.GetOrderDetailById(orderId)
+		invoices, err := taxInvoiceEntity.GetTaxInvoicesByOrderId(ctx.Param("orderId"))
 		if err != nil {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.RP_BAD_REQUEST_002, err.Error())
 			return
 		}
-
-		setting, _ := settingEntity.GetSettingByBranchId(branchId)
-		companyName := "POS System"
-		companyAddress := ""
-		companyPhone := ""
-		companyTaxId := ""
-		showCredit := true
-		if setting != nil {
-			if setting.CompanyName != "" {
-				companyName = setting.CompanyName
+		var invoice *entities.TaxInvoice
+		for i := range invoices {
+			if invoices[i].Status == constant.TaxInvoiceStatusIssued && invoices[i].BranchId.Hex() == utils.GetBranchId(ctx) {
+				invoice = &invoices[i]
 			}
-			companyAddress = setting.CompanyAddress
-			companyPhone = setting.CompanyPhone
-			companyTaxId = setting.CompanyTaxId
-			showCredit = setting.ShowCredit
 		}
+		if invoice == nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RP_BAD_REQUEST_002, "no tax invoice has been issued for this order")
+			return
+		}
+		printTaxInvoice(ctx, taxInvoiceEntity, settingEntity, invoice.Id.Hex())
+	}
+}
 
-		doc := pdf.NewPDF()
-		doc.AddPage()
+// GetTaxInvoicePDFById prints any tax invoice, replaced and cancelled ones carry a banner
+func GetTaxInvoicePDFById(taxInvoiceEntity repositories.ITaxInvoice, settingEntity repositories.ISetting) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		invoice, err := taxInvoiceEntity.GetTaxInvoiceById(ctx.Param("id"))
+		if err != nil || invoice.BranchId.Hex() != utils.GetBranchId(ctx) {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RP_BAD_REQUEST_002, "tax invoice not found")
+			return
+		}
+		printTaxInvoice(ctx, taxInvoiceEntity, settingEntity, invoice.Id.Hex())
+	}
+}
 
-		pdf.AddHeader(doc, companyName, companyAddress, companyPhone, "Tax Invoice / Receipt")
+func printTaxInvoice(ctx *gin.Context, taxInvoiceEntity repositories.ITaxInvoice, settingEntity repositories.ISetting, id string) {
+	// Only the first print is the original, every print after it is a copy
+	invoice, err := taxInvoiceEntity.AddTaxInvoicePrintById(id)
+	if err != nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.RP_BAD_REQUEST_002, err.Error())
+		return
+	}
+	setting, _ := settingEntity.GetSettingByBranchId(invoice.BranchId.Hex())
+	companyPhone := ""
+	showCredit := true
+	if setting != nil {
+		companyPhone = setting.CompanyPhone
+		showCredit = setting.ShowCredit
+	}
 
-		doc.SetFont("Arial", "", 9)
-		if companyTaxId != "" {
-			doc.CellFormat(0, 5, fmt.Sprintf("Tax ID: %s", companyTaxId), "", 1, "C", false, 0, "")
-		}
-		doc.Ln(2)
+	doc := pdf.NewPDF()
+	doc.AddPage()
+
+	title := "ใบกำกับภาษีอย่างย่อ / Abbreviated Tax Invoice"
+	if invoice.IsFull() {
+		title = "ใบกำกับภาษี / Tax Invoice"
+	}
+	pdf.AddHeader(doc, invoice.Seller.Name, invoice.Seller.Address, companyPhone, title)
+
+	doc.SetFont(pdf.FontFamily, "", 9)
+	doc.CellFormat(0, 5, fmt.Sprintf("เลขประจำตัวผู้เสียภาษี / Tax ID: %s  %s", invoice.Seller.TaxId, taxBranchLabel(invoice.Seller.BranchNo)), "", 1, "C", false, 0, "")
+	doc.Ln(2)
+
+	addTaxInvoiceMarks(doc, invoice)
 
-		doc.CellFormat(95, 5, fmt.Sprintf("Invoice No: %s", order.Code), "", 0, "L", false, 0, "")
-		doc.CellFormat(95, 5, fmt.Sprintf("Date: %s", order.CreatedDate.Format("02/01/2006")), "", 1, "R", false, 0, "")
-		if order.CustomerName != "" {
-			doc.CellFormat(0, 5, fmt.Sprintf("Customer: %s", order.CustomerName), "", 1, "L", false, 0, "")
+	doc.SetFont(pdf.FontFamily, "", 9)
+	doc.CellFormat(95, 5, fmt.Sprintf("เลขที่ / No: %s", invoice.Code), "", 0, "L", false, 0, "")
+	doc.CellFormat(95, 5, fmt.Sprintf("วันที่ / Date: %s", invoice.IssuedDate.In(utils.GetLocation()).Format("02/01/2006")), "", 1, "R", false, 0, "")
+	doc.CellFormat(0, 5, fmt.Sprintf("อ้างอิงบิลขาย / Order: %s", invoice.OrderCode), "", 1, "L", false, 0, "")
+	if invoice.ReplacesCode != "" {
+		doc.CellFormat(0, 5, fmt.Sprintf("ออกแทนใบกำกับภาษีอย่างย่อเลขที่ / Replaces: %s", invoice.ReplacesCode), "", 1, "L", false, 0, "")
+	}
+	if invoice.Buyer != nil {
+		doc.Ln(2)
+		doc.CellFormat(0, 5, fmt.Sprintf("ผู้ซื้อ / Buyer: %s", invoice.Buyer.Name), "", 1, "L", false, 0, "")
+		for _, line := range pdf.SplitText(doc, "ที่อยู่ / Address: "+invoice.Buyer.Address, 190) {
+			doc.CellFormat(0, 5, line, "", 1, "L", false, 0, "")
 		}
-		doc.Ln(3)
-
-		headers := []string{"#", "Description", "Qty", "Unit Price", "Amount"}
-		widths := []float64{10, 80, 20, 40, 40}
-		aligns := []string{"C", "L", "C", "R", "R"}
-		pdf.AddTableHeader(doc, headers, widths)
-
-		subtotal := 0.0
-		for i, item := range order.Items {
-			amount := item.Price - item.Discount
-			subtotal += amount
-			pdf.AddTableRow(doc, []string{
-				fmt.Sprintf("%d", i+1),
-				item.Product.Name,
-				fmt.Sprintf("%d", item.Quantity),
-				fmt.Sprintf("%.2f", item.Price/float64(item.Quantity)),
-				fmt.Sprintf("%.2f", amount),
-			}, widths, aligns)
+		if invoice.Buyer.TaxId != "" {
+			doc.CellFormat(0, 5, fmt.Sprintf("เลขประจำตัวผู้เสียภาษี / Tax ID: %s  %s", invoice.Buyer.TaxId, taxBranchLabel(invoice.Buyer.BranchNo)), "", 1, "L", false, 0, "")
 		}
+	}
+	doc.Ln(3)
+
+	headers := []string{"#", "Description", "Qty", "Unit Price", "Amount"}
+	widths := []float64{10, 80, 20, 40, 40}
+	aligns := []string{"C", "L", "C", "R", "R"}
+	pdf.AddTableHeader(doc, headers, widths)
+
+	hasExempt := false
+	for i, item := range invoice.Items {
+		name := item.Name
+		if item.VatExempt {
+			name += " *"
+			hasExempt = true
+		}
+		pdf.AddTableRow(doc, []string{
+			fmt.Sprintf("%d", i+1),
+			name,
+			fmt.Sprintf("%d", item.Quantity),
+			fmt.Sprintf("%.2f", item.UnitPrice),
+			fmt.Sprintf("%.2f", item.Amount),
+		}, widths, aligns)
+	}
 
-		doc.Ln(3)
-		totalWidth := float64(190)
+	doc.Ln(3)
+	totalWidth := float64(190)
 
-		pdf.AddSummaryLine(doc, "Subtotal:", fmt.Sprintf("%.2f", subtotal), totalWidth)
-		if order.Discount > 0 {
-			pdf.AddSummaryLine(doc, "Discount:", fmt.Sprintf("-%.2f", order.Discount), totalWidth)
-		}
+	pdf.AddSummaryLine(doc, "Subtotal:", fmt.Sprintf("%.2f", invoice.Subtotal), totalWidth)
+	if invoice.Discount > 0 {
+		pdf.AddSummaryLine(doc, "Discount:", fmt.Sprintf("-%.2f", invoice.Discount), totalWidth)
+	}
+	if invoice.ExemptAmount > 0 {
+		pdf.AddSummaryLine(doc, "มูลค่ายกเว้นภาษี / VAT Exempt:", fmt.Sprintf("%.2f", invoice.ExemptAmount), totalWidth)
+	}
+	for _, rate := range invoice.VatRates {
+		pdf.AddSummaryLine(doc, fmt.Sprintf("มูลค่าสินค้า / Before VAT %g%%:", rate.Rate), fmt.Sprintf("%.2f", rate.Amount), totalWidth)
+		pdf.AddSummaryLine(doc, fmt.Sprintf("ภาษีมูลค่าเพิ่ม / VAT %g%%:", rate.Rate), fmt.Sprintf("%.2f", rate.Vat), totalWidth)
+	}
+	pdf.AddSummaryLine(doc, "Grand Total:", fmt.Sprintf("%.2f", invoice.Total), totalWidth)
+	if !invoice.IsFull() {
+		doc.SetFont(pdf.FontFamily, "", 8)
+		doc.CellFormat(0, 5, "ราคารวมภาษีมูลค่าเพิ่มแล้ว / VAT included", "", 1, "R", false, 0, "")
+	}
+	if hasExempt {
+		doc.SetFont(pdf.FontFamily, "", 8)
+		doc.CellFormat(0, 5, "* สินค้าได้รับยกเว้นภาษีมูลค่าเพิ่ม / VAT exempt item", "", 1, "L", false, 0, "")
+	}
 
-		vatBase := order.Total / 1.07
-		vat := order.Total - vatBase
-		pdf.AddSummaryLine(doc, "Before VAT:", fmt.Sprintf("%.2f", vatBase), totalWidth)
-		pdf.AddSummaryLine(doc, "VAT 7%:", fmt.Sprintf("%.2f", vat), totalWidth)
-		pdf.AddSummaryLine(doc, "Grand Total:", fmt.Sprintf("%.2f", order.Total), totalWidth)
+	creditText := ""
+	if showCredit {
+		creditText = "Powered by POS System"
+	}
+	pdf.AddFooter(doc, creditText, false)
+
+	ctx.Header("Content-Type", "application/pdf")
+	ctx.Header("Content-Disposition", fmt.Sprintf("inline; filename=tax-invoice-%s.pdf", invoice.Code))
+	err = doc.Output(ctx.Writer)
+	if err != nil {
+		errcode.Abort(ctx, http.StatusInternalServerError, errcode.RP_INTERNAL_001, err.Error())
+		return
+	}
+}
 
-		creditText := ""
-		if showCredit {
-			creditText = "Powered by POS System"
-		}
-		pdf.AddFooter(doc, creditText, false)
+// addTaxInvoiceMarks stamps whether this print is the original, a copy or a replacement of a lost original
+func addTaxInvoiceMarks(doc *fpdf.Fpdf, invoice *entities.TaxInvoice) {
+	doc.SetFont(pdf.FontFamily, "B", 10)
+	switch invoice.Status {
+	case constant.TaxInvoiceStatusReplaced:
+		doc.SetTextColor(200, 0, 0)
+		doc.CellFormat(0, 6, "ยกเลิก / Replaced by "+invoice.ReplacedByCode, "", 1, "C", false, 0, "")
+	case constant.TaxInvoiceStatusCancelled:
+		doc.SetTextColor(200, 0, 0)
+		doc.CellFormat(0, 6, fmt.Sprintf("ยกเลิก / Cancelled: %s", invoice.CancelReason), "", 1, "C", false, 0, "")
+	}
+	doc.SetTextColor(0, 0, 0)
+
+	if len(invoice.Reissues) > 0 {
+		reissue := invoice.Reissues[len(invoice.Reissues)-1]
+		doc.CellFormat(0, 6, "ใบแทน / Reissued", "", 1, "R", false, 0, "")
+		doc.SetFont(pdf.FontFamily, "", 8)
+		doc.CellFormat(0, 5, fmt.Sprintf("ออกให้ใหม่แทนฉบับเดิม วันที่ %s เหตุผล: %s", reissue.ReissuedDate.In(utils.GetLocation()).Format("02/01/2006"), reissue.Reason), "", 1, "R", false, 0, "")
+	} else if invoice.PrintCount > 1 {
+		doc.CellFormat(0, 6, "สำเนา / Copy", "", 1, "R", false, 0, "")
+	} else {
+		doc.CellFormat(0, 6, "ต้นฉบับ / Original", "", 1, "R", false, 0, "")
+	}
+	doc.Ln(1)
+}
 
-		ctx.Header("Content-Type", "application/pdf")
-		ctx.Header("Content-Disposition", fmt.Sprintf("inline; filename=tax-invoice-%s.pdf", order.Code))
-		err = doc.Output(ctx.Writer)
-		if err != nil {
-			errcode.Abort(ctx, http.StatusInternalServerError, errcode.RP_INTERNAL_001, err.Error())
-			return
-		}
+// taxBranchLabel prints the Revenue Department branch of a VAT registrant
+func taxBranchLabel(branchNo string) string {
+	if branchNo == "" {
+		return ""
+	}
+	if branchNo == constant.HeadOfficeBranchNo {
+		return "สำนักงานใหญ่ / Head Office"
 	}
+	return "สาขาที่ / Branch " + branchNo
 }
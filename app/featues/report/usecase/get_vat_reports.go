@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"fmt"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/pdf"
+	"pos/app/core/utils"
+	"pos/app/data/repositories"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+func GetOutputVatReportPDF(taxInvoiceEntity repositories.ITaxInvoice, creditNoteEntity repositories.ICreditNote, settingEntity repositories.ISetting) gin.HandlerFunc {
+	return getOutputVatReport(taxInvoiceEntity, creditNoteEntity, settingEntity, writeVatReportPDF)
+}
+
+func GetOutputVatReportExcel(taxInvoiceEntity repositories.ITaxInvoice, creditNoteEntity repositories.ICreditNote, settingEntity repositories.ISetting) gin.HandlerFunc {
+	return getOutputVatReport(taxInvoiceEntity, creditNoteEntity, settingEntity, writeVatReportExcel)
+}
+
+func GetInputVatReportPDF(receiveEntity repositories.IReceive, supplierEntity repositories.ISupplier, settingEntity repositories.ISetting) gin.HandlerFunc {
+	return getInputVatReport(receiveEntity, supplierEntity, settingEntity, writeVatReportPDF)
+}
+
+func GetInputVatReportExcel(receiveEntity repositories.IReceive, supplierEntity repositories.ISupplier, settingEntity repositories.ISetting) gin.HandlerFunc {
+	return getInputVatReport(receiveEntity, supplierEntity, settingEntity, writeVatReportExcel)
+}
+
+func getOutputVatReport(
+	taxInvoiceEntity repositories.ITaxInvoice,
+	creditNoteEntity repositories.ICreditNote,
+	settingEntity repositories.ISetting,
+	write func(ctx *gin.Context, report *vatReport),
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := vatReportMonth{}
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RP_BAD_REQUEST_001, err.Error())
+			return
+		}
+		start, end, err := req.period()
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RP_BAD_REQUEST_001, "month must be YYYY-MM")
+			return
+		}
+		branchId := utils.GetBranchId(ctx)
+		setting, _ := settingEntity.GetSettingByBranchId(branchId)
+
+		report, err := buildOutputVatReport(taxInvoiceEntity, creditNoteEntity, setting, branchId, start, end)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RP_BAD_REQUEST_002, err.Error())
+			return
+		}
+		write(ctx, report)
+	}
+}
+
+func getInputVatReport(
+	receiveEntity repositories.IReceive,
+	supplierEntity repositories.ISupplier,
+	settingEntity repositories.ISetting,
+	write func(ctx *gin.Context, report *vatReport),
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := vatReportMonth{}
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RP_BAD_REQUEST_001, err.Error())
+			return
+		}
+		start, end, err := req.period()
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RP_BAD_REQUEST_001, "month must be YYYY-MM")
+			return
+		}
+		branchId := utils.GetBranchId(ctx)
+		setting, _ := settingEntity.GetSettingByBranchId(branchId)
+
+		report, err := buildInputVatReport(receiveEntity, supplierEntity, setting, branchId, start, end)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.RP_BAD_REQUEST_002, err.Error())
+			return
+		}
+		write(ctx, report)
+	}
+}
+
+// vatReportColumns returns the register headers and PDF widths, the name column takes the exempt column when it is hidden
+func vatReportColumns(report *vatReport) ([]string, []float64, []string) {
+	headers := []string{"#", "วันที่ / Date", "เลขที่ / No", "อ้างอิง / Ref", "ชื่อ / Name", "เลขผู้เสียภาษี / Tax ID", "สาขา / Branch"}
+	widths := []float64{8, 20, 30, 28, 58, 31, 16}
+	aligns := []string{"C", "L", "L", "L", "L", "L", "C"}
+	if report.ShowExempt {
+		headers = append(headers, "ยกเว้น / Exempt")
+		widths = append(widths, 20)
+		aligns = append(aligns, "R")
+	} else {
+		widths[4] += 20
+	}
+	headers = append(headers, "มูลค่า / Amount", "VAT", "รวม / Total")
+	widths = append(widths, 24, 20, 22)
+	aligns = append(aligns, "R", "R", "R")
+	return headers, widths, aligns
+}
+
+func vatReportCells(report *vatReport, i int, row vatReportRow) []string {
+	name := row.Name
+	if row.Note != "" {
+		name += " (" + row.Note + ")"
+	}
+	cells := []string{
+		fmt.Sprintf("%d", i+1),
+		row.Date.In(utils.GetLocation()).Format("02/01/2006"),
+		row.Code,
+		row.Reference,
+		name,
+		row.TaxId,
+		row.BranchNo,
+	}
+	if report.ShowExempt {
+		cells = append(cells, fmt.Sprintf("%.2f", row.Exempt))
+	}
+	return append(cells, fmt.Sprintf("%.2f", row.Taxable), fmt.Sprintf("%.2f", row.Vat), fmt.Sprintf("%.2f", row.Total))
+}
+
+func writeVatReportPDF(ctx *gin.Context, report *vatReport) {
+	doc := pdf.NewLandscapePDF()
+	doc.AddPage()
+	pdf.AddHeader(doc, report.Company, "", "", report.Title)
+
+	doc.SetFont(pdf.FontFamily, "", 9)
+	doc.CellFormat(0, 5, fmt.Sprintf("เดือนภาษี / Tax month: %s", report.Month.Format("01/2006")), "", 1, "C", false, 0, "")
+	doc.CellFormat(0, 5, fmt.Sprintf("เลขประจำตัวผู้เสียภาษี / Tax ID: %s  %s", report.TaxId, taxBranchLabel(report.BranchNo)), "", 1, "C", false, 0, "")
+	doc.Ln(3)
+
+	headers, widths, aligns := vatReportColumns(report)
+	pdf.AddTableHeader(doc, headers, widths)
+	for i, row := range report.Rows {
+		pdf.AddTableRow(doc, vatReportCells(report, i, row), widths, aligns)
+	}
+
+	doc.Ln(3)
+	totalWidth := float64(277)
+	if report.ShowExempt {
+		pdf.AddSummaryLine(doc, "มูลค่ายกเว้นภาษี / Exempt:", fmt.Sprintf("%.2f", report.Exempt), totalWidth)
+	}
+	pdf.AddSummaryLine(doc, "มูลค่าสินค้า / Amount:", fmt.Sprintf("%.2f", report.Taxable), totalWidth)
+	pdf.AddSummaryLine(doc, "ภาษีมูลค่าเพิ่ม / VAT:", fmt.Sprintf("%.2f", report.Vat), totalWidth)
+	pdf.AddSummaryLine(doc, "รวม / Total:", fmt.Sprintf("%.2f", report.Total), totalWidth)
+
+	ctx.Header("Content-Type", "application/pdf")
+	ctx.Header("Content-Disposition", fmt.Sprintf("inline; filename=%s-%s.pdf", report.FileName, report.Month.Format("200601")))
+	if err := doc.Output(ctx.Writer); err != nil {
+		errcode.Abort(ctx, http.StatusInternalServerError, errcode.RP_INTERNAL_001, err.Error())
+		return
+	}
+}
+
+func writeVatReportExcel(ctx *gin.Context, report *vatReport) {
+	f := excelize.NewFile()
+	sheet := report.Month.Format("2006-01")
+	f.SetSheetName("Sheet1", sheet)
+
+	f.SetCellValue(sheet, "A1", report.Title)
+	f.SetCellValue(sheet, "A2", fmt.Sprintf("%s  Tax ID: %s  %s", report.Company, report.TaxId, taxBranchLabel(report.BranchNo)))
+	f.SetCellValue(sheet, "A3", fmt.Sprintf("เดือนภาษี / Tax month: %s", report.Month.Format("01/2006")))
+
+	headers, _, _ := vatReportColumns(report)
+	headers = append(headers, "หมายเหตุ / Note")
+	headerRow := 5
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, headerRow)
+		f.SetCellValue(sheet, cell, h)
+	}
+	style, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	last, _ := excelize.CoordinatesToCellName(len(headers), headerRow)
+	f.SetCellStyle(sheet, fmt.Sprintf("A%d", headerRow), last, style)
+	f.SetCellStyle(sheet, "A1", "A1", style)
+
+	for i, row := range report.Rows {
+		values := []interface{}{
+			i + 1,
+			row.Date.In(utils.GetLocation()).Format("02/01/2006"),
+			row.Code,
+			row.Reference,
+			row.Name,
+			row.TaxId,
+			row.BranchNo,
+		}
+		if report.ShowExempt {
+			values = append(values, row.Exempt)
+		}
+		values = append(values, row.Taxable, row.Vat, row.Total, row.Note)
+		cell, _ := excelize.CoordinatesToCellName(1, headerRow+1+i)
+		f.SetSheetRow(sheet, cell, &values)
+	}
+
+	totals := []interface{}{"Total"}
+	if report.ShowExempt {
+		totals = append(totals, report.Exempt)
+	}
+	totals = append(totals, report.Taxable, report.Vat, report.Total)
+	cell, _ := excelize.CoordinatesToCellName(7, headerRow+len(report.Rows)+2)
+	f.SetSheetRow(sheet, cell, &totals)
+
+	for i := range headers {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		f.SetColWidth(sheet, col, col, 18)
+	}
+
+	ctx.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-%s.xlsx", report.FileName, report.Month.Format("200601")))
+	if err := f.Write(ctx.Writer); err != nil {
+		errcode.Abort(ctx, http.StatusInternalServerError, errcode.RP_INTERNAL_001, err.Error())
+		return
+	}
+}
@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"pos/app/data/entities"
+	"pos/app/domain/constant"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/skip2/go-qrcode"
+)
+
+// receipt is the one model every receipt output is rendered from, so A4, roll paper and
+// ESC/POS always print the same figures
+type receipt struct {
+	CompanyName    string
+	CompanyAddress string
+	CompanyPhone   string
+	CompanyTaxId   string
+	Footer         string
+	ShowCredit     bool
+	Logo           image.Image
+	Code           string
+	Date           time.Time
+	CustomerName   string
+	CustomerCode   string
+	Voided         bool
+	Lines          []receiptLine
+	Subtotal       float64
+	Discount       float64
+	Total          float64
+	PaymentType    string
+	Paid           float64
+	Change         float64
+	PromptPayQr    [][]bool
+}
+
+type receiptLine struct {
+	Name      string
+	Quantity  int
+	UnitPrice float64
+	Price     float64
+	Discount  float64
+	Total     float64
+}
+
+func buildReceipt(order *entities.OrderDetail, setting *entities.Setting) receipt {
+	result := receipt{
+		CompanyName:  "POS System",
+		ShowCredit:   true,
+		Code:         order.Code,
+		Date:         order.CreatedDate,
+		CustomerName: order.CustomerName,
+		CustomerCode: order.CustomerCode,
+		Voided:       order.Status == constant.OrderStatusVoided,
+		Lines:        []receiptLine{},
+		Subtotal:     order.Total + order.Discount,
+		Discount:     order.Discount,
+		Total:        order.Total,
+		PaymentType:  order.Payment.Type,
+		Paid:         order.Payment.Amount,
+		Change:       order.Payment.Change,
+	}
+	if setting != nil {
+		if setting.CompanyName != "" {
+			result.CompanyName = setting.CompanyName
+		}
+		result.CompanyAddress = setting.CompanyAddress
+		result.CompanyPhone = setting.CompanyPhone
+		result.CompanyTaxId = setting.CompanyTaxId
+		result.Footer = setting.ReceiptFooter
+		result.ShowCredit = setting.ShowCredit
+		result.Logo = loadLogo(setting.LogoUrl)
+		if setting.ReceiptPromptPayQr && setting.PromptPayId != "" && order.Total > 0 {
+			if code, err := qrcode.New(generatePromptPayPayload(setting.PromptPayId, order.Total), qrcode.Medium); err == nil {
+				code.DisableBorder = true
+				result.PromptPayQr = code.Bitmap()
+			}
+		}
+	}
+	for _, item := range order.Items {
+		line := receiptLine{
+			Name:     item.Product.Name,
+			Quantity: item.Quantity,
+			Price:    item.Price,
+			Discount: item.Discount,
+			Total:    item.Price - item.Discount,
+		}
+		if item.Quantity > 0 {
+			line.UnitPrice = item.Price / float64(item.Quantity)
+		}
+		result.Lines = append(result.Lines, line)
+	}
+	return result
+}
+
+// loadLogo downloads the branch logo, a receipt is still printed without it when it fails
+func loadLogo(url string) image.Image {
+	if url == "" {
+		return nil
+	}
+	client := http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		logrus.Warn("failed to load receipt logo: ", err)
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		logrus.Warn("failed to load receipt logo: ", resp.Status)
+		return nil
+	}
+	img, _, err := image.Decode(resp.Body)
+	if err != nil {
+		logrus.Warn("failed to decode receipt logo: ", err)
+		return nil
+	}
+	return img
+}
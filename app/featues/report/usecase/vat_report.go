@@ -0,0 +1,260 @@
+package usecase
+
+import (
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+	"time"
+)
+
+type vatReportMonth struct {
+	Month string `form:"month" binding:"required"`
+}
+
+// period returns the first moment of the tax month (YYYY-MM, Bangkok time) and of the month after it
+func (req vatReportMonth) period() (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation("2006-01", req.Month, utils.GetLocation())
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+type vatReportRow struct {
+	Date      time.Time
+	Code      string
+	Reference string
+	Name      string
+	TaxId     string
+	BranchNo  string
+	Note      string
+	Exempt    float64
+	Taxable   float64
+	Vat       float64
+	Total     float64
+}
+
+// vatReport is the monthly output or input tax register the accountant files with the ภ.พ.30 return
+type vatReport struct {
+	Title      string
+	FileName   string
+	Month      time.Time
+	Company    string
+	TaxId      string
+	BranchNo   string
+	ShowExempt bool
+	Rows       []vatReportRow
+	Exempt     float64
+	Taxable    float64
+	Vat        float64
+	Total      float64
+}
+
+func newVatReport(title string, fileName string, month time.Time, setting *entities.Setting) *vatReport {
+	report := &vatReport{
+		Title:    title,
+		FileName: fileName,
+		Month:    month,
+		Company:  "POS System",
+		BranchNo: setting.GetTaxBranchNo(),
+		Rows:     []vatReportRow{},
+	}
+	if setting != nil {
+		if setting.CompanyName != "" {
+			report.Company = setting.CompanyName
+		}
+		report.TaxId = setting.CompanyTaxId
+	}
+	return report
+}
+
+func (report *vatReport) add(row vatReportRow) {
+	report.Rows = append(report.Rows, row)
+	report.Exempt = utils.Round2(report.Exempt + row.Exempt)
+	report.Taxable = utils.Round2(report.Taxable + row.Taxable)
+	report.Vat = utils.Round2(report.Vat + row.Vat)
+	report.Total = utils.Round2(report.Total + row.Total)
+}
+
+// buildOutputVatReport lists every tax invoice issued in the month in number order, so the series
+// has no gaps. A sale is counted once, on the invoice that first covered it in the month:
+//   - an abbreviated invoice converted in the same month shows as cancelled, the full one carries the sale
+//   - a full invoice replacing an abbreviated one of an earlier month carries nothing, the sale was already filed
+//   - an invoice voided in the same month shows as cancelled, one voided later is reversed in the month of the void
+//
+// Credit notes of invoiced sales reduce the output tax in the month they are issued.
+func buildOutputVatReport(
+	taxInvoiceEntity repositories.ITaxInvoice,
+	creditNoteEntity repositories.ICreditNote,
+	setting *entities.Setting,
+	branchId string,
+	start time.Time,
+	end time.Time,
+) (*vatReport, error) {
+	report := newVatReport("รายงานภาษีขาย / Output Tax Report", "output-vat", start, setting)
+	report.ShowExempt = true
+
+	form := request.GetTaxInvoiceRange{StartDate: start, EndDate: end, BranchId: branchId}
+	invoices, err := taxInvoiceEntity.GetTaxInvoiceRange(form)
+	if err != nil {
+		return nil, err
+	}
+	inMonth := make(map[string]bool, len(invoices))
+	for _, invoice := range invoices {
+		inMonth[invoice.Id.Hex()] = true
+	}
+
+	for _, invoice := range invoices {
+		row := taxInvoiceRow(invoice)
+		counted := true
+		switch {
+		case invoice.Status == constant.TaxInvoiceStatusReplaced && invoice.ReplacedById != nil && inMonth[invoice.ReplacedById.Hex()]:
+			counted = false
+			row.Note = "ยกเลิก ออกแทนด้วย / replaced by " + invoice.ReplacedByCode
+		case invoice.Status == constant.TaxInvoiceStatusCancelled && invoice.CancelledDate != nil && invoice.CancelledDate.Before(end):
+			counted = false
+			row.Note = "ยกเลิก / cancelled: " + invoice.CancelReason
+		case invoice.ReplacesId != nil && !inMonth[invoice.ReplacesId.Hex()]:
+			counted = false
+			row.Note = "แทนใบกำกับภาษีงวดก่อน / replaces " + invoice.ReplacesCode
+		}
+		if !counted {
+			row.Exempt, row.Taxable, row.Vat, row.Total = 0, 0, 0, 0
+		}
+		report.add(row)
+	}
+
+	// Invoices of earlier months voided in this one are reversed here
+	cancelled, err := taxInvoiceEntity.GetCancelledTaxInvoiceRange(form)
+	if err != nil {
+		return nil, err
+	}
+	for _, invoice := range cancelled {
+		if !invoice.IssuedDate.Before(start) || invoice.ReplacesId != nil {
+			continue
+		}
+		row := taxInvoiceRow(invoice)
+		row.Date = *invoice.CancelledDate
+		row.Note = "ยกเลิกใบกำกับภาษีงวดก่อน / voided: " + invoice.CancelReason
+		row.Exempt, row.Taxable, row.Vat, row.Total = -row.Exempt, -row.Taxable, -row.Vat, -row.Total
+		report.add(row)
+	}
+
+	notes, err := creditNoteEntity.GetCreditNoteRange(request.GetCreditNoteRange{StartDate: start, EndDate: end, BranchId: branchId})
+	if err != nil {
+		return nil, err
+	}
+	for _, note := range notes {
+		if note.Status != constant.ACTIVE {
+			continue
+		}
+		invoices, _ := taxInvoiceEntity.GetTaxInvoicesByOrderId(note.OrderId.Hex())
+		invoice := entities.CreditedTaxInvoice(invoices)
+		if invoice == nil {
+			// The sale was never invoiced, so it is not in the output tax either
+			continue
+		}
+		report.add(creditNoteRow(note, invoice))
+	}
+	return report, nil
+}
+
+func taxInvoiceRow(invoice entities.TaxInvoice) vatReportRow {
+	row := vatReportRow{
+		Date:      invoice.IssuedDate,
+		Code:      invoice.Code,
+		Reference: invoice.OrderCode,
+		Name:      "ขายปลีก / Retail sale",
+		Exempt:    invoice.ExemptAmount,
+		Taxable:   invoice.TaxableAmount,
+		Vat:       invoice.VatAmount,
+		Total:     invoice.Total,
+	}
+	if invoice.Buyer != nil {
+		row.Name = invoice.Buyer.Name
+		row.TaxId = invoice.Buyer.TaxId
+		row.BranchNo = invoice.Buyer.BranchNo
+	}
+	return row
+}
+
+// creditNoteRow splits the refund of a credit note by the VAT treatment of the invoiced lines,
+// the amounts are negative as they reduce the output tax
+func creditNoteRow(note entities.CreditNote, invoice *entities.TaxInvoice) vatReportRow {
+	credit := invoice.CreditNoteInvoice(&note)
+	row := vatReportRow{
+		Date:      note.CreatedDate,
+		Code:      note.Code,
+		Reference: invoice.Code,
+		Name:      note.CustomerName,
+		Note:      "ใบลดหนี้ / credit note",
+		Exempt:    -credit.ExemptAmount,
+		Taxable:   -credit.TaxableAmount,
+		Vat:       -credit.VatAmount,
+		Total:     -utils.Round2(note.Total),
+	}
+	if invoice.Buyer != nil {
+		row.Name = invoice.Buyer.Name
+		row.TaxId = invoice.Buyer.TaxId
+		row.BranchNo = invoice.Buyer.BranchNo
+	}
+	if row.Name == "" {
+		row.Name = "ขายปลีก / Retail sale"
+	}
+	return row
+}
+
+// buildInputVatReport lists the supplier tax invoices dated in the month. Input tax can only be
+// claimed against an invoice naming a valid seller tax id, other invoices are listed with no amounts.
+func buildInputVatReport(
+	receiveEntity repositories.IReceive,
+	supplierEntity repositories.ISupplier,
+	setting *entities.Setting,
+	branchId string,
+	start time.Time,
+	end time.Time,
+) (*vatReport, error) {
+	report := newVatReport("รายงานภาษีซื้อ / Input Tax Report", "input-vat", start, setting)
+
+	receives, err := receiveEntity.GetTaxInvoiceReceives(request.GetReceiveRange{StartDate: start, EndDate: end, BranchId: branchId})
+	if err != nil {
+		return nil, err
+	}
+	suppliers := make(map[string]*entities.Supplier)
+	for _, recv := range receives {
+		supplierId := recv.SupplierId.Hex()
+		if _, ok := suppliers[supplierId]; !ok {
+			suppliers[supplierId], _ = supplierEntity.GetSupplierById(supplierId)
+		}
+		supplier := suppliers[supplierId]
+
+		taxable := recv.TaxInvoice.TaxableAmount
+		if taxable == 0 {
+			taxable = recv.TotalCost
+		}
+		row := vatReportRow{
+			Date:      recv.TaxInvoice.Date,
+			Code:      recv.TaxInvoice.Number,
+			Reference: recv.Code,
+			Taxable:   utils.Round2(taxable),
+			Vat:       utils.Round2(recv.TaxInvoice.VatAmount),
+			Total:     utils.Round2(taxable + recv.TaxInvoice.VatAmount),
+		}
+		if supplier != nil {
+			row.Name = supplier.Name
+			row.TaxId = supplier.TaxId
+			row.BranchNo = supplier.BranchNo
+			if row.BranchNo == "" {
+				row.BranchNo = constant.HeadOfficeBranchNo
+			}
+		}
+		if !utils.IsValidThaiId(row.TaxId) {
+			row.Note = "ไม่มีเลขประจำตัวผู้เสียภาษีผู้ขาย / no supplier tax id"
+			row.Taxable, row.Vat, row.Total = 0, 0, 0
+		}
+		report.add(row)
+	}
+	return report, nil
+}
@@ -6,6 +6,7 @@ import (
 	"pos/app/core/utils"
 	"pos/app/data/repositories"
 	"pos/app/domain/request"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,6 +18,12 @@ func UpsertSetting(settingEntity repositories.ISetting) gin.HandlerFunc {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.SE_BAD_REQUEST_001, err.Error())
 			return
 		}
+		if req.DayCloseTime != "" {
+			if _, err := time.Parse("15:04", req.DayCloseTime); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.SE_BAD_REQUEST_001, "dayCloseTime must be HH:mm")
+				return
+			}
+		}
 		req.BranchId = ctx.GetString("BranchId")
 		req.UpdatedBy = utils.GetUserId(ctx)
 
@@ -0,0 +1,73 @@
+package shift
+
+import (
+	"pos/app/domain"
+	"pos/app/featues/shift/usecase"
+	"pos/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ApplyShiftAPI(
+	route *gin.RouterGroup,
+	repository *domain.Repository,
+) {
+	shiftRoute := route.Group("shifts")
+
+	shiftRoute.POST("/open",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.OpenShift(repository.Shift, repository.Sequence),
+	)
+
+	shiftRoute.GET("/current",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetCurrentShift(repository.Shift),
+	)
+
+	shiftRoute.GET("",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetShifts(repository.Shift),
+	)
+
+	shiftRoute.GET("/:shiftId",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetShiftById(repository.Shift),
+	)
+
+	shiftRoute.POST("/:shiftId/cash-movements",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireIdempotency(repository.Idempotency),
+		usecase.AddCashMovement(repository.Shift),
+	)
+
+	shiftRoute.GET("/:shiftId/report",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetShiftReport(repository.Shift, repository.Order, repository.CreditNote, repository.Receivable),
+	)
+
+	shiftRoute.GET("/:shiftId/report/pdf",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetShiftReportPDF(repository.Shift, repository.Order, repository.CreditNote, repository.Receivable, repository.Setting),
+	)
+
+	shiftRoute.PATCH("/:shiftId/close",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.CloseShift(repository.Shift, repository.Order, repository.CreditNote, repository.Receivable),
+	)
+}
@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CloseShift closes the shift with the counted amounts and stores the Z report reconciliation.
+// The cash in the drawer must be counted, other payment types left out of the counts are taken as
+// counted at the expected amount since they are settled outside the drawer.
+func CloseShift(
+	shiftEntity repositories.IShift,
+	orderEntity repositories.IOrder,
+	creditNoteEntity repositories.ICreditNote,
+	receivableEntity repositories.IReceivable,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.CloseShift{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SH_BAD_REQUEST_001, err.Error())
+			return
+		}
+		shift, ok := getOwnShift(ctx, shiftEntity)
+		if !ok {
+			return
+		}
+		if shift.Status != constant.ShiftStatusOpen {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SH_BAD_REQUEST_002, "shift is already closed")
+			return
+		}
+
+		report, err := buildShiftReport(shift, orderEntity, creditNoteEntity, receivableEntity)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SH_BAD_REQUEST_002, err.Error())
+			return
+		}
+
+		counts := make(map[string]float64)
+		for _, count := range req.Counts {
+			counts[count.Type] += count.Amount
+		}
+		if _, ok := counts[constant.PaymentTypeCash]; !ok {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SH_BAD_REQUEST_001, "cash count is required")
+			return
+		}
+		for _, line := range report.Payments {
+			counted, ok := counts[line.Type]
+			if !ok {
+				counted = line.Expected
+			}
+			req.Summary = append(req.Summary, request.ShiftPaymentSummary{
+				Type:     line.Type,
+				Sales:    line.Sales,
+				Receipts: line.Receipts,
+				Refunds:  line.Refunds,
+				Expected: line.Expected,
+				Counted:  utils.Round2(counted),
+				Variance: utils.Round2(counted - line.Expected),
+			})
+		}
+		req.ClosedBy = utils.GetUserId(ctx)
+
+		result, err := shiftEntity.CloseShiftById(shift.Id.Hex(), req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SH_BAD_REQUEST_002, "shift is already closed")
+			return
+		}
+		report.Shift = *result
+		report.Final = true
+		report.Payments = result.Summary
+		ctx.JSON(http.StatusOK, report)
+	}
+}
+
+// buildShiftReport totals the payments, receipts of credit sales, refunds and cash movements
+// of the shift by payment type. A closed shift keeps the reconciliation stored at close time.
+func buildShiftReport(
+	shift *entities.Shift,
+	orderEntity repositories.IOrder,
+	creditNoteEntity repositories.ICreditNote,
+	receivableEntity repositories.IReceivable,
+) (*entities.ShiftReport, error) {
+	shiftId := shift.Id.Hex()
+	payments, err := orderEntity.GetPaymentsByShiftId(shiftId)
+	if err != nil {
+		return nil, err
+	}
+	creditNotes, err := creditNoteEntity.GetCreditNotesByShiftId(shiftId)
+	if err != nil {
+		return nil, err
+	}
+
+	receipts, err := receivableEntity.GetReceiptsByShiftId(shiftId)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &entities.ShiftReport{Shift: *shift}
+	sales := make(map[string]float64)
+	received := make(map[string]float64)
+	refunds := make(map[string]float64)
+	types := constant.PaymentTypes()
+
+	// Payments of one order share its total and change, count them once per order
+	orders := make(map[string][]entities.Payment)
+	var orderIds []string
+	for _, payment := range payments {
+		orderId := payment.OrderId.Hex()
+		if _, ok := orders[orderId]; !ok {
+			orderIds = append(orderIds, orderId)
+		}
+		orders[orderId] = append(orders[orderId], payment)
+	}
+	for _, orderId := range orderIds {
+		orderPayments := orders[orderId]
+		if orderPayments[0].Status == constant.OrderStatusVoided {
+			report.VoidedOrders++
+			report.VoidedTotal += orderPayments[0].Total
+			continue
+		}
+		report.TotalOrders++
+		report.TotalSales += orderPayments[0].Total
+		changeApplied := false
+		for _, payment := range orderPayments {
+			amount := payment.Amount
+			if payment.Type == constant.PaymentTypeCash && !changeApplied {
+				amount -= payment.Change
+				changeApplied = true
+			}
+			sales[payment.Type] += amount
+			if !utils.InArrayString(payment.Type, types) {
+				types = append(types, payment.Type)
+			}
+		}
+	}
+
+	for _, note := range creditNotes {
+		for _, refund := range note.Refunds {
+			refunds[refund.Type] += refund.Amount
+			report.TotalRefunds += refund.Amount
+			if !utils.InArrayString(refund.Type, types) {
+				types = append(types, refund.Type)
+			}
+		}
+	}
+
+	for _, receipt := range receipts {
+		received[receipt.PaymentType] += receipt.Amount
+		report.TotalReceipts += receipt.Amount
+		if !utils.InArrayString(receipt.PaymentType, types) {
+			types = append(types, receipt.PaymentType)
+		}
+	}
+
+	for _, movement := range shift.CashMovements {
+		if movement.Type == constant.CashMovementIn {
+			report.CashIn += movement.Amount
+		} else {
+			report.CashOut += movement.Amount
+		}
+	}
+
+	for _, paymentType := range types {
+		expected := sales[paymentType] + received[paymentType] - refunds[paymentType]
+		if paymentType == constant.PaymentTypeCash {
+			expected += shift.OpeningFloat + report.CashIn - report.CashOut
+		}
+		report.Payments = append(report.Payments, entities.ShiftPaymentSummary{
+			Type:     paymentType,
+			Sales:    utils.Round2(sales[paymentType]),
+			Receipts: utils.Round2(received[paymentType]),
+			Refunds:  utils.Round2(refunds[paymentType]),
+			Expected: utils.Round2(expected),
+		})
+	}
+	report.TotalSales = utils.Round2(report.TotalSales)
+	report.VoidedTotal = utils.Round2(report.VoidedTotal)
+	report.TotalRefunds = utils.Round2(report.TotalRefunds)
+	report.TotalReceipts = utils.Round2(report.TotalReceipts)
+	report.CashIn = utils.Round2(report.CashIn)
+	report.CashOut = utils.Round2(report.CashOut)
+
+	if shift.Status == constant.ShiftStatusClosed {
+		report.Final = true
+		report.Payments = shift.Summary
+	}
+	return report, nil
+}
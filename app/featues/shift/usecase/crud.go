@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"net/http"
+	coreConstant "pos/app/core/constant"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+func OpenShift(shiftEntity repositories.IShift, sequenceEntity repositories.ISequence) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.OpenShift{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SH_BAD_REQUEST_001, err.Error())
+			return
+		}
+		req.UserId = utils.GetUserId(ctx)
+		req.BranchId = utils.GetBranchId(ctx)
+
+		if current, _ := shiftEntity.GetOpenShiftByUserId(req.BranchId, req.UserId); current != nil {
+			errcode.AbortWithData(ctx, http.StatusConflict, errcode.SH_CONFLICT_001, "cashier already has an open shift", current)
+			return
+		}
+
+		sequence, _ := sequenceEntity.NextSequence(constant.SHIFT)
+		if sequence != nil {
+			req.Code = sequence.GenerateCode()
+		}
+
+		result, err := shiftEntity.OpenShift(req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusConflict, errcode.SH_CONFLICT_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetCurrentShift(shiftEntity repositories.IShift) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, err := shiftEntity.GetOpenShiftByUserId(utils.GetBranchId(ctx), utils.GetUserId(ctx))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SH_BAD_REQUEST_002, "no open shift")
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetShifts(shiftEntity repositories.IShift) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.GetShiftRange{}
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SH_BAD_REQUEST_001, err.Error())
+			return
+		}
+		req.BranchId = utils.GetBranchId(ctx)
+		result, err := shiftEntity.GetShiftRange(req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SH_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetShiftById(shiftEntity repositories.IShift) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, ok := getBranchShift(ctx, shiftEntity)
+		if !ok {
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func AddCashMovement(shiftEntity repositories.IShift) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.CashMovement{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SH_BAD_REQUEST_001, err.Error())
+			return
+		}
+		shift, ok := getOwnShift(ctx, shiftEntity)
+		if !ok {
+			return
+		}
+		req.CreatedBy = utils.GetUserId(ctx)
+
+		result, err := shiftEntity.AddCashMovementById(shift.Id.Hex(), req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SH_BAD_REQUEST_002, "shift is closed")
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func getBranchShift(ctx *gin.Context, shiftEntity repositories.IShift) (*entities.Shift, bool) {
+	shift, err := shiftEntity.GetShiftById(ctx.Param("shiftId"))
+	if err != nil || shift.BranchId.Hex() != utils.GetBranchId(ctx) {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.SH_BAD_REQUEST_002, "shift not found")
+		return nil, false
+	}
+	return shift, true
+}
+
+// getOwnShift loads the shift from the path, only its cashier or ADMIN/SUPER may change it
+func getOwnShift(ctx *gin.Context, shiftEntity repositories.IShift) (*entities.Shift, bool) {
+	shift, ok := getBranchShift(ctx, shiftEntity)
+	if !ok {
+		return nil, false
+	}
+	role := ctx.GetString("Role")
+	if shift.UserId != utils.GetUserId(ctx) && role != coreConstant.ADMIN && role != coreConstant.SUPER {
+		errcode.Abort(ctx, http.StatusForbidden, errcode.SY_FORBIDDEN_002, "shift belongs to another cashier")
+		return nil, false
+	}
+	return shift, true
+}
@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"fmt"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/pdf"
+	"pos/app/core/utils"
+	"pos/app/data/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetShiftReport returns the X report of an open shift, or the Z report once it is closed
+func GetShiftReport(
+	shiftEntity repositories.IShift,
+	orderEntity repositories.IOrder,
+	creditNoteEntity repositories.ICreditNote,
+	receivableEntity repositories.IReceivable,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		shift, ok := getBranchShift(ctx, shiftEntity)
+		if !ok {
+			return
+		}
+		report, err := buildShiftReport(shift, orderEntity, creditNoteEntity, receivableEntity)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SH_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, report)
+	}
+}
+
+func GetShiftReportPDF(
+	shiftEntity repositories.IShift,
+	orderEntity repositories.IOrder,
+	creditNoteEntity repositories.ICreditNote,
+	receivableEntity repositories.IReceivable,
+	settingEntity repositories.ISetting,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		shift, ok := getBranchShift(ctx, shiftEntity)
+		if !ok {
+			return
+		}
+		report, err := buildShiftReport(shift, orderEntity, creditNoteEntity, receivableEntity)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SH_BAD_REQUEST_002, err.Error())
+			return
+		}
+
+		setting, _ := settingEntity.GetSettingByBranchId(shift.BranchId.Hex())
+		companyName := "POS System"
+		companyAddress := ""
+		companyPhone := ""
+		showCredit := true
+		if setting != nil {
+			if setting.CompanyName != "" {
+				companyName = setting.CompanyName
+			}
+			companyAddress = setting.CompanyAddress
+			companyPhone = setting.CompanyPhone
+			showCredit = setting.ShowCredit
+		}
+
+		title := "X Report"
+		if report.Final {
+			title = "Z Report"
+		}
+
+		doc := pdf.NewPDF()
+		doc.AddPage()
+		pdf.AddHeader(doc, companyName, companyAddress, companyPhone, title)
+
+		doc.SetFont(pdf.FontFamily, "", 9)
+		closed := "-"
+		if shift.ClosedDate != nil {
+			closed = utils.ToFormat(*shift.ClosedDate)
+		}
+		doc.CellFormat(0, 5, fmt.Sprintf("Shift: %s    Cashier: %s", shift.Code, shift.UserId), "", 1, "L", false, 0, "")
+		doc.CellFormat(0, 5, fmt.Sprintf("Opened: %s    Closed: %s", utils.ToFormat(shift.OpenedDate), closed), "", 1, "L", false, 0, "")
+		doc.Ln(3)
+
+		headers := []string{"Payment", "Sales", "Receipts", "Refunds", "Expected", "Counted", "Variance"}
+		widths := []float64{30, 27, 27, 27, 27, 26, 26}
+		aligns := []string{"L", "R", "R", "R", "R", "R", "R"}
+		pdf.AddTableHeader(doc, headers, widths)
+		for _, line := range report.Payments {
+			counted := "-"
+			variance := "-"
+			if report.Final {
+				counted = fmt.Sprintf("%.2f", line.Counted)
+				variance = fmt.Sprintf("%.2f", line.Variance)
+			}
+			pdf.AddTableRow(doc, []string{
+				line.Type,
+				fmt.Sprintf("%.2f", line.Sales),
+				fmt.Sprintf("%.2f", line.Receipts),
+				fmt.Sprintf("%.2f", line.Refunds),
+				fmt.Sprintf("%.2f", line.Expected),
+				counted,
+				variance,
+			}, widths, aligns)
+		}
+
+		if len(shift.CashMovements) > 0 {
+			doc.Ln(5)
+			headers = []string{"Time", "Type", "Reason", "Amount"}
+			widths = []float64{40, 20, 100, 30}
+			aligns = []string{"L", "C", "L", "R"}
+			pdf.AddTableHeader(doc, headers, widths)
+			for _, movement := range shift.CashMovements {
+				pdf.AddTableRow(doc, []string{
+					utils.ToFormat(movement.CreatedDate),
+					movement.Type,
+					movement.Reason,
+					fmt.Sprintf("%.2f", movement.Amount),
+				}, widths, aligns)
+			}
+		}
+
+		doc.Ln(5)
+		totalWidth := float64(190)
+		pdf.AddSummaryLine(doc, "Opening Float:", fmt.Sprintf("%.2f", shift.OpeningFloat), totalWidth)
+		pdf.AddSummaryLine(doc, "Total Orders:", fmt.Sprintf("%d", report.TotalOrders), totalWidth)
+		pdf.AddSummaryLine(doc, "Total Sales:", fmt.Sprintf("%.2f", report.TotalSales), totalWidth)
+		pdf.AddSummaryLine(doc, "Voided Orders:", fmt.Sprintf("%d (%.2f)", report.VoidedOrders, report.VoidedTotal), totalWidth)
+		pdf.AddSummaryLine(doc, "Credit Receipts:", fmt.Sprintf("%.2f", report.TotalReceipts), totalWidth)
+		pdf.AddSummaryLine(doc, "Refunds:", fmt.Sprintf("%.2f", report.TotalRefunds), totalWidth)
+		pdf.AddSummaryLine(doc, "Cash In:", fmt.Sprintf("%.2f", report.CashIn), totalWidth)
+		pdf.AddSummaryLine(doc, "Cash Out:", fmt.Sprintf("%.2f", report.CashOut), totalWidth)
+		if report.Final {
+			pdf.AddSummaryLine(doc, "Total Variance:", fmt.Sprintf("%.2f", shift.TotalVariance), totalWidth)
+		}
+
+		creditText := ""
+		if showCredit {
+			creditText = "Powered by POS System"
+		}
+		pdf.AddFooter(doc, creditText, false)
+
+		ctx.Header("Content-Type", "application/pdf")
+		ctx.Header("Content-Disposition", fmt.Sprintf("inline; filename=shift-%s.pdf", shift.Code))
+		err = doc.Output(ctx.Writer)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.SH_INTERNAL_001, err.Error())
+			return
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package stock_adjustment
+
+import (
+	"pos/app/core/constant"
+	"pos/app/domain"
+	"pos/app/featues/stock_adjustment/usecase"
+	"pos/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ApplyStockAdjustmentAPI(
+	route *gin.RouterGroup,
+	repository *domain.Repository,
+) {
+	saRoute := route.Group("stock-adjustments")
+
+	saRoute.POST("",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireIdempotency(repository.Idempotency),
+		usecase.CreateStockAdjustment(repository.Transaction, repository.StockAdjustment, repository.Product, repository.Sequence, repository.Setting),
+	)
+
+	saRoute.GET("",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetStockAdjustments(repository.StockAdjustment),
+	)
+
+	saRoute.GET("/:id",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetStockAdjustmentById(repository.StockAdjustment),
+	)
+
+	saRoute.PATCH("/:id/approve",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.ApproveStockAdjustment(repository.Transaction, repository.StockAdjustment, repository.Product),
+	)
+
+	saRoute.PATCH("/:id/reject",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.RejectStockAdjustment(repository.StockAdjustment),
+	)
+}
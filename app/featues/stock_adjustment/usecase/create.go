@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	coreConstant "pos/app/core/constant"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateStockAdjustment values each line at the cost of its lot and moves the stock at once, unless
+// the branch asks an ADMIN to approve adjustments made by other roles. A pending adjustment is valued
+// again when it is approved.
+func CreateStockAdjustment(
+	transactionEntity repositories.ITransaction,
+	entity repositories.IStockAdjustment,
+	productEntity repositories.IProduct,
+	sequenceEntity repositories.ISequence,
+	settingEntity repositories.ISetting,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.StockAdjustment{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SA_BAD_REQUEST_001, err.Error())
+			return
+		}
+		req.CreatedBy = utils.GetUserId(ctx)
+		req.BranchId = utils.GetBranchId(ctx)
+
+		increase := constant.IsStockIncrease(req.Reason)
+		req.TotalCost = 0
+		for i, item := range req.Items {
+			lot, err := productEntity.GetProductStockById(item.StockId)
+			if err != nil || lot.BranchId.Hex() != req.BranchId {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.SA_BAD_REQUEST_003, "lot not found: "+item.StockId)
+				return
+			}
+			if !increase && lot.Quantity < item.Quantity {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.SA_BAD_REQUEST_002, fmt.Sprintf("lot %s holds %d, less than %d", lot.LotNumber, lot.Quantity, item.Quantity))
+				return
+			}
+			cost := utils.Round2(lot.CostPrice * float64(item.Quantity))
+			if !increase {
+				cost = -cost
+			}
+			req.Items[i].ProductId = lot.ProductId.Hex()
+			req.Items[i].UnitId = lot.UnitId.Hex()
+			req.Items[i].LotNumber = lot.LotNumber
+			req.Items[i].CostPrice = lot.CostPrice
+			req.Items[i].CostAmount = cost
+			req.TotalCost += cost
+		}
+		req.TotalCost = utils.Round2(req.TotalCost)
+
+		req.Status = constant.StockAdjustmentStatusApproved
+		setting, _ := settingEntity.GetSettingByBranchId(req.BranchId)
+		role := ctx.GetString("Role")
+		if setting != nil && setting.AdjustmentApproval && role != coreConstant.ADMIN && role != coreConstant.SUPER {
+			req.Status = constant.StockAdjustmentStatusPending
+		}
+
+		sequence, _ := sequenceEntity.NextSequence(constant.ADJUSTMENT)
+		if sequence != nil {
+			req.Code = sequence.GenerateCode()
+		}
+
+		unitIds := make([]string, len(req.Items))
+		for i, item := range req.Items {
+			unitIds[i] = item.UnitId
+		}
+		units := adjustmentUnits(productEntity, unitIds)
+		var result *entities.StockAdjustment
+		err := transactionEntity.WithTransaction(func(txCtx context.Context) error {
+			var err error
+			result, err = entity.CreateStockAdjustmentTx(txCtx, req)
+			if err != nil {
+				return err
+			}
+			if result.IsPending() {
+				return nil
+			}
+			return ApplyStockAdjustment(txCtx, entity, productEntity, result, units, "", req.CreatedBy)
+		})
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SA_BAD_REQUEST_002, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+// ApplyStockAdjustment moves the stock of every lot of an approved adjustment and records the
+// movement in the product history under the adjustment code, units maps the unit ids of the lots to
+// their names. The suffix is appended to the history description, stocktakes name the count there.
+// The lines are valued again at the cost the lots hold when the stock moves.
+func ApplyStockAdjustment(
+	ctx context.Context,
+	entity repositories.IStockAdjustment,
+	productEntity repositories.IProduct,
+	adjustment *entities.StockAdjustment,
+	units map[string]string,
+	suffix string,
+	createdBy string,
+) error {
+	increase := constant.IsStockIncrease(adjustment.Reason)
+	adjustment.TotalCost = 0
+	for i, item := range adjustment.Items {
+		var stock *entities.ProductStock
+		var err error
+		quantity := item.Quantity
+		if increase {
+			if stock, err = productEntity.AddProductStockQuantityByIdTx(ctx, item.StockId, item.Quantity); err != nil {
+				return err
+			}
+		} else {
+			quantity = -quantity
+			stock, err = productEntity.RemoveProductStockQuantityIfAvailableByIdTx(ctx, item.StockId, item.Quantity)
+			if errors.Is(err, repositories.ErrInsufficientStock) {
+				return fmt.Errorf("lot %s holds less than %d", item.LotNumber, item.Quantity)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		cost := utils.Round2(stock.CostPrice * float64(item.Quantity))
+		if !increase {
+			cost = -cost
+		}
+		adjustment.Items[i].CostPrice = stock.CostPrice
+		adjustment.Items[i].CostAmount = cost
+		adjustment.TotalCost += cost
+
+		valuation := request.StockValuation(stock, constant.ValuationTypeAdjustment, quantity, createdBy)
+		valuation.DocumentType = constant.ADJUSTMENT
+		valuation.DocumentId = adjustment.Id.Hex()
+		valuation.DocumentCode = adjustment.Code
+		if _, err := productEntity.CreateProductValuationTx(ctx, valuation); err != nil {
+			return err
+		}
+
+		balance := productEntity.GetProductStockBalanceTx(ctx, item.ProductId.Hex(), item.UnitId.Hex())
+		history := request.AdjustProductStockHistory(item.ProductId.Hex(), units[item.UnitId.Hex()], adjustment.Reason, adjustment.Items[i], balance, createdBy)
+		history.Description += suffix
+		history.BranchId = adjustment.BranchId.Hex()
+		history.DocumentType = constant.ADJUSTMENT
+		history.DocumentId = adjustment.Id.Hex()
+		history.DocumentCode = adjustment.Code
+		if _, err := productEntity.CreateProductHistoryTx(ctx, history); err != nil {
+			return err
+		}
+	}
+	adjustment.TotalCost = utils.Round2(adjustment.TotalCost)
+	return entity.UpdateStockAdjustmentCostTx(ctx, adjustment.Id.Hex(), adjustment.Items, adjustment.TotalCost)
+}
+
+// adjustmentUnits maps the unit ids of the lots to their names for the product history
+func adjustmentUnits(productEntity repositories.IProduct, unitIds []string) map[string]string {
+	units := make(map[string]string)
+	for _, unitId := range unitIds {
+		if _, ok := units[unitId]; ok {
+			continue
+		}
+		units[unitId] = ""
+		if unit, _ := productEntity.GetProductUnitById(unitId); unit != nil {
+			units[unitId] = unit.Unit
+		}
+	}
+	return units
+}
@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func GetStockAdjustments(entity repositories.IStockAdjustment) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.GetStockAdjustmentRange{}
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SA_BAD_REQUEST_001, err.Error())
+			return
+		}
+		req.BranchId = utils.GetBranchId(ctx)
+		result, err := entity.GetStockAdjustmentRange(req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.SA_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetStockAdjustmentById(entity repositories.IStockAdjustment) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, ok := getBranchStockAdjustment(ctx, entity)
+		if !ok {
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+// ApproveStockAdjustment moves the stock of a pending adjustment
+func ApproveStockAdjustment(transactionEntity repositories.ITransaction, entity repositories.IStockAdjustment, productEntity repositories.IProduct) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		adjustment, ok := getBranchStockAdjustment(ctx, entity)
+		if !ok {
+			return
+		}
+		if !adjustment.IsPending() {
+			errcode.Abort(ctx, http.StatusConflict, errcode.SA_CONFLICT_001, "adjustment is not pending")
+			return
+		}
+		userId := utils.GetUserId(ctx)
+
+		unitIds := make([]string, len(adjustment.Items))
+		for i, item := range adjustment.Items {
+			unitIds[i] = item.UnitId.Hex()
+		}
+		units := adjustmentUnits(productEntity, unitIds)
+		var result *entities.StockAdjustment
+		err := transactionEntity.WithTransaction(func(txCtx context.Context) error {
+			var err error
+			result, err = entity.ApproveStockAdjustmentTx(txCtx, adjustment.Id.Hex(), userId)
+			if err != nil {
+				return err
+			}
+			return ApplyStockAdjustment(txCtx, entity, productEntity, result, units, "", userId)
+		})
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.SA_CONFLICT_001, "adjustment is not pending")
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SA_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func RejectStockAdjustment(entity repositories.IStockAdjustment) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.RejectStockAdjustment{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SA_BAD_REQUEST_001, err.Error())
+			return
+		}
+		req.UpdatedBy = utils.GetUserId(ctx)
+
+		adjustment, ok := getBranchStockAdjustment(ctx, entity)
+		if !ok {
+			return
+		}
+		result, err := entity.RejectStockAdjustmentById(adjustment.Id.Hex(), req)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.SA_CONFLICT_001, "adjustment is not pending")
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SA_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func getBranchStockAdjustment(ctx *gin.Context, entity repositories.IStockAdjustment) (*entities.StockAdjustment, bool) {
+	result, err := entity.GetStockAdjustmentById(ctx.Param("id"))
+	if err != nil || result.BranchId.Hex() != utils.GetBranchId(ctx) {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.SA_BAD_REQUEST_003, "stock adjustment not found")
+		return nil, false
+	}
+	return result, true
+}
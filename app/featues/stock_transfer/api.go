@@ -20,7 +20,8 @@ func ApplyStockTransferAPI(
 		middlewares.RequireSession(repository.Session),
 		middlewares.RequireBranch(repository.Employee, repository.Branch),
 		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
-		usecase.CreateStockTransfer(repository.StockTransfer, repository.Product, repository.Sequence),
+		middlewares.RequireIdempotency(repository.Idempotency),
+		usecase.CreateStockTransfer(repository.Transaction, repository.StockTransfer, repository.Product, repository.Sequence),
 	)
 
 	stRoute.GET("",
@@ -42,7 +43,7 @@ func ApplyStockTransferAPI(
 		middlewares.RequireSession(repository.Session),
 		middlewares.RequireBranch(repository.Employee, repository.Branch),
 		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
-		usecase.ApproveStockTransfer(repository.StockTransfer, repository.Product),
+		usecase.ApproveStockTransfer(repository.Transaction, repository.StockTransfer, repository.Product, repository.Setting),
 	)
 
 	stRoute.PATCH("/:id/reject",
@@ -50,6 +51,6 @@ func ApplyStockTransferAPI(
 		middlewares.RequireSession(repository.Session),
 		middlewares.RequireBranch(repository.Employee, repository.Branch),
 		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
-		usecase.RejectStockTransfer(repository.StockTransfer, repository.Product),
+		usecase.RejectStockTransfer(repository.Transaction, repository.StockTransfer, repository.Product),
 	)
 }
@@ -1,9 +1,13 @@
 package usecase
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"pos/app/core/errcode"
 	"pos/app/core/utils"
+	"pos/app/data/entities"
 	"pos/app/data/repositories"
 	"pos/app/domain/constant"
 	"pos/app/domain/request"
@@ -11,7 +15,14 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func CreateStockTransfer(entity repositories.IStockTransfer, productEntity repositories.IProduct, sequenceEntity repositories.ISequence) gin.HandlerFunc {
+// CreateStockTransfer takes the lots out of the source branch together with the transfer, the
+// destination receives them on approval
+func CreateStockTransfer(
+	transactionEntity repositories.ITransaction,
+	entity repositories.IStockTransfer,
+	productEntity repositories.IProduct,
+	sequenceEntity repositories.ISequence,
+) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		req := request.StockTransfer{}
 		if err := ctx.ShouldBind(&req); err != nil {
@@ -26,18 +37,39 @@ func CreateStockTransfer(entity repositories.IStockTransfer, productEntity repos
 			req.Code = "TF-" + sequence.GenerateCode()
 		}
 
-		result, err := entity.CreateStockTransfer(req)
+		var result *entities.StockTransfer
+		err := transactionEntity.WithTransaction(func(txCtx context.Context) error {
+			var err error
+			result, err = entity.CreateStockTransferTx(txCtx, req)
+			if err != nil {
+				return err
+			}
+			for _, item := range req.Items {
+				if item.StockId == "" {
+					continue
+				}
+				stock, err := productEntity.RemoveProductStockQuantityIfAvailableByIdTx(txCtx, item.StockId, item.Quantity)
+				if errors.Is(err, repositories.ErrInsufficientStock) {
+					return fmt.Errorf("lot %s holds less than %d", item.StockId, item.Quantity)
+				}
+				if err != nil {
+					return err
+				}
+				valuation := request.StockValuation(stock, constant.ValuationTypeTransfer, -item.Quantity, req.CreatedBy)
+				valuation.DocumentType = constant.STOCK_TRANSFER
+				valuation.DocumentId = result.Id.Hex()
+				valuation.DocumentCode = result.Code
+				if _, err := productEntity.CreateProductValuationTx(txCtx, valuation); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
 		if err != nil {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.TR_BAD_REQUEST_002, err.Error())
 			return
 		}
 
-		for _, item := range req.Items {
-			if item.StockId != "" {
-				_, _ = productEntity.RemoveProductStockQuantityById(item.StockId, item.Quantity)
-			}
-		}
-
 		ctx.JSON(http.StatusOK, result)
 	}
 }
@@ -1,15 +1,20 @@
 package usecase
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"time"
 
 	"pos/app/core/errcode"
 	"pos/app/core/utils"
+	"pos/app/data/entities"
 	"pos/app/data/repositories"
+	"pos/app/domain/constant"
 	"pos/app/domain/request"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func GetStockTransfers(entity repositories.IStockTransfer) gin.HandlerFunc {
@@ -36,7 +41,12 @@ func GetStockTransferById(entity repositories.IStockTransfer) gin.HandlerFunc {
 	}
 }
 
-func ApproveStockTransfer(entity repositories.IStockTransfer, productEntity repositories.IProduct) gin.HandlerFunc {
+func ApproveStockTransfer(
+	transactionEntity repositories.ITransaction,
+	entity repositories.IStockTransfer,
+	productEntity repositories.IProduct,
+	settingEntity repositories.ISetting,
+) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		id := ctx.Param("id")
 		req := request.UpdateStockTransfer{
@@ -55,37 +65,69 @@ func ApproveStockTransfer(entity repositories.IStockTransfer, productEntity repo
 			return
 		}
 
-		result, err := entity.UpdateStockTransferStatus(id, req)
+		// Add stock to destination branch by creating new stock entries, valued at the cost of the source
+		// lot and priced with the lots of the destination under AVERAGE
+		setting, _ := settingEntity.GetSettingByBranchId(transfer.ToBranchId.Hex())
+		var result *entities.StockTransfer
+		err = transactionEntity.WithTransaction(func(txCtx context.Context) error {
+			var err error
+			result, err = entity.UpdateStockTransferStatusTx(txCtx, id, req)
+			if err != nil {
+				return err
+			}
+			for _, item := range transfer.Items {
+				if item.StockId == "" {
+					continue
+				}
+				sourceStock, err := productEntity.GetProductStockByIdTx(txCtx, item.StockId)
+				if err != nil {
+					return err
+				}
+				stock, err := productEntity.CreateProductStockTx(txCtx, request.ProductStock{
+					BranchId:   transfer.ToBranchId.Hex(),
+					ProductId:  item.ProductId.Hex(),
+					UnitId:     sourceStock.UnitId.Hex(),
+					LotNumber:  sourceStock.LotNumber,
+					CostPrice:  sourceStock.CostPrice,
+					Price:      sourceStock.Price,
+					Quantity:   item.Quantity,
+					ExpireDate: sourceStock.ExpireDate,
+					ImportDate: time.Now(),
+					Status:     sourceStock.Status,
+				})
+				if err != nil {
+					return err
+				}
+				valuation := request.StockValuation(stock, constant.ValuationTypeTransfer, stock.Quantity, req.UpdatedBy)
+				valuation.DocumentType = constant.STOCK_TRANSFER
+				valuation.DocumentId = transfer.Id.Hex()
+				valuation.DocumentCode = transfer.Code
+				if _, err := productEntity.CreateProductValuationTx(txCtx, valuation); err != nil {
+					return err
+				}
+				if setting.GetCostingMethod() == constant.CostingMethodAverage && stock.IsSellable() {
+					if _, err := productEntity.AverageProductStockCostTx(txCtx, valuation); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if errors.Is(err, mongo.ErrNoDocuments) && result == nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TR_BAD_REQUEST_002, "transfer is not pending")
+			return
+		}
 		if err != nil {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.TR_BAD_REQUEST_002, err.Error())
 			return
 		}
 
-		// Add stock to destination branch by creating new stock entries
-		for _, item := range transfer.Items {
-			if item.StockId != "" {
-				sourceStock, err := productEntity.GetProductStockById(item.StockId)
-				if err == nil && sourceStock != nil {
-					_, _ = productEntity.CreateProductStock(request.ProductStock{
-						BranchId:   transfer.ToBranchId.Hex(),
-						ProductId:  item.ProductId.Hex(),
-						UnitId:     sourceStock.UnitId.Hex(),
-						LotNumber:  sourceStock.LotNumber,
-						CostPrice:  sourceStock.CostPrice,
-						Price:      sourceStock.Price,
-						Quantity:   item.Quantity,
-						ExpireDate: sourceStock.ExpireDate,
-						ImportDate: time.Now(),
-					})
-				}
-			}
-		}
-
 		ctx.JSON(http.StatusOK, result)
 	}
 }
 
-func RejectStockTransfer(entity repositories.IStockTransfer, productEntity repositories.IProduct) gin.HandlerFunc {
+// RejectStockTransfer returns the transferred quantities to the source lots
+func RejectStockTransfer(transactionEntity repositories.ITransaction, entity repositories.IStockTransfer, productEntity repositories.IProduct) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		id := ctx.Param("id")
 		req := request.UpdateStockTransfer{
@@ -104,18 +146,40 @@ func RejectStockTransfer(entity repositories.IStockTransfer, productEntity repos
 			return
 		}
 
-		result, err := entity.UpdateStockTransferStatus(id, req)
+		var result *entities.StockTransfer
+		err = transactionEntity.WithTransaction(func(txCtx context.Context) error {
+			var err error
+			result, err = entity.UpdateStockTransferStatusTx(txCtx, id, req)
+			if err != nil {
+				return err
+			}
+			for _, item := range transfer.Items {
+				if item.StockId == "" {
+					continue
+				}
+				stock, err := productEntity.AddProductStockQuantityByIdTx(txCtx, item.StockId, item.Quantity)
+				if err != nil {
+					return err
+				}
+				valuation := request.StockValuation(stock, constant.ValuationTypeTransfer, item.Quantity, req.UpdatedBy)
+				valuation.DocumentType = constant.STOCK_TRANSFER
+				valuation.DocumentId = transfer.Id.Hex()
+				valuation.DocumentCode = transfer.Code
+				if _, err := productEntity.CreateProductValuationTx(txCtx, valuation); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if errors.Is(err, mongo.ErrNoDocuments) && result == nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TR_BAD_REQUEST_002, "transfer is not pending")
+			return
+		}
 		if err != nil {
 			errcode.Abort(ctx, http.StatusBadRequest, errcode.TR_BAD_REQUEST_002, err.Error())
 			return
 		}
 
-		for _, item := range transfer.Items {
-			if item.StockId != "" {
-				_, _ = productEntity.AddProductStockQuantityById(item.StockId, item.Quantity)
-			}
-		}
-
 		ctx.JSON(http.StatusOK, result)
 	}
 }
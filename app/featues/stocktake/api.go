@@ -0,0 +1,97 @@
+package stocktake
+
+import (
+	"pos/app/core/constant"
+	"pos/app/domain"
+	"pos/app/featues/stocktake/usecase"
+	"pos/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ApplyStocktakeAPI(
+	route *gin.RouterGroup,
+	repository *domain.Repository,
+) {
+	skRoute := route.Group("stocktakes")
+
+	skRoute.POST("",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		middlewares.RequireIdempotency(repository.Idempotency),
+		usecase.CreateStocktake(repository.Stocktake, repository.Product, repository.Sequence),
+	)
+
+	skRoute.GET("",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetStocktakes(repository.Stocktake),
+	)
+
+	skRoute.GET("/:id",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.GetStocktakeById(repository.Stocktake),
+	)
+
+	// Counting, open to every employee of the branch
+	skRoute.GET("/:id/sheet",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetStocktakeSheet(repository.Stocktake),
+	)
+
+	skRoute.PUT("/:id/counts",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.SetStocktakeCounts(repository.Stocktake),
+	)
+
+	skRoute.POST("/:id/scans",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireIdempotency(repository.Idempotency),
+		usecase.ScanStocktake(repository.Stocktake, repository.Product),
+	)
+
+	skRoute.PATCH("/:id/post",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.PostStocktake(repository.Transaction, repository.Stocktake, repository.StockAdjustment, repository.Product, repository.Sequence),
+	)
+
+	skRoute.PATCH("/:id/cancel",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.CancelStocktake(repository.Stocktake),
+	)
+
+	// Variance reports
+	skRoute.GET("/:id/variance/xlsx",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.GetStocktakeVarianceExcel(repository.Stocktake),
+	)
+
+	skRoute.GET("/:id/variance/pdf",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.GetStocktakeVariancePDF(repository.Stocktake, repository.Setting),
+	)
+}
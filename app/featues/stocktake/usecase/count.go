@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/request"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetStocktakeSheet lists the lots to count with what the employee has counted, a blind count leaves
+// out the system quantities
+func GetStocktakeSheet(entity repositories.IStocktake) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		stocktake, ok := getBranchStocktake(ctx, entity)
+		if !ok {
+			return
+		}
+		writeSheet(ctx, entity, stocktake)
+	}
+}
+
+// SetStocktakeCounts replaces what the employee counted of each lot, other counters keep their counts
+func SetStocktakeCounts(entity repositories.IStocktake) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.StocktakeCounts{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SK_BAD_REQUEST_001, err.Error())
+			return
+		}
+		stocktake, ok := getCountingStocktake(ctx, entity)
+		if !ok {
+			return
+		}
+		for _, item := range req.Items {
+			if stocktake.FindLine(item.StockId) == nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.SK_BAD_REQUEST_003, "lot is not in this stocktake: "+item.StockId)
+				return
+			}
+		}
+
+		userId := utils.GetUserId(ctx)
+		for _, item := range req.Items {
+			err := entity.SetStocktakeCount(request.StocktakeCount{
+				StocktakeId: stocktake.Id.Hex(),
+				StockId:     item.StockId,
+				CountedBy:   userId,
+				Quantity:    item.Quantity,
+			})
+			if err != nil {
+				errcode.Abort(ctx, http.StatusInternalServerError, errcode.SK_INTERNAL_001, err.Error())
+				return
+			}
+		}
+		writeSheet(ctx, entity, stocktake)
+	}
+}
+
+// ScanStocktake counts a scanned barcode into the lot of its product and unit, one piece per scan
+// unless a quantity is given
+func ScanStocktake(entity repositories.IStocktake, productEntity repositories.IProduct) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.StocktakeScan{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SK_BAD_REQUEST_001, err.Error())
+			return
+		}
+		if req.Quantity == 0 {
+			req.Quantity = 1
+		}
+		stocktake, ok := getCountingStocktake(ctx, entity)
+		if !ok {
+			return
+		}
+		unit, err := productEntity.GetProductUnitByBarcode(req.Barcode)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SK_BAD_REQUEST_003, "barcode not found: "+req.Barcode)
+			return
+		}
+
+		var lines []*entities.StocktakeLine
+		for i := range stocktake.Lines {
+			line := &stocktake.Lines[i]
+			if line.ProductId != unit.ProductId || line.UnitId != unit.Id {
+				continue
+			}
+			if req.LotNumber != "" && line.LotNumber != req.LotNumber {
+				continue
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) == 0 {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SK_BAD_REQUEST_003, "no lot of this barcode is in the stocktake")
+			return
+		}
+		if len(lines) > 1 {
+			lots := make([]string, len(lines))
+			for i, line := range lines {
+				lots[i] = line.LotNumber
+			}
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SK_BAD_REQUEST_002, "choose the lot number, the product has lots "+strings.Join(lots, ", "))
+			return
+		}
+
+		count, err := entity.AddStocktakeCount(request.StocktakeCount{
+			StocktakeId: stocktake.Id.Hex(),
+			StockId:     lines[0].StockId,
+			CountedBy:   utils.GetUserId(ctx),
+			Quantity:    req.Quantity,
+		})
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.SK_INTERNAL_001, err.Error())
+			return
+		}
+		sheet := stocktake.Sheet([]entities.StocktakeCount{*count}, count.CountedBy)
+		for _, line := range sheet {
+			if line.StockId == count.StockId {
+				ctx.JSON(http.StatusOK, line)
+				return
+			}
+		}
+	}
+}
+
+func writeSheet(ctx *gin.Context, entity repositories.IStocktake, stocktake *entities.Stocktake) {
+	counts, err := entity.GetStocktakeCounts(stocktake.Id.Hex())
+	if err != nil {
+		errcode.Abort(ctx, http.StatusInternalServerError, errcode.SK_INTERNAL_001, err.Error())
+		return
+	}
+	ctx.JSON(http.StatusOK, stocktake.Sheet(counts, utils.GetUserId(ctx)))
+}
+
+func getBranchStocktake(ctx *gin.Context, entity repositories.IStocktake) (*entities.Stocktake, bool) {
+	result, err := entity.GetStocktakeById(ctx.Param("id"))
+	if err != nil || result.BranchId.Hex() != utils.GetBranchId(ctx) {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.SK_BAD_REQUEST_003, "stocktake not found")
+		return nil, false
+	}
+	return result, true
+}
+
+func getCountingStocktake(ctx *gin.Context, entity repositories.IStocktake) (*entities.Stocktake, bool) {
+	result, ok := getBranchStocktake(ctx, entity)
+	if !ok {
+		return nil, false
+	}
+	if !result.IsCounting() {
+		errcode.Abort(ctx, http.StatusConflict, errcode.SK_CONFLICT_001, "stocktake is not counting")
+		return nil, false
+	}
+	return result, true
+}
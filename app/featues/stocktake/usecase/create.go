@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateStocktake starts a count and freezes the system quantity of every lot in scope
+func CreateStocktake(entity repositories.IStocktake, productEntity repositories.IProduct, sequenceEntity repositories.ISequence) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.Stocktake{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SK_BAD_REQUEST_001, err.Error())
+			return
+		}
+		req.CreatedBy = utils.GetUserId(ctx)
+		req.BranchId = utils.GetBranchId(ctx)
+
+		category, stockIds := "", []string(nil)
+		switch req.Scope {
+		case constant.StocktakeScopeCategory:
+			if req.Category == "" {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.SK_BAD_REQUEST_001, "category is required to count a category")
+				return
+			}
+			category = req.Category
+		case constant.StocktakeScopeLots:
+			if len(req.StockIds) == 0 {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.SK_BAD_REQUEST_001, "stockIds are required to count selected lots")
+				return
+			}
+			stockIds = req.StockIds
+		}
+		req.Category = category
+		req.StockIds = stockIds
+
+		stocks, err := productEntity.GetProductStocksForCount(req.BranchId, category, stockIds)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.SK_INTERNAL_001, err.Error())
+			return
+		}
+		if len(stocks) == 0 {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SK_BAD_REQUEST_003, "no lots to count")
+			return
+		}
+		req.Stocks = stocks
+
+		sequence, _ := sequenceEntity.NextSequence(constant.STOCKTAKE)
+		if sequence != nil {
+			req.Code = sequence.GenerateCode()
+		}
+
+		result, err := entity.CreateStocktake(req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SK_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"errors"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func GetStocktakes(entity repositories.IStocktake) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.GetStocktakeRange{}
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SK_BAD_REQUEST_001, err.Error())
+			return
+		}
+		req.BranchId = utils.GetBranchId(ctx)
+		result, err := entity.GetStocktakeRange(req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.SK_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+// GetStocktakeById returns the stocktake with its variance, while counting the variance is taken from
+// the counts so far
+func GetStocktakeById(entity repositories.IStocktake) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, ok := getStocktakeVariance(ctx, entity)
+		if !ok {
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func CancelStocktake(entity repositories.IStocktake) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		stocktake, ok := getBranchStocktake(ctx, entity)
+		if !ok {
+			return
+		}
+		result, err := entity.CancelStocktakeById(stocktake.Id.Hex(), utils.GetUserId(ctx))
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.SK_CONFLICT_001, "stocktake is not counting")
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SK_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func getStocktakeVariance(ctx *gin.Context, entity repositories.IStocktake) (*entities.Stocktake, bool) {
+	stocktake, ok := getBranchStocktake(ctx, entity)
+	if !ok {
+		return nil, false
+	}
+	if stocktake.IsCounting() {
+		counts, err := entity.GetStocktakeCounts(stocktake.Id.Hex())
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.SK_INTERNAL_001, err.Error())
+			return nil, false
+		}
+		stocktake.ApplyCounts(counts, false)
+	}
+	return stocktake, true
+}
@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+	stockAdjustment "pos/app/featues/stock_adjustment/usecase"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PostStocktake closes the count and posts the variance as two stock adjustments, FOUND for lots that
+// counted more and LOST for lots that counted less. The variance is added to what each lot holds now,
+// so sales made since the count started are kept.
+func PostStocktake(
+	transactionEntity repositories.ITransaction,
+	entity repositories.IStocktake,
+	adjustmentEntity repositories.IStockAdjustment,
+	productEntity repositories.IProduct,
+	sequenceEntity repositories.ISequence,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.PostStocktake{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SK_BAD_REQUEST_001, err.Error())
+			return
+		}
+		stocktake, ok := getCountingStocktake(ctx, entity)
+		if !ok {
+			return
+		}
+		counts, err := entity.GetStocktakeCounts(stocktake.Id.Hex())
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.SK_INTERNAL_001, err.Error())
+			return
+		}
+		stocktake.ApplyCounts(counts, req.ZeroUncounted)
+		req.PostedBy = utils.GetUserId(ctx)
+
+		found := varianceAdjustment(stocktake, constant.AdjustmentReasonFound, req.PostedBy)
+		lost := varianceAdjustment(stocktake, constant.AdjustmentReasonLost, req.PostedBy)
+		var adjustments []request.StockAdjustment
+		for _, adjustment := range []request.StockAdjustment{found, lost} {
+			if len(adjustment.Items) == 0 {
+				continue
+			}
+			sequence, _ := sequenceEntity.NextSequence(constant.ADJUSTMENT)
+			if sequence != nil {
+				adjustment.Code = sequence.GenerateCode()
+			}
+			adjustments = append(adjustments, adjustment)
+			req.AdjustmentCodes = append(req.AdjustmentCodes, adjustment.Code)
+		}
+		req.Lines = stocktake.Lines
+		req.SystemCost = stocktake.SystemCost
+		req.CountedCost = stocktake.CountedCost
+		req.VarianceCost = stocktake.VarianceCost
+		req.UncountedLines = stocktake.UncountedLines
+
+		// Unit names of the lots for the product history
+		units := make(map[string]string)
+		for _, line := range stocktake.Lines {
+			units[line.UnitId.Hex()] = line.Unit
+		}
+
+		var result *entities.Stocktake
+		err = transactionEntity.WithTransaction(func(txCtx context.Context) error {
+			var err error
+			result, err = entity.PostStocktakeTx(txCtx, stocktake.Id.Hex(), req)
+			if err != nil {
+				return err
+			}
+			for _, form := range adjustments {
+				adjustment, err := adjustmentEntity.CreateStockAdjustmentTx(txCtx, form)
+				if err != nil {
+					return err
+				}
+				if err := stockAdjustment.ApplyStockAdjustment(txCtx, adjustmentEntity, productEntity, adjustment, units, " ตรวจนับ "+stocktake.Code, adjustment.CreatedBy); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.SK_CONFLICT_001, "stocktake is not counting")
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SK_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+// varianceAdjustment collects the lots whose variance goes the way of the reason into an approved adjustment
+func varianceAdjustment(stocktake *entities.Stocktake, reason string, postedBy string) request.StockAdjustment {
+	increase := constant.IsStockIncrease(reason)
+	form := request.StockAdjustment{
+		Reason:      reason,
+		Note:        "ตรวจนับสต็อก " + stocktake.Code,
+		Status:      constant.StockAdjustmentStatusApproved,
+		StocktakeId: stocktake.Id.Hex(),
+		BranchId:    stocktake.BranchId.Hex(),
+		CreatedBy:   postedBy,
+	}
+	for _, line := range stocktake.Lines {
+		if line.Variance == 0 || (line.Variance > 0) != increase {
+			continue
+		}
+		form.Items = append(form.Items, request.StockAdjustmentItem{
+			StockId:    line.StockId,
+			Quantity:   int(math.Abs(float64(line.Variance))),
+			ProductId:  line.ProductId.Hex(),
+			UnitId:     line.UnitId.Hex(),
+			LotNumber:  line.LotNumber,
+			CostPrice:  line.CostPrice,
+			CostAmount: line.VarianceCost,
+		})
+		form.TotalCost += line.VarianceCost
+	}
+	form.TotalCost = math.Round(form.TotalCost*100) / 100
+	return form
+}
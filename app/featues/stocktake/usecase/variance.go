@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"fmt"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/pdf"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+var (
+	varianceHeaders = []string{"#", "สินค้า / Product", "รหัส / Serial", "ล็อต / Lot", "หมดอายุ / Expire", "หน่วย / Unit", "ทุน / Cost", "ระบบ / System", "นับได้ / Counted", "ผลต่าง / Variance", "มูลค่า / Value"}
+	varianceWidths  = []float64{8, 60, 28, 26, 22, 18, 20, 20, 22, 25, 28}
+	varianceAligns  = []string{"C", "L", "L", "L", "C", "C", "R", "R", "R", "R", "R"}
+)
+
+func GetStocktakeVarianceExcel(entity repositories.IStocktake) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		stocktake, ok := getStocktakeVariance(ctx, entity)
+		if !ok {
+			return
+		}
+
+		f := excelize.NewFile()
+		sheet := stocktake.Code
+		f.SetSheetName("Sheet1", sheet)
+		f.SetCellValue(sheet, "A1", "รายงานผลต่างการตรวจนับสต็อก / Stocktake Variance")
+		f.SetCellValue(sheet, "A2", fmt.Sprintf("%s  %s  %s", stocktake.Code, stocktake.Status, stocktake.CreatedDate.In(utils.GetLocation()).Format("02/01/2006")))
+
+		headerRow := 4
+		cell, _ := excelize.CoordinatesToCellName(1, headerRow)
+		f.SetSheetRow(sheet, cell, &varianceHeaders)
+		style, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+		last, _ := excelize.CoordinatesToCellName(len(varianceHeaders), headerRow)
+		f.SetCellStyle(sheet, fmt.Sprintf("A%d", headerRow), last, style)
+		f.SetCellStyle(sheet, "A1", "A1", style)
+
+		for i, line := range stocktake.Lines {
+			values := []interface{}{
+				i + 1,
+				line.ProductName,
+				line.SerialNumber,
+				line.LotNumber,
+				line.ExpireDate.In(utils.GetLocation()).Format("02/01/2006"),
+				line.Unit,
+				line.CostPrice,
+				line.SystemQuantity,
+			}
+			if line.Counted {
+				values = append(values, line.CountedQuantity, line.Variance, line.VarianceCost)
+			} else {
+				values = append(values, "-", "", "")
+			}
+			cell, _ := excelize.CoordinatesToCellName(1, headerRow+1+i)
+			f.SetSheetRow(sheet, cell, &values)
+		}
+
+		summaryRow := headerRow + len(stocktake.Lines) + 2
+		for i, summary := range varianceSummary(stocktake) {
+			cell, _ := excelize.CoordinatesToCellName(9, summaryRow+i)
+			f.SetSheetRow(sheet, cell, &[]interface{}{summary[0], summary[1]})
+		}
+
+		for i := range varianceHeaders {
+			col, _ := excelize.ColumnNumberToName(i + 1)
+			f.SetColWidth(sheet, col, col, 18)
+		}
+
+		ctx.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-variance.xlsx", stocktake.Code))
+		if err := f.Write(ctx.Writer); err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.SK_INTERNAL_001, err.Error())
+			return
+		}
+	}
+}
+
+func GetStocktakeVariancePDF(entity repositories.IStocktake, settingEntity repositories.ISetting) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		stocktake, ok := getStocktakeVariance(ctx, entity)
+		if !ok {
+			return
+		}
+		setting, err := settingEntity.GetSettingByBranchId(utils.GetBranchId(ctx))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.SK_INTERNAL_001, err.Error())
+			return
+		}
+
+		doc := pdf.NewLandscapePDF()
+		doc.AddPage()
+		pdf.AddHeader(doc, setting.CompanyName, setting.CompanyAddress, setting.CompanyPhone, "รายงานผลต่างการตรวจนับสต็อก / Stocktake Variance")
+
+		doc.SetFont(pdf.FontFamily, "", 9)
+		doc.CellFormat(0, 5, fmt.Sprintf("เลขที่ / No: %s  สถานะ / Status: %s", stocktake.Code, stocktake.Status), "", 1, "C", false, 0, "")
+		doc.CellFormat(0, 5, fmt.Sprintf("วันที่ / Date: %s", stocktake.CreatedDate.In(utils.GetLocation()).Format("02/01/2006")), "", 1, "C", false, 0, "")
+		doc.Ln(3)
+
+		pdf.AddTableHeader(doc, varianceHeaders, varianceWidths)
+		for i, line := range stocktake.Lines {
+			cells := []string{
+				fmt.Sprintf("%d", i+1),
+				line.ProductName,
+				line.SerialNumber,
+				line.LotNumber,
+				line.ExpireDate.In(utils.GetLocation()).Format("02/01/2006"),
+				line.Unit,
+				fmt.Sprintf("%.2f", line.CostPrice),
+				fmt.Sprintf("%d", line.SystemQuantity),
+			}
+			if line.Counted {
+				cells = append(cells, fmt.Sprintf("%d", line.CountedQuantity), fmt.Sprintf("%+d", line.Variance), fmt.Sprintf("%.2f", line.VarianceCost))
+			} else {
+				cells = append(cells, "-", "", "")
+			}
+			pdf.AddTableRow(doc, cells, varianceWidths, varianceAligns)
+		}
+
+		doc.Ln(3)
+		totalWidth := float64(277)
+		for _, summary := range varianceSummary(stocktake) {
+			pdf.AddSummaryLine(doc, summary[0].(string)+":", fmt.Sprint(summary[1]), totalWidth)
+		}
+		pdf.AddFooter(doc, "", setting.ShowCredit)
+
+		ctx.Header("Content-Type", "application/pdf")
+		ctx.Header("Content-Disposition", fmt.Sprintf("inline; filename=%s-variance.pdf", stocktake.Code))
+		if err := doc.Output(ctx.Writer); err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.SK_INTERNAL_001, err.Error())
+			return
+		}
+	}
+}
+
+func varianceSummary(stocktake *entities.Stocktake) [][2]interface{} {
+	return [][2]interface{}{
+		{"มูลค่าตามระบบ / System value", fmt.Sprintf("%.2f", stocktake.SystemCost)},
+		{"มูลค่านับได้ / Counted value", fmt.Sprintf("%.2f", stocktake.CountedCost)},
+		{"ผลต่างสุทธิ / Net variance", fmt.Sprintf("%.2f", stocktake.VarianceCost)},
+		{"รายการที่ไม่ได้นับ / Uncounted lots", stocktake.UncountedLines},
+	}
+}
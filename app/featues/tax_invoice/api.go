@@ -0,0 +1,62 @@
+package tax_invoice
+
+import (
+	"pos/app/core/constant"
+	"pos/app/domain"
+	"pos/app/featues/tax_invoice/usecase"
+	"pos/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ApplyTaxInvoiceAPI(
+	route *gin.RouterGroup,
+	repository *domain.Repository,
+) {
+	tiRoute := route.Group("tax-invoices")
+
+	tiRoute.POST("",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireIdempotency(repository.Idempotency),
+		usecase.IssueTaxInvoice(repository.Transaction, repository.TaxInvoice, repository.Order, repository.Setting, repository.Sequence),
+	)
+
+	tiRoute.GET("",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetTaxInvoices(repository.TaxInvoice),
+	)
+
+	tiRoute.GET("/:id",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetTaxInvoiceById(repository.TaxInvoice),
+	)
+
+	tiRoute.GET("/orders/:orderId",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		usecase.GetTaxInvoicesByOrderId(repository.TaxInvoice),
+	)
+
+	tiRoute.POST("/:id/convert",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireIdempotency(repository.Idempotency),
+		usecase.ConvertTaxInvoice(repository.Transaction, repository.TaxInvoice, repository.Order, repository.Setting, repository.Sequence),
+	)
+
+	tiRoute.POST("/:id/reissue",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.ReissueTaxInvoice(repository.TaxInvoice),
+	)
+}
@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/repositories"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetTaxInvoices(entity repositories.ITaxInvoice) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.GetTaxInvoiceRange{}
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TI_BAD_REQUEST_001, err.Error())
+			return
+		}
+		req.BranchId = utils.GetBranchId(ctx)
+		result, err := entity.GetTaxInvoiceRange(req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TI_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetTaxInvoiceById(entity repositories.ITaxInvoice) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, err := entity.GetTaxInvoiceById(ctx.Param("id"))
+		if err != nil || result.BranchId.Hex() != utils.GetBranchId(ctx) {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TI_BAD_REQUEST_003, "tax invoice not found")
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetTaxInvoicesByOrderId(entity repositories.ITaxInvoice) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, err := entity.GetTaxInvoicesByOrderId(ctx.Param("orderId"))
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TI_BAD_REQUEST_002, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+// ReissueTaxInvoice records that the customer lost the invoice, prints after it are marked as a replacement copy
+func ReissueTaxInvoice(entity repositories.ITaxInvoice) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.ReissueTaxInvoice{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TI_BAD_REQUEST_001, err.Error())
+			return
+		}
+		req.ReissuedBy = utils.GetUserId(ctx)
+
+		invoice, err := entity.GetTaxInvoiceById(ctx.Param("id"))
+		if err != nil || invoice.BranchId.Hex() != utils.GetBranchId(ctx) {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TI_BAD_REQUEST_003, "tax invoice not found")
+			return
+		}
+		result, err := entity.AddTaxInvoiceReissueById(invoice.Id.Hex(), req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TI_BAD_REQUEST_002, "only a tax invoice in force can be reissued")
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,255 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func IssueTaxInvoice(
+	transactionEntity repositories.ITransaction,
+	taxInvoiceEntity repositories.ITaxInvoice,
+	orderEntity repositories.IOrder,
+	settingEntity repositories.ISetting,
+	sequenceEntity repositories.ISequence,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.IssueTaxInvoice{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TI_BAD_REQUEST_001, err.Error())
+			return
+		}
+		branchId := utils.GetBranchId(ctx)
+		userId := utils.GetUserId(ctx)
+
+		if req.Type == constant.TaxInvoiceTypeFull {
+			if req.Buyer == nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.TI_BAD_REQUEST_001, "a full tax invoice needs the buyer name and address")
+				return
+			}
+			if err := validateBuyer(req.Buyer); err != nil {
+				errcode.Abort(ctx, http.StatusBadRequest, errcode.TI_BAD_REQUEST_001, err.Error())
+				return
+			}
+		} else {
+			// The abbreviated form does not carry the buyer
+			req.Buyer = nil
+		}
+
+		setting, _ := settingEntity.GetSettingByBranchId(branchId)
+		if err := validateSeller(setting); err != nil {
+			errcode.Abort(ctx, http.StatusForbidden, errcode.TI_FORBIDDEN_001, err.Error())
+			return
+		}
+
+		order, err := orderEntity.GetOrderDetailById(req.OrderId)
+		if err != nil || order.BranchId.Hex() != branchId {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TI_BAD_REQUEST_003, "order not found")
+			return
+		}
+		if order.Status == constant.OrderStatusVoided {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TI_BAD_REQUEST_002, "order is voided")
+			return
+		}
+
+		// A full invoice asked for a sale that already has an abbreviated one converts it
+		var current *entities.TaxInvoice
+		invoices, _ := taxInvoiceEntity.GetTaxInvoicesByOrderId(req.OrderId)
+		for i := range invoices {
+			if invoices[i].Status == constant.TaxInvoiceStatusIssued {
+				current = &invoices[i]
+			}
+		}
+		if current != nil && (current.IsFull() || req.Type == constant.TaxInvoiceTypeAbbreviated) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.TI_CONFLICT_001, "order already has tax invoice "+current.Code)
+			return
+		}
+
+		form := buildTaxInvoice(order, setting)
+		form.Type = req.Type
+		form.Buyer = req.Buyer
+		form.BranchId = branchId
+		form.CreatedBy = userId
+		if current != nil {
+			form.ReplacesId = current.Id.Hex()
+			form.ReplacesCode = current.Code
+		}
+
+		result, err := saveTaxInvoice(transactionEntity, taxInvoiceEntity, sequenceEntity, form)
+		if err != nil {
+			if mongo.IsDuplicateKeyError(err) || errors.Is(err, mongo.ErrNoDocuments) {
+				errcode.Abort(ctx, http.StatusConflict, errcode.TI_CONFLICT_001, "order already has a tax invoice")
+				return
+			}
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TI_BAD_REQUEST_002, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func ConvertTaxInvoice(
+	transactionEntity repositories.ITransaction,
+	taxInvoiceEntity repositories.ITaxInvoice,
+	orderEntity repositories.IOrder,
+	settingEntity repositories.ISetting,
+	sequenceEntity repositories.ISequence,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.ConvertTaxInvoice{}
+		if err := ctx.ShouldBind(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TI_BAD_REQUEST_001, err.Error())
+			return
+		}
+		if err := validateBuyer(&req.Buyer); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TI_BAD_REQUEST_001, err.Error())
+			return
+		}
+		branchId := utils.GetBranchId(ctx)
+
+		invoice, err := taxInvoiceEntity.GetTaxInvoiceById(ctx.Param("id"))
+		if err != nil || invoice.BranchId.Hex() != branchId {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TI_BAD_REQUEST_003, "tax invoice not found")
+			return
+		}
+		if invoice.IsFull() || invoice.Status != constant.TaxInvoiceStatusIssued {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TI_BAD_REQUEST_002, "only an abbreviated tax invoice in force can be converted")
+			return
+		}
+
+		setting, _ := settingEntity.GetSettingByBranchId(branchId)
+		if err := validateSeller(setting); err != nil {
+			errcode.Abort(ctx, http.StatusForbidden, errcode.TI_FORBIDDEN_001, err.Error())
+			return
+		}
+		order, err := orderEntity.GetOrderDetailById(invoice.OrderId.Hex())
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TI_BAD_REQUEST_003, "order not found")
+			return
+		}
+
+		form := buildTaxInvoice(order, setting)
+		form.Type = constant.TaxInvoiceTypeFull
+		form.Buyer = &req.Buyer
+		form.BranchId = branchId
+		form.CreatedBy = utils.GetUserId(ctx)
+		form.ReplacesId = invoice.Id.Hex()
+		form.ReplacesCode = invoice.Code
+
+		result, err := saveTaxInvoice(transactionEntity, taxInvoiceEntity, sequenceEntity, form)
+		if err != nil {
+			if mongo.IsDuplicateKeyError(err) || errors.Is(err, mongo.ErrNoDocuments) {
+				errcode.Abort(ctx, http.StatusConflict, errcode.TI_CONFLICT_001, "tax invoice was already converted or cancelled")
+				return
+			}
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.TI_BAD_REQUEST_002, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+// saveTaxInvoice numbers the invoice from the series of its type and branch and stores it,
+// taking the invoice it replaces out of force in the same transaction. The number is drawn in the
+// transaction too, so the series has no gaps.
+func saveTaxInvoice(
+	transactionEntity repositories.ITransaction,
+	taxInvoiceEntity repositories.ITaxInvoice,
+	sequenceEntity repositories.ISequence,
+	form request.TaxInvoice,
+) (*entities.TaxInvoice, error) {
+	field := constant.TAX_INVOICE_ABB
+	if form.Type == constant.TaxInvoiceTypeFull {
+		field = constant.TAX_INVOICE_FULL
+	}
+
+	var result *entities.TaxInvoice
+	err := transactionEntity.WithTransaction(func(txCtx context.Context) error {
+		sequence, err := sequenceEntity.NextSequenceTx(txCtx, constant.BranchSequence(field, form.BranchId))
+		if err != nil {
+			return err
+		}
+		form.Code = sequence.GenerateCode()
+		if form.ReplacesId != "" {
+			result, err = taxInvoiceEntity.ReplaceTaxInvoiceTx(txCtx, form)
+		} else {
+			result, err = taxInvoiceEntity.CreateTaxInvoiceTx(txCtx, form)
+		}
+		return err
+	})
+	return result, err
+}
+
+// buildTaxInvoice takes the lines of the order with the VAT treatment of each product,
+// products without their own rate are charged at the branch rate
+func buildTaxInvoice(order *entities.OrderDetail, setting *entities.Setting) request.TaxInvoice {
+	form := request.TaxInvoice{
+		OrderId:   order.Id.Hex(),
+		OrderCode: order.Code,
+		Seller: request.TaxInvoiceParty{
+			Name:     setting.CompanyName,
+			Address:  setting.CompanyAddress,
+			TaxId:    setting.CompanyTaxId,
+			BranchNo: setting.GetTaxBranchNo(),
+		},
+		Items:    []request.TaxInvoiceItem{},
+		Discount: order.Discount,
+	}
+	for _, item := range order.Items {
+		line := request.TaxInvoiceItem{
+			OrderItemId: item.Id.Hex(),
+			ProductId:   item.ProductId.Hex(),
+			Name:        item.Product.Name,
+			Quantity:    item.Quantity,
+			Discount:    item.Discount,
+			Amount:      item.Price - item.Discount,
+			VatRate:     item.Product.VatRate,
+			VatExempt:   item.Product.VatExempt,
+		}
+		if item.Quantity > 0 {
+			line.UnitPrice = item.Price / float64(item.Quantity)
+		}
+		if line.VatExempt {
+			line.VatRate = 0
+		} else if line.VatRate <= 0 {
+			line.VatRate = setting.GetVatRate()
+		}
+		form.Items = append(form.Items, line)
+	}
+	return form
+}
+
+func validateSeller(setting *entities.Setting) error {
+	if setting == nil || !setting.VatRegistered {
+		return errors.New("branch is not VAT registered")
+	}
+	if setting.CompanyName == "" || setting.CompanyAddress == "" || !utils.IsValidThaiId(setting.CompanyTaxId) {
+		return errors.New("company name, address and a valid tax id must be set before issuing tax invoices")
+	}
+	return nil
+}
+
+func validateBuyer(buyer *request.TaxInvoiceParty) error {
+	if buyer.TaxId == "" {
+		buyer.BranchNo = ""
+		return nil
+	}
+	if !utils.IsValidThaiId(buyer.TaxId) {
+		return errors.New("invalid buyer tax id")
+	}
+	if buyer.BranchNo == "" {
+		buyer.BranchNo = constant.HeadOfficeBranchNo
+	}
+	return nil
+}
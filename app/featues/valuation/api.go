@@ -0,0 +1,74 @@
+package valuation
+
+import (
+	"pos/app/core/constant"
+	"pos/app/domain"
+	"pos/app/featues/valuation/usecase"
+	"pos/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ApplyValuationAPI(
+	route *gin.RouterGroup,
+	repository *domain.Repository,
+) {
+	valuationRoute := route.Group("valuations")
+
+	valuationRoute.GET("/ledger",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.GetValuationLedger(repository.Product),
+	)
+
+	valuationRoute.POST("/opening",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.SUPER),
+		middlewares.RequireIdempotency(repository.Idempotency),
+		usecase.OpenValuations(repository.Transaction, repository.Product),
+	)
+
+	valuationRoute.GET("/inventory",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.GetInventoryValuation(repository.Product),
+	)
+
+	valuationRoute.GET("/inventory/xlsx",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.GetInventoryValuationExcel(repository.Product),
+	)
+
+	valuationRoute.GET("/cogs",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.GetCostOfGoodsSold(repository.Product),
+	)
+
+	valuationRoute.GET("/cogs/xlsx",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.GetCostOfGoodsSoldExcel(repository.Product),
+	)
+
+	valuationRoute.GET("/reconciliation",
+		middlewares.RequireAuthenticated(),
+		middlewares.RequireSession(repository.Session),
+		middlewares.RequireBranch(repository.Employee, repository.Branch),
+		middlewares.RequireAuthorization(constant.ADMIN, constant.SUPER),
+		usecase.GetValuationReconciliation(repository.Product),
+	)
+}
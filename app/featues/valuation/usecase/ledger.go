@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/repositories"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetValuationLedger lists the cost layers the documents of the branch moved in the period
+func GetValuationLedger(productEntity repositories.IProduct) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := request.GetProductValuationRange{}
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.VL_BAD_REQUEST_001, err.Error())
+			return
+		}
+		req.BranchId = utils.GetBranchId(ctx)
+		result, err := productEntity.GetProductValuationRange(req)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.VL_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+// OpenValuations posts the opening balances of the branch, the stock it held before its movements were
+// recorded in the ledger
+func OpenValuations(transactionEntity repositories.ITransaction, productEntity repositories.IProduct) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		branchId := utils.GetBranchId(ctx)
+		userId := utils.GetUserId(ctx)
+		var count int
+		err := transactionEntity.WithTransaction(func(txCtx context.Context) error {
+			var err error
+			count, err = productEntity.OpenProductValuationsTx(txCtx, branchId, userId)
+			return err
+		})
+		if errors.Is(err, repositories.ErrValuationOpened) {
+			errcode.Abort(ctx, http.StatusConflict, errcode.VL_CONFLICT_001, err.Error())
+			return
+		}
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.VL_INTERNAL_001, err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"entries": count})
+	}
+}
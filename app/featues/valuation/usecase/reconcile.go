@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/constant"
+	"pos/app/domain/request"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetValuationReconciliation checks the ledger of the branch against its lots. The ledger holds every
+// lot, the stock report only the sellable ones, so the blocked lots make up the difference; what is
+// left shows movements missing from the ledger, such as stock held before the ledger was opened.
+func GetValuationReconciliation(productEntity repositories.IProduct) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		branchId := utils.GetBranchId(ctx)
+		ledger, err := productEntity.GetInventoryValuation(request.GetInventoryValuation{
+			Date:     time.Now(),
+			BranchId: branchId,
+		})
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.VL_INTERNAL_001, err.Error())
+			return
+		}
+		stocks, err := productEntity.GetStockReport(branchId)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.VL_INTERNAL_001, err.Error())
+			return
+		}
+		blocked, err := productEntity.GetProductStocksByStatus(branchId, constant.NonSellableStockStatuses())
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.VL_INTERNAL_001, err.Error())
+			return
+		}
+
+		lines := make(map[primitive.ObjectID]*entities.ValuationReconciliation)
+		line := func(productId primitive.ObjectID, name string, serialNumber string) *entities.ValuationReconciliation {
+			if found, ok := lines[productId]; ok {
+				return found
+			}
+			lines[productId] = &entities.ValuationReconciliation{ProductId: productId, Name: name, SerialNumber: serialNumber}
+			return lines[productId]
+		}
+		for _, value := range ledger {
+			l := line(value.ProductId, value.Name, value.SerialNumber)
+			l.LedgerQuantity = value.Quantity
+			l.LedgerValue = value.Value
+		}
+		for _, stock := range stocks {
+			l := line(stock.ProductId, stock.Name, stock.SerialNumber)
+			l.StockQuantity = stock.TotalStock
+			l.StockValue = stock.TotalCost
+		}
+		for _, stock := range blocked {
+			l := line(stock.ProductId, stock.ProductName, stock.SerialNumber)
+			l.BlockedQuantity += stock.Quantity
+			l.BlockedValue += float64(stock.Quantity) * stock.CostPrice
+		}
+
+		result := make([]entities.ValuationReconciliation, 0, len(lines))
+		for _, l := range lines {
+			l.LedgerValue = utils.Round2(l.LedgerValue)
+			l.StockValue = utils.Round2(l.StockValue)
+			l.BlockedValue = utils.Round2(l.BlockedValue)
+			l.Difference = utils.Round2(l.LedgerValue - l.StockValue - l.BlockedValue)
+			result = append(result, *l)
+		}
+		sort.Slice(result, func(i, j int) bool {
+			return result[i].Name < result[j].Name
+		})
+		ctx.JSON(http.StatusOK, result)
+	}
+}
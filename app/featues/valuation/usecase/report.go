@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"fmt"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/core/utils"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"pos/app/domain/request"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// GetInventoryValuation values the stock of the branch at the end of the date from the ledger
+func GetInventoryValuation(productEntity repositories.IProduct) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		_, result, ok := getInventoryValuation(ctx, productEntity)
+		if !ok {
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetInventoryValuationExcel(productEntity repositories.IProduct) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		date, result, ok := getInventoryValuation(ctx, productEntity)
+		if !ok {
+			return
+		}
+
+		var total float64
+		rows := make([][]interface{}, 0, len(result))
+		for i, line := range result {
+			rows = append(rows, []interface{}{i + 1, line.SerialNumber, line.Name, line.Unit, line.Quantity, line.Value})
+			total += line.Value
+		}
+		rows = append(rows, []interface{}{"", "", "", "", "รวม / Total", utils.Round2(total)})
+		title := fmt.Sprintf("มูลค่าสินค้าคงเหลือ ณ %s / Inventory Valuation", date)
+		headers := []interface{}{"#", "รหัส / Serial", "สินค้า / Product", "หน่วย / Unit", "คงเหลือ / Quantity", "มูลค่า / Value"}
+		writeExcel(ctx, title, headers, rows, "inventory-valuation.xlsx")
+	}
+}
+
+// GetCostOfGoodsSold reports the cost of the sales of the branch in the period from the ledger
+func GetCostOfGoodsSold(productEntity repositories.IProduct) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		_, result, ok := getCostOfGoodsSold(ctx, productEntity)
+		if !ok {
+			return
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func GetCostOfGoodsSoldExcel(productEntity repositories.IProduct) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req, result, ok := getCostOfGoodsSold(ctx, productEntity)
+		if !ok {
+			return
+		}
+
+		var total float64
+		rows := make([][]interface{}, 0, len(result))
+		for i, line := range result {
+			rows = append(rows, []interface{}{
+				i + 1, line.SerialNumber, line.Name, line.Unit,
+				line.SoldQuantity, line.SoldCost, line.ReturnedQuantity, line.ReturnedCost, line.Quantity, line.Cost,
+			})
+			total += line.Cost
+		}
+		rows = append(rows, []interface{}{"", "", "", "", "", "", "", "", "รวม / Total", utils.Round2(total)})
+		location := utils.GetLocation()
+		title := fmt.Sprintf("ต้นทุนขาย %s - %s / Cost of Goods Sold", req.StartDate.In(location).Format("02/01/2006"), req.EndDate.In(location).Format("02/01/2006"))
+		headers := []interface{}{"#", "รหัส / Serial", "สินค้า / Product", "หน่วย / Unit", "ขาย / Sold", "ทุนขาย / Sold Cost", "รับคืน / Returned", "ทุนรับคืน / Returned Cost", "สุทธิ / Net", "ต้นทุนขาย / COGS"}
+		writeExcel(ctx, title, headers, rows, "cost-of-goods-sold.xlsx")
+	}
+}
+
+// getInventoryValuation binds the date and sums the ledger up to the end of that day in Bangkok
+func getInventoryValuation(ctx *gin.Context, productEntity repositories.IProduct) (string, []entities.InventoryValuation, bool) {
+	req := request.GetInventoryValuation{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.VL_BAD_REQUEST_001, err.Error())
+		return "", nil, false
+	}
+	date := req.Date.In(utils.GetLocation())
+	req.Date = utils.Bod(date).AddDate(0, 0, 1)
+	req.BranchId = utils.GetBranchId(ctx)
+	result, err := productEntity.GetInventoryValuation(req)
+	if err != nil {
+		errcode.Abort(ctx, http.StatusInternalServerError, errcode.VL_INTERNAL_001, err.Error())
+		return "", nil, false
+	}
+	for i := range result {
+		result[i].Value = utils.Round2(result[i].Value)
+	}
+	return date.Format("02/01/2006"), result, true
+}
+
+func getCostOfGoodsSold(ctx *gin.Context, productEntity repositories.IProduct) (request.GetCostOfGoodsSold, []entities.CostOfGoodsSold, bool) {
+	req := request.GetCostOfGoodsSold{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		errcode.Abort(ctx, http.StatusBadRequest, errcode.VL_BAD_REQUEST_001, err.Error())
+		return req, nil, false
+	}
+	req.BranchId = utils.GetBranchId(ctx)
+	result, err := productEntity.GetCostOfGoodsSold(req)
+	if err != nil {
+		errcode.Abort(ctx, http.StatusInternalServerError, errcode.VL_INTERNAL_001, err.Error())
+		return req, nil, false
+	}
+	for i := range result {
+		result[i].SoldCost = utils.Round2(result[i].SoldCost)
+		result[i].ReturnedCost = utils.Round2(result[i].ReturnedCost)
+		result[i].Cost = utils.Round2(result[i].Cost)
+	}
+	return req, result, true
+}
+
+func writeExcel(ctx *gin.Context, title string, headers []interface{}, rows [][]interface{}, filename string) {
+	f := excelize.NewFile()
+	sheet := "Valuation"
+	f.SetSheetName("Sheet1", sheet)
+	f.SetCellValue(sheet, "A1", title)
+
+	headerRow := 3
+	cell, _ := excelize.CoordinatesToCellName(1, headerRow)
+	f.SetSheetRow(sheet, cell, &headers)
+	style, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	last, _ := excelize.CoordinatesToCellName(len(headers), headerRow)
+	f.SetCellStyle(sheet, fmt.Sprintf("A%d", headerRow), last, style)
+	f.SetCellStyle(sheet, "A1", "A1", style)
+
+	for i := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, headerRow+1+i)
+		f.SetSheetRow(sheet, cell, &rows[i])
+	}
+
+	for i := range headers {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		f.SetColWidth(sheet, col, col, 18)
+	}
+
+	ctx.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	ctx.Header("Content-Disposition", "attachment; filename="+filename)
+	if err := f.Write(ctx.Writer); err != nil {
+		errcode.Abort(ctx, http.StatusInternalServerError, errcode.VL_INTERNAL_001, err.Error())
+		return
+	}
+}
@@ -5,23 +5,37 @@ import (
 	"pos/app/domain"
 	"pos/app/domain/request"
 	"pos/app/featues/branch"
+	"pos/app/featues/cart"
 	"pos/app/featues/catagory"
+	"pos/app/featues/credit_note"
 	"pos/app/featues/customer"
 	"pos/app/featues/customer_history"
 	"pos/app/featues/dashboard"
 	"pos/app/featues/dispensing"
+	"pos/app/featues/disposal"
+	"pos/app/featues/drug_interaction"
 	"pos/app/featues/employee"
+	"pos/app/featues/etax"
+	"pos/app/featues/ingredient"
 	"pos/app/featues/order"
 	"pos/app/featues/patient"
 	"pos/app/featues/product"
 	"pos/app/featues/promotion"
+	"pos/app/featues/recall"
+	"pos/app/featues/receivable"
 	"pos/app/featues/receive"
 	"pos/app/featues/report"
 	"pos/app/featues/setting"
+	"pos/app/featues/shift"
+	"pos/app/featues/stock_adjustment"
 	"pos/app/featues/stock_transfer"
+	"pos/app/featues/stocktake"
 	"pos/app/featues/supplier"
+	"pos/app/featues/tax_invoice"
+	"pos/app/featues/valuation"
 	"pos/db"
 	"pos/middlewares"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -52,6 +66,7 @@ func (app Routes) StartGin() {
 
 	repository := domain.InitRepository(resource)
 	initDefaultBranch(repository)
+	startStockExpiryJob(repository)
 
 	product.ApplyProductAPI(publicRoute, repository)
 	order.ApplyOrderAPI(publicRoute, repository)
@@ -69,6 +84,19 @@ func (app Routes) StartGin() {
 	patient.ApplyPatientAPI(publicRoute, repository)
 	dispensing.ApplyDispensingAPI(publicRoute, repository)
 	stock_transfer.ApplyStockTransferAPI(publicRoute, repository)
+	credit_note.ApplyCreditNoteAPI(publicRoute, repository)
+	cart.ApplyCartAPI(publicRoute, repository)
+	shift.ApplyShiftAPI(publicRoute, repository)
+	receivable.ApplyReceivableAPI(publicRoute, repository)
+	drug_interaction.ApplyDrugInteractionAPI(publicRoute, repository)
+	ingredient.ApplyIngredientAPI(publicRoute, repository)
+	tax_invoice.ApplyTaxInvoiceAPI(publicRoute, repository)
+	etax.ApplyEtaxAPI(publicRoute, repository)
+	stock_adjustment.ApplyStockAdjustmentAPI(publicRoute, repository)
+	stocktake.ApplyStocktakeAPI(publicRoute, repository)
+	recall.ApplyRecallAPI(publicRoute, repository)
+	disposal.ApplyDisposalAPI(publicRoute, repository)
+	valuation.ApplyValuationAPI(publicRoute, repository)
 
 	r.NoRoute(middlewares.NoRoute())
 
@@ -94,3 +122,26 @@ func initDefaultBranch(repository *domain.Repository) {
 	}
 	logrus.Info("initDefaultBranch: created default branch 'สำนักงานใหญ่'")
 }
+
+// startStockExpiryJob marks lots past their expire date as expired at start and then every hour, so they
+// leave sales and the stock value until they are disposed of
+func startStockExpiryJob(repository *domain.Repository) {
+	expire := func() {
+		count, err := repository.Product.ExpireProductStocks(time.Now())
+		if err != nil {
+			logrus.Error("startStockExpiryJob: failed to expire stocks: ", err)
+			return
+		}
+		if count > 0 {
+			logrus.Info("startStockExpiryJob: marked ", count, " lots expired")
+		}
+	}
+	expire()
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			expire()
+		}
+	}()
+}
@@ -22,6 +22,25 @@ func (r *Resource) Close() {
 	logrus.Warning("Closing all db connections")
 }
 
+// WithTransaction runs fn inside a multi-document transaction on the POS database.
+// Every operation that should take part in the transaction must use the session
+// context passed to fn. Transactions require MongoDB to run as a replica set.
+func (r *Resource) WithTransaction(fn func(ctx mongo.SessionContext) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	session, err := r.PosDb.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+	return err
+}
+
 func InitResource() (*Resource, error) {
 	err := godotenv.Load(".env")
 	if err != nil {
@@ -15,8 +15,9 @@ func NewCors(allowedOrigins []string) gin.HandlerFunc {
 			"Content-Type", "Content-Length",
 			"Accept-Encoding", "Accept-Language", "Accept",
 			"X-CSRF-Token", "Authorization", "X-Requested-With", "X-Access-Token",
+			"Idempotency-Key",
 		},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "Idempotent-Replayed"},
 		AllowCredentials: true,
 	})
 }
@@ -0,0 +1,103 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"pos/app/core/errcode"
+	"pos/app/data/entities"
+	"pos/app/data/repositories"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const idempotencyTTL = 24 * time.Hour
+
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyWriter) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// RequireIdempotency replays the stored response when a request is sent again with the same
+// Idempotency-Key header. Keys are scoped to the user and route and kept for idempotencyTTL.
+// Only successful responses are stored, a failed request releases the key so it can be retried.
+// Requests without the header are processed as usual.
+func RequireIdempotency(idempotencyEntity repositories.IIdempotency) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		idempotencyKey := ctx.GetHeader("Idempotency-Key")
+		if idempotencyKey == "" {
+			ctx.Next()
+			return
+		}
+
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusBadRequest, errcode.SY_BAD_REQUEST_001, err.Error())
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		fingerprint := hex.EncodeToString(sum[:])
+
+		key := "idempotency:" + ctx.GetString("UserId") + ":" + ctx.Request.Method + ":" + ctx.FullPath() + ":" + idempotencyKey
+		locked, err := idempotencyEntity.LockIdempotencyKey(key, fingerprint, idempotencyTTL)
+		if err != nil {
+			errcode.Abort(ctx, http.StatusInternalServerError, errcode.SY_INTERNAL_001, err.Error())
+			return
+		}
+		if !locked {
+			record, err := idempotencyEntity.GetIdempotencyRecord(key)
+			if err != nil {
+				errcode.Abort(ctx, http.StatusConflict, errcode.SY_CONFLICT_001, "request with this idempotency key is in progress")
+				return
+			}
+			if record.Fingerprint != fingerprint {
+				errcode.Abort(ctx, http.StatusUnprocessableEntity, errcode.SY_UNPROCESSABLE_001, "idempotency key was used with another request")
+				return
+			}
+			if record.Status != entities.IdempotencyCompleted {
+				errcode.Abort(ctx, http.StatusConflict, errcode.SY_CONFLICT_001, "request with this idempotency key is in progress")
+				return
+			}
+			logrus.Info("Replay idempotency key: " + idempotencyKey)
+			ctx.Header("Idempotent-Replayed", "true")
+			ctx.Data(record.StatusCode, record.ContentType, record.Body)
+			ctx.Abort()
+			return
+		}
+
+		writer := &idempotencyWriter{ResponseWriter: ctx.Writer, body: &bytes.Buffer{}}
+		ctx.Writer = writer
+		ctx.Next()
+
+		status := writer.Status()
+		if status >= http.StatusOK && status < http.StatusMultipleChoices {
+			err = idempotencyEntity.SaveIdempotencyRecord(key, entities.IdempotencyRecord{
+				Status:      entities.IdempotencyCompleted,
+				Fingerprint: fingerprint,
+				StatusCode:  status,
+				ContentType: writer.Header().Get("Content-Type"),
+				Body:        writer.body.Bytes(),
+			}, idempotencyTTL)
+		} else {
+			err = idempotencyEntity.RemoveIdempotencyKey(key)
+		}
+		if err != nil {
+			logrus.Error("failed to store idempotency key: ", err)
+		}
+	}
+}